@@ -0,0 +1,93 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bhojpur/iam/pkg/breach"
+	"github.com/spf13/cobra"
+)
+
+var breachCmdOpts struct {
+	Input  string
+	Output string
+	Rate   float64
+}
+
+// breachCmd represents the breach-filter command
+var breachCmd = &cobra.Command{
+	Use:   "breach-filter",
+	Short: "Builds the breached password filter of Bhojpur IAM from a breach dataset file",
+	Long: `Builds the breached password filter from a breach dataset file. Each line of the dataset is
+either a SHA-1 hash in hex, optionally followed by ":<count>" as in the Pwned Passwords files,
+or a plain password. Set breachedPasswordFile in conf/app.conf to the output file to use it.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if breachCmdOpts.Input == "" {
+			return fmt.Errorf("the dataset file is required")
+		}
+
+		// the dataset is read twice: once to size the filter, once to fill it
+		input, err := os.Open(breachCmdOpts.Input)
+		if err != nil {
+			return err
+		}
+		count, err := breach.CountLines(input)
+		input.Close()
+		if err != nil {
+			return err
+		}
+
+		filter := breach.NewFilter(count, breachCmdOpts.Rate)
+		input, err = os.Open(breachCmdOpts.Input)
+		if err != nil {
+			return err
+		}
+		defer input.Close()
+		if _, err = filter.AddLines(input); err != nil {
+			return err
+		}
+
+		output, err := os.Create(breachCmdOpts.Output)
+		if err != nil {
+			return err
+		}
+		if _, err = filter.WriteTo(output); err != nil {
+			output.Close()
+			return err
+		}
+		if err = output.Close(); err != nil {
+			return err
+		}
+
+		fmt.Printf("Wrote %d passwords to %s (%s)\n", count, breachCmdOpts.Output, filter)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(breachCmd)
+
+	breachCmd.Flags().StringVar(&breachCmdOpts.Input, "input", "", "[file path] the breach dataset file")
+	breachCmd.Flags().StringVar(&breachCmdOpts.Output, "output", "breach.bloom", "[file path] the filter file to write")
+	breachCmd.Flags().Float64Var(&breachCmdOpts.Rate, "rate", 0.001, "the false positive rate of the filter")
+}
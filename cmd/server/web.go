@@ -25,8 +25,10 @@ import (
 	"os"
 
 	"github.com/bhojpur/iam/pkg/authz"
+	"github.com/bhojpur/iam/pkg/ldap"
 	"github.com/bhojpur/iam/pkg/object"
 	"github.com/bhojpur/iam/pkg/proxy"
+	"github.com/bhojpur/iam/pkg/radius"
 	"github.com/bhojpur/iam/pkg/router"
 	_ "github.com/bhojpur/iam/pkg/router"
 	logsvr "github.com/bhojpur/logger/pkg/engine"
@@ -53,10 +55,15 @@ var webCmd = &cobra.Command{
 		object.InitDb()
 		object.InitDefaultStorageProvider()
 		object.InitLdapAutoSynchronizer()
+		object.InitBreachedPasswordFilter()
+		object.InitGeoIpDatabase()
 		proxy.InitHttpClient()
 		authz.InitAuthz()
 
 		go object.RunSyncUsersJob()
+		go object.RunProvisioningJob()
+		ldap.StartLdapServer()
+		radius.StartRadiusServer()
 
 		//websvr.DelStaticPath("/static")
 		websvr.SetStaticPath("/static", "pkg/webui/build/static")
@@ -83,7 +83,7 @@ require (
 	github.com/aws/aws-sdk-go v1.42.47 // indirect
 	github.com/bhojpur/session v0.0.2
 	github.com/dchest/captcha v0.0.0-20200903113550-03f5f0333e1f
-	github.com/go-asn1-ber/asn1-ber v1.5.3 // indirect
+	github.com/go-asn1-ber/asn1-ber v1.5.3
 	github.com/go-gomail/gomail v0.0.0-20160411212932-81ebce5c23df
 	github.com/go-ldap/ldap/v3 v3.4.1
 	github.com/go-sql-driver/mysql v1.6.0
@@ -107,7 +107,44 @@ p, *, *, POST, /api/upload-resource, *, *
 p, *, *, GET, /.well-known/openid-configuration, *, *
 p, *, *, *, /api/certs, *, *
 p, *, *, GET, /api/get-saml-login, *, *
+p, *, *, GET, /api/get-oidc-login, *, *
+p, *, *, POST, /api/verify-mfa, *, *
+p, *, *, POST, /api/initiate-totp, *, *
+p, *, *, POST, /api/enable-totp, *, *
+p, *, *, POST, /api/disable-totp, *, *
+p, *, *, POST, /api/regenerate-recovery-codes, *, *
+p, *, *, POST, /api/reset-mfa, *, *
+p, *, *, POST, /api/unlock-user, *, *
+p, *, *, GET, /api/get-user-sessions, *, *
+p, *, *, POST, /api/delete-user-session, *, *
+p, *, *, POST, /api/delete-user-sessions, *, *
+p, *, *, GET, /api/get-personal-access-tokens, *, *
+p, *, *, POST, /api/add-personal-access-token, *, *
+p, *, *, POST, /api/delete-personal-access-token, *, *
+p, *, *, GET, /api/get-service-account-credentials, *, *
+p, *, *, POST, /api/add-service-account-credential, *, *
+p, *, *, POST, /api/delete-service-account-credential, *, *
+p, *, *, POST, /api/impersonate-user, *, *
+p, *, *, POST, /api/end-impersonation, *, *
+p, *, *, POST, /api/change-expired-password, *, *
+p, *, *, POST, /api/request-password-reset, *, *
+p, *, *, POST, /api/reset-password, *, *
+p, *, *, POST, /api/send-magic-link, *, *
+p, *, *, POST, /api/login-magic-link, *, *
+p, *, *, POST, /api/begin-webauthn-registration, *, *
+p, *, *, POST, /api/finish-webauthn-registration, *, *
+p, *, *, POST, /api/begin-webauthn-login, *, *
+p, *, *, POST, /api/finish-webauthn-login, *, *
+p, *, *, GET, /api/get-webauthn-credentials, *, *
+p, *, *, POST, /api/rename-webauthn-credential, *, *
+p, *, *, POST, /api/delete-webauthn-credential, *, *
 p, *, *, POST, /api/acs, *, *
+p, *, *, GET, /cas/login, *, *
+p, *, *, GET, /cas/logout, *, *
+p, *, *, GET, /cas/serviceValidate, *, *
+p, *, *, GET, /cas/proxyValidate, *, *
+p, *, *, GET, /cas/p3/serviceValidate, *, *
+p, *, *, GET, /cas/p3/proxyValidate, *, *
 `
 
 		sa := stringadapter.NewAdapter(ruleText)
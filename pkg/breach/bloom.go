@@ -0,0 +1,196 @@
+package breach
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// the header of a filter file: the magic, then the number of hash functions and the number of bits
+var magic = []byte("IAMBLOOM1")
+
+// Filter is a bloom filter of the SHA-1 hashes of breached passwords. A password in the filter
+// is breached with the false positive rate of the filter, a password not in it is not breached.
+type Filter struct {
+	k    uint32
+	m    uint64
+	bits []uint64
+}
+
+// NewFilter returns a filter sized for n hashes with the false positive rate p
+func NewFilter(n uint64, p float64) *Filter {
+	if n == 0 {
+		n = 1
+	}
+	if p <= 0 || p >= 1 {
+		p = 0.001
+	}
+
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	m = (m + 63) / 64 * 64
+	k := uint32(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k == 0 {
+		k = 1
+	}
+	return &Filter{k: k, m: m, bits: make([]uint64, m/64)}
+}
+
+// the bits of a hash are chosen by double hashing, the SHA-1 hash is already uniform so its
+// first 16 bytes are used as the two hashes
+func (f *Filter) indexes(hash []byte, fn func(i uint64)) {
+	h1 := binary.BigEndian.Uint64(hash[0:8])
+	h2 := binary.BigEndian.Uint64(hash[8:16]) | 1
+	for i := uint32(0); i < f.k; i++ {
+		fn((h1 + uint64(i)*h2) % f.m)
+	}
+}
+
+func (f *Filter) Add(hash []byte) {
+	f.indexes(hash, func(i uint64) {
+		f.bits[i/64] |= 1 << (i % 64)
+	})
+}
+
+func (f *Filter) Contains(hash []byte) bool {
+	res := true
+	f.indexes(hash, func(i uint64) {
+		if f.bits[i/64]&(1<<(i%64)) == 0 {
+			res = false
+		}
+	})
+	return res
+}
+
+func (f *Filter) ContainsPassword(password string) bool {
+	hash := sha1.Sum([]byte(password))
+	return f.Contains(hash[:])
+}
+
+func (f *Filter) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	header := make([]byte, len(magic)+12)
+	copy(header, magic)
+	binary.BigEndian.PutUint32(header[len(magic):], f.k)
+	binary.BigEndian.PutUint64(header[len(magic)+4:], f.m)
+	if _, err := bw.Write(header); err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, 8)
+	for _, word := range f.bits {
+		binary.BigEndian.PutUint64(buf, word)
+		if _, err := bw.Write(buf); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(header) + 8*len(f.bits)), bw.Flush()
+}
+
+func ReadFilter(r io.Reader) (*Filter, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(magic)+12)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, err
+	}
+	if string(header[:len(magic)]) != string(magic) {
+		return nil, errors.New("the file is not a breached password filter")
+	}
+
+	f := &Filter{
+		k: binary.BigEndian.Uint32(header[len(magic):]),
+		m: binary.BigEndian.Uint64(header[len(magic)+4:]),
+	}
+	if f.k == 0 || f.m == 0 || f.m%64 != 0 {
+		return nil, errors.New("the breached password filter is corrupted")
+	}
+
+	f.bits = make([]uint64, f.m/64)
+	buf := make([]byte, 8)
+	for i := range f.bits {
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return nil, err
+		}
+		f.bits[i] = binary.BigEndian.Uint64(buf)
+	}
+	return f, nil
+}
+
+// ParseLine returns the SHA-1 hash of a line of a breach dataset. A line is either a SHA-1 hash
+// in hex, optionally followed by ":<count>" as in the Pwned Passwords files, or a password.
+// Empty lines are skipped.
+func ParseLine(line string) ([]byte, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return nil, false
+	}
+
+	s := line
+	if i := strings.IndexByte(s, ':'); i == 40 {
+		s = s[:i]
+	}
+	if len(s) == 40 {
+		if hash, err := hex.DecodeString(s); err == nil {
+			return hash, true
+		}
+	}
+
+	hash := sha1.Sum([]byte(line))
+	return hash[:], true
+}
+
+// CountLines returns the number of entries of a breach dataset, to size the filter
+func CountLines(r io.Reader) (uint64, error) {
+	scanner := bufio.NewScanner(r)
+	count := uint64(0)
+	for scanner.Scan() {
+		if _, ok := ParseLine(scanner.Text()); ok {
+			count++
+		}
+	}
+	return count, scanner.Err()
+}
+
+// AddLines adds the entries of a breach dataset to the filter
+func (f *Filter) AddLines(r io.Reader) (uint64, error) {
+	scanner := bufio.NewScanner(r)
+	count := uint64(0)
+	for scanner.Scan() {
+		hash, ok := ParseLine(scanner.Text())
+		if !ok {
+			continue
+		}
+		f.Add(hash)
+		count++
+	}
+	return count, scanner.Err()
+}
+
+func (f *Filter) String() string {
+	return fmt.Sprintf("%d bits, %d hash functions", f.m, f.k)
+}
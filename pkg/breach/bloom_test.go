@@ -0,0 +1,101 @@
+package breach
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	// the SHA-1 hash of "password"
+	want := "5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8"
+	for _, line := range []string{
+		"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824",
+		"5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8",
+		"password",
+		"password\r\n",
+	} {
+		hash, ok := ParseLine(line)
+		if !ok || fmt.Sprintf("%x", hash) != want {
+			t.Errorf("ParseLine(%q) = %x, want %s", line, hash, want)
+		}
+	}
+
+	if _, ok := ParseLine(""); ok {
+		t.Errorf("ParseLine() must skip empty lines")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	dataset := []string{}
+	for i := 0; i < 1000; i++ {
+		dataset = append(dataset, fmt.Sprintf("breached-%d", i))
+	}
+	dataset = append(dataset, "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824")
+	input := strings.Join(dataset, "\n")
+
+	n, err := CountLines(strings.NewReader(input))
+	if err != nil || n != 1001 {
+		t.Fatalf("CountLines() = %d, %v", n, err)
+	}
+
+	filter := NewFilter(n, 0.001)
+	if _, err = filter.AddLines(strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	if _, err = filter.WriteTo(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	filter, err = ReadFilter(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		if !filter.ContainsPassword(fmt.Sprintf("breached-%d", i)) {
+			t.Errorf("the filter must contain breached-%d", i)
+		}
+	}
+	if !filter.ContainsPassword("password") {
+		t.Errorf("the filter must contain the password of the hash")
+	}
+
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if filter.ContainsPassword(fmt.Sprintf("safe-%d", i)) {
+			falsePositives++
+		}
+	}
+	if falsePositives > 50 {
+		t.Errorf("%d false positives out of 10000, the rate should be about 0.001", falsePositives)
+	}
+}
+
+func TestReadFilterInvalid(t *testing.T) {
+	if _, err := ReadFilter(strings.NewReader("not a filter at all")); err == nil {
+		t.Errorf("ReadFilter() must reject other files")
+	}
+}
@@ -62,6 +62,9 @@ type RequestForm struct {
 
 	AutoSignin bool `json:"autoSignin"`
 
+	CaptchaId   string `json:"captchaId"`
+	CaptchaCode string `json:"captchaCode"`
+
 	RelayState   string `json:"relayState"`
 	SamlResponse string `json:"samlResponse"`
 }
@@ -73,6 +76,8 @@ type Response struct {
 	Name   string      `json:"name"`
 	Data   interface{} `json:"data"`
 	Data2  interface{} `json:"data2"`
+
+	Impersonation *ImpersonationInfo `json:"impersonation,omitempty"`
 }
 
 type Userinfo struct {
@@ -121,6 +126,11 @@ func (c *ApiController) Signup() {
 		return
 	}
 
+	if c.isHumanCheckFailed(form.CaptchaId, form.CaptchaCode) {
+		c.ResponseError("Turing test failed.")
+		return
+	}
+
 	organization := object.GetOrganization(fmt.Sprintf("%s/%s", "admin", form.Organization))
 	msg := object.CheckUserSignup(application, organization, form.Username, form.Password, form.Name, form.Email, form.Phone, form.Affiliation)
 	if msg != "" {
@@ -194,7 +204,7 @@ func (c *ApiController) Signup() {
 
 	if application.HasPromptPage() {
 		// The prompt page needs the user to be signed in
-		c.SetSessionUsername(user.GetId())
+		c.setLoginSession(application, user, []string{"pwd"})
 	}
 
 	object.DisableVerificationCode(form.Email)
@@ -202,7 +212,9 @@ func (c *ApiController) Signup() {
 
 	utils.LogInfo(c.Ctx, "API: [%s] is signed up as new user", userId)
 
-	c.ResponseOk(userId)
+	// the sign up succeeds, the warning is only shown to the user
+	c.Data["json"] = Response{Status: "ok", Msg: object.GetBreachedPasswordWarning(organization, form.Password), Data: userId}
+	c.ServeJSON()
 }
 
 // Logout
@@ -215,8 +227,7 @@ func (c *ApiController) Logout() {
 	user := c.GetSessionUsername()
 	utils.LogInfo(c.Ctx, "API: [%s] logged out", user)
 
-	c.SetSessionUsername("")
-	c.SetSessionData(nil)
+	c.clearLoginSession()
 
 	c.ResponseOk(user)
 }
@@ -239,13 +250,17 @@ func (c *ApiController) GetAccount() {
 		return
 	}
 
+	object.ExtendUserWithFederatedIdentities(user)
+
 	organization := object.GetMaskedOrganization(object.GetOrganizationByUser(user))
 	resp := Response{
 		Status: "ok",
 		Sub:    user.Id,
 		Name:   user.Name,
-		Data:   user,
+		Data:   object.GetMaskedUser(user),
 		Data2:  organization,
+
+		Impersonation: c.getImpersonation(),
 	}
 	c.Data["json"] = resp
 	c.ServeJSON()
@@ -300,15 +315,30 @@ func (c *ApiController) GetUserInfo() {
 // @Title GetHumancheck
 // @router /api/get-human-check [get]
 func (c *ApiController) GetHumanCheck() {
-	c.Data["json"] = HumanCheck{Type: "none"}
-
 	provider := object.GetDefaultHumanCheckProvider()
 	if provider == nil {
 		id, img := object.GetCaptcha()
-		c.Data["json"] = HumanCheck{Type: "captcha", CaptchaId: id, CaptchaImage: img}
+		c.Data["json"] = HumanCheck{Type: object.HumanCheckTypeCaptcha, CaptchaId: id, CaptchaImage: img}
 		c.ServeJSON()
 		return
 	}
 
+	// the widget of the provider is rendered with the site key, its token is verified with the secret key
+	c.Data["json"] = HumanCheck{Type: provider.Type, AppKey: provider.ClientId}
 	c.ServeJSON()
 }
+
+// isHumanCheckPassed verifies the answer to the built-in captcha, or the token of the widget of the
+// human check provider, sent with the request
+func (c *ApiController) isHumanCheckPassed(captchaId string, key string) bool {
+	return object.VerifyHumanCheck(captchaId, key, utils.GetIPFromRequest(c.Ctx.Request))
+}
+
+// isHumanCheckFailed checks the human check of the requests which only need one once a human check
+// provider has been configured, like signing up or sending sign-in and password reset links
+func (c *ApiController) isHumanCheckFailed(captchaId string, key string) bool {
+	if object.GetDefaultHumanCheckProvider() == nil {
+		return false
+	}
+	return !c.isHumanCheckPassed(captchaId, key)
+}
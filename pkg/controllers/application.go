@@ -159,3 +159,48 @@ func (c *ApiController) DeleteApplication() {
 	c.Data["json"] = wrapActionResponse(object.DeleteApplication(&application))
 	c.ServeJSON()
 }
+
+// SyncProvisioning
+// @Title SyncProvisioning
+// @Tag Application API
+// @Description push all users and roles of the application's organization to its SCIM endpoint
+// @Param   id    query    string  true        "The id of the application"
+// @Success 200 {object} controllers.Response The Response object
+// @router /sync-provisioning [post]
+func (c *ApiController) SyncProvisioning() {
+	webform, _ := c.Input()
+	id := webform.Get("id")
+
+	application := object.GetApplication(id)
+	if application == nil {
+		c.ResponseError("No such application.")
+		return
+	}
+
+	if !object.SyncProvisioning(application) {
+		c.ResponseError("Provisioning is not enabled for the application.")
+		return
+	}
+
+	c.ResponseOk()
+}
+
+// GetProvisioningStatus
+// @Title GetProvisioningStatus
+// @Tag Application API
+// @Description get the provisioning status and the recent provisioning logs of an application
+// @Param   id    query    string  true        "The id of the application"
+// @Success 200 {object} controllers.Response The Response object
+// @router /get-provisioning-status [get]
+func (c *ApiController) GetProvisioningStatus() {
+	webform, _ := c.Input()
+	id := webform.Get("id")
+
+	application := object.GetApplication(id)
+	if application == nil {
+		c.ResponseError("No such application.")
+		return
+	}
+
+	c.ResponseOk(object.GetProvisioningStatus(application), object.GetProvisioningLogs(application, 100))
+}
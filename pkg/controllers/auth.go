@@ -44,11 +44,26 @@ func codeToResponse(code *object.Code) *Response {
 	return &Response{Status: "ok", Msg: "", Data: code.Code}
 }
 
-// HandleLoggedIn ...
-func (c *ApiController) HandleLoggedIn(application *object.Application, user *object.User, form *RequestForm) (resp *Response) {
+// HandleLoggedIn signs in the user, amr is the authentication methods references (RFC 8176)
+// of the login that are returned in the issued tokens
+func (c *ApiController) HandleLoggedIn(application *object.Application, user *object.User, form *RequestForm, amr []string) (resp *Response) {
+	if user.IsServiceAccount() {
+		return &Response{Status: "error", Msg: "The service account can't sign in interactively"}
+	}
+
+	// all the factors of the login have been verified
+	object.ResetSigninFailures(user)
+
+	// the password must be replaced before the user is signed in with it, after the second factor
+	if utils.ContainsString(amr, "pwd") && object.IsPasswordExpired(object.GetOrganizationByUser(user), user) {
+		return c.handlePasswordExpired(user, form, amr)
+	}
+
+	object.AddLoginHistory(user, c.getDevice(), utils.GetIPFromRequest(c.Ctx.Request))
+
 	userId := user.GetId()
 	if form.Type == ResponseTypeLogin {
-		c.SetSessionUsername(userId)
+		c.setLoginSession(application, user, amr)
 		utils.LogInfo(c.Ctx, "API: [%s] signed in", userId)
 		resp = &Response{Status: "ok", Msg: "", Data: userId}
 	} else if form.Type == ResponseTypeCode {
@@ -66,12 +81,13 @@ func (c *ApiController) HandleLoggedIn(application *object.Application, user *ob
 			c.ResponseError("Challenge method should be S256")
 			return
 		}
-		code := object.GetOAuthCode(userId, clientId, responseType, redirectUri, scope, state, nonce, codeChallenge)
+		actor, actorExpireTime := c.getImpersonator()
+		code := object.GetOAuthCode(userId, clientId, responseType, redirectUri, scope, state, nonce, codeChallenge, amr, actor, actorExpireTime)
 		resp = codeToResponse(code)
 
 		if application.EnableSigninSession || application.HasPromptPage() {
 			// The prompt page needs the user to be signed in
-			c.SetSessionUsername(userId)
+			c.setLoginSession(application, user, amr)
 		}
 	} else {
 		resp = &Response{Status: "error", Msg: fmt.Sprintf("Unknown response type: %s", form.Type)}
@@ -124,6 +140,84 @@ func setHttpClient(idProvider idp.IdProvider, providerType string) {
 	}
 }
 
+func getIdProvider(provider *object.Provider, clientId string, clientSecret string, redirectUri string) idp.IdProvider {
+	providerInfo := &idp.ProviderInfo{
+		Type:         provider.Type,
+		SubType:      provider.SubType,
+		ClientId:     clientId,
+		ClientSecret: clientSecret,
+		AppId:        provider.AppId,
+		IssuerUrl:    provider.IssuerUrl,
+		AuthUrl:      provider.CustomAuthUrl,
+		TokenUrl:     provider.CustomTokenUrl,
+		UserInfoUrl:  provider.CustomUserInfoUrl,
+		AuthStyle:    provider.AuthStyle,
+		Scopes:       provider.Scopes,
+		EnablePkce:   provider.EnablePkce,
+		UserMapping:  provider.UserMapping,
+	}
+
+	idProvider := idp.GetIdProvider(providerInfo, redirectUri)
+	if idProvider == nil {
+		return nil
+	}
+
+	setHttpClient(idProvider, provider.Type)
+	return idProvider
+}
+
+func (c *ApiController) getSessionString(key string) string {
+	value, ok := c.GetSession(key).(string)
+	if !ok {
+		return ""
+	}
+	return value
+}
+
+// GetOidcLogin ...
+// @Title GetOidcLogin
+// @Tag Login API
+// @Description redirect to the authorization URL of a generic OpenID Connect provider or a custom OAuth provider, the nonce and PKCE verifier are kept in the session
+// @Param   id    query    string  true        "The id of the provider"
+// @Param   state    query    string  true        "state"
+// @Param   redirectUri    query    string  true        "redirect uri"
+// @Success 302 redirect to the OpenID provider
+// @router /get-oidc-login [get]
+func (c *ApiController) GetOidcLogin() {
+	webform, _ := c.Input()
+	providerId := webform.Get("id")
+	state := webform.Get("state")
+	redirectUri := webform.Get("redirectUri")
+
+	provider := object.GetProvider(providerId)
+	if provider == nil || (provider.Type != "OpenID" && provider.Type != "Custom") {
+		c.ResponseError(fmt.Sprintf("The OpenID provider: %s is not found", providerId))
+		return
+	}
+
+	codeVerifier := utils.GenerateClientSecret() + utils.GenerateClientSecret()
+	var authUrl string
+	switch idProvider := getIdProvider(provider, provider.ClientId, provider.ClientSecret, redirectUri).(type) {
+	case *idp.OpenIdIdProvider:
+		idProvider.Nonce = utils.GenerateClientSecret()
+		idProvider.CodeVerifier = codeVerifier
+
+		var err error
+		authUrl, err = idProvider.GetAuthUrl(state)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+		c.SetSession("oidcNonce", idProvider.Nonce)
+	case *idp.CustomIdProvider:
+		idProvider.CodeVerifier = codeVerifier
+		authUrl = idProvider.GetAuthUrl(state)
+	}
+
+	c.SetSession("oidcCodeVerifier", codeVerifier)
+	c.Redirect(authUrl, 302)
+}
+
 // Login ...
 // @Title Login
 // @Tag Login API
@@ -152,15 +246,18 @@ func (c *ApiController) Login() {
 
 		var user *object.User
 		var msg string
+		var amr []string
 
 		if form.Password == "" {
 			var verificationCodeType string
 			var checkResult string
+			checkDest := form.Username
 
 			// check result through Email or Phone
 			if strings.Contains(form.Username, "@") {
 				verificationCodeType = "email"
-				checkResult = object.CheckVerificationCode(form.Username, form.Code)
+				checkResult = object.CheckVerificationCode(checkDest, form.Code)
+				amr = []string{"otp"}
 			} else {
 				verificationCodeType = "phone"
 				if len(form.PhonePrefix) == 0 {
@@ -168,8 +265,9 @@ func (c *ApiController) Login() {
 					c.ResponseError(responseText)
 					return
 				}
-				checkPhone := fmt.Sprintf("+%s%s", form.PhonePrefix, form.Username)
-				checkResult = object.CheckVerificationCode(checkPhone, form.Code)
+				checkDest = fmt.Sprintf("+%s%s", form.PhonePrefix, form.Username)
+				checkResult = object.CheckVerificationCode(checkDest, form.Code)
+				amr = []string{"sms"}
 			}
 			if len(checkResult) != 0 {
 				responseText := fmt.Sprintf("%s%s", verificationCodeType, checkResult)
@@ -178,7 +276,7 @@ func (c *ApiController) Login() {
 			}
 
 			// disable the verification code
-			object.DisableVerificationCode(form.Username)
+			object.DisableVerificationCode(checkDest)
 
 			user = object.GetUserByFields(form.Organization, form.Username)
 			if user == nil {
@@ -186,15 +284,34 @@ func (c *ApiController) Login() {
 				return
 			}
 		} else {
+			// a captcha is required once the failed sign-ins of the user or the address pile up
+			clientIp := utils.GetIPFromRequest(c.Ctx.Request)
+			if object.IsCaptchaRequired(form.Organization, form.Username, clientIp) {
+				if !c.isHumanCheckPassed(form.CaptchaId, form.CaptchaCode) {
+					c.Data["json"] = &Response{Status: ResponseStatusCaptcha, Msg: "Please complete the captcha to sign in"}
+					c.ServeJSON()
+					return
+				}
+				c.Ctx.Input.SetData("captchaVerified", true)
+			}
+
+			application := object.GetApplication(fmt.Sprintf("admin/%s", form.Application))
+			if resp := c.checkLoginRiskBeforePassword(application, object.GetUserByFields(form.Organization, form.Username), &form); resp != nil {
+				c.Data["json"] = resp
+				c.ServeJSON()
+				return
+			}
+
 			password := form.Password
-			user, msg = object.CheckUserPassword(form.Organization, form.Username, password)
+			user, msg = object.CheckUserPassword(form.Organization, form.Username, password, clientIp)
+			amr = []string{"pwd"}
 		}
 
 		if msg != "" {
 			resp = &Response{Status: "error", Msg: msg}
 		} else {
 			application := object.GetApplication(fmt.Sprintf("admin/%s", form.Application))
-			resp = c.HandleLoggedIn(application, user, &form)
+			resp = c.handleLoggedInWithMfa(application, user, &form, amr)
 
 			record := object.NewRecord(c.Ctx)
 			record.Organization = application.Organization
@@ -229,13 +346,30 @@ func (c *ApiController) Login() {
 				clientSecret = provider.ClientSecret2
 			}
 
-			idProvider := idp.GetIdProvider(provider.Type, provider.SubType, clientId, clientSecret, provider.AppId, form.RedirectUri)
+			idProvider := getIdProvider(provider, clientId, clientSecret, form.RedirectUri)
 			if idProvider == nil {
 				c.ResponseError(fmt.Sprintf("The provider type: %s is not supported", provider.Type))
 				return
 			}
 
-			setHttpClient(idProvider, provider.Type)
+			if oidcProvider, ok := idProvider.(*idp.OpenIdIdProvider); ok {
+				oidcProvider.Nonce = c.getSessionString("oidcNonce")
+				oidcProvider.CodeVerifier = c.getSessionString("oidcCodeVerifier")
+				if oidcProvider.Nonce == "" {
+					c.ResponseError("The OpenID login has not been started in this session")
+					return
+				}
+				c.DelSession("oidcNonce")
+				c.DelSession("oidcCodeVerifier")
+			}
+			if customProvider, ok := idProvider.(*idp.CustomIdProvider); ok && customProvider.EnablePkce {
+				customProvider.CodeVerifier = c.getSessionString("oidcCodeVerifier")
+				if customProvider.CodeVerifier == "" {
+					c.ResponseError("The OAuth login has not been started in this session")
+					return
+				}
+				c.DelSession("oidcCodeVerifier")
+			}
 
 			authState, err := websvr.AppConfig.String("authState")
 			if form.State != authState && form.State != application.Name {
@@ -266,13 +400,8 @@ func (c *ApiController) Login() {
 			if provider.Category == "SAML" {
 				user = object.GetUser(fmt.Sprintf("%s/%s", application.Organization, userInfo.Id))
 			} else if provider.Category == "OAuth" {
-				user = object.GetUserByField(application.Organization, provider.Type, userInfo.Id)
-				if user == nil {
-					user = object.GetUserByField(application.Organization, provider.Type, userInfo.Username)
-				}
-				if user == nil {
-					user = object.GetUserByField(application.Organization, "name", userInfo.Username)
-				}
+				// only an existing federated identity links the account, a matching username is not enough
+				user = object.GetUserByFederatedIdentity(application.Organization, provider.Name, userInfo.Id)
 			}
 
 			if user != nil && user.IsDeleted == false {
@@ -282,7 +411,8 @@ func (c *ApiController) Login() {
 					c.ResponseError("the user is forbidden to sign in, please contact the administrator")
 				}
 
-				resp = c.HandleLoggedIn(application, user, &form)
+				// the identity provider has authenticated the user
+				resp = c.handleLoggedInWithMfa(application, user, &form, []string{"fed"})
 
 				record := object.NewRecord(c.Ctx)
 				record.Organization = application.Organization
@@ -300,6 +430,11 @@ func (c *ApiController) Login() {
 					return
 				}
 
+				if object.GetUserByField(application.Organization, "name", userInfo.Username) != nil {
+					c.ResponseError(fmt.Sprintf("The username: %s already exists, please sign in to that account and link it to provider: %s from the account page", userInfo.Username, provider.Type))
+					return
+				}
+
 				properties := map[string]string{}
 				properties["no"] = strconv.Itoa(len(object.GetUsers(application.Organization)) + 2)
 				user = &object.User{
@@ -320,8 +455,6 @@ func (c *ApiController) Login() {
 					SignupApplication: application.Name,
 					Properties:        properties,
 				}
-				// sync info from 3rd-party if possible
-				object.SetUserOAuthProperties(organization, user, provider.Type, userInfo)
 
 				affected := object.AddUser(user)
 				if !affected {
@@ -329,9 +462,9 @@ func (c *ApiController) Login() {
 					return
 				}
 
-				object.LinkUserAccount(user, provider.Type, userInfo.Id)
+				object.LinkFederatedIdentity(user, provider, userInfo)
 
-				resp = c.HandleLoggedIn(application, user, &form)
+				resp = c.handleLoggedInWithMfa(application, user, &form, []string{"fed"})
 
 				record := object.NewRecord(c.Ctx)
 				record.Organization = application.Organization
@@ -348,10 +481,7 @@ func (c *ApiController) Login() {
 				return
 			}
 
-			oldUser := object.GetUserByField(application.Organization, provider.Type, userInfo.Id)
-			if oldUser == nil {
-				oldUser = object.GetUserByField(application.Organization, provider.Type, userInfo.Username)
-			}
+			oldUser := object.GetUserByFederatedIdentity(application.Organization, provider.Name, userInfo.Id)
 			if oldUser != nil {
 				c.ResponseError(fmt.Sprintf("The account for provider: %s and username: %s (%s) is already linked to another account: %s (%s)", provider.Type, userInfo.Username, userInfo.DisplayName, oldUser.Name, oldUser.DisplayName))
 				return
@@ -360,9 +490,9 @@ func (c *ApiController) Login() {
 			user := object.GetUser(userId)
 
 			// sync info from 3rd-party if possible
-			object.SetUserOAuthProperties(organization, user, provider.Type, userInfo)
+			object.SyncUserFromUserInfo(organization, user, userInfo)
 
-			isLinked := object.LinkUserAccount(user, provider.Type, userInfo.Id)
+			isLinked := object.LinkFederatedIdentity(user, provider, userInfo)
 			if isLinked {
 				resp = &Response{Status: "ok", Msg: "", Data: isLinked}
 			} else {
@@ -374,7 +504,7 @@ func (c *ApiController) Login() {
 			// user already signed in to Bhojpur IAM, so let the user click the avatar button to do the quick sign-in
 			application := object.GetApplication(fmt.Sprintf("admin/%s", form.Application))
 			user := c.getCurrentUser()
-			resp = c.HandleLoggedIn(application, user, &form)
+			resp = c.HandleLoggedIn(application, user, &form, c.getSessionAmr("amr"))
 		} else {
 			c.ResponseError(fmt.Sprintf("unknown authentication type (not password or provider), form = %s", utils.StructToJson(form)))
 			return
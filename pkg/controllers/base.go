@@ -60,6 +60,11 @@ func (c *ApiController) IsGlobalAdmin() bool {
 
 // GetSessionUsername ...
 func (c *ApiController) GetSessionUsername() string {
+	// a request signed in by a personal access token has no session
+	if userId, ok := c.Ctx.Input.GetData("personalAccessTokenUser").(string); ok {
+		return userId
+	}
+
 	// check if user session expired
 	sessionData := c.GetSessionData()
 	if sessionData != nil &&
@@ -0,0 +1,184 @@
+package controllers
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/iam/pkg/object"
+	"github.com/bhojpur/iam/pkg/utils"
+)
+
+// a CAS ticket requested with "renew" is only issued within this many seconds after signing in
+const casNewLoginSeconds = 60
+
+func (c *ApiController) isNewLogin() bool {
+	loginTime, ok := c.GetSession("loginTime").(int64)
+	return ok && time.Now().Unix()-loginTime < casNewLoginSeconds
+}
+
+func addQueryParam(rawUrl string, key string, value string) string {
+	separator := "?"
+	if strings.Contains(rawUrl, "?") {
+		separator = "&"
+	}
+	return fmt.Sprintf("%s%s%s=%s", rawUrl, separator, key, url.QueryEscape(value))
+}
+
+// CasLogin
+// @Title CasLogin
+// @Tag CAS API
+// @Description redirect to the service with a service ticket, signing the user in first if needed
+// @Param   service    query    string  true        "The URL of the service"
+// @Param   renew    query    string  false        "Whether the user must sign in again"
+// @Param   gateway    query    string  false        "Whether to redirect to the service without a ticket instead of asking the user to sign in"
+// @router /cas/login [get]
+func (c *ApiController) CasLogin() {
+	service := c.Ctx.Input.Query("service")
+	renew := c.Ctx.Input.Query("renew") == "true"
+	gateway := c.Ctx.Input.Query("gateway") == "true"
+
+	application := object.GetApplicationByCasService(service)
+	if application == nil {
+		c.ResponseError(fmt.Sprintf("The service: \"%s\" is not allowed to use CAS", service))
+		return
+	}
+
+	userId := c.GetSessionUsername()
+	if userId != "" {
+		user := object.GetUser(userId)
+		if user != nil && user.Owner == application.Organization && !user.IsForbidden && !user.IsDeleted {
+			isFromNewLogin := c.isNewLogin()
+			if !renew || isFromNewLogin {
+				ticket := object.AddCasTicket(application, user, service, isFromNewLogin)
+				utils.LogInfo(c.Ctx, "API: [%s] got CAS ticket for service: %s", userId, service)
+				c.Ctx.Redirect(http.StatusFound, addQueryParam(service, "ticket", ticket))
+				return
+			}
+		}
+	}
+
+	// "gateway" is ignored together with "renew"
+	if gateway && !renew {
+		c.Ctx.Redirect(http.StatusFound, service)
+		return
+	}
+
+	// the user signs in again, either because of "renew" or because the user of the
+	// session doesn't belong to the application's organization
+	if userId != "" {
+		c.clearLoginSession()
+	}
+
+	loginUrl := addQueryParam(fmt.Sprintf("/login/cas/%s", url.PathEscape(application.Name)), "service", service)
+	if renew {
+		loginUrl = addQueryParam(loginUrl, "renew", "true")
+	}
+	c.Ctx.Redirect(http.StatusFound, loginUrl)
+}
+
+// CasLogout
+// @Title CasLogout
+// @Tag CAS API
+// @Description sign out and redirect to the service if it is allowed to use CAS
+// @Param   service    query    string  false        "The URL to redirect to after signing out"
+// @router /cas/logout [get]
+func (c *ApiController) CasLogout() {
+	service := c.Ctx.Input.Query("service")
+
+	user := c.GetSessionUsername()
+	utils.LogInfo(c.Ctx, "API: [%s] logged out from CAS", user)
+	c.clearLoginSession()
+
+	if service != "" && object.GetApplicationByCasService(service) != nil {
+		c.Ctx.Redirect(http.StatusFound, service)
+	} else {
+		c.Ctx.Redirect(http.StatusFound, "/")
+	}
+}
+
+// validateCasTicket writes the CAS service response in XML, or in JSON when "format=JSON".
+// Proxy granting tickets are not issued, so "pgtUrl" is ignored.
+func (c *ApiController) validateCasTicket(withAttributes bool) {
+	ticket := c.Ctx.Input.Query("ticket")
+	service := c.Ctx.Input.Query("service")
+	renew := c.Ctx.Input.Query("renew") == "true"
+	format := c.Ctx.Input.Query("format")
+
+	resp := object.ValidateCasTicket(ticket, service, renew, withAttributes)
+
+	if strings.ToUpper(format) == "JSON" {
+		c.Data["json"] = map[string]interface{}{"serviceResponse": resp}
+		c.ServeJSON()
+		return
+	}
+
+	data, err := xml.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	c.Ctx.Output.Header("Content-Type", "application/xml; charset=utf-8")
+	err = c.Ctx.Output.Body(data)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// CasServiceValidate
+// @Title CasServiceValidate
+// @Tag CAS API
+// @Description validate a service ticket (CAS 2.0)
+// @Param   service    query    string  true        "The URL of the service"
+// @Param   ticket    query    string  true        "The service ticket"
+// @Param   format    query    string  false        "XML or JSON"
+// @router /cas/serviceValidate [get]
+func (c *ApiController) CasServiceValidate() {
+	c.validateCasTicket(false)
+}
+
+// CasProxyValidate
+// @Title CasProxyValidate
+// @Tag CAS API
+// @Description validate a service ticket (CAS 2.0)
+// @Param   service    query    string  true        "The URL of the service"
+// @Param   ticket    query    string  true        "The service ticket"
+// @Param   format    query    string  false        "XML or JSON"
+// @router /cas/proxyValidate [get]
+func (c *ApiController) CasProxyValidate() {
+	c.validateCasTicket(false)
+}
+
+// CasP3ServiceValidate
+// @Title CasP3ServiceValidate
+// @Tag CAS API
+// @Description validate a service ticket and release the user attributes configured for the application (CAS 3.0)
+// @Param   service    query    string  true        "The URL of the service"
+// @Param   ticket    query    string  true        "The service ticket"
+// @Param   format    query    string  false        "XML or JSON"
+// @router /cas/p3/serviceValidate [get]
+func (c *ApiController) CasP3ServiceValidate() {
+	c.validateCasTicket(true)
+}
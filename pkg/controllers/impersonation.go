@@ -0,0 +1,154 @@
+package controllers
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/iam/pkg/object"
+	"github.com/bhojpur/iam/pkg/utils"
+)
+
+// ImpersonationInfo tells the web UI that the current user is impersonated by an admin
+type ImpersonationInfo struct {
+	Impersonator string `json:"impersonator"`
+	ExpireTime   int64  `json:"expireTime"`
+}
+
+func (c *ApiController) getImpersonation() *ImpersonationInfo {
+	impersonator := c.getSessionString("impersonator")
+	if impersonator == "" {
+		return nil
+	}
+
+	expireTime, _ := c.GetSession("impersonationExpireTime").(int64)
+	return &ImpersonationInfo{Impersonator: impersonator, ExpireTime: expireTime}
+}
+
+// getImpersonator returns the admin impersonating the current user if any, and the end of the
+// impersonation in Unix seconds
+func (c *ApiController) getImpersonator() (string, int64) {
+	expireTime, _ := c.GetSession("impersonationExpireTime").(int64)
+	return c.getSessionString("impersonator"), expireTime
+}
+
+// requireNotImpersonated responds with an error and returns false while the current user is
+// impersonated, so that the admin can't create or change the credentials of the user
+func (c *ApiController) requireNotImpersonated() bool {
+	if c.getSessionString("impersonator") != "" {
+		c.ResponseError("The credentials of the user can't be changed during an impersonation")
+		return false
+	}
+	return true
+}
+
+func (c *ApiController) clearImpersonation() {
+	c.DelSession("impersonator")
+	c.DelSession("impersonatorSessionId")
+	c.DelSession("impersonatorLoginTime")
+	c.DelSession("impersonatorAmr")
+	c.DelSession("impersonationExpireTime")
+}
+
+// ImpersonateUser
+// @Title ImpersonateUser
+// @Tag User API
+// @Description sign in as a user for a limited time, for the admins of the user. The requests are recorded with the admin
+// @Param   id    formData    string  true        "The id of the user"
+// @Success 200 {object} controllers.Response The Response object
+// @router /impersonate-user [post]
+func (c *ApiController) ImpersonateUser() {
+	actorId, ok := c.RequireSignedIn()
+	if !ok {
+		return
+	}
+	if c.getSessionString("impersonator") != "" {
+		c.ResponseError("Please end the current impersonation first")
+		return
+	}
+	if strings.HasPrefix(actorId, "app/") {
+		c.ResponseError("Only users can impersonate other users")
+		return
+	}
+
+	actor := object.GetUser(actorId)
+	if actor == nil {
+		c.ResponseError("Session outdated. Please login again.")
+		return
+	}
+	userId := c.Ctx.Request.Form.Get("id")
+	user := object.GetUser(userId)
+	if user == nil {
+		c.ResponseError(fmt.Sprintf("The user: %s doesn't exist", userId))
+		return
+	}
+	if err := object.CheckImpersonation(actor, user); err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	// the session of the admin is restored when the impersonation ends
+	loginTime, _ := c.GetSession("loginTime").(int64)
+	c.SetSession("impersonator", actorId)
+	c.SetSession("impersonatorSessionId", c.getSessionString("sessionId"))
+	c.SetSession("impersonatorLoginTime", loginTime)
+	c.SetSession("impersonatorAmr", c.getSessionString("amr"))
+	c.SetSession("impersonationExpireTime", time.Now().Unix()+object.GetImpersonationTimeout())
+
+	sessionId := object.AddUserSession(user, "", utils.GetIPFromRequest(c.Ctx.Request), c.Ctx.Request.UserAgent(), c.getSessionAmr("amr"), actorId)
+	c.SetSessionUsername(user.GetId())
+	c.SetSession("loginTime", time.Now().Unix())
+	c.SetSession("sessionId", sessionId)
+
+	utils.LogInfo(c.Ctx, "API: [%s] started impersonating [%s]", actorId, user.GetId())
+	c.ResponseOk(user.GetId())
+}
+
+// EndImpersonation
+// @Title EndImpersonation
+// @Tag User API
+// @Description end the impersonation of the current user, and sign the admin back in
+// @Success 200 {object} controllers.Response The Response object
+// @router /end-impersonation [post]
+func (c *ApiController) EndImpersonation() {
+	actorId := c.getSessionString("impersonator")
+	if actorId == "" {
+		c.ResponseError("You are not impersonating any user")
+		return
+	}
+
+	userId := c.GetSessionUsername()
+	if sessionId := c.getSessionString("sessionId"); sessionId != "" {
+		object.DeleteUserSession(sessionId)
+	}
+
+	loginTime, _ := c.GetSession("impersonatorLoginTime").(int64)
+	c.SetSessionUsername(actorId)
+	c.SetSession("loginTime", loginTime)
+	c.SetSession("amr", c.getSessionString("impersonatorAmr"))
+	c.SetSession("sessionId", c.getSessionString("impersonatorSessionId"))
+	c.clearImpersonation()
+
+	utils.LogInfo(c.Ctx, "API: [%s] ended impersonating [%s]", actorId, userId)
+	c.ResponseOk(actorId)
+}
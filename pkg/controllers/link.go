@@ -27,7 +27,8 @@ import (
 )
 
 type LinkForm struct {
-	ProviderType string `json:"providerType"`
+	Provider string `json:"provider"`
+	Subject  string `json:"subject"`
 }
 
 // Unlink ...
@@ -44,18 +45,18 @@ func (c *ApiController) Unlink() {
 	if err != nil {
 		panic(err)
 	}
-	providerType := form.ProviderType
 
-	user := object.GetUser(userId)
-	value := object.GetUserField(user, providerType)
-
-	if value == "" {
-		c.ResponseError("Please link first", value)
+	if form.Provider == "" {
+		c.ResponseError("Missing parameter: provider")
 		return
 	}
 
-	object.ClearUserOAuthProperties(user, providerType)
+	user := object.GetUser(userId)
+	affected := object.UnlinkFederatedIdentity(user, form.Provider, form.Subject)
+	if !affected {
+		c.ResponseError("Please link first")
+		return
+	}
 
-	object.LinkUserAccount(user, providerType, "")
 	c.ResponseOk()
 }
@@ -0,0 +1,175 @@
+package controllers
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bhojpur/iam/pkg/object"
+	"github.com/bhojpur/iam/pkg/utils"
+)
+
+const magicLinkRequestedMsg = "If the account exists and has an email, a sign-in link has been sent to it"
+
+type MagicLinkForm struct {
+	RequestForm
+
+	LoginUrl string `json:"loginUrl"`
+	Token    string `json:"token"`
+}
+
+// getMagicLinkNonce returns the nonce binding the sign-in links to the browser session
+func (c *ApiController) getMagicLinkNonce() string {
+	nonce := c.getSessionString("magicLinkNonce")
+	if nonce == "" {
+		nonce = utils.GenerateId()
+		c.SetSession("magicLinkNonce", nonce)
+	}
+	return nonce
+}
+
+// SendMagicLink
+// @Title SendMagicLink
+// @Tag Login API
+// @Description email a single-use sign-in link, to be opened in the same browser
+// @Param   oAuthParams     query    string  true        "oAuth parameters"
+// @Param   body    body   controllers.MagicLinkForm  true        "The application, username, login type and login page URL"
+// @Success 200 {object} controllers.Response The Response object
+// @router /send-magic-link [post]
+func (c *ApiController) SendMagicLink() {
+	var form MagicLinkForm
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &form)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	username := strings.TrimSpace(form.Username)
+	if form.Application == "" || username == "" {
+		c.ResponseError("Missing parameter.")
+		return
+	}
+	if form.Type == ResponseTypeLogin && c.GetSessionUsername() != "" {
+		c.ResponseError("Please sign out first before signing in", c.GetSessionUsername())
+		return
+	}
+	if !object.IsMagicLinkLoginUrlValid(form.LoginUrl) {
+		c.ResponseError(fmt.Sprintf("The login URL: %s is invalid", form.LoginUrl))
+		return
+	}
+
+	application := object.GetApplication(fmt.Sprintf("admin/%s", form.Application))
+	if application == nil {
+		c.ResponseError(fmt.Sprintf("The application: %s does not exist", form.Application))
+		return
+	}
+	if !application.EnableMagicLink || application.GetEmailProvider() == nil {
+		c.ResponseError(fmt.Sprintf("The application: %s doesn't allow signing in with an email link", form.Application))
+		return
+	}
+
+	if c.isHumanCheckFailed(form.CaptchaId, form.CaptchaCode) {
+		c.ResponseError("Turing test failed.")
+		return
+	}
+
+	clientIp := utils.GetIPFromRequest(c.Ctx.Request)
+	if object.IsMagicLinkRateLimited(clientIp) {
+		c.ResponseError("Too many sign-in links have been requested, please try again later")
+		return
+	}
+
+	// whatever happens from here, the response is the same
+	user := object.GetUserByFields(application.Organization, username)
+	if user != nil && !user.IsForbidden && !user.IsDeleted {
+		pendingForm := RequestForm{Type: form.Type, AutoSignin: form.AutoSignin}
+		err = object.SendMagicLink(application, user, utils.StructToJson(pendingForm), c.Ctx.Request.URL.RawQuery, c.getMagicLinkNonce(), form.LoginUrl, clientIp)
+		if err != nil {
+			utils.LogWarning(c.Ctx, "API: failed to send the sign-in link of [%s]: %s", user.GetId(), err.Error())
+		} else {
+			utils.LogInfo(c.Ctx, "API: [%s] requested a sign-in link", user.GetId())
+		}
+	}
+
+	c.Data["json"] = Response{Status: "ok", Msg: magicLinkRequestedMsg}
+	c.ServeJSON()
+}
+
+// LoginWithMagicLink
+// @Title LoginWithMagicLink
+// @Tag Login API
+// @Description sign in with the link sent by email, from the browser session which requested it
+// @Param   oAuthParams     query    string  true        "oAuth parameters"
+// @Param   body    body   controllers.MagicLinkForm  true        "The application and the token of the link"
+// @Success 200 {object} controllers.Response The Response object
+// @router /login-magic-link [post]
+func (c *ApiController) LoginWithMagicLink() {
+	var magicLinkForm MagicLinkForm
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &magicLinkForm)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	application := object.GetApplication(fmt.Sprintf("admin/%s", magicLinkForm.Application))
+	if application == nil || !application.EnableMagicLink {
+		c.ResponseError(fmt.Sprintf("The application: %s doesn't allow signing in with an email link", magicLinkForm.Application))
+		return
+	}
+
+	link, err := object.ConsumeMagicLink(application, magicLinkForm.Token, c.getSessionString("magicLinkNonce"), c.Ctx.Request.URL.RawQuery)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	var form RequestForm
+	err = json.Unmarshal([]byte(link.Form), &form)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+	if form.Type == ResponseTypeLogin && c.GetSessionUsername() != "" {
+		c.ResponseError("Please sign out first before signing in", c.GetSessionUsername())
+		return
+	}
+	form.Application = application.Name
+	form.Organization = link.Organization
+
+	user := object.GetUser(fmt.Sprintf("%s/%s", link.Organization, link.User))
+	if user == nil || user.IsForbidden || user.IsDeleted {
+		c.ResponseError(fmt.Sprintf("The user: %s/%s doesn't exist", link.Organization, link.User))
+		return
+	}
+
+	c.DelSession("magicLinkNonce")
+	resp := c.handleLoggedInWithMfa(application, user, &form, []string{"otp"})
+
+	record := object.NewRecord(c.Ctx)
+	record.Organization = application.Organization
+	record.User = user.Name
+	go object.AddRecord(record)
+
+	c.Data["json"] = resp
+	c.ServeJSON()
+}
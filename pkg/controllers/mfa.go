@@ -0,0 +1,403 @@
+package controllers
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/iam/pkg/object"
+	"github.com/bhojpur/iam/pkg/utils"
+)
+
+const (
+	ResponseStatusMfa       = "mfa"
+	ResponseStatusMfaEnroll = "mfaEnroll"
+	ResponseStatusCaptcha   = "captcha"
+
+	// the second login step must be completed within this time
+	mfaLoginTimeout = 300
+)
+
+type MfaForm struct {
+	Passcode     string `json:"passcode"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+func (c *ApiController) getSessionAmr(key string) []string {
+	value := c.getSessionString(key)
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, ",")
+}
+
+// handleLoggedInWithMfa signs the user in after the first factor given by amr, or applies the
+// MFA policy of the application: the login is kept pending in the session until the user has
+// verified one of the allowed second factors, or has enrolled one when MFA is required. A first
+// step that has already used several factors, like a passkey, only goes through the risk checks.
+func (c *ApiController) handleLoggedInWithMfa(application *object.Application, user *object.User, form *RequestForm, amr []string) *Response {
+	resp, isRiskMfaRequired := c.checkLoginRisk(application, user, form)
+	if resp != nil {
+		return resp
+	}
+	if utils.ContainsString(amr, "mfa") {
+		return c.HandleLoggedIn(application, user, form, amr)
+	}
+
+	policy := object.GetMfaPolicy(object.GetOrganizationByUser(user), application)
+	// a risky login asks for a second factor even when the policy doesn't, but a factor can't
+	// be enrolled in it
+	if isRiskMfaRequired {
+		policy = getRiskMfaPolicy(application, user)
+	}
+	mfaTypes := policy.GetUserMfaTypes(user)
+	isEnrollment := len(mfaTypes) == 0 && policy.IsRequired(user) && !isRiskMfaRequired
+	if len(mfaTypes) == 0 && !isEnrollment {
+		return c.HandleLoggedIn(application, user, form, amr)
+	}
+
+	// the credentials of the first step are not needed anymore
+	pendingForm := *form
+	pendingForm.Password = ""
+	pendingForm.Code = ""
+	pendingForm.SamlResponse = ""
+
+	c.SetSession("mfaUserId", user.GetId())
+	c.SetSession("mfaForm", utils.StructToJson(pendingForm))
+	c.SetSession("mfaTime", time.Now().Unix())
+	c.SetSession("mfaTypes", strings.Join(policy.Types, ","))
+	c.SetSession("mfaAmr", strings.Join(amr, ","))
+	c.SetSession("mfaEnroll", isEnrollment)
+	c.DelSession("mfaEnrolled")
+
+	if isEnrollment {
+		return &Response{Status: ResponseStatusMfaEnroll, Msg: "Please set up a second factor to sign in", Data: policy.Types}
+	}
+	return &Response{Status: ResponseStatusMfa, Msg: "Please verify with your second factor", Data: mfaTypes}
+}
+
+func (c *ApiController) clearMfaLogin() {
+	c.DelSession("mfaUserId")
+	c.DelSession("mfaForm")
+	c.DelSession("mfaTime")
+	c.DelSession("mfaTypes")
+	c.DelSession("mfaAmr")
+	c.DelSession("mfaEnroll")
+	c.DelSession("mfaEnrolled")
+}
+
+// isMfaTypeAllowed checks the factor type against the policy of the pending login
+func (c *ApiController) isMfaTypeAllowed(mfaType string) bool {
+	policy := &object.MfaPolicy{Types: c.getSessionAmr("mfaTypes")}
+	return policy.IsTypeAllowed(mfaType)
+}
+
+// getMfaEnrollmentUser returns the user to enroll a second factor for: the current user, or
+// the user of a pending login that must enroll one before signing in
+func (c *ApiController) getMfaEnrollmentUser(mfaType string) (*object.User, bool) {
+	userId := c.GetSessionUsername()
+	if userId == "" {
+		isEnrollment, _ := c.GetSession("mfaEnroll").(bool)
+		if isEnrollment {
+			userId = c.getPendingMfaUserId()
+		}
+		if userId != "" && !c.isMfaTypeAllowed(mfaType) {
+			c.ResponseError(fmt.Sprintf("The second factor: %s is not allowed", mfaType))
+			return nil, false
+		}
+	}
+	if userId == "" {
+		c.ResponseError("Please sign in first")
+		return nil, false
+	}
+
+	user := object.GetUser(userId)
+	if user == nil {
+		c.ResponseError(fmt.Sprintf("The user: %s doesn't exist", userId))
+		return nil, false
+	}
+	return user, true
+}
+
+// setMfaEnrolled marks the pending login as verified by the factor that has just been enrolled
+func (c *ApiController) setMfaEnrolled(user *object.User, mfaType string) {
+	isEnrollment, _ := c.GetSession("mfaEnroll").(bool)
+	if isEnrollment && c.GetSessionUsername() == "" && c.getPendingMfaUserId() == user.GetId() {
+		c.SetSession("mfaEnrolled", mfaType)
+	}
+}
+
+// checkMfaLockout returns false and responds with the error when the user of the pending login is
+// locked out. The failures of the second factor are counted with the ones of the password, so
+// that signing in again doesn't allow more attempts.
+func (c *ApiController) checkMfaLockout(user *object.User) bool {
+	msg := object.CheckSigninLockout(object.GetOrganizationByUser(user), user, utils.GetIPFromRequest(c.Ctx.Request))
+	if msg != "" {
+		c.ResponseError(msg)
+		return false
+	}
+	return true
+}
+
+func (c *ApiController) addMfaFailure(user *object.User, msg string) {
+	object.AddSigninFailure(user.Owner, user, utils.GetIPFromRequest(c.Ctx.Request), msg)
+}
+
+// completeMfaLogin signs in the user of the pending login with the second factor
+func (c *ApiController) completeMfaLogin(user *object.User, mfaType string) {
+	var form RequestForm
+	err := utils.JsonToStruct(c.getSessionString("mfaForm"), &form)
+	amr := object.AddAmr(c.getSessionAmr("mfaAmr"), object.GetAmr(mfaType)...)
+	c.clearMfaLogin()
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	application := object.GetApplication(fmt.Sprintf("admin/%s", form.Application))
+	resp := c.HandleLoggedIn(application, user, &form, amr)
+
+	record := object.NewRecord(c.Ctx)
+	record.Organization = application.Organization
+	record.User = user.Name
+	go object.AddRecord(record)
+
+	c.Data["json"] = resp
+	c.ServeJSON()
+}
+
+// VerifyMfa
+// @Title VerifyMfa
+// @Tag Login API
+// @Description complete a login that requires MFA with a passcode of the authenticator app or a recovery code, or after the enrollment of a second factor
+// @Param   oAuthParams     query    string  true        "oAuth parameters"
+// @Param   body    body   controllers.MfaForm  true        "The passcode or the recovery code"
+// @Success 200 {object} controllers.Response The Response object
+// @router /verify-mfa [post]
+func (c *ApiController) VerifyMfa() {
+	var mfaForm MfaForm
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &mfaForm)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	userId := c.getPendingMfaUserId()
+	if userId == "" {
+		c.clearMfaLogin()
+		c.ResponseError("The login has expired, please sign in again")
+		return
+	}
+
+	user := object.GetUser(userId)
+	if user == nil || user.IsForbidden || user.IsDeleted {
+		c.clearMfaLogin()
+		c.ResponseError(fmt.Sprintf("The user: %s doesn't exist", userId))
+		return
+	}
+
+	if mfaType := c.getSessionString("mfaEnrolled"); mfaType != "" {
+		c.completeMfaLogin(user, mfaType)
+		return
+	}
+
+	mfaType := object.MfaTypeTotp
+	if mfaForm.RecoveryCode != "" {
+		mfaType = "recovery"
+	}
+	if !c.isMfaTypeAllowed(mfaType) {
+		c.ResponseError(fmt.Sprintf("The second factor: %s is not allowed", mfaType))
+		return
+	}
+
+	if !c.checkMfaLockout(user) {
+		return
+	}
+
+	msg := object.CheckMfa(user, mfaForm.Passcode, mfaForm.RecoveryCode)
+	if msg != "" {
+		c.addMfaFailure(user, msg)
+		c.ResponseError(msg)
+		return
+	}
+
+	c.completeMfaLogin(user, mfaType)
+}
+
+// InitiateTotp
+// @Title InitiateTotp
+// @Tag MFA API
+// @Description generate a TOTP secret for the current user, or for the user of a pending login that must enroll a second factor, it is kept in the session until it is confirmed by enable-totp
+// @Success 200 {object} controllers.Response The Response object, data is the secret and data2 is the otpauth:// URI
+// @router /initiate-totp [post]
+func (c *ApiController) InitiateTotp() {
+	if !c.requireNotImpersonated() {
+		return
+	}
+
+	user, ok := c.getMfaEnrollmentUser(object.MfaTypeTotp)
+	if !ok {
+		return
+	}
+	if user.TotpSecret != "" {
+		c.ResponseError("TOTP has already been enabled for the user")
+		return
+	}
+	if !object.IsTotpEncryptionConfigured() {
+		c.ResponseError("TOTP is not available, totpEncryptionKey is not configured")
+		return
+	}
+
+	issuer := "Bhojpur IAM"
+	organization := object.GetOrganizationByUser(user)
+	if organization != nil && organization.DisplayName != "" {
+		issuer = organization.DisplayName
+	}
+
+	secret := object.GenerateTotpSecret()
+	c.SetSession("totpSecret", secret)
+	c.ResponseOk(secret, object.GetTotpUri(issuer, user.Name, secret))
+}
+
+// EnableTotp
+// @Title EnableTotp
+// @Tag MFA API
+// @Description confirm the TOTP secret of initiate-totp with a passcode and enable MFA for the current user
+// @Param   passcode    formData    string  true        "The passcode of the authenticator app"
+// @Success 200 {object} controllers.Response The Response object, data is the recovery codes
+// @router /enable-totp [post]
+func (c *ApiController) EnableTotp() {
+	if !c.requireNotImpersonated() {
+		return
+	}
+
+	user, ok := c.getMfaEnrollmentUser(object.MfaTypeTotp)
+	if !ok {
+		return
+	}
+
+	secret := c.getSessionString("totpSecret")
+	if secret == "" {
+		c.ResponseError("Please generate a TOTP secret first")
+		return
+	}
+
+	codes, msg := object.EnableTotp(user, secret, c.Ctx.Request.Form.Get("passcode"))
+	if msg != "" {
+		c.ResponseError(msg)
+		return
+	}
+
+	c.DelSession("totpSecret")
+	c.setMfaEnrolled(user, object.MfaTypeTotp)
+	utils.LogInfo(c.Ctx, "API: [%s] enabled TOTP", user.GetId())
+	c.ResponseOk(codes)
+}
+
+// DisableTotp
+// @Title DisableTotp
+// @Tag MFA API
+// @Description remove the authenticator app and the recovery codes of the current user
+// @Param   passcode    formData    string  false        "The passcode of the authenticator app"
+// @Param   recoveryCode    formData    string  false        "A recovery code"
+// @Success 200 {object} controllers.Response The Response object
+// @router /disable-totp [post]
+func (c *ApiController) DisableTotp() {
+	if !c.requireNotImpersonated() {
+		return
+	}
+
+	user, ok := c.requireMfaUser()
+	if !ok {
+		return
+	}
+
+	object.DisableTotp(user)
+	utils.LogInfo(c.Ctx, "API: [%s] disabled TOTP", user.GetId())
+	c.ResponseOk()
+}
+
+// RegenerateRecoveryCodes
+// @Title RegenerateRecoveryCodes
+// @Tag MFA API
+// @Description replace the recovery codes of the current user
+// @Param   passcode    formData    string  false        "The passcode of the authenticator app"
+// @Param   recoveryCode    formData    string  false        "A recovery code"
+// @Success 200 {object} controllers.Response The Response object, data is the recovery codes
+// @router /regenerate-recovery-codes [post]
+func (c *ApiController) RegenerateRecoveryCodes() {
+	if !c.requireNotImpersonated() {
+		return
+	}
+
+	user, ok := c.requireMfaUser()
+	if !ok {
+		return
+	}
+
+	c.ResponseOk(object.RegenerateRecoveryCodes(user))
+}
+
+// requireMfaUser returns the current user after checking the second factor of the request
+func (c *ApiController) requireMfaUser() (*object.User, bool) {
+	userId, ok := c.RequireSignedIn()
+	if !ok {
+		return nil, false
+	}
+
+	user := object.GetUser(userId)
+	if user == nil {
+		c.ResponseError(fmt.Sprintf("The user: %s doesn't exist", userId))
+		return nil, false
+	}
+
+	msg := object.CheckMfa(user, c.Ctx.Request.Form.Get("passcode"), c.Ctx.Request.Form.Get("recoveryCode"))
+	if msg != "" {
+		c.ResponseError(msg)
+		return nil, false
+	}
+	return user, true
+}
+
+// ResetMfa
+// @Title ResetMfa
+// @Tag MFA API
+// @Description remove the authenticator app and the recovery codes of a user, for the admins
+// @Param   id    formData    string  true        "The id of the user"
+// @Success 200 {object} controllers.Response The Response object
+// @router /reset-mfa [post]
+func (c *ApiController) ResetMfa() {
+	if !c.requireNotImpersonated() {
+		return
+	}
+
+	targetUser, ok := c.requireUserAdmin(c.Ctx.Request.Form.Get("id"))
+	if !ok {
+		return
+	}
+
+	utils.LogInfo(c.Ctx, "API: [%s] reset MFA of [%s]", c.GetSessionUsername(), targetUser.GetId())
+	c.Data["json"] = wrapActionResponse(object.ResetMfa(targetUser))
+	c.ServeJSON()
+}
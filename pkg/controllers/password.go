@@ -0,0 +1,222 @@
+package controllers
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/iam/pkg/object"
+	"github.com/bhojpur/iam/pkg/utils"
+)
+
+const ResponseStatusPasswordExpired = "passwordExpired"
+
+// handlePasswordExpired keeps the login pending in the session until the user has replaced the
+// expired password, the first factor and the second one have already been verified
+func (c *ApiController) handlePasswordExpired(user *object.User, form *RequestForm, amr []string) *Response {
+	pendingForm := *form
+	pendingForm.Password = ""
+	pendingForm.Code = ""
+	pendingForm.SamlResponse = ""
+
+	c.SetSession("passwordExpiredUserId", user.GetId())
+	c.SetSession("passwordExpiredForm", utils.StructToJson(pendingForm))
+	c.SetSession("passwordExpiredTime", time.Now().Unix())
+	c.SetSession("passwordExpiredAmr", strings.Join(amr, ","))
+	return &Response{Status: ResponseStatusPasswordExpired, Msg: "Your password has expired, please set a new one"}
+}
+
+func (c *ApiController) clearPasswordExpired() {
+	c.DelSession("passwordExpiredUserId")
+	c.DelSession("passwordExpiredForm")
+	c.DelSession("passwordExpiredTime")
+	c.DelSession("passwordExpiredAmr")
+}
+
+// ChangeExpiredPassword
+// @Title ChangeExpiredPassword
+// @Tag Login API
+// @Description set a new password for a login whose password has expired, and continue the login
+// @Param   oAuthParams     query    string  true        "oAuth parameters"
+// @Param   newPassword    formData    string  true        "The new password"
+// @Success 200 {object} controllers.Response The Response object
+// @router /change-expired-password [post]
+func (c *ApiController) ChangeExpiredPassword() {
+	userId := c.getSessionString("passwordExpiredUserId")
+	startTime, _ := c.GetSession("passwordExpiredTime").(int64)
+	if userId == "" || time.Now().Unix()-startTime > mfaLoginTimeout {
+		c.clearPasswordExpired()
+		c.ResponseError("The login has expired, please sign in again")
+		return
+	}
+
+	user := object.GetUser(userId)
+	if user == nil || user.IsForbidden || user.IsDeleted {
+		c.clearPasswordExpired()
+		c.ResponseError(fmt.Sprintf("The user: %s doesn't exist", userId))
+		return
+	}
+
+	newPassword := c.Ctx.Request.Form.Get("newPassword")
+	if strings.Contains(newPassword, " ") {
+		c.ResponseError("New password cannot contain blank space.")
+		return
+	}
+	organization := object.GetOrganizationByUser(user)
+	if violations := object.CheckPasswordPolicy(organization, user, newPassword); len(violations) != 0 {
+		c.ResponseError(object.GetPasswordViolationMsg(violations), violations)
+		return
+	}
+
+	var form RequestForm
+	err := utils.JsonToStruct(c.getSessionString("passwordExpiredForm"), &form)
+	amr := c.getSessionAmr("passwordExpiredAmr")
+	c.clearPasswordExpired()
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	user.Password = newPassword
+	object.SetUserField(user, "password", user.Password)
+	utils.LogInfo(c.Ctx, "API: [%s] changed the expired password", userId)
+
+	application := object.GetApplication(fmt.Sprintf("admin/%s", form.Application))
+	resp := c.HandleLoggedIn(application, user, &form, amr)
+	if resp.Status == "ok" && resp.Msg == "" {
+		resp.Msg = object.GetBreachedPasswordWarning(organization, newPassword)
+	}
+	c.Data["json"] = resp
+	c.ServeJSON()
+}
+
+// passwordResetRequestedMsg is the response to every password reset request, so that the
+// request doesn't reveal whether an account exists
+const passwordResetRequestedMsg = "If the account exists, a password reset link or code has been sent to its email or phone"
+
+// RequestPasswordReset
+// @Title RequestPasswordReset
+// @Tag Login API
+// @Description send a password reset link or code to the email or phone of the user
+// @Param   application     formData    string  true        "The application name"
+// @Param   username    formData    string  true        "The username, email or phone of the user"
+// @Param   captchaId    formData    string  false        "The id of the captcha"
+// @Param   captchaCode    formData    string  false        "The answer to the captcha, or the token of the human check widget"
+// @Success 200 {object} controllers.Response The Response object
+// @router /request-password-reset [post]
+func (c *ApiController) RequestPasswordReset() {
+	applicationName := c.Ctx.Request.Form.Get("application")
+	username := strings.TrimSpace(c.Ctx.Request.Form.Get("username"))
+	if applicationName == "" || username == "" {
+		c.ResponseError("Missing parameter.")
+		return
+	}
+
+	application := object.GetApplication(fmt.Sprintf("admin/%s", applicationName))
+	if application == nil {
+		c.ResponseError(fmt.Sprintf("The application: %s does not exist", applicationName))
+		return
+	}
+	if application.GetEmailProvider() == nil && application.GetSmsProvider() == nil {
+		c.ResponseError("The application has no Email or SMS provider to send a password reset")
+		return
+	}
+	if c.isHumanCheckFailed(c.Ctx.Request.Form.Get("captchaId"), c.Ctx.Request.Form.Get("captchaCode")) {
+		c.ResponseError("Turing test failed.")
+		return
+	}
+
+	// whatever happens from here, the response is the same
+	user := object.GetUserByFields(application.Organization, username)
+	if user != nil && !user.IsForbidden && !user.IsDeleted && user.Ldap == "" {
+		err := object.SendPasswordReset(application, user, utils.GetIPFromRequest(c.Ctx.Request))
+		if err != nil {
+			utils.LogWarning(c.Ctx, "API: failed to send the password reset of [%s]: %s", user.GetId(), err.Error())
+		} else {
+			utils.LogInfo(c.Ctx, "API: [%s] requested a password reset", user.GetId())
+		}
+	}
+
+	c.Data["json"] = Response{Status: "ok", Msg: passwordResetRequestedMsg}
+	c.ServeJSON()
+}
+
+// ResetPassword
+// @Title ResetPassword
+// @Tag Login API
+// @Description set a new password with the link sent by email or the code sent by SMS, then sign the user out everywhere
+// @Param   application     formData    string  true        "The application name"
+// @Param   token    formData    string  false        "The token of the password reset link"
+// @Param   username    formData    string  false        "The username, email or phone of the user, with the code"
+// @Param   code    formData    string  false        "The password reset code"
+// @Param   newPassword    formData    string  true        "The new password"
+// @Success 200 {object} controllers.Response The Response object
+// @router /reset-password [post]
+func (c *ApiController) ResetPassword() {
+	applicationName := c.Ctx.Request.Form.Get("application")
+	token := c.Ctx.Request.Form.Get("token")
+	username := strings.TrimSpace(c.Ctx.Request.Form.Get("username"))
+	code := c.Ctx.Request.Form.Get("code")
+	newPassword := c.Ctx.Request.Form.Get("newPassword")
+	if applicationName == "" || newPassword == "" || (token == "" && (username == "" || code == "")) {
+		c.ResponseError("Missing parameter.")
+		return
+	}
+	if strings.Contains(newPassword, " ") {
+		c.ResponseError("New password cannot contain blank space.")
+		return
+	}
+
+	application := object.GetApplication(fmt.Sprintf("admin/%s", applicationName))
+	if application == nil {
+		c.ResponseError(fmt.Sprintf("The application: %s does not exist", applicationName))
+		return
+	}
+
+	var reset *object.PasswordReset
+	var err error
+	if token != "" {
+		reset, err = object.GetPasswordResetByToken(application, token)
+	} else {
+		reset, err = object.GetPasswordResetByCode(application, object.GetUserByFields(application.Organization, username), code)
+	}
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	violations, err := object.ResetUserPassword(reset, newPassword, utils.GetIPFromRequest(c.Ctx.Request))
+	if err != nil {
+		if len(violations) != 0 {
+			c.ResponseError(err.Error(), violations)
+		} else {
+			c.ResponseError(err.Error())
+		}
+		return
+	}
+
+	utils.LogInfo(c.Ctx, "API: [%s/%s] reset the password", reset.Organization, reset.User)
+	organization := object.GetOrganization(fmt.Sprintf("admin/%s", reset.Organization))
+	c.Data["json"] = Response{Status: "ok", Msg: object.GetBreachedPasswordWarning(organization, newPassword)}
+	c.ServeJSON()
+}
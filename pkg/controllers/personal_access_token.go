@@ -0,0 +1,132 @@
+package controllers
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bhojpur/iam/pkg/object"
+	"github.com/bhojpur/iam/pkg/utils"
+)
+
+type PersonalAccessTokenForm struct {
+	DisplayName string   `json:"displayName"`
+	Scopes      []string `json:"scopes"`
+	ExpireTime  string   `json:"expireTime"`
+}
+
+// isPersonalAccessTokenRequest checks whether the request is authenticated by a personal access
+// token instead of a web session
+func (c *ApiController) isPersonalAccessTokenRequest() bool {
+	_, ok := c.Ctx.Input.GetData("personalAccessTokenUser").(string)
+	return ok
+}
+
+// GetPersonalAccessTokens
+// @Title GetPersonalAccessTokens
+// @Tag User API
+// @Description get the personal access tokens of the current user, or of a user for its admins
+// @Param   id    query    string  false        "The id of the user, the current user by default"
+// @Success 200 {array} object.PersonalAccessToken The Response object
+// @router /get-personal-access-tokens [get]
+func (c *ApiController) GetPersonalAccessTokens() {
+	user, ok := c.requireSelfOrUserAdmin(c.Ctx.Input.Query("id"))
+	if !ok {
+		return
+	}
+
+	c.ResponseOk(object.GetPersonalAccessTokens(user))
+}
+
+// AddPersonalAccessToken
+// @Title AddPersonalAccessToken
+// @Tag User API
+// @Description create a personal access token for the current user, the token is only returned in this response
+// @Param   body    body   controllers.PersonalAccessTokenForm  true        "The details of the token"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-personal-access-token [post]
+func (c *ApiController) AddPersonalAccessToken() {
+	if !c.requireNotImpersonated() {
+		return
+	}
+
+	userId, ok := c.RequireSignedIn()
+	if !ok {
+		return
+	}
+	if strings.HasPrefix(userId, "app/") {
+		c.ResponseError("Only users can create personal access tokens")
+		return
+	}
+	// a token can't be used to create further tokens that outlive it
+	if c.isPersonalAccessTokenRequest() {
+		c.ResponseError("Personal access tokens can't be created with a personal access token")
+		return
+	}
+
+	user := object.GetUser(userId)
+	if user == nil {
+		c.ResponseError("Session outdated. Please login again.")
+		return
+	}
+
+	var form PersonalAccessTokenForm
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &form)
+	if err != nil {
+		panic(err)
+	}
+
+	pat, token, err := object.AddPersonalAccessToken(user, form.DisplayName, form.Scopes, form.ExpireTime)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	utils.LogInfo(c.Ctx, "API: [%s] created the personal access token [%s]", userId, pat.GetId())
+	c.ResponseOk(pat, token)
+}
+
+// DeletePersonalAccessToken
+// @Title DeletePersonalAccessToken
+// @Tag User API
+// @Description revoke a personal access token of the current user, or of a user for its admins
+// @Param   id    formData    string  true        "The id of the token"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-personal-access-token [post]
+func (c *ApiController) DeletePersonalAccessToken() {
+	id := c.Ctx.Request.Form.Get("id")
+	pat := object.GetPersonalAccessToken(id)
+	if pat == nil {
+		c.ResponseError(fmt.Sprintf("The personal access token: %s doesn't exist", id))
+		return
+	}
+
+	user, ok := c.requireSelfOrUserAdmin(fmt.Sprintf("%s/%s", pat.Owner, pat.User))
+	if !ok {
+		return
+	}
+
+	utils.LogInfo(c.Ctx, "API: [%s] revoked the personal access token [%s] of [%s]", c.GetSessionUsername(), id, user.GetId())
+	c.Data["json"] = wrapActionResponse(object.DeletePersonalAccessToken(pat))
+	c.ServeJSON()
+}
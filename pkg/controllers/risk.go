@@ -0,0 +1,148 @@
+package controllers
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"github.com/bhojpur/iam/pkg/object"
+	"github.com/bhojpur/iam/pkg/utils"
+	logsvr "github.com/bhojpur/logger/pkg/engine"
+)
+
+const (
+	deviceCookieName   = "iam_device"
+	deviceCookieMaxAge = 365 * 24 * 3600
+)
+
+// getDevice returns the fingerprint of the browser, from a random id kept in a long-lived cookie
+// that is set on the first login from the browser
+func (c *ApiController) getDevice() string {
+	deviceId := c.Ctx.GetCookie(deviceCookieName)
+	if deviceId == "" {
+		deviceId, _ = c.Ctx.Input.GetData(deviceCookieName).(string)
+	}
+	if deviceId == "" {
+		deviceId = utils.GenerateId()
+		c.Ctx.SetCookie(deviceCookieName, deviceId, deviceCookieMaxAge, "/", nil, c.Ctx.Input.IsSecure(), true)
+		c.Ctx.Input.SetData(deviceCookieName, deviceId)
+	}
+
+	return utils.GetSha256Hash(deviceId)
+}
+
+// isCaptchaVerified checks the captcha of the login form, unless it has already been verified
+// for the failed sign-ins earlier in the request
+func (c *ApiController) isCaptchaVerified(form *RequestForm) bool {
+	if isVerified, _ := c.Ctx.Input.GetData("captchaVerified").(bool); isVerified {
+		return true
+	}
+
+	isHuman := c.isHumanCheckPassed(form.CaptchaId, form.CaptchaCode)
+	if isHuman {
+		c.Ctx.Input.SetData("captchaVerified", true)
+	}
+	return isHuman
+}
+
+// getRiskMfaPolicy returns the MFA policy of a risky login: a second factor is required even
+// when the policy of the organization or the application doesn't require one
+func getRiskMfaPolicy(application *object.Application, user *object.User) *object.MfaPolicy {
+	policy := object.GetMfaPolicy(object.GetOrganizationByUser(user), application)
+	return &object.MfaPolicy{Policy: object.MfaPolicyRequired, Types: policy.Types}
+}
+
+// getLoginRiskAssessment assesses the risk of the login of the user once per request, as both
+// the checks before and after the password use it
+func (c *ApiController) getLoginRiskAssessment(user *object.User) *object.RiskAssessment {
+	if assessment, ok := c.Ctx.Input.GetData("riskAssessment").(*object.RiskAssessment); ok {
+		return assessment
+	}
+
+	assessment := object.AssessLoginRisk(user, c.getDevice(), utils.GetIPFromRequest(c.Ctx.Request))
+	c.Ctx.Input.SetData("riskAssessment", assessment)
+	return assessment
+}
+
+// getLoginRisk applies the risk rules of the organization to the login of the user. It returns
+// the response that stops the login when it is blocked or needs a captcha, whether a second
+// factor is required and whether the user is told about the login.
+func (c *ApiController) getLoginRisk(application *object.Application, user *object.User, form *RequestForm) (*Response, bool, bool) {
+	assessment := c.getLoginRiskAssessment(user)
+	if assessment.HasAction(object.RiskActionAllow) {
+		return nil, false, false
+	}
+
+	if assessment.HasAction(object.RiskActionBlock) {
+		utils.LogWarning(c.Ctx, "API: the login of [%s] is blocked with the risk score %d", user.GetId(), assessment.Score)
+		return &Response{Status: "error", Msg: "The sign-in looks unusual and has been blocked, please contact the administrator"}, false, false
+	}
+
+	// a user without a second factor can't be asked for one, a captcha is asked instead and the
+	// user is told about the login
+	isMfaRequired := assessment.HasAction(object.RiskActionMfa)
+	isCaptchaRequired := assessment.HasAction(object.RiskActionCaptcha)
+	isNotified := assessment.HasAction(object.RiskActionNotify)
+	if isMfaRequired && len(getRiskMfaPolicy(application, user).GetUserMfaTypes(user)) == 0 {
+		isMfaRequired = false
+		isCaptchaRequired = true
+		isNotified = true
+	}
+
+	if isCaptchaRequired && !c.isCaptchaVerified(form) {
+		return &Response{Status: ResponseStatusCaptcha, Msg: "Please complete the captcha to sign in"}, false, false
+	}
+
+	return nil, isMfaRequired, isNotified
+}
+
+// checkLoginRiskBeforePassword blocks the password login or asks for its captcha before the
+// password is checked, so that these responses don't tell whether the password is correct
+func (c *ApiController) checkLoginRiskBeforePassword(application *object.Application, user *object.User, form *RequestForm) *Response {
+	if user == nil || user.IsDeleted {
+		return nil
+	}
+
+	resp, _, _ := c.getLoginRisk(application, user, form)
+	return resp
+}
+
+// checkLoginRisk applies the risk rules of the organization to the login of the user after its
+// first factor. It returns the response that stops the login when it is blocked or needs a
+// captcha, and whether a second factor is required.
+func (c *ApiController) checkLoginRisk(application *object.Application, user *object.User, form *RequestForm) (*Response, bool) {
+	resp, isMfaRequired, isNotified := c.getLoginRisk(application, user, form)
+	if resp != nil {
+		return resp, false
+	}
+
+	if isNotified && application != nil {
+		// the request is over when the notification is sent, so nothing is read from it there
+		assessment := c.getLoginRiskAssessment(user)
+		clientIp := utils.GetIPFromRequest(c.Ctx.Request)
+		go func() {
+			err := object.SendRiskNotification(application, user, clientIp, assessment)
+			if err != nil {
+				logsvr.Warning("(%s) API: failed to notify [%s] of the unusual login: %s", clientIp, user.GetId(), err.Error())
+			}
+		}()
+	}
+
+	return nil, isMfaRequired
+}
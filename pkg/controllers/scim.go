@@ -0,0 +1,616 @@
+package controllers
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bhojpur/iam/pkg/object"
+	"github.com/bhojpur/iam/pkg/scim"
+	"github.com/bhojpur/iam/pkg/utils"
+	websvr "github.com/bhojpur/web/pkg/engine"
+)
+
+func getScimBaseUrl() string {
+	origin, _ := websvr.AppConfig.String("origin")
+	return fmt.Sprintf("%s/scim/v2", strings.TrimSuffix(origin, "/"))
+}
+
+func (c *ApiController) scimResponse(status int, data interface{}) {
+	c.Ctx.Output.Header("Content-Type", "application/scim+json; charset=utf-8")
+	c.Ctx.Output.SetStatus(status)
+	if data == nil {
+		c.Ctx.Output.Body([]byte{})
+		return
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		panic(err)
+	}
+	c.Ctx.Output.Body(body)
+}
+
+func (c *ApiController) scimError(err error) {
+	scimErr, ok := err.(*scim.Error)
+	if !ok {
+		scimErr = scim.NewError(400, "invalidValue", err.Error())
+	}
+	c.scimResponse(scimErr.GetStatus(), scimErr)
+}
+
+func (c *ApiController) scimResourceResponse(status int, resource map[string]interface{}) {
+	c.Ctx.Output.Header("ETag", scim.GetVersion(resource))
+	c.Ctx.Output.Header("Location", resource["meta"].(map[string]interface{})["location"].(string))
+	c.scimResponse(status, resource)
+}
+
+// getScimOrganization authenticates the request by the SCIM bearer token of an organization
+func (c *ApiController) getScimOrganization() (*object.Organization, bool) {
+	token := ""
+	tokens := strings.SplitN(c.Ctx.Request.Header.Get("Authorization"), " ", 2)
+	if len(tokens) == 2 && strings.EqualFold(tokens[0], "Bearer") {
+		token = strings.TrimSpace(tokens[1])
+	}
+
+	organization := object.GetOrganizationByScimToken(token)
+	if organization == nil {
+		c.Ctx.Output.Header("WWW-Authenticate", "Bearer")
+		c.scimError(scim.NewError(401, "", "invalid SCIM token"))
+		return nil, false
+	}
+
+	return organization, true
+}
+
+func (c *ApiController) getScimBody() (map[string]interface{}, bool) {
+	var resource map[string]interface{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &resource)
+	if err != nil || resource == nil {
+		c.scimError(scim.NewError(400, "invalidSyntax", "the request body is not a JSON object"))
+		return nil, false
+	}
+	return resource, true
+}
+
+// checkScimPrecondition enforces the If-Match header of modifying requests
+func (c *ApiController) checkScimPrecondition(resource map[string]interface{}) bool {
+	ifMatch := c.Ctx.Request.Header.Get("If-Match")
+	if ifMatch != "" && !scim.MatchETag(ifMatch, scim.GetVersion(resource)) {
+		c.scimError(scim.NewError(412, "", "the resource has been modified"))
+		return false
+	}
+	return true
+}
+
+// writeScimResource answers a GET of a single resource, honoring If-None-Match
+func (c *ApiController) writeScimResource(resource map[string]interface{}) {
+	ifNoneMatch := c.Ctx.Request.Header.Get("If-None-Match")
+	if ifNoneMatch != "" && scim.MatchETag(ifNoneMatch, scim.GetVersion(resource)) {
+		c.Ctx.Output.Header("ETag", scim.GetVersion(resource))
+		c.scimResponse(304, nil)
+		return
+	}
+
+	c.scimResourceResponse(200, scim.Project(resource, c.Ctx.Input.Query("attributes"), c.Ctx.Input.Query("excludedAttributes")))
+}
+
+func (c *ApiController) writeScimList(resources []map[string]interface{}) {
+	var filter scim.Filter
+	var err error
+	if s := c.Ctx.Input.Query("filter"); s != "" {
+		filter, err = scim.ParseFilter(s)
+		if err != nil {
+			c.scimError(scim.NewError(400, "invalidFilter", err.Error()))
+			return
+		}
+	}
+
+	startIndex := 1
+	if s := c.Ctx.Input.Query("startIndex"); s != "" {
+		startIndex = utils.ParseInt(s)
+	}
+	count := scim.MaxResults
+	if s := c.Ctx.Input.Query("count"); s != "" {
+		count = utils.ParseInt(s)
+	}
+
+	resp := scim.NewListResponse(resources, filter, startIndex, count)
+	for i, resource := range resp.Resources {
+		resp.Resources[i] = scim.Project(resource, c.Ctx.Input.Query("attributes"), c.Ctx.Input.Query("excludedAttributes"))
+	}
+	c.scimResponse(200, resp)
+}
+
+func getScimUser(organization *object.Organization, id string) *object.User {
+	user := object.GetUserByField(organization.Name, "id", id)
+	if user == nil || user.IsDeleted {
+		return nil
+	}
+	return user
+}
+
+func (c *ApiController) saveScimUser(organization *object.Organization, user *object.User, resource map[string]interface{}) {
+	oldUser := *user
+	password, err := scim.ApplyUserResource(resource, user)
+	if err != nil {
+		c.scimError(err)
+		return
+	}
+	if password != "" {
+		if violations := object.CheckPasswordPolicy(organization, &oldUser, password); len(violations) != 0 {
+			c.scimError(scim.NewError(400, "invalidValue", object.GetPasswordViolationMsg(violations)))
+			return
+		}
+	}
+
+	user.UpdatedTime = utils.GetCurrentTime()
+	columns := []string{"external_id", "display_name", "title", "language", "homepage", "email", "phone", "avatar", "is_forbidden", "properties", "updated_time"}
+	object.UpdateUser(user.GetId(), user, columns, false)
+	if password != "" {
+		user.Password = password
+		object.SetUserField(user, "password", password)
+	}
+
+	user = getScimUser(organization, user.Id)
+	c.scimResourceResponse(200, scim.GetUserResource(user, object.GetRoles(organization.Name), getScimBaseUrl()))
+}
+
+// ScimGetServiceProviderConfig ...
+// @router /scim/v2/ServiceProviderConfig [get]
+// @Tag SCIM API
+func (c *ApiController) ScimGetServiceProviderConfig() {
+	c.scimResponse(200, scim.GetServiceProviderConfig(getScimBaseUrl()))
+}
+
+// ScimGetResourceTypes ...
+// @router /scim/v2/ResourceTypes [get]
+// @Tag SCIM API
+func (c *ApiController) ScimGetResourceTypes() {
+	id := c.Ctx.Input.Param(":id")
+	resourceTypes := scim.GetResourceTypes(getScimBaseUrl())
+	if id == "" {
+		c.scimResponse(200, scim.NewListResponse(resourceTypes, nil, 1, scim.MaxResults))
+		return
+	}
+
+	for _, resourceType := range resourceTypes {
+		if resourceType["id"] == id {
+			c.scimResponse(200, resourceType)
+			return
+		}
+	}
+	c.scimError(scim.NewError(404, "", fmt.Sprintf("resource type %s not found", id)))
+}
+
+// ScimGetSchemas ...
+// @router /scim/v2/Schemas [get]
+// @Tag SCIM API
+func (c *ApiController) ScimGetSchemas() {
+	id := c.Ctx.Input.Param(":id")
+	schemas := scim.GetSchemas(getScimBaseUrl())
+	if id == "" {
+		c.scimResponse(200, scim.NewListResponse(schemas, nil, 1, scim.MaxResults))
+		return
+	}
+
+	for _, schema := range schemas {
+		if schema["id"] == id {
+			c.scimResponse(200, schema)
+			return
+		}
+	}
+	c.scimError(scim.NewError(404, "", fmt.Sprintf("schema %s not found", id)))
+}
+
+// ScimGetUsers ...
+// @router /scim/v2/Users [get]
+// @Tag SCIM API
+func (c *ApiController) ScimGetUsers() {
+	organization, ok := c.getScimOrganization()
+	if !ok {
+		return
+	}
+
+	roles := object.GetRoles(organization.Name)
+	resources := []map[string]interface{}{}
+	for _, user := range object.GetUsers(organization.Name) {
+		if !user.IsDeleted {
+			resources = append(resources, scim.GetUserResource(user, roles, getScimBaseUrl()))
+		}
+	}
+	c.writeScimList(resources)
+}
+
+// ScimGetUser ...
+// @router /scim/v2/Users/:id [get]
+// @Tag SCIM API
+func (c *ApiController) ScimGetUser() {
+	organization, ok := c.getScimOrganization()
+	if !ok {
+		return
+	}
+
+	user := getScimUser(organization, c.Ctx.Input.Param(":id"))
+	if user == nil {
+		c.scimError(scim.NewError(404, "", "user not found"))
+		return
+	}
+
+	c.writeScimResource(scim.GetUserResource(user, object.GetRoles(organization.Name), getScimBaseUrl()))
+}
+
+// ScimAddUser ...
+// @router /scim/v2/Users [post]
+// @Tag SCIM API
+func (c *ApiController) ScimAddUser() {
+	organization, ok := c.getScimOrganization()
+	if !ok {
+		return
+	}
+
+	resource, ok := c.getScimBody()
+	if !ok {
+		return
+	}
+
+	user := &object.User{
+		Owner:       organization.Name,
+		CreatedTime: utils.GetCurrentTime(),
+		Id:          utils.GenerateId(),
+		Type:        "normal-user",
+		Avatar:      organization.DefaultAvatar,
+		Address:     []string{},
+		Score:       getInitScore(),
+	}
+	password, err := scim.ApplyUserResource(resource, user)
+	if err != nil {
+		c.scimError(err)
+		return
+	}
+
+	if object.GetUserByField(organization.Name, "name", user.Name) != nil {
+		c.scimError(scim.NewError(409, "uniqueness", fmt.Sprintf("the user %s already exists", user.Name)))
+		return
+	}
+
+	if password != "" {
+		if violations := object.CheckPasswordPolicy(organization, nil, password); len(violations) != 0 {
+			c.scimError(scim.NewError(400, "invalidValue", object.GetPasswordViolationMsg(violations)))
+			return
+		}
+	}
+
+	user.Password = password
+	if !object.AddUser(user) {
+		c.scimError(scim.NewError(400, "invalidValue", "failed to add the user"))
+		return
+	}
+
+	user = getScimUser(organization, user.Id)
+	c.scimResourceResponse(201, scim.GetUserResource(user, object.GetRoles(organization.Name), getScimBaseUrl()))
+}
+
+// ScimReplaceUser ...
+// @router /scim/v2/Users/:id [put]
+// @Tag SCIM API
+func (c *ApiController) ScimReplaceUser() {
+	organization, ok := c.getScimOrganization()
+	if !ok {
+		return
+	}
+
+	user := getScimUser(organization, c.Ctx.Input.Param(":id"))
+	if user == nil {
+		c.scimError(scim.NewError(404, "", "user not found"))
+		return
+	}
+
+	if !c.checkScimPrecondition(scim.GetUserResource(user, object.GetRoles(organization.Name), getScimBaseUrl())) {
+		return
+	}
+
+	resource, ok := c.getScimBody()
+	if !ok {
+		return
+	}
+
+	c.saveScimUser(organization, user, resource)
+}
+
+// ScimPatchUser ...
+// @router /scim/v2/Users/:id [patch]
+// @Tag SCIM API
+func (c *ApiController) ScimPatchUser() {
+	organization, ok := c.getScimOrganization()
+	if !ok {
+		return
+	}
+
+	user := getScimUser(organization, c.Ctx.Input.Param(":id"))
+	if user == nil {
+		c.scimError(scim.NewError(404, "", "user not found"))
+		return
+	}
+
+	resource := scim.GetUserResource(user, object.GetRoles(organization.Name), getScimBaseUrl())
+	if !c.checkScimPrecondition(resource) {
+		return
+	}
+
+	var request scim.PatchRequest
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &request)
+	if err != nil {
+		c.scimError(scim.NewError(400, "invalidSyntax", err.Error()))
+		return
+	}
+
+	err = scim.ApplyPatch(resource, request.Operations)
+	if err != nil {
+		c.scimError(err)
+		return
+	}
+
+	c.saveScimUser(organization, user, resource)
+}
+
+// ScimDeleteUser ...
+// @router /scim/v2/Users/:id [delete]
+// @Tag SCIM API
+func (c *ApiController) ScimDeleteUser() {
+	organization, ok := c.getScimOrganization()
+	if !ok {
+		return
+	}
+
+	user := getScimUser(organization, c.Ctx.Input.Param(":id"))
+	if user == nil {
+		c.scimError(scim.NewError(404, "", "user not found"))
+		return
+	}
+
+	roles := object.GetRoles(organization.Name)
+	if !c.checkScimPrecondition(scim.GetUserResource(user, roles, getScimBaseUrl())) {
+		return
+	}
+
+	// drop the memberships as well, so that a user re-created with the same name starts clean
+	for _, role := range roles {
+		users := []string{}
+		for _, userId := range role.Users {
+			if userId != user.GetId() {
+				users = append(users, userId)
+			}
+		}
+		if len(users) != len(role.Users) {
+			role.Users = users
+			object.UpdateRole(role.GetId(), role)
+		}
+	}
+
+	object.DeleteUser(user)
+	c.scimResponse(204, nil)
+}
+
+func getScimUserMap(organization *object.Organization) map[string]*object.User {
+	users := map[string]*object.User{}
+	for _, user := range object.GetUsers(organization.Name) {
+		if !user.IsDeleted {
+			users[user.GetId()] = user
+		}
+	}
+	return users
+}
+
+func getScimRole(organization *object.Organization, id string) *object.Role {
+	return object.GetRole(fmt.Sprintf("%s/%s", organization.Name, id))
+}
+
+// getScimMember resolves the "value" of a group member: the SCIM id of a user, or the id of
+// another group
+func getScimMember(organization *object.Organization) func(value string) (string, bool, error) {
+	return func(value string) (string, bool, error) {
+		if user := getScimUser(organization, value); user != nil {
+			return user.GetId(), false, nil
+		}
+		if role := getScimRole(organization, value); role != nil {
+			return role.GetId(), true, nil
+		}
+		return "", false, scim.NewError(400, "invalidValue", fmt.Sprintf("the member %s does not exist", value))
+	}
+}
+
+func (c *ApiController) saveScimGroup(organization *object.Organization, role *object.Role, resource map[string]interface{}) {
+	err := scim.ApplyGroupResource(resource, role, getScimMember(organization))
+	if err != nil {
+		c.scimError(err)
+		return
+	}
+
+	object.UpdateRole(role.GetId(), role)
+
+	role = getScimRole(organization, role.Name)
+	c.scimResourceResponse(200, scim.GetGroupResource(role, getScimUserMap(organization), getScimBaseUrl()))
+}
+
+// ScimGetGroups ...
+// @router /scim/v2/Groups [get]
+// @Tag SCIM API
+func (c *ApiController) ScimGetGroups() {
+	organization, ok := c.getScimOrganization()
+	if !ok {
+		return
+	}
+
+	users := getScimUserMap(organization)
+	resources := []map[string]interface{}{}
+	for _, role := range object.GetRoles(organization.Name) {
+		resources = append(resources, scim.GetGroupResource(role, users, getScimBaseUrl()))
+	}
+	c.writeScimList(resources)
+}
+
+// ScimGetGroup ...
+// @router /scim/v2/Groups/:id [get]
+// @Tag SCIM API
+func (c *ApiController) ScimGetGroup() {
+	organization, ok := c.getScimOrganization()
+	if !ok {
+		return
+	}
+
+	role := getScimRole(organization, c.Ctx.Input.Param(":id"))
+	if role == nil {
+		c.scimError(scim.NewError(404, "", "group not found"))
+		return
+	}
+
+	c.writeScimResource(scim.GetGroupResource(role, getScimUserMap(organization), getScimBaseUrl()))
+}
+
+// ScimAddGroup ...
+// @router /scim/v2/Groups [post]
+// @Tag SCIM API
+func (c *ApiController) ScimAddGroup() {
+	organization, ok := c.getScimOrganization()
+	if !ok {
+		return
+	}
+
+	resource, ok := c.getScimBody()
+	if !ok {
+		return
+	}
+
+	role := &object.Role{
+		Owner:       organization.Name,
+		CreatedTime: utils.GetCurrentTime(),
+		IsEnabled:   true,
+	}
+	err := scim.ApplyGroupResource(resource, role, getScimMember(organization))
+	if err != nil {
+		c.scimError(err)
+		return
+	}
+
+	role.Name = strings.TrimSpace(role.DisplayName)
+	if role.Name == "" || strings.ContainsAny(role.Name, "/") {
+		c.scimError(scim.NewError(400, "invalidValue", fmt.Sprintf("invalid displayName: %s", role.Name)))
+		return
+	}
+	if getScimRole(organization, role.Name) != nil {
+		c.scimError(scim.NewError(409, "uniqueness", fmt.Sprintf("the group %s already exists", role.Name)))
+		return
+	}
+
+	object.AddRole(role)
+
+	role = getScimRole(organization, role.Name)
+	c.scimResourceResponse(201, scim.GetGroupResource(role, getScimUserMap(organization), getScimBaseUrl()))
+}
+
+// ScimReplaceGroup ...
+// @router /scim/v2/Groups/:id [put]
+// @Tag SCIM API
+func (c *ApiController) ScimReplaceGroup() {
+	organization, ok := c.getScimOrganization()
+	if !ok {
+		return
+	}
+
+	role := getScimRole(organization, c.Ctx.Input.Param(":id"))
+	if role == nil {
+		c.scimError(scim.NewError(404, "", "group not found"))
+		return
+	}
+
+	if !c.checkScimPrecondition(scim.GetGroupResource(role, getScimUserMap(organization), getScimBaseUrl())) {
+		return
+	}
+
+	resource, ok := c.getScimBody()
+	if !ok {
+		return
+	}
+
+	c.saveScimGroup(organization, role, resource)
+}
+
+// ScimPatchGroup ...
+// @router /scim/v2/Groups/:id [patch]
+// @Tag SCIM API
+func (c *ApiController) ScimPatchGroup() {
+	organization, ok := c.getScimOrganization()
+	if !ok {
+		return
+	}
+
+	role := getScimRole(organization, c.Ctx.Input.Param(":id"))
+	if role == nil {
+		c.scimError(scim.NewError(404, "", "group not found"))
+		return
+	}
+
+	resource := scim.GetGroupResource(role, getScimUserMap(organization), getScimBaseUrl())
+	if !c.checkScimPrecondition(resource) {
+		return
+	}
+
+	var request scim.PatchRequest
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &request)
+	if err != nil {
+		c.scimError(scim.NewError(400, "invalidSyntax", err.Error()))
+		return
+	}
+
+	err = scim.ApplyPatch(resource, request.Operations)
+	if err != nil {
+		c.scimError(err)
+		return
+	}
+
+	c.saveScimGroup(organization, role, resource)
+}
+
+// ScimDeleteGroup ...
+// @router /scim/v2/Groups/:id [delete]
+// @Tag SCIM API
+func (c *ApiController) ScimDeleteGroup() {
+	organization, ok := c.getScimOrganization()
+	if !ok {
+		return
+	}
+
+	role := getScimRole(organization, c.Ctx.Input.Param(":id"))
+	if role == nil {
+		c.scimError(scim.NewError(404, "", "group not found"))
+		return
+	}
+
+	if !c.checkScimPrecondition(scim.GetGroupResource(role, getScimUserMap(organization), getScimBaseUrl())) {
+		return
+	}
+
+	object.DeleteRole(role)
+	c.scimResponse(204, nil)
+}
@@ -0,0 +1,117 @@
+package controllers
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+
+	"github.com/bhojpur/iam/pkg/object"
+	"github.com/bhojpur/iam/pkg/utils"
+)
+
+// requireServiceAccountAdmin returns the service account of the id after checking that the
+// current user administers it
+func (c *ApiController) requireServiceAccountAdmin(userId string) (*object.User, bool) {
+	user, ok := c.requireUserAdmin(userId)
+	if !ok {
+		return nil, false
+	}
+	if !user.IsServiceAccount() {
+		c.ResponseError(fmt.Sprintf("The user: %s is not a service account", userId))
+		return nil, false
+	}
+
+	return user, true
+}
+
+// GetServiceAccountCredentials
+// @Title GetServiceAccountCredentials
+// @Tag User API
+// @Description get the credentials of a service account, for its admins
+// @Param   id    query    string  true        "The id of the service account"
+// @Success 200 {array} object.ServiceAccountCredential The Response object
+// @router /get-service-account-credentials [get]
+func (c *ApiController) GetServiceAccountCredentials() {
+	user, ok := c.requireServiceAccountAdmin(c.Ctx.Input.Query("id"))
+	if !ok {
+		return
+	}
+
+	c.ResponseOk(object.GetServiceAccountCredentials(user))
+}
+
+// AddServiceAccountCredential
+// @Title AddServiceAccountCredential
+// @Tag User API
+// @Description add a client secret or a public key to a service account, for its admins. A credential is rotated by adding a new one before deleting the old one. A generated secret is only returned in this response
+// @Param   id    formData    string  true        "The id of the service account"
+// @Param   type    formData    string  true        "The type of the credential: secret or public-key"
+// @Param   publicKey    formData    string  false        "The PEM encoded public key, for the public-key type"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-service-account-credential [post]
+func (c *ApiController) AddServiceAccountCredential() {
+	if !c.requireNotImpersonated() {
+		return
+	}
+
+	user, ok := c.requireServiceAccountAdmin(c.Ctx.Request.Form.Get("id"))
+	if !ok {
+		return
+	}
+
+	credential, secret, err := object.AddServiceAccountCredential(user, c.Ctx.Request.Form.Get("type"), c.Ctx.Request.Form.Get("publicKey"))
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	utils.LogInfo(c.Ctx, "API: [%s] added the credential [%s] to the service account [%s]", c.GetSessionUsername(), credential.GetId(), user.GetId())
+	c.ResponseOk(credential, secret)
+}
+
+// DeleteServiceAccountCredential
+// @Title DeleteServiceAccountCredential
+// @Tag User API
+// @Description delete a credential of a service account, for its admins
+// @Param   id    formData    string  true        "The id of the credential"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-service-account-credential [post]
+func (c *ApiController) DeleteServiceAccountCredential() {
+	if !c.requireNotImpersonated() {
+		return
+	}
+
+	id := c.Ctx.Request.Form.Get("id")
+	credential := object.GetServiceAccountCredential(id)
+	if credential == nil {
+		c.ResponseError(fmt.Sprintf("The credential: %s doesn't exist", id))
+		return
+	}
+
+	user, ok := c.requireServiceAccountAdmin(fmt.Sprintf("%s/%s", credential.Owner, credential.User))
+	if !ok {
+		return
+	}
+
+	utils.LogInfo(c.Ctx, "API: [%s] deleted the credential [%s] of the service account [%s]", c.GetSessionUsername(), id, user.GetId())
+	c.Data["json"] = wrapActionResponse(object.DeleteServiceAccountCredential(credential))
+	c.ServeJSON()
+}
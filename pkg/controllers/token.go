@@ -160,7 +160,8 @@ func (c *ApiController) GetOAuthCode() {
 		return
 	}
 
-	c.Data["json"] = object.GetOAuthCode(userId, clientId, responseType, redirectUri, scope, state, nonce, codeChallenge)
+	actor, actorExpireTime := c.getImpersonator()
+	c.Data["json"] = object.GetOAuthCode(userId, clientId, responseType, redirectUri, scope, state, nonce, codeChallenge, c.getSessionAmr("amr"), actor, actorExpireTime)
 	c.ServeJSON()
 }
 
@@ -172,6 +173,7 @@ func (c *ApiController) GetOAuthCode() {
 // @Param   client_id     query    string  true        "OAuth client id"
 // @Param   client_secret     query    string  true        "OAuth client secret"
 // @Param   code     query    string  true        "OAuth code"
+// @Param   assertion     query    string  false        "JWT assertion of a service account, for the jwt-bearer grant type"
 // @Success 200 {object} object.TokenWrapper The Response object
 // @router /login/oauth/access_token [post]
 func (c *ApiController) GetOAuthToken() {
@@ -186,6 +188,13 @@ func (c *ApiController) GetOAuthToken() {
 		clientId, clientSecret, _ = c.Ctx.Request.BasicAuth()
 	}
 
+	// the service accounts authenticate with their own credentials instead of an authorization code
+	if grantType == object.GrantTypeClientCredentials || grantType == object.GrantTypeJwtBearer {
+		c.Data["json"] = object.GetServiceAccountToken(grantType, clientId, clientSecret, webform.Get("assertion"), webform.Get("scope"), utils.GetIPFromRequest(c.Ctx.Request))
+		c.ServeJSON()
+		return
+	}
+
 	c.Data["json"] = object.GetOAuthToken(grantType, clientId, clientSecret, code, verifier)
 	c.ServeJSON()
 }
@@ -50,7 +50,7 @@ func (c *ApiController) GetGlobalUsers() {
 	} else {
 		limit := utils.ParseInt(limit)
 		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetGlobalUserCount(field, value)))
-		users := object.GetPaginationGlobalUsers(paginator.Offset(), limit, field, value, sortField, sortOrder)
+		users := object.GetMaskedUsers(object.GetPaginationGlobalUsers(paginator.Offset(), limit, field, value, sortField, sortOrder))
 		c.ResponseOk(users, paginator.Nums())
 	}
 }
@@ -77,7 +77,7 @@ func (c *ApiController) GetUsers() {
 	} else {
 		limit := utils.ParseInt(limit)
 		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetUserCount(owner, field, value)))
-		users := object.GetPaginationUsers(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		users := object.GetMaskedUsers(object.GetPaginationUsers(owner, paginator.Offset(), limit, field, value, sortField, sortOrder))
 		c.ResponseOk(users, paginator.Nums())
 	}
 }
@@ -102,6 +102,8 @@ func (c *ApiController) GetUser() {
 		user = object.GetUserByEmail(owner, email)
 	}
 
+	object.ExtendUserWithFederatedIdentities(user)
+
 	c.Data["json"] = object.GetMaskedUser(user)
 	c.ServeJSON()
 }
@@ -159,6 +161,14 @@ func (c *ApiController) AddUser() {
 		panic(err)
 	}
 
+	if user.Password != "" {
+		organization := object.GetOrganization(fmt.Sprintf("admin/%s", user.Owner))
+		if violations := object.CheckPasswordPolicy(organization, nil, user.Password); len(violations) != 0 {
+			c.ResponseError(object.GetPasswordViolationMsg(violations), violations)
+			return
+		}
+	}
+
 	c.Data["json"] = wrapActionResponse(object.AddUser(&user))
 	c.ServeJSON()
 }
@@ -227,6 +237,10 @@ func (c *ApiController) GetEmailAndPhone() {
 // @Success 200 {object} controllers.Response The Response object
 // @router /set-password [post]
 func (c *ApiController) SetPassword() {
+	if !c.requireNotImpersonated() {
+		return
+	}
+
 	userOwner := c.Ctx.Request.Form.Get("userOwner")
 	userName := c.Ctx.Request.Form.Get("userName")
 	oldPassword := c.Ctx.Request.Form.Get("oldPassword")
@@ -246,8 +260,10 @@ func (c *ApiController) SetPassword() {
 	}
 
 	hasPermission := false
+	isAdmin := false
 	if strings.HasPrefix(requestUserId, "app/") {
 		hasPermission = true
+		isAdmin = true
 	} else {
 		requestUser := object.GetUser(requestUserId)
 		if requestUser == nil {
@@ -256,10 +272,12 @@ func (c *ApiController) SetPassword() {
 		}
 		if requestUser.IsGlobalAdmin {
 			hasPermission = true
+			isAdmin = true
 		} else if requestUserId == userId {
 			hasPermission = true
 		} else if targetUser.Owner == requestUser.Owner && requestUser.IsAdmin {
 			hasPermission = true
+			isAdmin = true
 		}
 	}
 	if !hasPermission {
@@ -280,14 +298,77 @@ func (c *ApiController) SetPassword() {
 		return
 	}
 
-	if len(newPassword) <= 5 {
-		c.ResponseError("New password must have at least 6 characters")
+	organization := object.GetOrganizationByUser(targetUser)
+	if !isAdmin {
+		// the admins can reset the password at any time
+		if violation := object.CheckPasswordMinAge(organization, targetUser); violation != nil {
+			c.ResponseError(violation.Msg, []*object.PasswordViolation{violation})
+			return
+		}
+	}
+	if violations := object.CheckPasswordPolicy(organization, targetUser, newPassword); len(violations) != 0 {
+		c.ResponseError(object.GetPasswordViolationMsg(violations), violations)
 		return
 	}
 
 	targetUser.Password = newPassword
 	object.SetUserField(targetUser, "password", targetUser.Password)
-	c.Data["json"] = Response{Status: "ok"}
+	c.Data["json"] = Response{Status: "ok", Msg: object.GetBreachedPasswordWarning(organization, newPassword)}
+	c.ServeJSON()
+}
+
+// requireUserAdmin returns the user of the id after checking that the current user administers it:
+// the global admins, the built-in users and the admins of the organization of the user. Like
+// impersonation, the global admins and the built-in users are only administered by global admins.
+func (c *ApiController) requireUserAdmin(userId string) (*object.User, bool) {
+	requestUserId, ok := c.RequireSignedIn()
+	if !ok {
+		return nil, false
+	}
+
+	targetUser := object.GetUser(userId)
+	if targetUser == nil {
+		c.ResponseError(fmt.Sprintf("The user: %s doesn't exist", userId))
+		return nil, false
+	}
+
+	hasPermission := false
+	if strings.HasPrefix(requestUserId, "app/") {
+		hasPermission = true
+	} else {
+		requestUser := object.GetUser(requestUserId)
+		if requestUser == nil {
+			c.ResponseError("Session outdated. Please login again.")
+			return nil, false
+		}
+		if requestUser.IsGlobalAdmin || requestUser.Owner == "built-in" {
+			hasPermission = true
+		} else if targetUser.Owner == requestUser.Owner && requestUser.IsAdmin {
+			hasPermission = !targetUser.IsGlobalAdmin && targetUser.Owner != "built-in"
+		}
+	}
+	if !hasPermission {
+		c.ResponseError("You don't have the permission to do this.")
+		return nil, false
+	}
+	return targetUser, true
+}
+
+// UnlockUser
+// @Title UnlockUser
+// @Tag User API
+// @Description clear the failed sign-ins of a user that is locked out, for the admins
+// @Param   id    formData    string  true        "The id of the user"
+// @Success 200 {object} controllers.Response The Response object
+// @router /unlock-user [post]
+func (c *ApiController) UnlockUser() {
+	targetUser, ok := c.requireUserAdmin(c.Ctx.Request.Form.Get("id"))
+	if !ok {
+		return
+	}
+
+	utils.LogInfo(c.Ctx, "API: [%s] unlocked [%s]", c.GetSessionUsername(), targetUser.GetId())
+	c.Data["json"] = wrapActionResponse(object.UnlockUser(targetUser))
 	c.ServeJSON()
 }
 
@@ -301,7 +382,7 @@ func (c *ApiController) CheckUserPassword() {
 		panic(err)
 	}
 
-	_, msg := object.CheckUserPassword(user.Owner, user.Name, user.Password)
+	_, msg := object.CheckUserPassword(user.Owner, user.Name, user.Password, utils.GetIPFromRequest(c.Ctx.Request))
 	if msg == "" {
 		c.ResponseOk()
 	} else {
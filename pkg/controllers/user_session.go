@@ -0,0 +1,157 @@
+package controllers
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/iam/pkg/object"
+	"github.com/bhojpur/iam/pkg/utils"
+)
+
+// setLoginSession signs the user in to the web session, and records the session so that it can
+// be listed and revoked
+func (c *ApiController) setLoginSession(application *object.Application, user *object.User, amr []string) {
+	if sessionId := c.getSessionString("sessionId"); sessionId != "" {
+		object.DeleteUserSession(sessionId)
+	}
+
+	applicationName := ""
+	if application != nil {
+		applicationName = application.Name
+	}
+	// a sign-in as another user ends the impersonation, the quick sign-in of the impersonated
+	// user to an application doesn't
+	impersonator := c.getSessionString("impersonator")
+	if impersonator != "" && c.GetSessionUsername() != user.GetId() {
+		c.clearImpersonation()
+		impersonator = ""
+	}
+	sessionId := object.AddUserSession(user, applicationName, utils.GetIPFromRequest(c.Ctx.Request), c.Ctx.Request.UserAgent(), amr, impersonator)
+
+	c.SetSessionUsername(user.GetId())
+	c.SetSession("loginTime", time.Now().Unix())
+	c.SetSession("amr", strings.Join(amr, ","))
+	c.SetSession("sessionId", sessionId)
+}
+
+// clearLoginSession signs the web session out and deletes its record, together with the one of
+// the admin when it is impersonating the user
+func (c *ApiController) clearLoginSession() {
+	if sessionId := c.getSessionString("sessionId"); sessionId != "" {
+		object.DeleteUserSession(sessionId)
+		c.DelSession("sessionId")
+	}
+	if sessionId := c.getSessionString("impersonatorSessionId"); sessionId != "" {
+		object.DeleteUserSession(sessionId)
+	}
+	c.clearImpersonation()
+
+	c.SetSessionUsername("")
+	c.SetSessionData(nil)
+}
+
+// requireSelfOrUserAdmin returns the user of the id after checking that it is the current user or
+// that the current user administers it. An empty id is the current user.
+func (c *ApiController) requireSelfOrUserAdmin(userId string) (*object.User, bool) {
+	requestUserId, ok := c.RequireSignedIn()
+	if !ok {
+		return nil, false
+	}
+
+	if userId == "" || userId == requestUserId {
+		user := object.GetUser(requestUserId)
+		if user == nil {
+			c.ResponseError("Session outdated. Please login again.")
+			return nil, false
+		}
+		return user, true
+	}
+
+	return c.requireUserAdmin(userId)
+}
+
+// GetUserSessions
+// @Title GetUserSessions
+// @Tag User API
+// @Description get the active sessions of the current user, or of a user for its admins
+// @Param   id    query    string  false        "The id of the user, the current user by default"
+// @Success 200 {array} object.UserSession The Response object
+// @router /get-user-sessions [get]
+func (c *ApiController) GetUserSessions() {
+	user, ok := c.requireSelfOrUserAdmin(c.Ctx.Input.Query("id"))
+	if !ok {
+		return
+	}
+
+	currentSessionId := c.getSessionString("sessionId")
+	sessions := object.GetUserSessions(user)
+	for _, session := range sessions {
+		session.IsCurrent = session.GetId() == currentSessionId
+	}
+
+	c.ResponseOk(sessions)
+}
+
+// DeleteUserSession
+// @Title DeleteUserSession
+// @Tag User API
+// @Description revoke a session of the current user, or of a user for its admins
+// @Param   id    formData    string  true        "The id of the session"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-user-session [post]
+func (c *ApiController) DeleteUserSession() {
+	sessionId := c.Ctx.Request.Form.Get("id")
+	session := object.GetUserSession(sessionId)
+	if session == nil {
+		c.ResponseError(fmt.Sprintf("The session: %s doesn't exist", sessionId))
+		return
+	}
+
+	user, ok := c.requireSelfOrUserAdmin(fmt.Sprintf("%s/%s", session.Owner, session.User))
+	if !ok {
+		return
+	}
+
+	utils.LogInfo(c.Ctx, "API: [%s] revoked the session [%s] of [%s]", c.GetSessionUsername(), sessionId, user.GetId())
+	c.Data["json"] = wrapActionResponse(object.DeleteUserSession(sessionId))
+	c.ServeJSON()
+}
+
+// DeleteUserSessions
+// @Title DeleteUserSessions
+// @Tag User API
+// @Description revoke all the sessions of the current user, or of a user for its admins
+// @Param   id    formData    string  false        "The id of the user, the current user by default"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-user-sessions [post]
+func (c *ApiController) DeleteUserSessions() {
+	user, ok := c.requireSelfOrUserAdmin(c.Ctx.Request.Form.Get("id"))
+	if !ok {
+		return
+	}
+
+	utils.LogInfo(c.Ctx, "API: [%s] revoked all the sessions of [%s]", c.GetSessionUsername(), user.GetId())
+	c.Data["json"] = wrapActionResponse(object.DeleteUserSessions(user))
+	c.ServeJSON()
+}
@@ -57,8 +57,10 @@ func (c *ApiController) UploadUsers() {
 	utils.EnsureFileFolderExists(path)
 	saveFile(path, &file)
 
-	affected := object.UploadUsers(owner, fileId)
-	if affected {
+	affected, msg := object.UploadUsers(owner, fileId)
+	if msg != "" {
+		c.ResponseError(msg)
+	} else if affected {
 		c.ResponseOk()
 	} else {
 		c.ResponseError("Failed to import users")
@@ -54,18 +54,13 @@ func (c *ApiController) SendVerificationCode() {
 	checkUser := c.Ctx.Request.Form.Get("checkUser")
 	remoteAddr := utils.GetIPFromRequest(c.Ctx.Request)
 
-	if len(destType) == 0 || len(dest) == 0 || len(orgId) == 0 || !strings.Contains(orgId, "/") || len(checkType) == 0 || len(checkId) == 0 || len(checkKey) == 0 {
+	// the widgets of the human check providers have no captcha id, only a token
+	if len(destType) == 0 || len(dest) == 0 || len(orgId) == 0 || !strings.Contains(orgId, "/") || len(checkType) == 0 || len(checkKey) == 0 {
 		c.ResponseError("Missing parameter.")
 		return
 	}
 
-	isHuman := false
-	captchaProvider := object.GetDefaultHumanCheckProvider()
-	if captchaProvider == nil {
-		isHuman = object.VerifyCaptcha(checkId, checkKey)
-	}
-
-	if !isHuman {
+	if !c.isHumanCheckPassed(checkId, checkKey) {
 		c.ResponseError("Turing test failed.")
 		return
 	}
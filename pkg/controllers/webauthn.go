@@ -0,0 +1,388 @@
+package controllers
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bhojpur/iam/pkg/object"
+	"github.com/bhojpur/iam/pkg/utils"
+	"github.com/bhojpur/iam/pkg/webauthn"
+)
+
+// a ceremony must be completed within this time, it matches the timeout given to the browser
+const webauthnChallengeTimeout = webauthn.Timeout / 1000
+
+type WebauthnRegistrationForm struct {
+	Name       string                       `json:"name"`
+	Credential webauthn.PublicKeyCredential `json:"credential"`
+}
+
+type WebauthnLoginForm struct {
+	RequestForm
+	Credential webauthn.PublicKeyCredential `json:"credential"`
+}
+
+func (c *ApiController) setWebauthnChallenge(challenge string) {
+	c.SetSession("webauthnChallenge", challenge)
+	c.SetSession("webauthnTime", time.Now().Unix())
+}
+
+// popWebauthnChallenge returns the challenge of the ceremony and removes it from the session,
+// so that a challenge can only be answered once
+func (c *ApiController) popWebauthnChallenge() string {
+	challenge := c.getSessionString("webauthnChallenge")
+	startTime, _ := c.GetSession("webauthnTime").(int64)
+	c.DelSession("webauthnChallenge")
+	c.DelSession("webauthnTime")
+	if time.Now().Unix()-startTime > webauthnChallengeTimeout {
+		return ""
+	}
+	return challenge
+}
+
+// getPendingMfaUserId returns the user of the login waiting for the second factor, if any
+func (c *ApiController) getPendingMfaUserId() string {
+	userId := c.getSessionString("mfaUserId")
+	startTime, _ := c.GetSession("mfaTime").(int64)
+	if userId == "" || time.Now().Unix()-startTime > mfaLoginTimeout {
+		return ""
+	}
+	return userId
+}
+
+// BeginWebauthnRegistration
+// @Title BeginWebauthnRegistration
+// @Tag WebAuthn API
+// @Description get the options of navigator.credentials.create() to register a passkey for the current user, or for the user of a pending login that must enroll a second factor
+// @Success 200 {object} controllers.Response The Response object, data is the PublicKeyCredentialCreationOptions
+// @router /begin-webauthn-registration [post]
+func (c *ApiController) BeginWebauthnRegistration() {
+	if !c.requireNotImpersonated() {
+		return
+	}
+
+	user, ok := c.getMfaEnrollmentUser(object.MfaTypeWebauthn)
+	if !ok {
+		return
+	}
+
+	displayName := user.DisplayName
+	if displayName == "" {
+		displayName = user.Name
+	}
+
+	rp := object.GetWebauthnRelyingParty(object.GetOrganizationByUser(user))
+	challenge := webauthn.NewChallenge()
+	c.setWebauthnChallenge(challenge)
+
+	userEntity := webauthn.UserEntity{Id: webauthn.Encode([]byte(user.Id)), Name: user.Name, DisplayName: displayName}
+	c.ResponseOk(rp.GetCreationOptions(challenge, userEntity, user.GetWebauthnCredentialDescriptors()))
+}
+
+// FinishWebauthnRegistration
+// @Title FinishWebauthnRegistration
+// @Tag WebAuthn API
+// @Description verify the credential created by the browser and add it to the passkeys of the current user
+// @Param   body    body   controllers.WebauthnRegistrationForm  true        "The name and the created credential"
+// @Success 200 {object} controllers.Response The Response object, data is the passkey
+// @router /finish-webauthn-registration [post]
+func (c *ApiController) FinishWebauthnRegistration() {
+	if !c.requireNotImpersonated() {
+		return
+	}
+
+	user, ok := c.getMfaEnrollmentUser(object.MfaTypeWebauthn)
+	if !ok {
+		return
+	}
+
+	var form WebauthnRegistrationForm
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &form)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	rp := object.GetWebauthnRelyingParty(object.GetOrganizationByUser(user))
+	credential, err := rp.VerifyRegistration(&form.Credential, c.popWebauthnChallenge(), false)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	res, msg := object.AddWebauthnCredential(user, credential, form.Name)
+	if msg != "" {
+		c.ResponseError(msg)
+		return
+	}
+
+	c.setMfaEnrolled(user, object.MfaTypeWebauthn)
+	utils.LogInfo(c.Ctx, "API: [%s] registered passkey: %s", user.GetId(), res.Name)
+	c.ResponseOk(res)
+}
+
+// BeginWebauthnLogin
+// @Title BeginWebauthnLogin
+// @Tag WebAuthn API
+// @Description get the options of navigator.credentials.get(), either to sign in with a passkey or for the second factor of a pending login
+// @Param   application    formData    string  true        "The name of the application"
+// @Param   username    formData    string  false        "The username, the passkeys of the user are allowed instead of the discoverable ones"
+// @Success 200 {object} controllers.Response The Response object, data is the PublicKeyCredentialRequestOptions
+// @router /begin-webauthn-login [post]
+func (c *ApiController) BeginWebauthnLogin() {
+	c.DelSession("webauthnUserId")
+
+	var user *object.User
+	var organization *object.Organization
+	userVerification := "required"
+	if mfaUserId := c.getPendingMfaUserId(); mfaUserId != "" {
+		user = object.GetUser(mfaUserId)
+		if user == nil {
+			c.ResponseError(fmt.Sprintf("The user: %s doesn't exist", mfaUserId))
+			return
+		}
+		organization = object.GetOrganizationByUser(user)
+		userVerification = "discouraged"
+	} else {
+		application := object.GetApplication(fmt.Sprintf("admin/%s", c.Ctx.Request.Form.Get("application")))
+		if application == nil {
+			c.ResponseError(fmt.Sprintf("The application: %s doesn't exist", c.Ctx.Request.Form.Get("application")))
+			return
+		}
+		organization = object.GetOrganization(fmt.Sprintf("admin/%s", application.Organization))
+
+		username := c.Ctx.Request.Form.Get("username")
+		if username != "" {
+			user = object.GetUserByFields(application.Organization, username)
+		}
+	}
+
+	allowCredentials := []webauthn.CredentialDescriptor{}
+	if user != nil {
+		allowCredentials = user.GetWebauthnCredentialDescriptors()
+		c.SetSession("webauthnUserId", user.GetId())
+	}
+
+	rp := object.GetWebauthnRelyingParty(organization)
+	challenge := webauthn.NewChallenge()
+	c.setWebauthnChallenge(challenge)
+	c.ResponseOk(rp.GetRequestOptions(challenge, allowCredentials, userVerification))
+}
+
+// FinishWebauthnLogin
+// @Title FinishWebauthnLogin
+// @Tag WebAuthn API
+// @Description verify the assertion of a passkey and sign in, or complete a pending login that requires MFA
+// @Param   oAuthParams     query    string  true        "oAuth parameters"
+// @Param   body    body   controllers.WebauthnLoginForm  true        "The login information and the assertion"
+// @Success 200 {object} controllers.Response The Response object
+// @router /finish-webauthn-login [post]
+func (c *ApiController) FinishWebauthnLogin() {
+	var form WebauthnLoginForm
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &form)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	challenge := c.popWebauthnChallenge()
+	if challenge == "" {
+		c.ResponseError("The passkey request has expired, please try again")
+		return
+	}
+
+	loginForm := form.RequestForm
+	mfaUserId := c.getPendingMfaUserId()
+	isMfa := mfaUserId != ""
+
+	var user *object.User
+	if isMfa {
+		if !c.isMfaTypeAllowed(object.MfaTypeWebauthn) {
+			c.ResponseError(fmt.Sprintf("The second factor: %s is not allowed", object.MfaTypeWebauthn))
+			return
+		}
+
+		err = utils.JsonToStruct(c.getSessionString("mfaForm"), &loginForm)
+		if err != nil {
+			c.clearMfaLogin()
+			c.ResponseError(err.Error())
+			return
+		}
+		user = object.GetUser(mfaUserId)
+	} else {
+		if loginForm.Type == ResponseTypeLogin && c.GetSessionUsername() != "" {
+			c.ResponseError("Please sign out first before signing in", c.GetSessionUsername())
+			return
+		}
+
+		application := object.GetApplication(fmt.Sprintf("admin/%s", loginForm.Application))
+		if application == nil {
+			c.ResponseError(fmt.Sprintf("The application: %s doesn't exist", loginForm.Application))
+			return
+		}
+		organization := object.GetOrganization(fmt.Sprintf("admin/%s", application.Organization))
+		if !object.GetMfaPolicy(organization, application).IsTypeAllowed(object.MfaTypeWebauthn) {
+			c.ResponseError("Passkeys are not allowed for the application")
+			return
+		}
+
+		if userId := c.getSessionString("webauthnUserId"); userId != "" {
+			user = object.GetUser(userId)
+		} else {
+			// the user handle of a discoverable credential is the id of the user
+			userHandle, err := webauthn.Decode(form.Credential.Response.UserHandle)
+			if err == nil && len(userHandle) != 0 {
+				user = object.GetUserByField(application.Organization, "id", string(userHandle))
+			}
+		}
+		if user != nil && user.Owner != application.Organization {
+			user = nil
+		}
+	}
+	c.DelSession("webauthnUserId")
+
+	if user == nil || user.IsDeleted {
+		c.ResponseError("The passkey is not registered for the user")
+		return
+	}
+	if user.IsForbidden {
+		c.ResponseError("the user is forbidden to sign in, please contact the administrator")
+		return
+	}
+	if isMfa && !c.checkMfaLockout(user) {
+		return
+	}
+
+	// passwordless sign-in requires user verification, so that the passkey counts as two factors
+	msg := object.CheckWebauthnAssertion(user, &form.Credential, challenge, !isMfa)
+	if msg != "" {
+		if isMfa {
+			c.addMfaFailure(user, msg)
+		}
+		c.ResponseError(msg)
+		return
+	}
+
+	if isMfa {
+		c.completeMfaLogin(user, object.MfaTypeWebauthn)
+		return
+	}
+
+	// the passkey has verified the user, it is both the possession and the inherence factor
+	application := object.GetApplication(fmt.Sprintf("admin/%s", loginForm.Application))
+	resp := c.handleLoggedInWithMfa(application, user, &loginForm, object.AddAmr(nil, "hwk", "user"))
+
+	record := object.NewRecord(c.Ctx)
+	record.Organization = application.Organization
+	record.User = user.Name
+	go object.AddRecord(record)
+
+	c.Data["json"] = resp
+	c.ServeJSON()
+}
+
+// GetWebauthnCredentials
+// @Title GetWebauthnCredentials
+// @Tag WebAuthn API
+// @Description get the passkeys of the current user
+// @Success 200 {array} object.WebauthnCredential The Response object
+// @router /get-webauthn-credentials [get]
+func (c *ApiController) GetWebauthnCredentials() {
+	userId, ok := c.RequireSignedIn()
+	if !ok {
+		return
+	}
+
+	user := object.GetUser(userId)
+	if user == nil {
+		c.ResponseError(fmt.Sprintf("The user: %s doesn't exist", userId))
+		return
+	}
+
+	credentials := user.WebauthnCredentials
+	if credentials == nil {
+		credentials = []*object.WebauthnCredential{}
+	}
+	c.ResponseOk(credentials)
+}
+
+// RenameWebauthnCredential
+// @Title RenameWebauthnCredential
+// @Tag WebAuthn API
+// @Description rename a passkey of the current user
+// @Param   id    formData    string  true        "The id of the passkey"
+// @Param   name    formData    string  true        "The new name of the passkey"
+// @Success 200 {object} controllers.Response The Response object
+// @router /rename-webauthn-credential [post]
+func (c *ApiController) RenameWebauthnCredential() {
+	if !c.requireNotImpersonated() {
+		return
+	}
+
+	userId, ok := c.RequireSignedIn()
+	if !ok {
+		return
+	}
+
+	user := object.GetUser(userId)
+	if user == nil {
+		c.ResponseError(fmt.Sprintf("The user: %s doesn't exist", userId))
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.RenameWebauthnCredential(user, c.Ctx.Request.Form.Get("id"), c.Ctx.Request.Form.Get("name")))
+	c.ServeJSON()
+}
+
+// DeleteWebauthnCredential
+// @Title DeleteWebauthnCredential
+// @Tag WebAuthn API
+// @Description delete a passkey of the current user
+// @Param   id    formData    string  true        "The id of the passkey"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-webauthn-credential [post]
+func (c *ApiController) DeleteWebauthnCredential() {
+	if !c.requireNotImpersonated() {
+		return
+	}
+
+	userId, ok := c.RequireSignedIn()
+	if !ok {
+		return
+	}
+
+	user := object.GetUser(userId)
+	if user == nil {
+		c.ResponseError(fmt.Sprintf("The user: %s doesn't exist", userId))
+		return
+	}
+
+	id := c.Ctx.Request.Form.Get("id")
+	affected := object.DeleteWebauthnCredential(user, id)
+	if affected {
+		utils.LogInfo(c.Ctx, "API: [%s] deleted passkey: %s", userId, id)
+	}
+	c.Data["json"] = wrapActionResponse(affected)
+	c.ServeJSON()
+}
@@ -0,0 +1,102 @@
+package cred
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// the second recommended option of RFC 9106, section 4
+const (
+	argon2Memory  = 64 * 1024
+	argon2Time    = 3
+	argon2Threads = 4
+	argon2KeyLen  = 32
+	argon2SaltLen = 16
+)
+
+// Argon2idCredManager stores the passwords as Argon2id hashes in the PHC string format. The
+// Argon2i hashes and the Django "argon2$..." hashes of migrated users are accepted too.
+type Argon2idCredManager struct{}
+
+func NewArgon2idCredManager() *Argon2idCredManager {
+	cm := &Argon2idCredManager{}
+	return cm
+}
+
+func (cm *Argon2idCredManager) GetHashedPassword(password string, userSalt string, organizationSalt string) string {
+	salt := getRandomSalt(argon2SaltLen)
+	hash := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, argon2Memory, argon2Time, argon2Threads, encodePhcBase64(salt), encodePhcBase64(hash))
+}
+
+func parseArgon2Hash(hashedPwd string) (*phcHash, bool) {
+	// Django prefixes the PHC string with the name of its hasher
+	if strings.HasPrefix(hashedPwd, "argon2$") {
+		hashedPwd = strings.TrimPrefix(hashedPwd, "argon2")
+	}
+
+	hash, ok := parsePhcHash(hashedPwd)
+	if !ok || (hash.Id != "argon2id" && hash.Id != "argon2i") {
+		return nil, false
+	}
+	if hash.Version != "" && hash.Version != fmt.Sprintf("%d", argon2.Version) {
+		return nil, false
+	}
+	return hash, true
+}
+
+func (cm *Argon2idCredManager) IsPasswordCorrect(plainPwd string, hashedPwd string, userSalt string, organizationSalt string) bool {
+	hash, ok := parseArgon2Hash(hashedPwd)
+	if !ok {
+		return false
+	}
+
+	memory, ok1 := hash.getIntParam("m", 0)
+	time, ok2 := hash.getIntParam("t", 0)
+	threads, ok3 := hash.getIntParam("p", 0)
+	if !ok1 || !ok2 || !ok3 || threads > 255 {
+		return false
+	}
+
+	var key []byte
+	if hash.Id == "argon2id" {
+		key = argon2.IDKey([]byte(plainPwd), hash.Salt, uint32(time), uint32(memory), uint8(threads), uint32(len(hash.Hash)))
+	} else {
+		key = argon2.Key([]byte(plainPwd), hash.Salt, uint32(time), uint32(memory), uint8(threads), uint32(len(hash.Hash)))
+	}
+	return subtle.ConstantTimeCompare(key, hash.Hash) == 1
+}
+
+func (cm *Argon2idCredManager) NeedsRehash(hashedPwd string) bool {
+	hash, ok := parseArgon2Hash(hashedPwd)
+	if !ok || hash.Id != "argon2id" || !strings.HasPrefix(hashedPwd, "$") {
+		return true
+	}
+
+	memory, _ := hash.getIntParam("m", 0)
+	time, _ := hash.getIntParam("t", 0)
+	return memory < argon2Memory || time < argon2Time
+}
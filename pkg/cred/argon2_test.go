@@ -0,0 +1,46 @@
+package cred
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import "testing"
+
+func TestArgon2idCredManager(t *testing.T) {
+	cm := NewArgon2idCredManager()
+	hash := cm.GetHashedPassword("Secret123!", "", "")
+	if !cm.IsPasswordCorrect("Secret123!", hash, "", "") || cm.IsPasswordCorrect("secret123!", hash, "", "") {
+		t.Errorf("IsPasswordCorrect() fails for %s", hash)
+	}
+	if cm.NeedsRehash(hash) {
+		t.Errorf("NeedsRehash() = true for a new hash")
+	}
+
+	// the example of the reference implementation
+	hash = "$argon2i$v=19$m=65536,t=2,p=4$c29tZXNhbHQ$RdescudvJCsgt3ub+b+dWRWJTmaaJObG"
+	if !cm.IsPasswordCorrect("password", hash, "", "") {
+		t.Errorf("IsPasswordCorrect() fails for the Argon2i hash %s", hash)
+	}
+	if !cm.IsPasswordCorrect("password", "argon2"+hash, "", "") {
+		t.Errorf("IsPasswordCorrect() fails for the Django hash")
+	}
+	if !cm.NeedsRehash(hash) {
+		t.Errorf("NeedsRehash() = false for an Argon2i hash")
+	}
+}
@@ -20,6 +20,8 @@ package cred
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
 
+import "strings"
+
 type CredManager interface {
 	GetHashedPassword(password string, userSalt string, organizationSalt string) string
 	IsPasswordCorrect(password string, passwordHash string, userSalt string, organizationSalt string) bool
@@ -34,6 +36,62 @@ func GetCredManager(passwordType string) CredManager {
 		return NewMd5UserSaltCredManager()
 	} else if passwordType == "bcrypt" {
 		return NewBcryptCredManager()
+	} else if passwordType == "argon2id" {
+		return NewArgon2idCredManager()
+	} else if passwordType == "scrypt" {
+		return NewScryptCredManager()
+	} else if passwordType == "pbkdf2" {
+		return NewPbkdf2CredManager()
 	}
 	return nil
 }
+
+// RehashChecker is implemented by the credential managers whose hashes carry their own
+// parameters. NeedsRehash is true when the hash uses a foreign format or weaker parameters
+// than the ones the manager hashes new passwords with.
+type RehashChecker interface {
+	NeedsRehash(passwordHash string) bool
+}
+
+// the password types ordered from the weakest to the strongest
+var passwordTypeStrengths = map[string]int{
+	"plain":    0,
+	"md5-salt": 1,
+	"salt":     1,
+	"pbkdf2":   2,
+	"bcrypt":   3,
+	"scrypt":   3,
+	"argon2id": 4,
+}
+
+// GetPasswordType returns the password type of a hash that describes its own scheme, such as
+// the bcrypt, PHC, Django, Keycloak and ASP.NET Identity hashes of migrated users. It returns
+// "" for the other hashes, whose type is the one of the organization.
+func GetPasswordType(passwordHash string) string {
+	if strings.HasPrefix(passwordHash, "$2a$") || strings.HasPrefix(passwordHash, "$2b$") || strings.HasPrefix(passwordHash, "$2y$") {
+		return "bcrypt"
+	} else if _, ok := parseArgon2Hash(passwordHash); ok {
+		return "argon2id"
+	} else if _, ok := parseScryptHash(passwordHash); ok {
+		return "scrypt"
+	} else if _, ok := parsePbkdf2Hash(passwordHash); ok {
+		return "pbkdf2"
+	}
+	return ""
+}
+
+// NeedsRehash checks whether a hash of the password type should be replaced by a hash of the
+// current password type of the organization: when the current type is stronger, or when it is
+// the same type with stronger parameters.
+func NeedsRehash(passwordType string, passwordHash string, currentPasswordType string) bool {
+	if passwordType != currentPasswordType {
+		strength, ok1 := passwordTypeStrengths[passwordType]
+		currentStrength, ok2 := passwordTypeStrengths[currentPasswordType]
+		return ok1 && ok2 && strength < currentStrength
+	}
+
+	if checker, ok := GetCredManager(passwordType).(RehashChecker); ok {
+		return checker.NeedsRehash(passwordHash)
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package cred
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import "testing"
+
+func TestGetPasswordType(t *testing.T) {
+	tests := map[string]string{
+		"123456": "",
+		"8d969eef6ecad3c29a3a629280e686cf0c3f5d5a86aff3ca12020c923adc6c92":                         "",
+		"$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy":                             "bcrypt",
+		"$argon2i$v=19$m=65536,t=2,p=4$c29tZXNhbHQ$RdescudvJCsgt3ub+b+dWRWJTmaaJObG":               "argon2id",
+		"$scrypt$ln=10,r=8,p=1$MDEyMzQ1Njc4OWFiY2RlZg$kABuFq4aWAgCW0okjxMER1qL3SCUNtgZsloVM+AU054": "scrypt",
+		"pbkdf2_sha256$1000$Y8fXgTWqFZ5s$dBxoqjjmuzabCdDUY68xyt4bbvPMOz3HTJOnOjxtgJU=":             "pbkdf2",
+		"AQAAAAEAACcQAAAAEDAxMjM0NTY3ODlhYmNkZWYZGY8Lx/hbod+ztuLwaYqAkeKk/JSNxW79ohMGWadp6Q==":     "pbkdf2",
+		"{\"note\": \"a plain password\"}":                                                         "",
+	}
+	for hash, want := range tests {
+		if got := GetPasswordType(hash); got != want {
+			t.Errorf("GetPasswordType(%s) = %q, want %q", hash, got, want)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	bcryptHash := NewBcryptCredManager().GetHashedPassword("Secret123!", "", "")
+	tests := []struct {
+		passwordType        string
+		hash                string
+		currentPasswordType string
+		want                bool
+	}{
+		{"plain", "Secret123!", "argon2id", true},
+		{"salt", "8d969eef", "bcrypt", true},
+		{"bcrypt", bcryptHash, "bcrypt", false},
+		{"bcrypt", bcryptHash, "pbkdf2", false},
+		{"bcrypt", bcryptHash, "argon2id", true},
+		{"argon2id", "$argon2i$v=19$m=65536,t=2,p=4$c29tZXNhbHQ$RdescudvJCsgt3ub+b+dWRWJTmaaJObG", "argon2id", true},
+		{"plain", "Secret123!", "unknown", false},
+	}
+	for _, test := range tests {
+		if got := NeedsRehash(test.passwordType, test.hash, test.currentPasswordType); got != test.want {
+			t.Errorf("NeedsRehash(%s, %s, %s) = %v, want %v", test.passwordType, test.hash, test.currentPasswordType, got, test.want)
+		}
+	}
+}
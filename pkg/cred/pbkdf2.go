@@ -0,0 +1,229 @@
+package cred
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// the iterations recommended by OWASP for PBKDF2-HMAC-SHA256
+const (
+	pbkdf2Iterations = 600000
+	pbkdf2KeyLen     = 32
+	pbkdf2SaltLen    = 16
+)
+
+// Pbkdf2CredManager stores the passwords as PBKDF2-HMAC-SHA256 hashes in the PHC string format
+// "$pbkdf2-sha256$i=<iterations>,l=<key length>$<salt>$<hash>". The PBKDF2 hashes of migrated
+// users are accepted too:
+//   - passlib: $pbkdf2[-sha256|-sha512]$<iterations>$<salt>$<hash>
+//   - Django: pbkdf2_sha256$<iterations>$<salt>$<hash> and pbkdf2_sha1$...
+//   - Keycloak: the JSON of a credential of a realm export, with its "secretData" and
+//     "credentialData", or the two merged in one JSON object
+//   - ASP.NET Identity: the base64 of the version 2 or version 3 format
+type Pbkdf2CredManager struct{}
+
+func NewPbkdf2CredManager() *Pbkdf2CredManager {
+	cm := &Pbkdf2CredManager{}
+	return cm
+}
+
+func (cm *Pbkdf2CredManager) GetHashedPassword(password string, userSalt string, organizationSalt string) string {
+	salt := getRandomSalt(pbkdf2SaltLen)
+	hash := pbkdf2.Key([]byte(password), salt, pbkdf2Iterations, pbkdf2KeyLen, sha256.New)
+	return fmt.Sprintf("$pbkdf2-sha256$i=%d,l=%d$%s$%s", pbkdf2Iterations, pbkdf2KeyLen, encodePhcBase64(salt), encodePhcBase64(hash))
+}
+
+type pbkdf2Hash struct {
+	Format     string
+	HashFunc   func() hash.Hash
+	Iterations int
+	Salt       []byte
+	Hash       []byte
+}
+
+func getPbkdf2HashFunc(name string) func() hash.Hash {
+	switch strings.ToLower(name) {
+	case "", "sha1":
+		return sha1.New
+	case "sha256":
+		return sha256.New
+	case "sha512":
+		return sha512.New
+	}
+	return nil
+}
+
+func parsePbkdf2Hash(hashedPwd string) (*pbkdf2Hash, bool) {
+	if strings.HasPrefix(hashedPwd, "$pbkdf2") {
+		return parsePhcPbkdf2Hash(hashedPwd)
+	} else if strings.HasPrefix(hashedPwd, "pbkdf2_") {
+		return parseDjangoPbkdf2Hash(hashedPwd)
+	} else if strings.HasPrefix(hashedPwd, "{") {
+		return parseKeycloakPbkdf2Hash(hashedPwd)
+	}
+	return parseAspNetPbkdf2Hash(hashedPwd)
+}
+
+func parsePhcPbkdf2Hash(hashedPwd string) (*pbkdf2Hash, bool) {
+	fields := strings.Split(hashedPwd[1:], "$")
+	hashFunc := getPbkdf2HashFunc(strings.TrimPrefix(strings.TrimPrefix(fields[0], "pbkdf2"), "-"))
+	if hashFunc == nil {
+		return nil, false
+	}
+
+	// passlib writes the iterations alone instead of "i=<iterations>"
+	if len(fields) == 4 {
+		if iterations, err := strconv.Atoi(fields[1]); err == nil {
+			fields[1] = fmt.Sprintf("i=%d", iterations)
+		}
+	}
+
+	phc, ok := parsePhcHash("$" + strings.Join(fields, "$"))
+	if !ok {
+		return nil, false
+	}
+	iterations, ok := phc.getIntParam("i", 0)
+	if !ok {
+		return nil, false
+	}
+	return &pbkdf2Hash{Format: "phc", HashFunc: hashFunc, Iterations: iterations, Salt: phc.Salt, Hash: phc.Hash}, true
+}
+
+func parseDjangoPbkdf2Hash(hashedPwd string) (*pbkdf2Hash, bool) {
+	fields := strings.Split(hashedPwd, "$")
+	if len(fields) != 4 {
+		return nil, false
+	}
+
+	hashFunc := getPbkdf2HashFunc(strings.TrimPrefix(fields[0], "pbkdf2_"))
+	iterations, err1 := strconv.Atoi(fields[1])
+	hash, err2 := base64.StdEncoding.DecodeString(fields[3])
+	if hashFunc == nil || err1 != nil || iterations <= 0 || err2 != nil || len(hash) == 0 {
+		return nil, false
+	}
+	return &pbkdf2Hash{Format: "django", HashFunc: hashFunc, Iterations: iterations, Salt: []byte(fields[2]), Hash: hash}, true
+}
+
+type keycloakCredential struct {
+	SecretData     string `json:"secretData"`
+	CredentialData string `json:"credentialData"`
+
+	Value             string `json:"value"`
+	HashedSaltedValue string `json:"hashedSaltedValue"`
+	Salt              string `json:"salt"`
+	HashIterations    int    `json:"hashIterations"`
+	Algorithm         string `json:"algorithm"`
+}
+
+func parseKeycloakPbkdf2Hash(hashedPwd string) (*pbkdf2Hash, bool) {
+	var credential keycloakCredential
+	if err := json.Unmarshal([]byte(hashedPwd), &credential); err != nil {
+		return nil, false
+	}
+	// since Keycloak 11, the secret and the parameters are JSON strings of their own
+	if credential.SecretData != "" && json.Unmarshal([]byte(credential.SecretData), &credential) != nil {
+		return nil, false
+	}
+	if credential.CredentialData != "" && json.Unmarshal([]byte(credential.CredentialData), &credential) != nil {
+		return nil, false
+	}
+
+	value := credential.Value
+	if value == "" {
+		value = credential.HashedSaltedValue
+	}
+	algorithm := credential.Algorithm
+	if algorithm == "" {
+		algorithm = "pbkdf2-sha256"
+	}
+	if !strings.HasPrefix(algorithm, "pbkdf2") {
+		return nil, false
+	}
+
+	hashFunc := getPbkdf2HashFunc(strings.TrimPrefix(strings.TrimPrefix(algorithm, "pbkdf2"), "-"))
+	salt, err1 := base64.StdEncoding.DecodeString(credential.Salt)
+	hash, err2 := base64.StdEncoding.DecodeString(value)
+	if hashFunc == nil || credential.HashIterations <= 0 || err1 != nil || err2 != nil || len(hash) == 0 {
+		return nil, false
+	}
+	return &pbkdf2Hash{Format: "keycloak", HashFunc: hashFunc, Iterations: credential.HashIterations, Salt: salt, Hash: hash}, true
+}
+
+func parseAspNetPbkdf2Hash(hashedPwd string) (*pbkdf2Hash, bool) {
+	data, err := base64.StdEncoding.DecodeString(hashedPwd)
+	if err != nil || len(data) == 0 {
+		return nil, false
+	}
+
+	switch data[0] {
+	case 0x00:
+		// version 2: PBKDF2-HMAC-SHA1, 1000 iterations, 128-bit salt, 256-bit key
+		if len(data) != 1+16+32 {
+			return nil, false
+		}
+		return &pbkdf2Hash{Format: "aspnet", HashFunc: sha1.New, Iterations: 1000, Salt: data[1:17], Hash: data[17:]}, true
+	case 0x01:
+		// version 3: the PRF, the iterations and the salt length are stored in big endian
+		if len(data) < 13 {
+			return nil, false
+		}
+		prf := binary.BigEndian.Uint32(data[1:5])
+		iterations := binary.BigEndian.Uint32(data[5:9])
+		saltLen := binary.BigEndian.Uint32(data[9:13])
+		if prf > 2 || iterations == 0 || iterations > 1<<30 || saltLen < 16 || uint64(len(data)) < 13+uint64(saltLen)+16 {
+			return nil, false
+		}
+		hashFunc := []func() hash.Hash{sha1.New, sha256.New, sha512.New}[prf]
+		return &pbkdf2Hash{Format: "aspnet", HashFunc: hashFunc, Iterations: int(iterations), Salt: data[13 : 13+saltLen], Hash: data[13+saltLen:]}, true
+	}
+	return nil, false
+}
+
+func (cm *Pbkdf2CredManager) IsPasswordCorrect(plainPwd string, hashedPwd string, userSalt string, organizationSalt string) bool {
+	hash, ok := parsePbkdf2Hash(hashedPwd)
+	if !ok {
+		return false
+	}
+
+	key := pbkdf2.Key([]byte(plainPwd), hash.Salt, hash.Iterations, len(hash.Hash), hash.HashFunc)
+	return subtle.ConstantTimeCompare(key, hash.Hash) == 1
+}
+
+func (cm *Pbkdf2CredManager) NeedsRehash(hashedPwd string) bool {
+	hash, ok := parsePbkdf2Hash(hashedPwd)
+	if !ok || hash.Format != "phc" {
+		return true
+	}
+	return hash.Iterations < pbkdf2Iterations || hash.HashFunc().Size() < sha256.Size
+}
@@ -0,0 +1,55 @@
+package cred
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import "testing"
+
+func TestPbkdf2CredManager(t *testing.T) {
+	cm := NewPbkdf2CredManager()
+	hash := cm.GetHashedPassword("Secret123!", "", "")
+	if !cm.IsPasswordCorrect("Secret123!", hash, "", "") || cm.IsPasswordCorrect("secret123!", hash, "", "") {
+		t.Errorf("IsPasswordCorrect() fails for %s", hash)
+	}
+	if cm.NeedsRehash(hash) {
+		t.Errorf("NeedsRehash() = true for a new hash")
+	}
+
+	// the hashes of "Secret123!" in the formats of other systems
+	hashes := map[string]string{
+		"django":     "pbkdf2_sha256$1000$Y8fXgTWqFZ5s$dBxoqjjmuzabCdDUY68xyt4bbvPMOz3HTJOnOjxtgJU=",
+		"passlib":    "$pbkdf2-sha512$1000$MDEyMzQ1Njc4OWFiY2RlZg$UtZmnukH4dhmeR7J5VTGVgQ0T/m2hD7Xfjn6bTmXqKQra9n2lordHKI7eHzzzn4QTeUK2NGHodBrs2u6/tIiZQ",
+		"keycloak":   "{\"type\": \"password\", \"secretData\": \"{\\\"value\\\": \\\"NJnuedIEq2YnADzAKH3f8f7XjfgFBxG0t4rfnV/hW9FRP6yM7Y7G/Hj4H0v5p+/AeqRHPFGxwzE2kOgeoFFiKw==\\\", \\\"salt\\\": \\\"MDEyMzQ1Njc4OWFiY2RlZg==\\\", \\\"additionalParameters\\\": {}}\", \"credentialData\": \"{\\\"hashIterations\\\": 27500, \\\"algorithm\\\": \\\"pbkdf2-sha256\\\", \\\"additionalParameters\\\": {}}\"}",
+		"aspnet v2":  "ADAxMjM0NTY3ODlhYmNkZWZnUNQbDViY24xKvBKpj195f7YlRdfqIvBCOH+WMz0hfw==",
+		"aspnet v3":  "AQAAAAEAACcQAAAAEDAxMjM0NTY3ODlhYmNkZWYZGY8Lx/hbod+ztuLwaYqAkeKk/JSNxW79ohMGWadp6Q==",
+		"keycloak 8": "{\"hashedSaltedValue\": \"NJnuedIEq2YnADzAKH3f8f7XjfgFBxG0t4rfnV/hW9FRP6yM7Y7G/Hj4H0v5p+/AeqRHPFGxwzE2kOgeoFFiKw==\", \"salt\": \"MDEyMzQ1Njc4OWFiY2RlZg==\", \"hashIterations\": 27500, \"algorithm\": \"pbkdf2-sha256\"}",
+	}
+	for format, hash := range hashes {
+		if !cm.IsPasswordCorrect("Secret123!", hash, "", "") {
+			t.Errorf("IsPasswordCorrect() fails for the %s hash", format)
+		}
+		if cm.IsPasswordCorrect("Secret123?", hash, "", "") {
+			t.Errorf("IsPasswordCorrect() accepts a wrong password for the %s hash", format)
+		}
+		if !cm.NeedsRehash(hash) {
+			t.Errorf("NeedsRehash() = false for the %s hash", format)
+		}
+	}
+}
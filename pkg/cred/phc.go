@@ -0,0 +1,104 @@
+package cred
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strconv"
+	"strings"
+)
+
+// phcHash is a hash in the PHC string format: $<id>[$v=<version>][$<param>=<value>(,<param>=<value>)*][$<salt>[$<hash>]]
+type phcHash struct {
+	Id      string
+	Version string
+	Params  map[string]string
+	Salt    []byte
+	Hash    []byte
+}
+
+// decodePhcBase64 decodes the unpadded base64 of the PHC format. passlib writes "." instead of
+// "+", so both are accepted.
+func decodePhcBase64(s string) ([]byte, error) {
+	s = strings.ReplaceAll(strings.TrimRight(s, "="), ".", "+")
+	return base64.RawStdEncoding.DecodeString(s)
+}
+
+func parsePhcHash(s string) (*phcHash, bool) {
+	if !strings.HasPrefix(s, "$") {
+		return nil, false
+	}
+
+	fields := strings.Split(s[1:], "$")
+	res := &phcHash{Id: fields[0], Params: map[string]string{}}
+	fields = fields[1:]
+	if len(fields) != 0 && strings.HasPrefix(fields[0], "v=") {
+		res.Version = strings.TrimPrefix(fields[0], "v=")
+		fields = fields[1:]
+	}
+	if len(fields) != 0 && strings.Contains(fields[0], "=") {
+		for _, param := range strings.Split(fields[0], ",") {
+			tokens := strings.SplitN(param, "=", 2)
+			if len(tokens) != 2 {
+				return nil, false
+			}
+			res.Params[tokens[0]] = tokens[1]
+		}
+		fields = fields[1:]
+	}
+	if len(fields) != 2 {
+		return nil, false
+	}
+
+	var err error
+	if res.Salt, err = decodePhcBase64(fields[0]); err != nil {
+		return nil, false
+	}
+	if res.Hash, err = decodePhcBase64(fields[1]); err != nil || len(res.Hash) == 0 {
+		return nil, false
+	}
+	return res, true
+}
+
+// getIntParam returns the integer parameter of the hash, or defaultValue when it is missing.
+// ok is false when the parameter is not a positive integer.
+func (h *phcHash) getIntParam(name string, defaultValue int) (int, bool) {
+	s, found := h.Params[name]
+	if !found {
+		return defaultValue, defaultValue > 0
+	}
+	value, err := strconv.Atoi(s)
+	return value, err == nil && value > 0
+}
+
+func encodePhcBase64(b []byte) string {
+	return base64.RawStdEncoding.EncodeToString(b)
+}
+
+func getRandomSalt(size int) []byte {
+	salt := make([]byte, size)
+	_, err := rand.Read(salt)
+	if err != nil {
+		panic(err)
+	}
+	return salt
+}
@@ -0,0 +1,118 @@
+package cred
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// N = 2^15 as recommended by the scrypt package, that takes about 100ms
+const (
+	scryptLogN    = 15
+	scryptR       = 8
+	scryptP       = 1
+	scryptKeyLen  = 32
+	scryptSaltLen = 16
+)
+
+// ScryptCredManager stores the passwords as scrypt hashes in the PHC string format
+// "$scrypt$ln=<log2(N)>,r=<r>,p=<p>$<salt>$<hash>". The Django "scrypt$..." hashes of migrated
+// users are accepted too.
+type ScryptCredManager struct{}
+
+func NewScryptCredManager() *ScryptCredManager {
+	cm := &ScryptCredManager{}
+	return cm
+}
+
+func (cm *ScryptCredManager) GetHashedPassword(password string, userSalt string, organizationSalt string) string {
+	salt := getRandomSalt(scryptSaltLen)
+	hash, err := scrypt.Key([]byte(password), salt, 1<<scryptLogN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("$scrypt$ln=%d,r=%d,p=%d$%s$%s", scryptLogN, scryptR, scryptP, encodePhcBase64(salt), encodePhcBase64(hash))
+}
+
+type scryptHash struct {
+	N    int
+	R    int
+	P    int
+	Salt []byte
+	Hash []byte
+}
+
+func parseScryptHash(hashedPwd string) (*scryptHash, bool) {
+	// Django: scrypt$<salt>$<N>$<r>$<p>$<base64 hash>, the salt is used as it is
+	if strings.HasPrefix(hashedPwd, "scrypt$") {
+		fields := strings.Split(hashedPwd, "$")
+		if len(fields) != 6 {
+			return nil, false
+		}
+		n, err1 := strconv.Atoi(fields[2])
+		r, err2 := strconv.Atoi(fields[3])
+		p, err3 := strconv.Atoi(fields[4])
+		hash, err4 := base64.StdEncoding.DecodeString(fields[5])
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil || len(hash) == 0 {
+			return nil, false
+		}
+		return &scryptHash{N: n, R: r, P: p, Salt: []byte(fields[1]), Hash: hash}, true
+	}
+
+	hash, ok := parsePhcHash(hashedPwd)
+	if !ok || hash.Id != "scrypt" {
+		return nil, false
+	}
+	logN, ok1 := hash.getIntParam("ln", 0)
+	r, ok2 := hash.getIntParam("r", 0)
+	p, ok3 := hash.getIntParam("p", 0)
+	if !ok1 || !ok2 || !ok3 || logN >= 32 {
+		return nil, false
+	}
+	return &scryptHash{N: 1 << logN, R: r, P: p, Salt: hash.Salt, Hash: hash.Hash}, true
+}
+
+func (cm *ScryptCredManager) IsPasswordCorrect(plainPwd string, hashedPwd string, userSalt string, organizationSalt string) bool {
+	hash, ok := parseScryptHash(hashedPwd)
+	if !ok {
+		return false
+	}
+
+	key, err := scrypt.Key([]byte(plainPwd), hash.Salt, hash.N, hash.R, hash.P, len(hash.Hash))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(key, hash.Hash) == 1
+}
+
+func (cm *ScryptCredManager) NeedsRehash(hashedPwd string) bool {
+	hash, ok := parseScryptHash(hashedPwd)
+	if !ok || !strings.HasPrefix(hashedPwd, "$") {
+		return true
+	}
+	return hash.N < 1<<scryptLogN || hash.R < scryptR
+}
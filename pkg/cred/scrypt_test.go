@@ -0,0 +1,46 @@
+package cred
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import "testing"
+
+func TestScryptCredManager(t *testing.T) {
+	cm := NewScryptCredManager()
+	hash := cm.GetHashedPassword("Secret123!", "", "")
+	if !cm.IsPasswordCorrect("Secret123!", hash, "", "") || cm.IsPasswordCorrect("secret123!", hash, "", "") {
+		t.Errorf("IsPasswordCorrect() fails for %s", hash)
+	}
+	if cm.NeedsRehash(hash) {
+		t.Errorf("NeedsRehash() = true for a new hash")
+	}
+
+	for _, hash := range []string{
+		"$scrypt$ln=10,r=8,p=1$MDEyMzQ1Njc4OWFiY2RlZg$kABuFq4aWAgCW0okjxMER1qL3SCUNtgZsloVM+AU054",
+		"scrypt$Y8fXgTWqFZ5s$1024$8$1$s9n8u3xiOgFbetfUy/8tMN1Mv5Cim6+HTxY2rSQRdsK6xZ7q4LuVnSqaEMvNCvBxApnC14YbKdQV6YoZleda3g==",
+	} {
+		if !cm.IsPasswordCorrect("Secret123!", hash, "", "") {
+			t.Errorf("IsPasswordCorrect() fails for %s", hash)
+		}
+		if !cm.NeedsRehash(hash) {
+			t.Errorf("NeedsRehash() = false for %s", hash)
+		}
+	}
+}
@@ -117,6 +117,7 @@ func (idp *BaiduIdProvider) GetUserInfo(token *oauth2.Token) (*UserInfo, error)
 		Username:    baiduUser.Username,
 		DisplayName: baiduUser.Username,
 		AvatarUrl:   fmt.Sprintf("https://himg.bdimg.com/sys/portrait/item/%s", baiduUser.Portrait),
+		Profile:     baiduUser,
 	}
 	return &userInfo, nil
 }
@@ -0,0 +1,125 @@
+package idp
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// getValueByPath walks a decoded JSON document along a claim path such as
+// "email", "profile.name" or "emails[0].value". A leading "$" or "$." is
+// accepted, so JSONPath-style expressions like "$.data.user.id" work as well.
+func getValueByPath(data interface{}, path string) interface{} {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+	path = strings.TrimPrefix(path, ".")
+	if path == "" {
+		return data
+	}
+
+	current := data
+	for _, segment := range strings.Split(path, ".") {
+		name := segment
+		indexes := []int{}
+		if i := strings.Index(segment, "["); i >= 0 {
+			name = segment[:i]
+			for _, part := range strings.Split(segment[i:], "[")[1:] {
+				index, err := strconv.Atoi(strings.TrimSuffix(part, "]"))
+				if err != nil {
+					return nil
+				}
+				indexes = append(indexes, index)
+			}
+		}
+
+		if name != "" {
+			m, ok := current.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			current = m[name]
+		}
+
+		for _, index := range indexes {
+			array, ok := current.([]interface{})
+			if !ok || index < 0 || index >= len(array) {
+				return nil
+			}
+			current = array[index]
+		}
+	}
+
+	return current
+}
+
+func getStringByPath(data interface{}, path string) string {
+	value := getValueByPath(data, path)
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// mapUserInfo builds a UserInfo from the decoded JSON data according to userMapping, whose keys
+// are "id", "username", "displayName", "email" and "avatarUrl". Keys missing from
+// userMapping fall back to defaultMapping.
+func mapUserInfo(data interface{}, userMapping map[string]string, defaultMapping map[string]string) *UserInfo {
+	getPath := func(key string) string {
+		if path, ok := userMapping[key]; ok && path != "" {
+			return path
+		}
+		return defaultMapping[key]
+	}
+
+	getString := func(key string) string {
+		path := getPath(key)
+		if path == "" {
+			return ""
+		}
+		return getStringByPath(data, path)
+	}
+
+	userInfo := UserInfo{
+		Id:          getString("id"),
+		Username:    getString("username"),
+		DisplayName: getString("displayName"),
+		Email:       getString("email"),
+		AvatarUrl:   getString("avatarUrl"),
+		Profile:     data,
+	}
+	if userInfo.Username == "" {
+		userInfo.Username = userInfo.Id
+	}
+	if userInfo.DisplayName == "" {
+		userInfo.DisplayName = userInfo.Username
+	}
+
+	return &userInfo
+}
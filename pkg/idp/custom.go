@@ -0,0 +1,158 @@
+package idp
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// CustomIdProvider is a plain OAuth 2.0 provider whose endpoints, scopes and user info
+// mapping all come from the provider configuration instead of code.
+type CustomIdProvider struct {
+	Client      *http.Client
+	Config      *oauth2.Config
+	UserInfoUrl string
+	EnablePkce  bool
+	UserMapping map[string]string
+
+	// CodeVerifier is bound to the browser session that started the login
+	CodeVerifier string
+}
+
+var customDefaultUserMapping = map[string]string{
+	"id":          "id",
+	"username":    "username",
+	"displayName": "name",
+	"email":       "email",
+	"avatarUrl":   "avatar",
+}
+
+func NewCustomIdProvider(info *ProviderInfo, redirectUrl string) *CustomIdProvider {
+	idp := &CustomIdProvider{
+		UserInfoUrl: info.UserInfoUrl,
+		EnablePkce:  info.EnablePkce,
+		UserMapping: info.UserMapping,
+	}
+
+	idp.Config = &oauth2.Config{
+		ClientID:     info.ClientId,
+		ClientSecret: info.ClientSecret,
+		RedirectURL:  redirectUrl,
+		Scopes:       strings.Fields(info.Scopes),
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   info.AuthUrl,
+			TokenURL:  info.TokenUrl,
+			AuthStyle: getAuthStyle(info.AuthStyle),
+		},
+	}
+
+	return idp
+}
+
+// getAuthStyle maps the "authStyle" setting of a provider: "Header" sends the client
+// credentials with HTTP Basic auth, "Params" sends them in the POST body.
+func getAuthStyle(authStyle string) oauth2.AuthStyle {
+	switch authStyle {
+	case "Header":
+		return oauth2.AuthStyleInHeader
+	case "Params":
+		return oauth2.AuthStyleInParams
+	default:
+		return oauth2.AuthStyleAutoDetect
+	}
+}
+
+func (idp *CustomIdProvider) SetHttpClient(client *http.Client) {
+	idp.Client = client
+}
+
+// GetAuthUrl returns the authorization URL to redirect the browser to, the PKCE code challenge
+// is derived from idp.CodeVerifier.
+func (idp *CustomIdProvider) GetAuthUrl(state string) string {
+	options := []oauth2.AuthCodeOption{}
+	if idp.EnablePkce && idp.CodeVerifier != "" {
+		sum := sha256.Sum256([]byte(idp.CodeVerifier))
+		challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+		options = append(options, oauth2.SetAuthURLParam("code_challenge", challenge))
+		options = append(options, oauth2.SetAuthURLParam("code_challenge_method", "S256"))
+	}
+
+	return idp.Config.AuthCodeURL(state, options...)
+}
+
+func (idp *CustomIdProvider) GetToken(code string) (*oauth2.Token, error) {
+	options := []oauth2.AuthCodeOption{}
+	if idp.EnablePkce && idp.CodeVerifier != "" {
+		options = append(options, oauth2.SetAuthURLParam("code_verifier", idp.CodeVerifier))
+	}
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, idp.Client)
+	return idp.Config.Exchange(ctx, code, options...)
+}
+
+func (idp *CustomIdProvider) GetUserInfo(token *oauth2.Token) (*UserInfo, error) {
+	if idp.UserInfoUrl == "" {
+		return nil, fmt.Errorf("the user info URL of the provider is empty")
+	}
+
+	req, err := http.NewRequest("GET", idp.UserInfoUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Authorization", "Bearer "+token.AccessToken)
+	req.Header.Add("Accept", "application/json")
+	resp, err := idp.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("the user info request returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	var data interface{}
+	err = json.Unmarshal(body, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	userInfo := mapUserInfo(data, idp.UserMapping, customDefaultUserMapping)
+	if userInfo.Id == "" {
+		return nil, fmt.Errorf("the user id is empty, please check the user mapping of the provider")
+	}
+	return userInfo, nil
+}
@@ -0,0 +1,121 @@
+package idp
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestCustomIdProvider(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		clientId, clientSecret, ok := r.BasicAuth()
+		if !ok || clientId != "client" || clientSecret != "secret" {
+			http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"access","token_type":"Bearer","expires_in":3600}`)
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"code":0,"data":{"uid":1001,"login":"bob","nick":"Bob","mails":["bob@example.com"]}}`)
+	})
+
+	idp := NewCustomIdProvider(&ProviderInfo{
+		ClientId:     "client",
+		ClientSecret: "secret",
+		AuthUrl:      server.URL + "/authorize",
+		TokenUrl:     server.URL + "/token",
+		UserInfoUrl:  server.URL + "/userinfo",
+		AuthStyle:    "Header",
+		UserMapping: map[string]string{
+			"id":          "$.data.uid",
+			"username":    "$.data.login",
+			"displayName": "$.data.nick",
+			"email":       "$.data.mails[0]",
+		},
+	}, "http://localhost/callback")
+	idp.SetHttpClient(server.Client())
+
+	token, err := idp.GetToken("code")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userInfo, err := idp.GetUserInfo(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userInfo.Id != "1001" || userInfo.Username != "bob" || userInfo.DisplayName != "Bob" || userInfo.Email != "bob@example.com" {
+		t.Errorf("unexpected user info: %+v", userInfo)
+	}
+}
+
+func TestCustomIdProviderPkce(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code_verifier") != "verifier" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"access","token_type":"Bearer","expires_in":3600}`)
+	})
+
+	idp := NewCustomIdProvider(&ProviderInfo{
+		ClientId:     "client",
+		ClientSecret: "secret",
+		AuthUrl:      server.URL + "/authorize",
+		TokenUrl:     server.URL + "/token",
+		AuthStyle:    "Params",
+		EnablePkce:   true,
+	}, "http://localhost/callback")
+	idp.SetHttpClient(server.Client())
+	idp.CodeVerifier = "verifier"
+
+	authUrl, err := url.Parse(idp.GetAuthUrl("state"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the S256 challenge of "verifier"
+	if authUrl.Query().Get("code_challenge") != "iMnq5o6zALKXGivsnlom_0F5_WYda32GHkxlV7mq7hQ" || authUrl.Query().Get("code_challenge_method") != "S256" {
+		t.Errorf("unexpected PKCE parameters in the authorization URL: %s", authUrl.RawQuery)
+	}
+
+	_, err = idp.GetToken("code")
+	if err != nil {
+		t.Errorf("the code verifier is not sent with the token request: %s", err.Error())
+	}
+}
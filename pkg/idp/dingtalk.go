@@ -328,6 +328,7 @@ func (idp *DingTalkIdProvider) GetUserInfo(token *oauth2.Token) (*UserInfo, erro
 		DisplayName: dtUserInfo.Result.Name,
 		Email:       dtUserInfo.Result.Email,
 		AvatarUrl:   dtUserInfo.Result.Avatar,
+		Profile:     dtUserInfo,
 	}
 
 	return &userInfo, nil
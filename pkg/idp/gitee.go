@@ -207,6 +207,7 @@ func (idp *GiteeIdProvider) GetUserInfo(token *oauth2.Token) (*UserInfo, error)
 		DisplayName: gtUserInfo.Name,
 		Email:       gtUserInfo.Email,
 		AvatarUrl:   gtUserInfo.AvatarUrl,
+		Profile:     gtUserInfo,
 	}
 
 	return &userInfo, nil
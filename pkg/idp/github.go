@@ -195,6 +195,7 @@ func (idp *GithubIdProvider) GetUserInfo(token *oauth2.Token) (*UserInfo, error)
 		DisplayName: githubUserInfo.Name,
 		Email:       githubUserInfo.Email,
 		AvatarUrl:   githubUserInfo.AvatarUrl,
+		Profile:     githubUserInfo,
 	}
 	return &userInfo, nil
 }
@@ -231,6 +231,7 @@ func (idp *GitlabIdProvider) GetUserInfo(token *oauth2.Token) (*UserInfo, error)
 		DisplayName: guser.Name,
 		AvatarUrl:   guser.AvatarUrl,
 		Email:       guser.Email,
+		Profile:     guser,
 	}
 	return &userInfo, nil
 }
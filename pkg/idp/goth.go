@@ -247,6 +247,7 @@ func getUser(gothUser goth.User) *UserInfo {
 		DisplayName: gothUser.NickName,
 		Email:       gothUser.Email,
 		AvatarUrl:   gothUser.AvatarURL,
+		Profile:     gothUser.RawData,
 	}
 	//Some idp return an empty Name
 	//so construct the Name with firstname and lastname or nickname
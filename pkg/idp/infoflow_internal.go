@@ -189,6 +189,7 @@ func (idp *InfoflowInternalIdProvider) GetUserInfo(token *oauth2.Token) (*UserIn
 		DisplayName: infoResp.Name,
 		AvatarUrl:   infoResp.Avatar,
 		Email:       infoResp.Email,
+		Profile:     infoResp,
 	}
 
 	if userInfo.Id == "" {
@@ -184,6 +184,7 @@ func (idp *InfoflowIdProvider) GetUserInfo(token *oauth2.Token) (*UserInfo, erro
 		Username:    infoResp.Name,
 		DisplayName: infoResp.Name,
 		Email:       infoResp.Email,
+		Profile:     infoResp,
 	}
 
 	if userInfo.Id == "" {
@@ -191,6 +191,7 @@ func (idp *LarkIdProvider) GetUserInfo(token *oauth2.Token) (*UserInfo, error) {
 		Username:    larkUserInfo.Data.Name,
 		Email:       larkUserInfo.Data.Email,
 		AvatarUrl:   larkUserInfo.Data.AvatarUrl,
+		Profile:     larkUserInfo,
 	}
 
 	return &userInfo, nil
@@ -309,6 +309,7 @@ func (idp *LinkedInIdProvider) GetUserInfo(token *oauth2.Token) (*UserInfo, erro
 		Username:    username,
 		Email:       linkedInUserEmail.Elements[0].Handle.EmailAddress,
 		AvatarUrl:   linkedInUserInfo.ProfilePicture.DisplayImage1.Elements[0].Identifiers[0].Identifier,
+		Profile:     linkedInUserInfo,
 	}
 	return &userInfo, nil
 }
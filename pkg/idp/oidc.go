@@ -0,0 +1,324 @@
+package idp
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// OpenIdIdProvider is a generic OpenID Connect upstream identity provider (Okta, Auth0,
+// Keycloak, ...). The endpoints are read from "<issuer>/.well-known/openid-configuration".
+type OpenIdIdProvider struct {
+	Client      *http.Client
+	Config      *oauth2.Config
+	IssuerUrl   string
+	EnablePkce  bool
+	UserMapping map[string]string
+
+	// Nonce and CodeVerifier are bound to the browser session that started the login
+	Nonce        string
+	CodeVerifier string
+
+	discovery *OpenIdDiscovery
+	claims    map[string]interface{}
+}
+
+type OpenIdDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+var openIdDefaultUserMapping = map[string]string{
+	"id":          "sub",
+	"username":    "preferred_username",
+	"displayName": "name",
+	"email":       "email",
+	"avatarUrl":   "picture",
+}
+
+func NewOpenIdIdProvider(info *ProviderInfo, redirectUrl string) *OpenIdIdProvider {
+	idp := &OpenIdIdProvider{
+		IssuerUrl:   strings.TrimSuffix(info.IssuerUrl, "/"),
+		EnablePkce:  info.EnablePkce,
+		UserMapping: info.UserMapping,
+	}
+
+	scopes := strings.Fields(info.Scopes)
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "profile", "email"}
+	}
+
+	idp.Config = &oauth2.Config{
+		ClientID:     info.ClientId,
+		ClientSecret: info.ClientSecret,
+		RedirectURL:  redirectUrl,
+		Scopes:       scopes,
+	}
+
+	return idp
+}
+
+func (idp *OpenIdIdProvider) SetHttpClient(client *http.Client) {
+	idp.Client = client
+}
+
+func (idp *OpenIdIdProvider) getJson(url string, v interface{}) error {
+	resp, err := idp.Client.Get(url)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %d: %s", url, resp.StatusCode, string(body))
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+func (idp *OpenIdIdProvider) getDiscovery() (*OpenIdDiscovery, error) {
+	if idp.discovery != nil {
+		return idp.discovery, nil
+	}
+
+	if idp.IssuerUrl == "" {
+		return nil, fmt.Errorf("the issuer URL of the OpenID provider is empty")
+	}
+
+	var discovery OpenIdDiscovery
+	err := idp.getJson(fmt.Sprintf("%s/.well-known/openid-configuration", idp.IssuerUrl), &discovery)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSuffix(discovery.Issuer, "/") != idp.IssuerUrl {
+		return nil, fmt.Errorf("issuer mismatch in discovery document, expected: %s, got: %s", idp.IssuerUrl, discovery.Issuer)
+	}
+
+	idp.discovery = &discovery
+	idp.Config.Endpoint = oauth2.Endpoint{
+		AuthURL:  discovery.AuthorizationEndpoint,
+		TokenURL: discovery.TokenEndpoint,
+	}
+	return idp.discovery, nil
+}
+
+// GetAuthUrl returns the authorization URL to redirect the browser to. The nonce and
+// the PKCE code challenge are derived from idp.Nonce and idp.CodeVerifier.
+func (idp *OpenIdIdProvider) GetAuthUrl(state string) (string, error) {
+	_, err := idp.getDiscovery()
+	if err != nil {
+		return "", err
+	}
+
+	options := []oauth2.AuthCodeOption{}
+	if idp.Nonce != "" {
+		options = append(options, oauth2.SetAuthURLParam("nonce", idp.Nonce))
+	}
+	if idp.EnablePkce && idp.CodeVerifier != "" {
+		sum := sha256.Sum256([]byte(idp.CodeVerifier))
+		challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+		options = append(options, oauth2.SetAuthURLParam("code_challenge", challenge))
+		options = append(options, oauth2.SetAuthURLParam("code_challenge_method", "S256"))
+	}
+
+	return idp.Config.AuthCodeURL(state, options...), nil
+}
+
+func (idp *OpenIdIdProvider) GetToken(code string) (*oauth2.Token, error) {
+	_, err := idp.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	options := []oauth2.AuthCodeOption{}
+	if idp.EnablePkce && idp.CodeVerifier != "" {
+		options = append(options, oauth2.SetAuthURLParam("code_verifier", idp.CodeVerifier))
+	}
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, idp.Client)
+	token, err := idp.Config.Exchange(ctx, code, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	rawIdToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIdToken == "" {
+		return nil, fmt.Errorf("no id_token in the token response of the OpenID provider")
+	}
+
+	claims, err := idp.verifyIdToken(rawIdToken)
+	if err != nil {
+		return nil, err
+	}
+
+	idp.claims = claims
+	return token, nil
+}
+
+func (idp *OpenIdIdProvider) verifyIdToken(rawIdToken string) (map[string]interface{}, error) {
+	jws, err := jose.ParseSigned(rawIdToken)
+	if err != nil {
+		return nil, fmt.Errorf("malformed id_token: %s", err.Error())
+	}
+	if len(jws.Signatures) != 1 {
+		return nil, fmt.Errorf("id_token must have exactly one signature")
+	}
+
+	var jwks jose.JSONWebKeySet
+	err = idp.getJson(idp.discovery.JwksUri, &jwks)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := jwks.Keys
+	if kid := jws.Signatures[0].Header.KeyID; kid != "" {
+		keys = jwks.Key(kid)
+	}
+
+	var payload []byte
+	for _, key := range keys {
+		payload, err = jws.Verify(key)
+		if err == nil {
+			break
+		}
+	}
+	if payload == nil {
+		return nil, fmt.Errorf("failed to verify the signature of id_token")
+	}
+
+	claims := map[string]interface{}{}
+	err = json.Unmarshal(payload, &claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if iss, _ := claims["iss"].(string); strings.TrimSuffix(iss, "/") != idp.IssuerUrl {
+		return nil, fmt.Errorf("invalid issuer in id_token: %s", iss)
+	}
+
+	if !hasAudience(claims["aud"], idp.Config.ClientID) {
+		return nil, fmt.Errorf("id_token is not issued for client: %s", idp.Config.ClientID)
+	}
+	if azp, ok := claims["azp"].(string); ok && azp != idp.Config.ClientID {
+		return nil, fmt.Errorf("invalid authorized party in id_token: %s", azp)
+	}
+
+	now := time.Now().Unix()
+	exp, _ := claims["exp"].(float64)
+	if int64(exp)+60 < now {
+		return nil, fmt.Errorf("id_token has expired")
+	}
+
+	if idp.Nonce != "" {
+		if nonce, _ := claims["nonce"].(string); nonce != idp.Nonce {
+			return nil, fmt.Errorf("nonce mismatch in id_token")
+		}
+	}
+
+	return claims, nil
+}
+
+func hasAudience(aud interface{}, clientId string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == clientId
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s == clientId {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (idp *OpenIdIdProvider) GetUserInfo(token *oauth2.Token) (*UserInfo, error) {
+	if idp.claims == nil {
+		return nil, fmt.Errorf("the id_token has not been verified")
+	}
+
+	claims := map[string]interface{}{}
+	for k, v := range idp.claims {
+		claims[k] = v
+	}
+
+	if idp.discovery.UserinfoEndpoint != "" {
+		req, err := http.NewRequest("GET", idp.discovery.UserinfoEndpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Add("Authorization", "Bearer "+token.AccessToken)
+		resp, err := idp.Client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusOK {
+			userinfo := map[string]interface{}{}
+			err = json.Unmarshal(body, &userinfo)
+			if err != nil {
+				return nil, err
+			}
+
+			// the "sub" of the userinfo response must match the one in id_token, see OIDC core 5.3.2
+			if userinfo["sub"] != claims["sub"] {
+				return nil, fmt.Errorf("sub mismatch between id_token and userinfo response")
+			}
+			for k, v := range userinfo {
+				claims[k] = v
+			}
+		}
+	}
+
+	userInfo := mapUserInfo(claims, idp.UserMapping, openIdDefaultUserMapping)
+	if userInfo.Id == "" {
+		return nil, fmt.Errorf("the user id is empty, please check the user mapping of the provider")
+	}
+	return userInfo, nil
+}
@@ -0,0 +1,155 @@
+package idp
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+func newOpenIdTestServer(t *testing.T, claims map[string]interface{}) *httptest.Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, (&jose.SignerOptions{}).WithHeader("kid", "test-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	claims["iss"] = server.URL
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(OpenIdDiscovery{
+			Issuer:                server.URL,
+			AuthorizationEndpoint: server.URL + "/authorize",
+			TokenEndpoint:         server.URL + "/token",
+			UserinfoEndpoint:      server.URL + "/userinfo",
+			JwksUri:               server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "test-key", Algorithm: "RS256", Use: "sig"}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("code_verifier") != "verifier" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+
+		payload, _ := json.Marshal(claims)
+		jws, _ := signer.Sign(payload)
+		idToken, _ := jws.CompactSerialize()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"access","token_type":"Bearer","expires_in":3600,"id_token":"%s"}`, idToken)
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"sub":"%s","profile":{"login":"alice"}}`, claims["sub"])
+	})
+
+	return server
+}
+
+func getOpenIdTestUserInfo(t *testing.T, claims map[string]interface{}) (*UserInfo, error) {
+	server := newOpenIdTestServer(t, claims)
+	defer server.Close()
+
+	idp := NewOpenIdIdProvider(&ProviderInfo{
+		ClientId:    "client",
+		IssuerUrl:   server.URL,
+		EnablePkce:  true,
+		UserMapping: map[string]string{"username": "profile.login"},
+	}, "http://localhost/callback")
+	idp.SetHttpClient(server.Client())
+	idp.Nonce = "nonce"
+	idp.CodeVerifier = "verifier"
+
+	token, err := idp.GetToken("code")
+	if err != nil {
+		return nil, err
+	}
+	return idp.GetUserInfo(token)
+}
+
+func TestOpenIdIdProvider(t *testing.T) {
+	validClaims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"sub":   "123",
+			"aud":   "client",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"nonce": "nonce",
+			"email": "alice@example.com",
+		}
+	}
+
+	userInfo, err := getOpenIdTestUserInfo(t, validClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userInfo.Id != "123" || userInfo.Username != "alice" || userInfo.Email != "alice@example.com" {
+		t.Errorf("unexpected user info: %+v", userInfo)
+	}
+
+	claims := validClaims()
+	claims["nonce"] = "other"
+	if _, err = getOpenIdTestUserInfo(t, claims); err == nil {
+		t.Error("id_token with a wrong nonce should be rejected")
+	}
+
+	claims = validClaims()
+	claims["aud"] = []string{"another-client"}
+	if _, err = getOpenIdTestUserInfo(t, claims); err == nil {
+		t.Error("id_token for another audience should be rejected")
+	}
+
+	claims = validClaims()
+	claims["exp"] = time.Now().Add(-time.Hour).Unix()
+	if _, err = getOpenIdTestUserInfo(t, claims); err == nil {
+		t.Error("expired id_token should be rejected")
+	}
+}
+
+func TestGetValueByPath(t *testing.T) {
+	var data interface{}
+	json.Unmarshal([]byte(`{"data":{"user":{"id":42,"emails":[{"value":"a@b.c"}]}}}`), &data)
+
+	if s := getStringByPath(data, "$.data.user.id"); s != "42" {
+		t.Errorf("got %s", s)
+	}
+	if s := getStringByPath(data, "data.user.emails[0].value"); s != "a@b.c" {
+		t.Errorf("got %s", s)
+	}
+	if s := getStringByPath(data, "data.user.emails[1].value"); s != "" {
+		t.Errorf("got %s", s)
+	}
+}
@@ -33,6 +33,25 @@ type UserInfo struct {
 	DisplayName string
 	Email       string
 	AvatarUrl   string
+
+	// Profile is the user info as returned by the provider, before it is mapped
+	Profile interface{}
+}
+
+type ProviderInfo struct {
+	Type         string
+	SubType      string
+	ClientId     string
+	ClientSecret string
+	AppId        string
+	IssuerUrl    string
+	AuthUrl      string
+	TokenUrl     string
+	UserInfoUrl  string
+	AuthStyle    string
+	Scopes       string
+	EnablePkce   bool
+	UserMapping  map[string]string
 }
 
 type IdProvider interface {
@@ -41,7 +60,13 @@ type IdProvider interface {
 	GetUserInfo(token *oauth2.Token) (*UserInfo, error)
 }
 
-func GetIdProvider(typ string, subType string, clientId string, clientSecret string, appId string, redirectUrl string) IdProvider {
+func GetIdProvider(info *ProviderInfo, redirectUrl string) IdProvider {
+	typ := info.Type
+	subType := info.SubType
+	clientId := info.ClientId
+	clientSecret := info.ClientSecret
+	appId := info.AppId
+
 	if typ == "GitHub" {
 		return NewGithubIdProvider(clientId, clientSecret, redirectUrl)
 	} else if typ == "Google" {
@@ -82,6 +107,10 @@ func GetIdProvider(typ string, subType string, clientId string, clientSecret str
 		} else {
 			return nil
 		}
+	} else if typ == "OpenID" {
+		return NewOpenIdIdProvider(info, redirectUrl)
+	} else if typ == "Custom" {
+		return NewCustomIdProvider(info, redirectUrl)
 	} else if isGothSupport(typ) {
 		return NewGothIdProvider(typ, clientId, clientSecret, redirectUrl)
 	}
@@ -186,6 +186,7 @@ func (idp *QqIdProvider) GetUserInfo(token *oauth2.Token) (*UserInfo, error) {
 		Id:          openId,
 		DisplayName: qqUserInfo.Nickname,
 		AvatarUrl:   qqUserInfo.FigureurlQq1,
+		Profile:     qqUserInfo,
 	}
 	return &userInfo, nil
 }
@@ -166,6 +166,7 @@ func (idp *WeComInternalIdProvider) GetUserInfo(token *oauth2.Token) (*UserInfo,
 		DisplayName: infoResp.Name,
 		Email:       infoResp.Email,
 		AvatarUrl:   infoResp.Avatar,
+		Profile:     infoResp,
 	}
 
 	if userInfo.Id == "" {
@@ -184,6 +184,7 @@ func (idp *WeComIdProvider) GetUserInfo(token *oauth2.Token) (*UserInfo, error)
 		Username:    wecomUserInfo.UserInfo.Name,
 		DisplayName: wecomUserInfo.UserInfo.Name,
 		AvatarUrl:   wecomUserInfo.UserInfo.Avatar,
+		Profile:     wecomUserInfo,
 	}
 	return &userInfo, nil
 }
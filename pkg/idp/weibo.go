@@ -245,6 +245,7 @@ func (idp *WeiBoIdProvider) GetUserInfo(token *oauth2.Token) (*UserInfo, error)
 		DisplayName: weiboUserInfo.Name,
 		AvatarUrl:   weiboUserInfo.AvatarLarge,
 		Email:       e.Email,
+		Profile:     weiboUserInfo,
 	}
 	return &userInfo, nil
 }
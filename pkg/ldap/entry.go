@@ -0,0 +1,237 @@
+package ldap
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/bhojpur/iam/pkg/object"
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+// Each organization is served as the base DN "o=<organization>". Users live under
+// "ou=users,o=<organization>" and roles under "ou=groups,o=<organization>".
+
+type Entry struct {
+	Dn         string
+	Attributes []*Attribute
+}
+
+type Attribute struct {
+	Name   string
+	Values []string
+}
+
+func (entry *Entry) addAttribute(name string, values ...string) {
+	nonEmptyValues := []string{}
+	for _, value := range values {
+		if value != "" {
+			nonEmptyValues = append(nonEmptyValues, value)
+		}
+	}
+
+	if len(nonEmptyValues) == 0 {
+		return
+	}
+	entry.Attributes = append(entry.Attributes, &Attribute{Name: name, Values: nonEmptyValues})
+}
+
+func (entry *Entry) getAttributeValues(name string) []string {
+	for _, attribute := range entry.Attributes {
+		if strings.EqualFold(attribute.Name, name) {
+			return attribute.Values
+		}
+	}
+	return nil
+}
+
+func getOrganizationDn(organization string) string {
+	return fmt.Sprintf("o=%s", escapeDnValue(organization))
+}
+
+func getUsersDn(organization string) string {
+	return fmt.Sprintf("ou=users,%s", getOrganizationDn(organization))
+}
+
+func getGroupsDn(organization string) string {
+	return fmt.Sprintf("ou=groups,%s", getOrganizationDn(organization))
+}
+
+func getUserDn(owner string, name string) string {
+	return fmt.Sprintf("uid=%s,%s", escapeDnValue(name), getUsersDn(owner))
+}
+
+func getRoleDn(owner string, name string) string {
+	return fmt.Sprintf("cn=%s,%s", escapeDnValue(name), getGroupsDn(owner))
+}
+
+func escapeDnValue(value string) string {
+	var sb strings.Builder
+	for i, c := range value {
+		switch {
+		case strings.ContainsRune(",+\"\\<>;=", c),
+			i == 0 && (c == ' ' || c == '#'),
+			i == len(value)-1 && c == ' ':
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+// parseUserDn returns the organization and user name of a DN like "uid=alice,ou=users,o=built-in".
+// "cn" is accepted in place of "uid" since many clients build bind DNs that way.
+func parseUserDn(dn string) (string, string, error) {
+	parsedDn, err := goldap.ParseDN(dn)
+	if err != nil {
+		return "", "", err
+	}
+
+	rdns := parsedDn.RDNs
+	if len(rdns) != 3 || len(rdns[0].Attributes) != 1 || len(rdns[1].Attributes) != 1 || len(rdns[2].Attributes) != 1 {
+		return "", "", fmt.Errorf("invalid user DN: %s", dn)
+	}
+
+	nameType := strings.ToLower(rdns[0].Attributes[0].Type)
+	if (nameType != "uid" && nameType != "cn") ||
+		!strings.EqualFold(rdns[1].Attributes[0].Type, "ou") || !strings.EqualFold(rdns[1].Attributes[0].Value, "users") ||
+		!strings.EqualFold(rdns[2].Attributes[0].Type, "o") {
+		return "", "", fmt.Errorf("invalid user DN: %s", dn)
+	}
+
+	return rdns[2].Attributes[0].Value, rdns[0].Attributes[0].Value, nil
+}
+
+// getIdNumber derives a stable POSIX id from the given id
+func getIdNumber(id string) string {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return strconv.FormatUint(uint64(10000+h.Sum32()%(1<<30)), 10)
+}
+
+// getUserIdNumber prefers the "uidNumber" user property, so that administrators can pin
+// the numbers of accounts that already own files on the clients
+func getUserIdNumber(user *object.User) string {
+	if uidNumber := user.Properties["uidNumber"]; uidNumber != "" {
+		return uidNumber
+	}
+	return getIdNumber(user.GetId())
+}
+
+func getRootDseEntry(organizations []string) *Entry {
+	entry := &Entry{Dn: ""}
+	entry.addAttribute("objectClass", "top")
+	namingContexts := []string{}
+	for _, organization := range organizations {
+		namingContexts = append(namingContexts, getOrganizationDn(organization))
+	}
+	entry.addAttribute("namingContexts", namingContexts...)
+	entry.addAttribute("supportedLDAPVersion", "3")
+	entry.addAttribute("vendorName", "Bhojpur IAM")
+	return entry
+}
+
+func getOrganizationEntries(organization *object.Organization) []*Entry {
+	entry := &Entry{Dn: getOrganizationDn(organization.Name)}
+	entry.addAttribute("objectClass", "top", "organization")
+	entry.addAttribute("o", organization.Name)
+	entry.addAttribute("description", organization.DisplayName)
+
+	usersEntry := &Entry{Dn: getUsersDn(organization.Name)}
+	usersEntry.addAttribute("objectClass", "top", "organizationalUnit")
+	usersEntry.addAttribute("ou", "users")
+
+	groupsEntry := &Entry{Dn: getGroupsDn(organization.Name)}
+	groupsEntry.addAttribute("objectClass", "top", "organizationalUnit")
+	groupsEntry.addAttribute("ou", "groups")
+
+	return []*Entry{entry, usersEntry, groupsEntry}
+}
+
+func getUserEntry(user *object.User, roles []*object.Role) *Entry {
+	entry := &Entry{Dn: getUserDn(user.Owner, user.Name)}
+	entry.addAttribute("objectClass", "top", "person", "organizationalPerson", "inetOrgPerson", "posixAccount")
+	entry.addAttribute("uid", user.Name)
+	entry.addAttribute("cn", user.Name)
+
+	sn := user.DisplayName
+	if sn == "" {
+		sn = user.Name
+	}
+	entry.addAttribute("sn", sn)
+	entry.addAttribute("displayName", user.DisplayName)
+	entry.addAttribute("mail", user.Email)
+	entry.addAttribute("mobile", user.Phone)
+	entry.addAttribute("title", user.Title)
+	entry.addAttribute("labeledURI", user.Homepage)
+	entry.addAttribute("entryUUID", user.Id)
+
+	idNumber := getUserIdNumber(user)
+	entry.addAttribute("uidNumber", idNumber)
+	entry.addAttribute("gidNumber", idNumber)
+	entry.addAttribute("homeDirectory", fmt.Sprintf("/home/%s", user.Name))
+	entry.addAttribute("loginShell", "/bin/bash")
+
+	memberOf := []string{}
+	userId := user.GetId()
+	for _, role := range roles {
+		for _, roleUser := range role.Users {
+			if roleUser == userId {
+				memberOf = append(memberOf, getRoleDn(role.Owner, role.Name))
+				break
+			}
+		}
+	}
+	entry.addAttribute("memberOf", memberOf...)
+
+	return entry
+}
+
+func getRoleEntry(role *object.Role) *Entry {
+	entry := &Entry{Dn: getRoleDn(role.Owner, role.Name)}
+	entry.addAttribute("objectClass", "top", "groupOfNames")
+	entry.addAttribute("cn", role.Name)
+	entry.addAttribute("description", role.DisplayName)
+
+	members := []string{}
+	for _, id := range role.Users {
+		owner, name := splitId(id)
+		members = append(members, getUserDn(owner, name))
+	}
+	for _, id := range role.Roles {
+		owner, name := splitId(id)
+		members = append(members, getRoleDn(owner, name))
+	}
+	entry.addAttribute("member", members...)
+
+	return entry
+}
+
+func splitId(id string) (string, string) {
+	tokens := strings.SplitN(id, "/", 2)
+	if len(tokens) != 2 {
+		return "", id
+	}
+	return tokens[0], tokens[1]
+}
@@ -0,0 +1,155 @@
+package ldap
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+// matchFilter evaluates a search filter of RFC 4511 section 4.5.1 against the entry.
+// Values are compared case-insensitively, and ordering matches compare numerically when
+// both sides are integers.
+func matchFilter(filter *ber.Packet, entry *Entry) (bool, error) {
+	if filter.ClassType != ber.ClassContext {
+		return false, fmt.Errorf("invalid filter class: %d", filter.ClassType)
+	}
+
+	switch filter.Tag {
+	case goldap.FilterAnd:
+		for _, child := range filter.Children {
+			ok, err := matchFilter(child, entry)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	case goldap.FilterOr:
+		for _, child := range filter.Children {
+			ok, err := matchFilter(child, entry)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				return true, nil
+			}
+		}
+		return false, nil
+	case goldap.FilterNot:
+		if len(filter.Children) != 1 {
+			return false, fmt.Errorf("invalid not filter")
+		}
+		ok, err := matchFilter(filter.Children[0], entry)
+		return !ok, err
+	case goldap.FilterPresent:
+		return entry.getAttributeValues(filter.Data.String()) != nil, nil
+	case goldap.FilterEqualityMatch, goldap.FilterApproxMatch, goldap.FilterGreaterOrEqual, goldap.FilterLessOrEqual:
+		if len(filter.Children) != 2 {
+			return false, fmt.Errorf("invalid %s filter", goldap.FilterMap[uint64(filter.Tag)])
+		}
+		name := packetString(filter.Children[0])
+		assertion := packetString(filter.Children[1])
+		for _, value := range entry.getAttributeValues(name) {
+			if compareValue(filter.Tag, value, assertion) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case goldap.FilterSubstrings:
+		if len(filter.Children) != 2 {
+			return false, fmt.Errorf("invalid substrings filter")
+		}
+		name := packetString(filter.Children[0])
+		for _, value := range entry.getAttributeValues(name) {
+			if matchSubstrings(filter.Children[1].Children, value) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case goldap.FilterExtensibleMatch:
+		// matching rules are not supported, the filter evaluates to undefined
+		return false, nil
+	default:
+		return false, fmt.Errorf("unknown filter tag: %d", filter.Tag)
+	}
+}
+
+func packetString(packet *ber.Packet) string {
+	if s, ok := packet.Value.(string); ok {
+		return s
+	}
+	return packet.Data.String()
+}
+
+func compareValue(tag ber.Tag, value string, assertion string) bool {
+	switch tag {
+	case goldap.FilterGreaterOrEqual, goldap.FilterLessOrEqual:
+		cmp := 0
+		valueInt, err1 := strconv.ParseInt(value, 10, 64)
+		assertionInt, err2 := strconv.ParseInt(assertion, 10, 64)
+		if err1 == nil && err2 == nil {
+			if valueInt < assertionInt {
+				cmp = -1
+			} else if valueInt > assertionInt {
+				cmp = 1
+			}
+		} else {
+			cmp = strings.Compare(strings.ToLower(value), strings.ToLower(assertion))
+		}
+
+		if tag == goldap.FilterGreaterOrEqual {
+			return cmp >= 0
+		}
+		return cmp <= 0
+	default:
+		return strings.EqualFold(value, assertion)
+	}
+}
+
+func matchSubstrings(substrings []*ber.Packet, value string) bool {
+	value = strings.ToLower(value)
+	for i, substring := range substrings {
+		s := strings.ToLower(packetString(substring))
+		switch substring.Tag {
+		case goldap.FilterSubstringsInitial:
+			if i != 0 || !strings.HasPrefix(value, s) {
+				return false
+			}
+			value = value[len(s):]
+		case goldap.FilterSubstringsAny:
+			index := strings.Index(value, s)
+			if index < 0 {
+				return false
+			}
+			value = value[index+len(s):]
+		case goldap.FilterSubstringsFinal:
+			if i != len(substrings)-1 || !strings.HasSuffix(value, s) {
+				return false
+			}
+			value = ""
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package ldap
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+
+	"github.com/bhojpur/iam/pkg/object"
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+func compileFilter(t *testing.T, filter string) *ber.Packet {
+	packet, err := goldap.CompileFilter(filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// round-trip through the wire format so that the packet looks like a received one
+	return ber.DecodePacket(packet.Bytes())
+}
+
+func TestMatchFilter(t *testing.T) {
+	user := &object.User{Owner: "built-in", Name: "alice", DisplayName: "Alice", Email: "alice@example.com", Properties: map[string]string{"uidNumber": "1500"}}
+	role := &object.Role{Owner: "built-in", Name: "admins", Users: []string{"built-in/alice"}}
+	entry := getUserEntry(user, []*object.Role{role})
+
+	tests := map[string]bool{
+		"(objectClass=*)":                           true,
+		"(objectClass=posixAccount)":                true,
+		"(objectclass=INETORGPERSON)":               true,
+		"(&(uid=alice)(mail=*@example.com))":        true,
+		"(|(uid=bob)(cn=ali*))":                     true,
+		"(!(uid=alice))":                            false,
+		"(uidNumber>=1000)":                         true,
+		"(uidNumber<=999)":                          false,
+		"(memberOf=cn=admins,ou=groups,o=built-in)": true,
+		"(mail=*@example.org)":                      false,
+		"(telephoneNumber=*)":                       false,
+	}
+
+	for filter, expected := range tests {
+		ok, err := matchFilter(compileFilter(t, filter), entry)
+		if err != nil {
+			t.Errorf("%s: %s", filter, err.Error())
+		} else if ok != expected {
+			t.Errorf("%s: expected %v, got %v", filter, expected, ok)
+		}
+	}
+}
+
+func TestParseUserDn(t *testing.T) {
+	organization, name, err := parseUserDn("uid=alice,ou=users,o=built-in")
+	if err != nil || organization != "built-in" || name != "alice" {
+		t.Errorf("unexpected result: %s, %s, %v", organization, name, err)
+	}
+
+	organization, name, err = parseUserDn(getUserDn("my org", "bob,jr"))
+	if err != nil || organization != "my org" || name != "bob,jr" {
+		t.Errorf("unexpected result: %s, %s, %v", organization, name, err)
+	}
+
+	_, _, err = parseUserDn("cn=admins,ou=groups,o=built-in")
+	if err == nil {
+		t.Error("a group DN should not be accepted as a user DN")
+	}
+}
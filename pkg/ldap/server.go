@@ -0,0 +1,498 @@
+package ldap
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/iam/pkg/object"
+	logsvr "github.com/bhojpur/logger/pkg/engine"
+	websvr "github.com/bhojpur/web/pkg/engine"
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+const (
+	applicationExtendedResponse = 24
+
+	startTlsOid = "1.3.6.1.4.1.1466.20037"
+
+	maxAcceptDelay = time.Second
+)
+
+// session is the state of one client connection. Only a simple bind and StartTLS change it.
+type session struct {
+	conn      net.Conn
+	tlsConfig *tls.Config
+	isTls     bool
+	user      *object.User
+}
+
+// StartLdapServer serves the organizations, users and roles over LDAP when "ldapServerPort"
+// is configured, and over LDAPS when "ldapsServerPort" is. "ldapCertFile" and "ldapKeyFile"
+// are the certificate of LDAPS, which also enables StartTLS on the plain port. Any user can
+// bind to check a password, but only the accounts listed in "ldapServiceAccounts"
+// (comma-separated "<organization>/<name>") are allowed to search.
+//
+// A bind only checks the password, so the users who must sign in with a second factor cannot
+// bind. The listed accounts bypass the MFA policy of their organization, unless they are
+// admins or have enrolled a second factor themselves, as those are used by people.
+func StartLdapServer() {
+	tlsConfig := getTlsConfig()
+
+	port, _ := websvr.AppConfig.String("ldapServerPort")
+	if port != "" {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
+		if err != nil {
+			panic(err)
+		}
+
+		logsvr.Info(fmt.Sprintf("LDAP server is listening on port: %s", port))
+		go serve(listener, tlsConfig, false)
+	}
+
+	ldapsPort, _ := websvr.AppConfig.String("ldapsServerPort")
+	if ldapsPort != "" {
+		if tlsConfig == nil {
+			panic("ldapCertFile and ldapKeyFile are required by ldapsServerPort")
+		}
+
+		listener, err := tls.Listen("tcp", fmt.Sprintf(":%s", ldapsPort), tlsConfig)
+		if err != nil {
+			panic(err)
+		}
+
+		logsvr.Info(fmt.Sprintf("LDAPS server is listening on port: %s", ldapsPort))
+		go serve(listener, tlsConfig, true)
+	}
+}
+
+func getTlsConfig() *tls.Config {
+	certFile, _ := websvr.AppConfig.String("ldapCertFile")
+	keyFile, _ := websvr.AppConfig.String("ldapKeyFile")
+	if certFile == "" || keyFile == "" {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		panic(err)
+	}
+
+	return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+}
+
+// serve accepts the connections until the listener is closed, and backs off on temporary
+// errors like running out of file descriptors instead of spinning
+func serve(listener net.Listener, tlsConfig *tls.Config, isTls bool) {
+	var delay time.Duration
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+
+			if delay == 0 {
+				delay = 5 * time.Millisecond
+			} else {
+				delay *= 2
+			}
+			if delay > maxAcceptDelay {
+				delay = maxAcceptDelay
+			}
+
+			logsvr.Error(fmt.Sprintf("LDAP server failed to accept connection: %s, retrying in %v", err.Error(), delay))
+			time.Sleep(delay)
+			continue
+		}
+
+		delay = 0
+		go handleConnection(&session{conn: conn, tlsConfig: tlsConfig, isTls: isTls})
+	}
+}
+
+func getLdapSearchAccounts() []string {
+	searchAccounts, _ := websvr.AppConfig.String("ldapServiceAccounts")
+	res := []string{}
+	for _, account := range strings.Split(searchAccounts, ",") {
+		account = strings.TrimSpace(account)
+		if account != "" {
+			res = append(res, account)
+		}
+	}
+	return res
+}
+
+func isLdapSearchAccount(user *object.User) bool {
+	if user == nil {
+		return false
+	}
+
+	for _, account := range getLdapSearchAccounts() {
+		if account == user.GetId() {
+			return true
+		}
+	}
+	return false
+}
+
+// isMfaExempted tells whether the MFA policy is skipped for a bind, which is only the case for
+// the non-interactive search accounts
+func isMfaExempted(user *object.User) bool {
+	if !isLdapSearchAccount(user) {
+		return false
+	}
+
+	return !user.IsAdmin && !user.IsGlobalAdmin && !user.IsMfaEnabled()
+}
+
+func handleConnection(s *session) {
+	// StartTLS replaces the connection of the session
+	defer func() {
+		if r := recover(); r != nil {
+			logsvr.Error(fmt.Sprintf("LDAP server panicked on connection from %s: %v", s.conn.RemoteAddr(), r))
+		}
+		s.conn.Close()
+	}()
+
+	for {
+		packet, err := ber.ReadPacket(s.conn)
+		if err != nil {
+			if err != io.EOF {
+				logsvr.Warning(fmt.Sprintf("LDAP server failed to read from %s: %s", s.conn.RemoteAddr(), err.Error()))
+			}
+			return
+		}
+
+		if len(packet.Children) < 2 {
+			return
+		}
+
+		messageId, ok := packet.Children[0].Value.(int64)
+		if !ok {
+			return
+		}
+
+		request := packet.Children[1]
+		if request.ClassType != ber.ClassApplication {
+			return
+		}
+
+		switch request.Tag {
+		case goldap.ApplicationBindRequest:
+			s.handleBind(messageId, request)
+		case goldap.ApplicationSearchRequest:
+			s.handleSearch(messageId, request)
+		case goldap.ApplicationUnbindRequest:
+			return
+		case goldap.ApplicationAbandonRequest:
+			// requests are answered synchronously, so there is nothing to abandon
+		case goldap.ApplicationExtendedRequest:
+			if !s.handleExtended(messageId, request) {
+				return
+			}
+		default:
+			// modify, add, delete, modify DN and compare all respond with the request tag + 1
+			s.writeResult(messageId, request.Tag+1, goldap.LDAPResultUnwillingToPerform, "the directory is read-only")
+		}
+	}
+}
+
+func (s *session) write(messageId int64, response *ber.Packet) {
+	envelope := ber.NewSequence("LDAP Response")
+	envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageId, "Message ID"))
+	envelope.AppendChild(response)
+
+	_, err := s.conn.Write(envelope.Bytes())
+	if err != nil {
+		logsvr.Warning(fmt.Sprintf("LDAP server failed to write to %s: %s", s.conn.RemoteAddr(), err.Error()))
+	}
+}
+
+func newResult(tag ber.Tag, resultCode uint16, message string) *ber.Packet {
+	response := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, goldap.ApplicationMap[uint8(tag)])
+	response.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(resultCode), "Result Code"))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, message, "Diagnostic Message"))
+	return response
+}
+
+func (s *session) writeResult(messageId int64, tag ber.Tag, resultCode uint16, message string) {
+	s.write(messageId, newResult(tag, resultCode, message))
+}
+
+// handleExtended answers an extended request, only StartTLS is supported. It returns false when
+// the connection must be closed.
+func (s *session) handleExtended(messageId int64, request *ber.Packet) bool {
+	if len(request.Children) == 0 || request.Children[0].Data.String() != startTlsOid {
+		s.writeResult(messageId, applicationExtendedResponse, goldap.LDAPResultProtocolError, "only the StartTLS extended operation is supported")
+		return true
+	}
+
+	if s.tlsConfig == nil {
+		s.writeResult(messageId, applicationExtendedResponse, goldap.LDAPResultUnavailable, "TLS is not configured")
+		return true
+	}
+	if s.isTls {
+		s.writeResult(messageId, applicationExtendedResponse, goldap.LDAPResultOperationsError, "TLS is already established")
+		return true
+	}
+
+	response := newResult(applicationExtendedResponse, goldap.LDAPResultSuccess, "")
+	// the responseName [10] of RFC 4511 section 4.14.2
+	response.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 10, startTlsOid, "Response Name"))
+	s.write(messageId, response)
+
+	tlsConn := tls.Server(s.conn, s.tlsConfig)
+	err := tlsConn.Handshake()
+	if err != nil {
+		logsvr.Warning(fmt.Sprintf("LDAP server failed the TLS handshake with %s: %s", s.conn.RemoteAddr(), err.Error()))
+		return false
+	}
+
+	// a bind before StartTLS doesn't carry over, see RFC 4513 section 3.1.3
+	s.conn = tlsConn
+	s.isTls = true
+	s.user = nil
+	return true
+}
+
+func (s *session) handleBind(messageId int64, request *ber.Packet) {
+	s.user = nil
+
+	if len(request.Children) != 3 {
+		s.writeResult(messageId, goldap.ApplicationBindResponse, goldap.LDAPResultProtocolError, "malformed bind request")
+		return
+	}
+
+	dn := packetString(request.Children[1])
+	auth := request.Children[2]
+	if auth.ClassType != ber.ClassContext || auth.Tag != 0 {
+		s.writeResult(messageId, goldap.ApplicationBindResponse, goldap.LDAPResultAuthMethodNotSupported, "only simple bind is supported")
+		return
+	}
+
+	password := auth.Data.String()
+	if dn == "" && password == "" {
+		// anonymous bind, which is accepted but cannot search
+		s.writeResult(messageId, goldap.ApplicationBindResponse, goldap.LDAPResultSuccess, "")
+		return
+	}
+
+	// an empty password would be an unauthenticated bind of RFC 4513 section 5.1.2, which must not succeed
+	if password == "" {
+		s.writeResult(messageId, goldap.ApplicationBindResponse, goldap.LDAPResultInvalidCredentials, "the password is empty")
+		return
+	}
+
+	organization, username, err := parseUserDn(dn)
+	if err != nil {
+		s.writeResult(messageId, goldap.ApplicationBindResponse, goldap.LDAPResultInvalidCredentials, err.Error())
+		return
+	}
+
+	clientIp, _, err := net.SplitHostPort(s.conn.RemoteAddr().String())
+	if err != nil {
+		clientIp = s.conn.RemoteAddr().String()
+	}
+
+	user, msg := object.CheckUserPassword(organization, username, password, clientIp)
+	if msg != "" {
+		s.writeResult(messageId, goldap.ApplicationBindResponse, goldap.LDAPResultInvalidCredentials, msg)
+		return
+	}
+
+	if !isMfaExempted(user) && object.IsSecondFactorRequired(user, nil) {
+		s.writeResult(messageId, goldap.ApplicationBindResponse, goldap.LDAPResultInvalidCredentials, "the user must sign in with a second factor")
+		return
+	}
+
+	object.ResetSigninFailures(user)
+	s.user = user
+	s.writeResult(messageId, goldap.ApplicationBindResponse, goldap.LDAPResultSuccess, "")
+}
+
+// getSearchableOrganizations returns the organizations the bound account may read: a search
+// account of the "built-in" organization can read all of them, other ones only their own.
+func (s *session) getSearchableOrganizations() []*object.Organization {
+	if s.user.Owner == "built-in" {
+		return object.GetOrganizations("admin")
+	}
+
+	organization := object.GetOrganization(fmt.Sprintf("admin/%s", s.user.Owner))
+	if organization == nil {
+		return []*object.Organization{}
+	}
+	return []*object.Organization{organization}
+}
+
+func (s *session) getEntries(baseDn *goldap.DN, scope int64) []*Entry {
+	organizations := s.getSearchableOrganizations()
+
+	if len(baseDn.RDNs) == 0 {
+		names := []string{}
+		for _, organization := range organizations {
+			names = append(names, organization.Name)
+		}
+		if scope == goldap.ScopeBaseObject {
+			return []*Entry{getRootDseEntry(names)}
+		}
+	}
+
+	entries := []*Entry{}
+	for _, organization := range organizations {
+		organizationDn, _ := goldap.ParseDN(getOrganizationDn(organization.Name))
+		if !organizationDn.EqualFold(baseDn) && !organizationDn.AncestorOfFold(baseDn) && !baseDn.AncestorOfFold(organizationDn) {
+			continue
+		}
+
+		entries = append(entries, getOrganizationEntries(organization)...)
+
+		roles := []*object.Role{}
+		for _, role := range object.GetRoles(organization.Name) {
+			if role.IsEnabled {
+				roles = append(roles, role)
+			}
+		}
+
+		for _, user := range object.GetUsers(organization.Name) {
+			if user.IsDeleted || user.IsForbidden {
+				continue
+			}
+			entries = append(entries, getUserEntry(user, roles))
+		}
+		for _, role := range roles {
+			entries = append(entries, getRoleEntry(role))
+		}
+	}
+	return entries
+}
+
+func isInScope(entryDn *goldap.DN, baseDn *goldap.DN, scope int64) bool {
+	switch scope {
+	case goldap.ScopeBaseObject:
+		return entryDn.EqualFold(baseDn)
+	case goldap.ScopeSingleLevel:
+		return len(entryDn.RDNs) == len(baseDn.RDNs)+1 && baseDn.AncestorOfFold(entryDn)
+	default:
+		return entryDn.EqualFold(baseDn) || baseDn.AncestorOfFold(entryDn)
+	}
+}
+
+func getEntryPacket(entry *Entry, attributes []string, typesOnly bool) *ber.Packet {
+	all := len(attributes) == 0
+	requested := map[string]bool{}
+	for _, attribute := range attributes {
+		if attribute == "*" {
+			all = true
+		}
+		requested[strings.ToLower(attribute)] = true
+	}
+
+	response := ber.Encode(ber.ClassApplication, ber.TypeConstructed, goldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.Dn, "Object Name"))
+
+	attributesPacket := ber.NewSequence("Attributes")
+	for _, attribute := range entry.Attributes {
+		if !all && !requested[strings.ToLower(attribute.Name)] {
+			continue
+		}
+
+		attributePacket := ber.NewSequence("Attribute")
+		attributePacket.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, attribute.Name, "Type"))
+		values := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		if !typesOnly {
+			for _, value := range attribute.Values {
+				values.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+			}
+		}
+		attributePacket.AppendChild(values)
+		attributesPacket.AppendChild(attributePacket)
+	}
+	response.AppendChild(attributesPacket)
+
+	return response
+}
+
+func (s *session) handleSearch(messageId int64, request *ber.Packet) {
+	if len(request.Children) != 8 {
+		s.writeResult(messageId, goldap.ApplicationSearchResultDone, goldap.LDAPResultProtocolError, "malformed search request")
+		return
+	}
+
+	if !isLdapSearchAccount(s.user) {
+		s.writeResult(messageId, goldap.ApplicationSearchResultDone, goldap.LDAPResultInsufficientAccessRights, "only the accounts in ldapServiceAccounts are allowed to search")
+		return
+	}
+
+	baseDn, err := goldap.ParseDN(packetString(request.Children[0]))
+	if err != nil {
+		s.writeResult(messageId, goldap.ApplicationSearchResultDone, goldap.LDAPResultInvalidDNSyntax, err.Error())
+		return
+	}
+
+	scope, _ := request.Children[1].Value.(int64)
+	sizeLimit, _ := request.Children[3].Value.(int64)
+	typesOnly, _ := request.Children[5].Value.(bool)
+	filter := request.Children[6]
+	attributes := []string{}
+	for _, attribute := range request.Children[7].Children {
+		attributes = append(attributes, packetString(attribute))
+	}
+	if len(attributes) == 1 && attributes[0] == "1.1" {
+		// "1.1" asks for no attributes at all, see RFC 4511 section 4.5.1.8
+		attributes = []string{""}
+	}
+
+	count := int64(0)
+	for _, entry := range s.getEntries(baseDn, scope) {
+		entryDn, err := goldap.ParseDN(entry.Dn)
+		if err != nil || (entry.Dn != "" && !isInScope(entryDn, baseDn, scope)) {
+			continue
+		}
+
+		ok, err := matchFilter(filter, entry)
+		if err != nil {
+			s.writeResult(messageId, goldap.ApplicationSearchResultDone, goldap.LDAPResultProtocolError, err.Error())
+			return
+		}
+		if !ok {
+			continue
+		}
+
+		if sizeLimit > 0 && count >= sizeLimit {
+			s.writeResult(messageId, goldap.ApplicationSearchResultDone, goldap.LDAPResultSizeLimitExceeded, "")
+			return
+		}
+
+		s.write(messageId, getEntryPacket(entry, attributes, typesOnly))
+		count++
+	}
+
+	s.writeResult(messageId, goldap.ApplicationSearchResultDone, goldap.LDAPResultSuccess, "")
+}
@@ -0,0 +1,146 @@
+package ldap
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bhojpur/iam/pkg/object"
+	websvr "github.com/bhojpur/web/pkg/engine"
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+func getTestTlsConfig(t *testing.T) *tls.Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+}
+
+func TestStartTls(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		serve(listener, getTestTlsConfig(t), false)
+		close(done)
+	}()
+
+	conn, err := goldap.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = conn.StartTLS(&tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatalf("StartTLS() error: %v", err)
+	}
+
+	// an anonymous bind doesn't need the database
+	err = conn.UnauthenticatedBind("")
+	if err != nil {
+		t.Errorf("bind after StartTLS error: %v", err)
+	}
+
+	err = conn.StartTLS(&tls.Config{InsecureSkipVerify: true})
+	if err == nil {
+		t.Errorf("StartTLS() twice should fail")
+	}
+	conn.Close()
+
+	listener.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("serve() didn't return after the listener was closed")
+	}
+}
+
+func TestStartTlsNotConfigured(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	go serve(listener, nil, false)
+
+	conn, err := goldap.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	err = conn.StartTLS(&tls.Config{InsecureSkipVerify: true})
+	if !goldap.IsErrorWithCode(err, goldap.LDAPResultUnavailable) {
+		t.Errorf("StartTLS() error = %v, want unavailable", err)
+	}
+}
+
+func TestIsMfaExempted(t *testing.T) {
+	err := websvr.AppConfig.Set("ldapServiceAccounts", "built-in/ldap-reader, built-in/admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer websvr.AppConfig.Set("ldapServiceAccounts", "")
+
+	tests := []struct {
+		name string
+		user *object.User
+		want bool
+	}{
+		{"search account", &object.User{Owner: "built-in", Name: "ldap-reader"}, true},
+		{"not listed", &object.User{Owner: "built-in", Name: "alice"}, false},
+		{"listed admin", &object.User{Owner: "built-in", Name: "admin", IsGlobalAdmin: true}, false},
+		{"listed with totp", &object.User{Owner: "built-in", Name: "ldap-reader", TotpSecret: "secret"}, false},
+		{"no user", nil, false},
+	}
+	for _, test := range tests {
+		if got := isMfaExempted(test.user); got != test.want {
+			t.Errorf("%s: isMfaExempted() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -210,6 +210,71 @@ func (a *Adapter) createTable() {
 	if err != nil {
 		panic(err)
 	}
+
+	err = a.Engine.Sync2(new(FederatedIdentity))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(ProvisionedObject))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(ProvisioningStatus))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(ProvisioningLog))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(CasTicket))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(PasswordReset))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(MagicLink))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(UserSession))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(PersonalAccessToken))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(ServiceAccountCredential))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(ServiceAccountAssertion))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(LoginHistory))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(Migration))
+	if err != nil {
+		panic(err)
+	}
 }
 
 func GetSession(owner string, offset, limit int, field, value, sortField, sortOrder string) *orm.Session {
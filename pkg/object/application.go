@@ -42,6 +42,7 @@ type Application struct {
 	EnableSignUp        bool            `json:"enableSignUp"`
 	EnableSigninSession bool            `json:"enableSigninSession"`
 	EnableCodeSignin    bool            `json:"enableCodeSignin"`
+	EnableMagicLink     bool            `json:"enableMagicLink"`
 	Providers           []*ProviderItem `orm:"mediumtext" json:"providers"`
 	SignupItems         []*SignupItem   `orm:"varchar(1000)" json:"signupItems"`
 	OrganizationObj     *Organization   `orm:"-" json:"organizationObj"`
@@ -59,6 +60,21 @@ type Application struct {
 	TermsOfUse           string   `orm:"varchar(100)" json:"termsOfUse"`
 	SignupHtml           string   `orm:"mediumtext" json:"signupHtml"`
 	SigninHtml           string   `orm:"mediumtext" json:"signinHtml"`
+
+	EnableProvisioning bool   `json:"enableProvisioning"`
+	ProvisioningUrl    string `orm:"varchar(200)" json:"provisioningUrl"`
+	ProvisioningToken  string `orm:"varchar(200)" json:"provisioningToken"`
+
+	EnableCas     bool     `json:"enableCas"`
+	CasAttributes []string `orm:"varchar(1000)" json:"casAttributes"`
+
+	EnableRadius        bool     `json:"enableRadius"`
+	RadiusSecret        string   `orm:"varchar(100)" json:"radiusSecret"`
+	RadiusClients       []string `orm:"varchar(1000)" json:"radiusClients"`
+	RadiusLegacyClients bool     `json:"radiusLegacyClients"`
+
+	MfaPolicy string   `orm:"varchar(100)" json:"mfaPolicy"`
+	MfaTypes  []string `orm:"varchar(100)" json:"mfaTypes"`
 }
 
 func GetApplicationCount(owner, field, value string) int {
@@ -224,6 +240,12 @@ func GetMaskedApplication(application *Application, userId string) *Application
 	if application.ClientSecret != "" {
 		application.ClientSecret = "***"
 	}
+	if application.ProvisioningToken != "" {
+		application.ProvisioningToken = "***"
+	}
+	if application.RadiusSecret != "" {
+		application.RadiusSecret = "***"
+	}
 	return application
 }
 
@@ -240,7 +262,8 @@ func GetMaskedApplications(applications []*Application, userId string) []*Applic
 
 func UpdateApplication(id string, application *Application) bool {
 	owner, name := utils.GetOwnerAndNameFromId(id)
-	if getApplication(owner, name) == nil {
+	oldApplication := getApplication(owner, name)
+	if oldApplication == nil {
 		return false
 	}
 
@@ -248,6 +271,13 @@ func UpdateApplication(id string, application *Application) bool {
 		application.Name = name
 	}
 
+	if application.ProvisioningToken == "***" {
+		application.ProvisioningToken = oldApplication.ProvisioningToken
+	}
+	if application.RadiusSecret == "***" {
+		application.RadiusSecret = oldApplication.RadiusSecret
+	}
+
 	for _, providerItem := range application.Providers {
 		providerItem.Provider = nil
 	}
@@ -257,6 +287,12 @@ func UpdateApplication(id string, application *Application) bool {
 		panic(err)
 	}
 
+	// do an initial full sync when provisioning gets enabled or is pointed at another endpoint
+	if affected != 0 && application.isProvisioningEnabled() &&
+		(!oldApplication.isProvisioningEnabled() || oldApplication.ProvisioningUrl != application.ProvisioningUrl) {
+		SyncProvisioning(application)
+	}
+
 	return affected != 0
 }
 
@@ -272,6 +308,10 @@ func AddApplication(application *Application) bool {
 		panic(err)
 	}
 
+	if affected != 0 {
+		SyncProvisioning(application)
+	}
+
 	return affected != 0
 }
 
@@ -285,6 +325,10 @@ func DeleteApplication(application *Application) bool {
 		panic(err)
 	}
 
+	if affected != 0 {
+		deleteProvisioningData(application)
+	}
+
 	return affected != 0
 }
 
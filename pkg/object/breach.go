@@ -0,0 +1,97 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/bhojpur/iam/pkg/breach"
+	websvr "github.com/bhojpur/web/pkg/engine"
+)
+
+const (
+	BreachedPasswordCheckOff   = "Off"
+	BreachedPasswordCheckWarn  = "Warn"
+	BreachedPasswordCheckBlock = "Block"
+)
+
+var (
+	breachedPasswordFilter     *breach.Filter
+	breachedPasswordFilterLock sync.RWMutex
+)
+
+// InitBreachedPasswordFilter loads the filter built by "iamsvr breach-filter" from the file set
+// in breachedPasswordFile. Without it, no password is considered breached.
+func InitBreachedPasswordFilter() {
+	path, err := websvr.AppConfig.String("breachedPasswordFile")
+	if err != nil || path == "" {
+		return
+	}
+
+	err = LoadBreachedPasswordFilter(path)
+	if err != nil {
+		panic(fmt.Errorf("failed to load the breached password filter %s: %s", path, err.Error()))
+	}
+}
+
+func LoadBreachedPasswordFilter(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	filter, err := breach.ReadFilter(file)
+	if err != nil {
+		return err
+	}
+
+	breachedPasswordFilterLock.Lock()
+	breachedPasswordFilter = filter
+	breachedPasswordFilterLock.Unlock()
+	return nil
+}
+
+func IsPasswordBreached(password string) bool {
+	breachedPasswordFilterLock.RLock()
+	defer breachedPasswordFilterLock.RUnlock()
+
+	if breachedPasswordFilter == nil || password == "" {
+		return false
+	}
+	return breachedPasswordFilter.ContainsPassword(password)
+}
+
+// GetBreachedPasswordWarning returns the warning to show when the organization only warns
+// about breached passwords and the password is one. Blocked passwords are rejected by
+// CheckPasswordPolicy instead.
+func GetBreachedPasswordWarning(organization *Organization, password string) string {
+	if organization == nil || organization.BreachedPasswordCheck != BreachedPasswordCheckWarn {
+		return ""
+	}
+
+	if IsPasswordBreached(password) {
+		return "This password has appeared in a data breach, please consider changing it"
+	}
+	return ""
+}
@@ -0,0 +1,301 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/iam/pkg/utils"
+	websvr "github.com/bhojpur/web/pkg/engine"
+)
+
+// CasTicket is a CAS service ticket. Tickets are deleted when they are validated, so each
+// ticket can only be validated once.
+type CasTicket struct {
+	Owner       string `orm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `orm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `orm:"varchar(100)" json:"createdTime"`
+
+	Application    string `orm:"varchar(100)" json:"application"`
+	Organization   string `orm:"varchar(100)" json:"organization"`
+	User           string `orm:"varchar(100)" json:"user"`
+	Service        string `orm:"varchar(1000)" json:"service"`
+	IsFromNewLogin bool   `json:"isFromNewLogin"`
+	ExpireTime     int64  `json:"expireTime"`
+}
+
+type CasServiceResponse struct {
+	XMLName xml.Name                  `xml:"cas:serviceResponse" json:"-"`
+	Xmlns   string                    `xml:"xmlns:cas,attr" json:"-"`
+	Success *CasAuthenticationSuccess `xml:"cas:authenticationSuccess,omitempty" json:"authenticationSuccess,omitempty"`
+	Failure *CasAuthenticationFailure `xml:"cas:authenticationFailure,omitempty" json:"authenticationFailure,omitempty"`
+}
+
+type CasAuthenticationSuccess struct {
+	User       string        `xml:"cas:user" json:"user"`
+	Attributes CasAttributes `xml:"cas:attributes,omitempty" json:"attributes,omitempty"`
+}
+
+type CasAuthenticationFailure struct {
+	Code        string `xml:"code,attr" json:"code"`
+	Description string `xml:",chardata" json:"description"`
+}
+
+// CasAttributes are the released user attributes, each of which can have several values
+type CasAttributes map[string][]string
+
+func (attributes CasAttributes) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if len(attributes) == 0 {
+		return nil
+	}
+
+	names := []string{}
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	err := e.EncodeToken(start)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		for _, value := range attributes[name] {
+			err = e.EncodeElement(value, xml.StartElement{Name: xml.Name{Local: "cas:" + name}})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+var casTicketTimeout int64
+
+func init() {
+	var err error
+	casTicketTimeout, err = websvr.AppConfig.Int64("casTicketTimeout")
+	if err != nil || casTicketTimeout <= 0 {
+		// the timeout recommended by the CAS protocol specification
+		casTicketTimeout = 10
+	}
+}
+
+// isCasServiceMatched returns true when the service URL is under the redirect URL: the scheme
+// and host must be the same, and the path the same or continuing it after a "/"
+func isCasServiceMatched(service string, redirectUri string) bool {
+	serviceUrl, err := url.Parse(service)
+	if err != nil {
+		return false
+	}
+	redirectUrl, err := url.Parse(redirectUri)
+	if err != nil || redirectUrl.Host == "" {
+		return false
+	}
+
+	if !strings.EqualFold(serviceUrl.Scheme, redirectUrl.Scheme) || !strings.EqualFold(serviceUrl.Host, redirectUrl.Host) {
+		return false
+	}
+
+	prefix := strings.TrimSuffix(redirectUrl.Path, "/")
+	return serviceUrl.Path == prefix || strings.HasPrefix(serviceUrl.Path, prefix+"/")
+}
+
+// GetApplicationByCasService returns the application with CAS enabled that has a redirect URL
+// the service URL is under
+func GetApplicationByCasService(service string) *Application {
+	if service == "" {
+		return nil
+	}
+
+	for _, application := range GetApplications("admin") {
+		if !application.EnableCas {
+			continue
+		}
+
+		for _, redirectUri := range application.RedirectUris {
+			if redirectUri != "" && isCasServiceMatched(service, redirectUri) {
+				extendApplicationWithOrg(application)
+				return application
+			}
+		}
+	}
+	return nil
+}
+
+func AddCasTicket(application *Application, user *User, service string, isFromNewLogin bool) string {
+	ticket := &CasTicket{
+		Owner:          application.Owner,
+		Name:           fmt.Sprintf("ST-%s", utils.GenerateClientSecret()),
+		CreatedTime:    utils.GetCurrentTime(),
+		Application:    application.Name,
+		Organization:   user.Owner,
+		User:           user.Name,
+		Service:        service,
+		IsFromNewLogin: isFromNewLogin,
+		ExpireTime:     time.Now().Unix() + casTicketTimeout,
+	}
+
+	_, err := adapter.Engine.Insert(ticket)
+	if err != nil {
+		panic(err)
+	}
+
+	return ticket.Name
+}
+
+// consumeCasTicket returns the ticket and deletes it, so that concurrent validations of the
+// same ticket can't both succeed
+func consumeCasTicket(name string) *CasTicket {
+	ticket := CasTicket{Owner: "admin", Name: name}
+	existed, err := adapter.Engine.Get(&ticket)
+	if err != nil {
+		panic(err)
+	}
+	if !existed {
+		return nil
+	}
+
+	affected, err := adapter.Engine.Delete(&CasTicket{Owner: ticket.Owner, Name: ticket.Name})
+	if err != nil {
+		panic(err)
+	}
+	if affected == 0 {
+		return nil
+	}
+
+	return &ticket
+}
+
+func deleteExpiredCasTickets() {
+	_, err := adapter.Engine.Where("expire_time < ?", time.Now().Unix()).Delete(&CasTicket{})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newCasFailure(code string, description string) *CasServiceResponse {
+	return &CasServiceResponse{
+		Xmlns:   "http://www.yale.edu/tp/cas",
+		Failure: &CasAuthenticationFailure{Code: code, Description: description},
+	}
+}
+
+// ValidateCasTicket validates a service ticket for the service as described in section 2.5 of
+// the CAS protocol specification. The attributes configured for the application are released
+// when withAttributes is true (CAS 3.0).
+func ValidateCasTicket(name string, service string, renew bool, withAttributes bool) *CasServiceResponse {
+	if name == "" || service == "" {
+		return newCasFailure("INVALID_REQUEST", "both the ticket and the service parameters are required")
+	}
+
+	deleteExpiredCasTickets()
+
+	ticket := consumeCasTicket(name)
+	if ticket == nil || ticket.ExpireTime < time.Now().Unix() {
+		return newCasFailure("INVALID_TICKET", fmt.Sprintf("ticket %s not recognized", name))
+	}
+	if ticket.Service != service {
+		return newCasFailure("INVALID_SERVICE", fmt.Sprintf("ticket %s was not issued for service %s", name, service))
+	}
+	if renew && !ticket.IsFromNewLogin {
+		return newCasFailure("INVALID_TICKET", fmt.Sprintf("ticket %s was not issued from a new login", name))
+	}
+
+	application := getApplication(ticket.Owner, ticket.Application)
+	user := getUser(ticket.Organization, ticket.User)
+	if application == nil || !application.EnableCas || user == nil || user.IsForbidden || user.IsDeleted {
+		return newCasFailure("INVALID_TICKET", fmt.Sprintf("ticket %s is no longer valid", name))
+	}
+
+	success := &CasAuthenticationSuccess{User: user.Name}
+	if withAttributes {
+		success.Attributes = getCasAttributes(application, user, ticket)
+	}
+
+	return &CasServiceResponse{
+		Xmlns:   "http://www.yale.edu/tp/cas",
+		Success: success,
+	}
+}
+
+func getCasAttributes(application *Application, user *User, ticket *CasTicket) CasAttributes {
+	attributes := CasAttributes{
+		"authenticationDate":                     {ticket.CreatedTime},
+		"isFromNewLogin":                         {fmt.Sprintf("%v", ticket.IsFromNewLogin)},
+		"longTermAuthenticationRequestTokenUsed": {"false"},
+	}
+
+	for _, name := range application.CasAttributes {
+		var values []string
+		switch name {
+		case "name":
+			values = []string{user.Name}
+		case "id":
+			values = []string{user.Id}
+		case "organization":
+			values = []string{user.Owner}
+		case "displayName":
+			values = []string{user.DisplayName}
+		case "email":
+			values = []string{user.Email}
+		case "phone":
+			values = []string{user.Phone}
+		case "avatar":
+			values = []string{user.Avatar}
+		case "affiliation":
+			values = []string{user.Affiliation}
+		case "title":
+			values = []string{user.Title}
+		case "tag":
+			values = []string{user.Tag}
+		case "language":
+			values = []string{user.Language}
+		case "homepage":
+			values = []string{user.Homepage}
+		case "roles":
+			for _, role := range GetRolesByUser(user.GetId()) {
+				values = append(values, role.Name)
+			}
+		default:
+			// any other attribute is looked up in the properties of the user
+			if value, ok := user.Properties[name]; ok {
+				values = []string{value}
+			}
+		}
+
+		nonEmptyValues := []string{}
+		for _, value := range values {
+			if value != "" {
+				nonEmptyValues = append(nonEmptyValues, value)
+			}
+		}
+		if len(nonEmptyValues) != 0 {
+			attributes[name] = nonEmptyValues
+		}
+	}
+	return attributes
+}
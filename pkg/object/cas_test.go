@@ -0,0 +1,103 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestCasServiceResponse(t *testing.T) {
+	resp := &CasServiceResponse{
+		Xmlns: "http://www.yale.edu/tp/cas",
+		Success: &CasAuthenticationSuccess{
+			User: "alice",
+			Attributes: CasAttributes{
+				"email": {"alice@example.com"},
+				"roles": {"admins", "staff"},
+			},
+		},
+	}
+
+	data, err := xml.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<cas:serviceResponse xmlns:cas="http://www.yale.edu/tp/cas"><cas:authenticationSuccess><cas:user>alice</cas:user>` +
+		`<cas:attributes><cas:email>alice@example.com</cas:email><cas:roles>admins</cas:roles><cas:roles>staff</cas:roles></cas:attributes>` +
+		`</cas:authenticationSuccess></cas:serviceResponse>`
+	if string(data) != expected {
+		t.Errorf("unexpected XML: %s", data)
+	}
+
+	data, err = json.Marshal(map[string]interface{}{"serviceResponse": resp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"serviceResponse":{"authenticationSuccess":{"user":"alice","attributes":{"email":["alice@example.com"],"roles":["admins","staff"]}}}}` {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+
+	// CAS 2.0 responses have no attributes element
+	resp.Success.Attributes = nil
+	data, err = xml.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "attributes") {
+		t.Errorf("unexpected XML: %s", data)
+	}
+
+	data, err = xml.Marshal(newCasFailure("INVALID_TICKET", "ticket ST-1 not recognized"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `<cas:serviceResponse xmlns:cas="http://www.yale.edu/tp/cas"><cas:authenticationFailure code="INVALID_TICKET">ticket ST-1 not recognized</cas:authenticationFailure></cas:serviceResponse>` {
+		t.Errorf("unexpected XML: %s", data)
+	}
+}
+
+func TestIsCasServiceMatched(t *testing.T) {
+	tests := []struct {
+		service     string
+		redirectUri string
+		expected    bool
+	}{
+		{"https://app.example.com/cas", "https://app.example.com/cas", true},
+		{"https://app.example.com/cas/login?next=/", "https://app.example.com/cas", true},
+		{"https://app.example.com/cas/login", "https://app.example.com/cas/", true},
+		{"https://APP.example.com/", "https://app.example.com", true},
+		{"https://app.example.com/cassandra", "https://app.example.com/cas", false},
+		{"https://app.example.com.evil.net/cas", "https://app.example.com", false},
+		{"https://app.example.com@evil.net/cas", "https://app.example.com", false},
+		{"http://app.example.com/cas", "https://app.example.com/cas", false},
+		{"https://app.example.com:8443/cas", "https://app.example.com/cas", false},
+		{"https://app.example.com/cas", "/cas", false},
+	}
+
+	for _, test := range tests {
+		if actual := isCasServiceMatched(test.service, test.redirectUri); actual != test.expected {
+			t.Errorf("isCasServiceMatched(%q, %q) = %v, want %v", test.service, test.redirectUri, actual, test.expected)
+		}
+	}
+}
@@ -50,8 +50,8 @@ func CheckUserSignup(application *Application, organization *Organization, usern
 		}
 	}
 
-	if len(password) <= 5 {
-		return "password must have at least 6 characters"
+	if violations := CheckPasswordPolicy(organization, nil, password); len(violations) != 0 {
+		return GetPasswordViolationMsg(violations)
 	}
 
 	if application.IsSignupItemVisible("Email") {
@@ -111,20 +111,23 @@ func CheckPassword(user *User, password string) string {
 		return "organization does not exist"
 	}
 
-	credManager := cred.GetCredManager(organization.PasswordType)
-	if credManager != nil {
-		if organization.MasterPassword != "" {
-			if credManager.IsPasswordCorrect(password, organization.MasterPassword, "", organization.PasswordSalt) {
-				return ""
-			}
+	if organization.MasterPassword != "" {
+		credManager := cred.GetCredManager(getPasswordTypeByHash(organization, organization.MasterPassword))
+		if credManager != nil && credManager.IsPasswordCorrect(password, organization.MasterPassword, "", organization.PasswordSalt) {
+			return ""
 		}
+	}
 
+	passwordType := getPasswordTypeByHash(organization, user.Password)
+	credManager := cred.GetCredManager(passwordType)
+	if credManager != nil {
 		if credManager.IsPasswordCorrect(password, user.Password, user.PasswordSalt, organization.PasswordSalt) {
+			rehashUserPassword(organization, user, password, passwordType)
 			return ""
 		}
 		return "password incorrect"
 	} else {
-		return fmt.Sprintf("unsupported password type: %s", organization.PasswordType)
+		return fmt.Sprintf("unsupported password type: %s", passwordType)
 	}
 }
 
@@ -164,24 +167,49 @@ func checkLdapUserPassword(user *User, password string) (*User, string) {
 	return user, ""
 }
 
-func CheckUserPassword(organization string, username string, password string) (*User, string) {
+// CheckUserPassword checks the password of the user, the failures are counted per user and per
+// client IP address, and the user is locked out temporarily after too many of them
+func CheckUserPassword(organization string, username string, password string, clientIp string) (*User, string) {
 	user := GetUserByFields(organization, username)
 	if user == nil || user.IsDeleted == true {
-		return nil, "the user does not exist, please sign up first"
+		if msg := CheckSigninLockout(GetOrganization(fmt.Sprintf("admin/%s", organization)), nil, clientIp); msg != "" {
+			return nil, msg
+		}
+
+		msg := "the user does not exist, please sign up first"
+		AddSigninFailure(organization, nil, clientIp, msg)
+		return nil, msg
 	}
 
 	if user.IsForbidden {
 		return nil, "the user is forbidden to sign in, please contact the administrator"
 	}
+
+	if user.IsServiceAccount() {
+		return nil, "the service account can't sign in interactively, please use its client credentials"
+	}
+
+	if msg := CheckSigninLockout(GetOrganizationByUser(user), user, clientIp); msg != "" {
+		return nil, msg
+	}
+
+	var msg string
 	//for ldap users
 	if user.Ldap != "" {
-		return checkLdapUserPassword(user, password)
+		_, msg = checkLdapUserPassword(user, password)
+	} else {
+		msg = CheckPassword(user, password)
 	}
 
-	msg := CheckPassword(user, password)
 	if msg != "" {
+		AddSigninFailure(organization, user, clientIp, msg)
 		return nil, msg
 	}
 
+	// the failures of a user with a second factor are only reset once the login is complete, so
+	// that the failed attempts of the second factor are not reset by the password
+	if !user.IsMfaEnabled() {
+		ResetSigninFailures(user)
+	}
 	return user, ""
 }
@@ -0,0 +1,247 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+
+	"github.com/bhojpur/dbm/pkg/core"
+	"github.com/bhojpur/iam/pkg/idp"
+	"github.com/bhojpur/iam/pkg/utils"
+)
+
+// FederatedIdentity links a user to an account at an upstream identity provider. A user
+// can hold any number of links, including several accounts of the same provider.
+type FederatedIdentity struct {
+	Owner       string `orm:"varchar(100) notnull pk unique(subject)" json:"owner"`
+	Name        string `orm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `orm:"varchar(100)" json:"createdTime"`
+
+	User         string `orm:"varchar(100) index" json:"user"`
+	Provider     string `orm:"varchar(100) unique(subject)" json:"provider"`
+	ProviderType string `orm:"varchar(100)" json:"providerType"`
+	Subject      string `orm:"varchar(100) unique(subject)" json:"subject"`
+	Username     string `orm:"varchar(100)" json:"username"`
+	DisplayName  string `orm:"varchar(100)" json:"displayName"`
+	Email        string `orm:"varchar(100)" json:"email"`
+	AvatarUrl    string `orm:"varchar(500)" json:"avatarUrl"`
+	Profile      string `orm:"mediumtext" json:"profile"`
+	LinkedTime   string `orm:"varchar(100)" json:"linkedTime"`
+}
+
+func GetFederatedIdentities(owner string, user string) []*FederatedIdentity {
+	identities := []*FederatedIdentity{}
+	err := adapter.Engine.Asc("created_time").Find(&identities, &FederatedIdentity{Owner: owner, User: user})
+	if err != nil {
+		panic(err)
+	}
+
+	return identities
+}
+
+func getFederatedIdentity(owner string, provider string, subject string) *FederatedIdentity {
+	if owner == "" || provider == "" || subject == "" {
+		return nil
+	}
+
+	identity := FederatedIdentity{Owner: owner, Provider: provider, Subject: subject}
+	existed, err := adapter.Engine.Get(&identity)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &identity
+	}
+
+	return nil
+}
+
+func GetUserByFederatedIdentity(owner string, provider string, subject string) *User {
+	identity := getFederatedIdentity(owner, provider, subject)
+	if identity == nil {
+		return nil
+	}
+
+	return getUser(identity.Owner, identity.User)
+}
+
+func addFederatedIdentity(identity *FederatedIdentity) bool {
+	affected, err := adapter.Engine.Insert(identity)
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func LinkFederatedIdentity(user *User, provider *Provider, userInfo *idp.UserInfo) bool {
+	if getFederatedIdentity(user.Owner, provider.Name, userInfo.Id) != nil {
+		return false
+	}
+
+	identity := &FederatedIdentity{
+		Owner:        user.Owner,
+		Name:         utils.GenerateId(),
+		CreatedTime:  utils.GetCurrentTime(),
+		User:         user.Name,
+		Provider:     provider.Name,
+		ProviderType: provider.Type,
+		Subject:      userInfo.Id,
+		Username:     userInfo.Username,
+		DisplayName:  userInfo.DisplayName,
+		Email:        userInfo.Email,
+		AvatarUrl:    userInfo.AvatarUrl,
+		LinkedTime:   utils.GetCurrentTime(),
+	}
+	// the profile as returned by the provider, the migrated links have none
+	if userInfo.Profile != nil {
+		identity.Profile = utils.StructToJson(userInfo.Profile)
+	}
+	return addFederatedIdentity(identity)
+}
+
+// UnlinkFederatedIdentity removes the user's links to the provider. An empty subject
+// removes every account of that provider.
+func UnlinkFederatedIdentity(user *User, provider string, subject string) bool {
+	identity := &FederatedIdentity{Owner: user.Owner, User: user.Name, Provider: provider, Subject: subject}
+	affected, err := adapter.Engine.Delete(identity)
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func deleteFederatedIdentitiesByUser(owner string, user string) {
+	_, err := adapter.Engine.Delete(&FederatedIdentity{Owner: owner, User: user})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func renameFederatedIdentitiesUser(owner string, oldName string, newName string) {
+	_, err := adapter.Engine.Cols("user").Update(&FederatedIdentity{User: newName}, &FederatedIdentity{Owner: owner, User: oldName})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func ExtendUserWithFederatedIdentities(user *User) {
+	if user == nil {
+		return
+	}
+
+	user.FederatedIdentities = GetFederatedIdentities(user.Owner, user.Name)
+}
+
+func (identity *FederatedIdentity) GetId() string {
+	return fmt.Sprintf("%s/%s", identity.Owner, identity.Name)
+}
+
+// legacyOAuthColumns are the per-provider user columns that held the linked account id
+// before federated identities, keyed by column name
+var legacyOAuthColumns = map[string]string{
+	"github":   "GitHub",
+	"google":   "Google",
+	"qq":       "QQ",
+	"wechat":   "WeChat",
+	"facebook": "Facebook",
+	"dingtalk": "DingTalk",
+	"weibo":    "Weibo",
+	"gitee":    "Gitee",
+	"linkedin": "LinkedIn",
+	"wecom":    "WeCom",
+	"lark":     "Lark",
+	"gitlab":   "GitLab",
+	"apple":    "Apple",
+	"azuread":  "AzureAD",
+	"slack":    "Slack",
+	"openid":   "OpenID",
+}
+
+func getLegacyProviderName(user *User, providerType string) string {
+	application := GetApplicationByUser(user)
+	if application != nil {
+		for _, providerItem := range application.Providers {
+			if providerItem.Provider != nil && providerItem.Provider.Type == providerType {
+				return providerItem.Provider.Name
+			}
+		}
+	}
+
+	for _, provider := range GetProviders("admin") {
+		if provider.Category == "OAuth" && provider.Type == providerType {
+			return provider.Name
+		}
+	}
+
+	return providerType
+}
+
+// migrateFederatedIdentities moves the account links stored in the legacy per-provider
+// user columns and the "oauth_<type>_*" properties into federated identities. It is run
+// once, and the migrated columns are emptied.
+func migrateFederatedIdentities() {
+	tableName := adapter.Engine.TableName(&User{})
+	rows, err := adapter.Engine.QueryString(fmt.Sprintf("select * from %s", adapter.Engine.Quote(tableName)))
+	if err != nil {
+		panic(err)
+	}
+
+	for _, row := range rows {
+		for column, providerType := range legacyOAuthColumns {
+			subject := row[column]
+			if subject == "" {
+				continue
+			}
+
+			user := getUser(row["owner"], row["name"])
+			if user == nil {
+				continue
+			}
+
+			prefix := fmt.Sprintf("oauth_%s_", providerType)
+			userInfo := &idp.UserInfo{
+				Id:          subject,
+				Username:    user.Properties[prefix+"username"],
+				DisplayName: user.Properties[prefix+"displayName"],
+				Email:       user.Properties[prefix+"email"],
+				AvatarUrl:   user.Properties[prefix+"avatarUrl"],
+			}
+			provider := &Provider{Name: getLegacyProviderName(user, providerType), Type: providerType}
+			LinkFederatedIdentity(user, provider, userInfo)
+
+			for _, key := range []string{"id", "username", "displayName", "email", "avatarUrl"} {
+				delete(user.Properties, prefix+key)
+			}
+			_, err = adapter.Engine.ID(core.PK{user.Owner, user.Name}).Cols("properties").Update(user)
+			if err != nil {
+				panic(err)
+			}
+
+			_, err = adapter.Engine.Exec(fmt.Sprintf("update %s set %s = '' where owner = ? and name = ?", adapter.Engine.Quote(tableName), adapter.Engine.Quote(column)), user.Owner, user.Name)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	websvr "github.com/bhojpur/web/pkg/engine"
+)
+
+const (
+	HumanCheckTypeCaptcha     = "captcha"
+	HumanCheckTypeRecaptchaV2 = "reCAPTCHA v2"
+	HumanCheckTypeRecaptchaV3 = "reCAPTCHA v3"
+	HumanCheckTypeHcaptcha    = "hCaptcha"
+	HumanCheckTypeTurnstile   = "Turnstile"
+
+	// the default minimum score of the reCAPTCHA v3 tokens, from 0.0 for bots to 1.0 for humans
+	defaultRecaptchaV3Score = 0.5
+	// the action the web UI executes reCAPTCHA v3 with
+	recaptchaV3Action = "submit"
+)
+
+// humanCheckVerifyUrls are the siteverify endpoints of the human check providers, the endpoint of
+// a provider overrides them, e.g. for a local stub in tests
+var humanCheckVerifyUrls = map[string]string{
+	HumanCheckTypeRecaptchaV2: "https://www.google.com/recaptcha/api/siteverify",
+	HumanCheckTypeRecaptchaV3: "https://www.google.com/recaptcha/api/siteverify",
+	HumanCheckTypeHcaptcha:    "https://api.hcaptcha.com/siteverify",
+	HumanCheckTypeTurnstile:   "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+}
+
+var humanCheckHttpClient = &http.Client{Timeout: 10 * time.Second}
+
+type humanCheckResponse struct {
+	Success    bool     `json:"success"`
+	Score      *float64 `json:"score"`
+	Action     string   `json:"action"`
+	Hostname   string   `json:"hostname"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+func getHumanCheckVerifyUrl(provider *Provider) string {
+	if provider.Endpoint != "" {
+		return provider.Endpoint
+	}
+	return humanCheckVerifyUrls[provider.Type]
+}
+
+// getHumanCheckHostname returns the host name of the "origin", which the tokens must have been
+// issued on, or "" when it is not configured
+func getHumanCheckHostname() string {
+	origin, _ := websvr.AppConfig.String("origin")
+	originUrl, err := url.Parse(origin)
+	if err != nil {
+		return ""
+	}
+	return originUrl.Hostname()
+}
+
+// verifyHumanCheckToken verifies the token of the widget of the provider against its siteverify
+// endpoint, the site key is the client id of the provider and the secret key its client secret.
+// The token must have been issued on the hostname unless it is empty, so that a token solved on
+// another site with the same site key is not accepted.
+func verifyHumanCheckToken(provider *Provider, token string, clientIp string, hostname string) error {
+	verifyUrl := getHumanCheckVerifyUrl(provider)
+	if verifyUrl == "" {
+		return fmt.Errorf("unknown human check provider type: %s", provider.Type)
+	}
+	if token == "" {
+		return fmt.Errorf("the human check token is missing")
+	}
+
+	form := url.Values{}
+	form.Set("secret", provider.ClientSecret)
+	form.Set("response", token)
+	if clientIp != "" {
+		form.Set("remoteip", clientIp)
+	}
+	if provider.Type == HumanCheckTypeHcaptcha {
+		form.Set("sitekey", provider.ClientId)
+	}
+
+	resp, err := humanCheckHttpClient.PostForm(verifyUrl, form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("the human check provider returned the status: %d", resp.StatusCode)
+	}
+
+	var res humanCheckResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return err
+	}
+
+	if !res.Success {
+		return fmt.Errorf("the human check failed: %s", strings.Join(res.ErrorCodes, ","))
+	}
+	if hostname != "" && !strings.EqualFold(res.Hostname, hostname) {
+		return fmt.Errorf("the human check token was issued on another host: %s", res.Hostname)
+	}
+	if provider.Type == HumanCheckTypeRecaptchaV3 {
+		if res.Action != recaptchaV3Action {
+			return fmt.Errorf("the human check token was issued for another action: %s", res.Action)
+		}
+
+		minScore := provider.ScoreThreshold
+		if minScore <= 0 {
+			minScore = defaultRecaptchaV3Score
+		}
+		if res.Score == nil || *res.Score < minScore {
+			return fmt.Errorf("the human check score is too low")
+		}
+	}
+
+	return nil
+}
+
+// VerifyHumanCheck verifies the human check of a request: the built-in captcha answered with the
+// digits of the captcha id when no human check provider is configured, or otherwise the token of
+// the widget of the provider
+func VerifyHumanCheck(captchaId string, key string, clientIp string) bool {
+	provider := GetDefaultHumanCheckProvider()
+	if provider == nil {
+		return VerifyCaptcha(captchaId, key)
+	}
+
+	err := verifyHumanCheckToken(provider, key, clientIp, getHumanCheckHostname())
+	return err == nil
+}
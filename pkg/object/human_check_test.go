@@ -0,0 +1,135 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newHumanCheckStub stands in for the siteverify endpoint of a provider, it accepts the token
+// "valid" with the secret "secret" and answers with the score and the action for reCAPTCHA v3
+func newHumanCheckStub(t *testing.T, score string, action string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("the method = %s, want POST", r.Method)
+		}
+		if r.FormValue("remoteip") != "127.0.0.1" {
+			t.Errorf("remoteip = %s, want 127.0.0.1", r.FormValue("remoteip"))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.FormValue("secret") != "secret" {
+			w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-secret"]}`))
+			return
+		}
+		if r.FormValue("response") != "valid" {
+			w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-response"]}`))
+			return
+		}
+		if score != "" {
+			w.Write([]byte(`{"success": true, "score": ` + score + `, "action": "` + action + `", "hostname": "localhost"}`))
+			return
+		}
+		w.Write([]byte(`{"success": true, "hostname": "localhost"}`))
+	}))
+}
+
+func TestVerifyHumanCheckToken(t *testing.T) {
+	server := newHumanCheckStub(t, "", "")
+	defer server.Close()
+
+	for _, providerType := range []string{HumanCheckTypeRecaptchaV2, HumanCheckTypeHcaptcha, HumanCheckTypeTurnstile} {
+		provider := &Provider{Category: "HumanCheck", Type: providerType, ClientId: "site", ClientSecret: "secret", Endpoint: server.URL}
+
+		if err := verifyHumanCheckToken(provider, "valid", "127.0.0.1", "localhost"); err != nil {
+			t.Errorf("%s: the valid token is rejected: %s", providerType, err.Error())
+		}
+		if err := verifyHumanCheckToken(provider, "invalid", "127.0.0.1", "localhost"); err == nil {
+			t.Errorf("%s: the invalid token is accepted", providerType)
+		}
+		if err := verifyHumanCheckToken(provider, "", "127.0.0.1", "localhost"); err == nil {
+			t.Errorf("%s: the missing token is accepted", providerType)
+		}
+
+		if err := verifyHumanCheckToken(provider, "valid", "127.0.0.1", "iam.example.com"); err == nil {
+			t.Errorf("%s: the token of another host is accepted", providerType)
+		}
+
+		provider.ClientSecret = "wrong"
+		if err := verifyHumanCheckToken(provider, "valid", "127.0.0.1", "localhost"); err == nil {
+			t.Errorf("%s: the token is accepted with a wrong secret", providerType)
+		}
+	}
+}
+
+func TestVerifyHumanCheckTokenScore(t *testing.T) {
+	scenarios := []struct {
+		score     string
+		threshold float64
+		want      bool
+	}{
+		{"0.9", 0, true},
+		{"0.5", 0, true},
+		{"0.3", 0, false},
+		{"0.3", 0.2, true},
+		{"0.7", 0.8, false},
+	}
+
+	for _, scenario := range scenarios {
+		server := newHumanCheckStub(t, scenario.score, "submit")
+		provider := &Provider{Category: "HumanCheck", Type: HumanCheckTypeRecaptchaV3, ClientSecret: "secret", Endpoint: server.URL, ScoreThreshold: scenario.threshold}
+
+		err := verifyHumanCheckToken(provider, "valid", "127.0.0.1", "localhost")
+		if (err == nil) != scenario.want {
+			t.Errorf("the score %s with the threshold %v: passed = %v, want %v", scenario.score, scenario.threshold, err == nil, scenario.want)
+		}
+		server.Close()
+	}
+}
+
+func TestVerifyHumanCheckTokenAction(t *testing.T) {
+	server := newHumanCheckStub(t, "0.9", "login")
+	defer server.Close()
+
+	provider := &Provider{Category: "HumanCheck", Type: HumanCheckTypeRecaptchaV3, ClientSecret: "secret", Endpoint: server.URL}
+	if err := verifyHumanCheckToken(provider, "valid", "127.0.0.1", "localhost"); err == nil {
+		t.Errorf("the token of another action is accepted")
+	}
+}
+
+func TestVerifyHumanCheckTokenUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	provider := &Provider{Category: "HumanCheck", Type: HumanCheckTypeTurnstile, ClientSecret: "secret", Endpoint: server.URL}
+	if err := verifyHumanCheckToken(provider, "valid", "127.0.0.1", "localhost"); err == nil {
+		t.Errorf("the token is accepted while the provider is unavailable")
+	}
+
+	provider = &Provider{Category: "HumanCheck", Type: "Unknown", ClientSecret: "secret"}
+	if err := verifyHumanCheckToken(provider, "valid", "127.0.0.1", "localhost"); err == nil {
+		t.Errorf("the token of an unknown provider type is accepted")
+	}
+}
@@ -0,0 +1,85 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strings"
+
+	websvr "github.com/bhojpur/web/pkg/engine"
+)
+
+var impersonationTimeout int64
+
+func init() {
+	var err error
+	impersonationTimeout, err = websvr.AppConfig.Int64("impersonationTimeout")
+	if err != nil || impersonationTimeout <= 0 {
+		impersonationTimeout = 30
+	}
+}
+
+// GetImpersonationTimeout returns the seconds an impersonation lasts before it is ended
+func GetImpersonationTimeout() int64 {
+	return impersonationTimeout * 60
+}
+
+// CheckImpersonation checks that the actor can impersonate the target user: the global admins
+// can impersonate any user, and the admins of an organization the users of their organization
+// that aren't global admins
+func CheckImpersonation(actor *User, target *User) error {
+	if actor.GetId() == target.GetId() {
+		return fmt.Errorf("You can't impersonate yourself")
+	}
+	if target.IsForbidden || target.IsDeleted {
+		return fmt.Errorf("The user: %s is forbidden to sign in", target.GetId())
+	}
+	if target.IsServiceAccount() {
+		return fmt.Errorf("The service account: %s can't be impersonated", target.GetId())
+	}
+
+	isActorGlobalAdmin := actor.IsGlobalAdmin || actor.Owner == "built-in"
+	if isActorGlobalAdmin {
+		return nil
+	}
+
+	if !actor.IsAdmin || actor.Owner != target.Owner {
+		return fmt.Errorf("You don't have the permission to impersonate the user: %s", target.GetId())
+	}
+	if target.IsGlobalAdmin || target.Owner == "built-in" {
+		return fmt.Errorf("Only the global admins can impersonate the user: %s", target.GetId())
+	}
+
+	return nil
+}
+
+// ActorClaim is the "act" claim of the tokens issued to an impersonation (RFC 8693), its subject
+// is the user that is impersonating the subject of the token
+type ActorClaim struct {
+	Sub string `json:"sub"`
+}
+
+func getActorClaim(actor string) *ActorClaim {
+	if actor == "" || strings.HasPrefix(actor, "app/") {
+		return nil
+	}
+	return &ActorClaim{Sub: actor}
+}
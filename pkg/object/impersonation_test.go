@@ -0,0 +1,62 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import "testing"
+
+func TestCheckImpersonation(t *testing.T) {
+	globalAdmin := &User{Owner: "built-in", Name: "admin"}
+	orgAdmin := &User{Owner: "example", Name: "alice", IsAdmin: true}
+	otherOrgAdmin := &User{Owner: "other", Name: "carol", IsAdmin: true}
+	user := &User{Owner: "example", Name: "bob"}
+	orgGlobalAdmin := &User{Owner: "example", Name: "dave", IsGlobalAdmin: true}
+	forbiddenUser := &User{Owner: "example", Name: "eve", IsForbidden: true}
+
+	cases := []struct {
+		actor   *User
+		target  *User
+		allowed bool
+	}{
+		{globalAdmin, user, true},
+		{globalAdmin, orgAdmin, true},
+		{orgAdmin, user, true},
+		{orgAdmin, orgAdmin, false},
+		{orgAdmin, orgGlobalAdmin, false},
+		{otherOrgAdmin, user, false},
+		{user, orgAdmin, false},
+		{globalAdmin, forbiddenUser, false},
+	}
+	for _, c := range cases {
+		err := CheckImpersonation(c.actor, c.target)
+		if (err == nil) != c.allowed {
+			t.Errorf("%s impersonating %s: allowed = %v, error = %v", c.actor.GetId(), c.target.GetId(), c.allowed, err)
+		}
+	}
+}
+
+func TestGetActorClaim(t *testing.T) {
+	if getActorClaim("") != nil || getActorClaim("app/app-example") != nil {
+		t.Errorf("only the users can be actors")
+	}
+	if act := getActorClaim("built-in/admin"); act == nil || act.Sub != "built-in/admin" {
+		t.Errorf("unexpected actor claim: %v", act)
+	}
+}
@@ -38,6 +38,8 @@ func InitDb() {
 	initBuiltInApplication()
 	initBuiltInCert()
 	initBuiltInLdap()
+
+	runMigration("federated-identities", migrateFederatedIdentities)
 }
 
 func initBuiltInOrganization() {
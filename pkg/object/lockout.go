@@ -0,0 +1,319 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/bhojpur/dbm/pkg/core"
+	"github.com/bhojpur/iam/pkg/utils"
+	websvr "github.com/bhojpur/web/pkg/engine"
+)
+
+const (
+	// the defaults of the lockout settings of organizations, the duration is in minutes
+	DefaultLockoutThreshold = 5
+	DefaultLockoutDuration  = 15
+	DefaultCaptchaThreshold = 3
+
+	maxSigninDelay = 30 * time.Second
+)
+
+// the failures from one IP address are counted over all the users, so that trying a password
+// against many accounts is stopped as well
+var ipLockoutThreshold int
+
+func init() {
+	var err error
+	ipLockoutThreshold, err = websvr.AppConfig.Int("ipLockoutThreshold")
+	if err != nil || ipLockoutThreshold <= 0 {
+		ipLockoutThreshold = 20
+	}
+}
+
+type LockoutPolicy struct {
+	Threshold        int
+	Duration         time.Duration
+	CaptchaThreshold int
+}
+
+func GetLockoutPolicy(organization *Organization) *LockoutPolicy {
+	res := &LockoutPolicy{
+		Threshold:        DefaultLockoutThreshold,
+		Duration:         DefaultLockoutDuration * time.Minute,
+		CaptchaThreshold: DefaultCaptchaThreshold,
+	}
+	if organization != nil {
+		if organization.LockoutThreshold > 0 {
+			res.Threshold = organization.LockoutThreshold
+		}
+		if organization.LockoutDuration > 0 {
+			res.Duration = time.Duration(organization.LockoutDuration) * time.Minute
+		}
+		if organization.CaptchaThreshold > 0 {
+			res.CaptchaThreshold = organization.CaptchaThreshold
+		}
+	}
+	return res
+}
+
+// signinFailures counts the consecutive failed sign-ins of a user or an IP address. The failures
+// are forgotten once no failure has happened for the lockout duration.
+type signinFailures struct {
+	Count    int
+	LastTime time.Time
+}
+
+func (f *signinFailures) getCount(duration time.Duration, now time.Time) int {
+	if f == nil || now.Sub(f.LastTime) > duration {
+		return 0
+	}
+	return f.Count
+}
+
+// getSigninDelay returns the time to wait after the last failure before the next attempt, it is
+// doubled with every failure
+func getSigninDelay(count int) time.Duration {
+	if count <= 0 {
+		return 0
+	}
+	if count > 6 {
+		return maxSigninDelay
+	}
+
+	delay := time.Duration(1<<uint(count-1)) * time.Second
+	if delay > maxSigninDelay {
+		return maxSigninDelay
+	}
+	return delay
+}
+
+// check returns the error of an attempt that is not allowed yet
+func (f *signinFailures) check(threshold int, duration time.Duration, now time.Time) string {
+	count := f.getCount(duration, now)
+	if count == 0 {
+		return ""
+	}
+
+	if count >= threshold {
+		wait := f.LastTime.Add(duration).Sub(now)
+		return fmt.Sprintf("Too many failed attempts, the sign-in is locked, please try again in %d minutes", int(wait.Minutes())+1)
+	}
+
+	wait := f.LastTime.Add(getSigninDelay(count)).Sub(now)
+	if wait > 0 {
+		return fmt.Sprintf("Too many failed attempts, please try again in %d seconds", int(wait.Seconds())+1)
+	}
+	return ""
+}
+
+var ipFailures = map[string]*signinFailures{}
+var ipFailuresLock sync.Mutex
+
+func getIpFailures(clientIp string) *signinFailures {
+	if clientIp == "" {
+		return nil
+	}
+
+	ipFailuresLock.Lock()
+	defer ipFailuresLock.Unlock()
+
+	failures, ok := ipFailures[clientIp]
+	if !ok {
+		return nil
+	}
+	res := *failures
+	return &res
+}
+
+func addIpFailure(clientIp string, duration time.Duration, now time.Time) int {
+	if clientIp == "" {
+		return 0
+	}
+
+	ipFailuresLock.Lock()
+	defer ipFailuresLock.Unlock()
+
+	// forget the addresses that have not failed recently
+	for ip, failures := range ipFailures {
+		if now.Sub(failures.LastTime) > duration {
+			delete(ipFailures, ip)
+		}
+	}
+
+	failures, ok := ipFailures[clientIp]
+	if !ok {
+		failures = &signinFailures{}
+		ipFailures[clientIp] = failures
+	}
+	failures.Count++
+	failures.LastTime = now
+	return failures.Count
+}
+
+func getUserFailures(user *User) *signinFailures {
+	if user.SigninWrongTimes == 0 {
+		return nil
+	}
+
+	lastTime, err := time.Parse(time.RFC3339, user.LastSigninWrongTime)
+	if err != nil {
+		return nil
+	}
+	return &signinFailures{Count: user.SigninWrongTimes, LastTime: lastTime}
+}
+
+// CheckSigninLockout returns the error when the user or the IP address has failed to sign in too
+// many times. user can be nil when the user doesn't exist, and clientIp can be empty when the
+// address of the client is not known.
+func CheckSigninLockout(organization *Organization, user *User, clientIp string) string {
+	policy := GetLockoutPolicy(organization)
+	now := time.Now()
+
+	if user != nil {
+		if msg := getUserFailures(user).check(policy.Threshold, policy.Duration, now); msg != "" {
+			return msg
+		}
+	}
+	return getIpFailures(clientIp).check(ipLockoutThreshold, policy.Duration, now)
+}
+
+// IsCaptchaRequired returns true when the failures of the user or the IP address have reached
+// the captcha threshold of the organization
+func IsCaptchaRequired(organization string, username string, clientIp string) bool {
+	policy := GetLockoutPolicy(GetOrganization(fmt.Sprintf("admin/%s", organization)))
+	now := time.Now()
+
+	if getIpFailures(clientIp).getCount(policy.Duration, now) >= policy.CaptchaThreshold {
+		return true
+	}
+
+	user := GetUserByFields(organization, username)
+	if user == nil {
+		return false
+	}
+	return getUserFailures(user).getCount(policy.Duration, now) >= policy.CaptchaThreshold
+}
+
+// AddSigninFailure counts a failed sign-in of the user and of the IP address, and records the
+// failure and the lockout it causes, so that they trigger the webhooks
+func AddSigninFailure(organization string, user *User, clientIp string, msg string) {
+	policy := GetLockoutPolicy(GetOrganization(fmt.Sprintf("admin/%s", organization)))
+	now := time.Now()
+
+	username := ""
+	isLocked := addIpFailure(clientIp, policy.Duration, now) == ipLockoutThreshold
+	if user != nil {
+		username = user.Name
+		user.SigninWrongTimes = incrUserSigninFailures(user, policy.Duration, now)
+		user.LastSigninWrongTime = now.Format(time.RFC3339)
+
+		// the concurrent failures can go past the threshold together
+		if user.SigninWrongTimes >= policy.Threshold {
+			isLocked = true
+		}
+	}
+
+	addSigninRecord(organization, username, clientIp, "signin-failure", msg)
+	if isLocked {
+		addSigninRecord(organization, username, clientIp, "lockout", "")
+	}
+}
+
+func ResetSigninFailures(user *User) {
+	if user.SigninWrongTimes == 0 {
+		return
+	}
+
+	user.SigninWrongTimes = 0
+	user.LastSigninWrongTime = ""
+	updateUserSigninFailures(user)
+}
+
+// UnlockUser clears the failed sign-ins of the user, it returns false if the user is not locked
+func UnlockUser(user *User) bool {
+	if user.SigninWrongTimes == 0 {
+		return false
+	}
+
+	ResetSigninFailures(user)
+	addSigninRecord(user.Owner, user.Name, "", "unlock", "")
+	return true
+}
+
+// incrUserSigninFailures counts a failure of the user in the database, so that the concurrent
+// failures are all counted, and returns the count of the failures
+func incrUserSigninFailures(user *User, duration time.Duration, now time.Time) int {
+	pk := core.PK{user.Owner, user.Name}
+	lastTime := now.Format(time.RFC3339)
+
+	// the failures older than the lockout duration are forgotten
+	failures := &User{SigninWrongTimes: 1, LastSigninWrongTime: lastTime}
+	affected, err := adapter.Engine.ID(pk).And("signin_wrong_times = 0 or last_signin_wrong_time < ?", now.Add(-duration).Format(time.RFC3339)).Cols("signin_wrong_times", "last_signin_wrong_time").Update(failures)
+	if err != nil {
+		panic(err)
+	}
+	if affected == 0 {
+		_, err = adapter.Engine.ID(pk).Incr("signin_wrong_times").Cols("last_signin_wrong_time").Update(&User{LastSigninWrongTime: lastTime})
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	res := User{}
+	_, err = adapter.Engine.ID(pk).Cols("signin_wrong_times").Get(&res)
+	if err != nil {
+		panic(err)
+	}
+	return res.SigninWrongTimes
+}
+
+func updateUserSigninFailures(user *User) {
+	_, err := adapter.Engine.ID(core.PK{user.Owner, user.Name}).Cols("signin_wrong_times", "last_signin_wrong_time").Update(user)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func addSigninRecord(organization string, username string, clientIp string, action string, msg string) {
+	requestUri := fmt.Sprintf("signin://%s/%s", organization, username)
+	if msg != "" {
+		requestUri = fmt.Sprintf("%s?msg=%s", requestUri, url.QueryEscape(msg))
+	}
+	if len(requestUri) > 1000 {
+		requestUri = requestUri[0:1000]
+	}
+
+	record := &Record{
+		Name:         utils.GenerateId(),
+		CreatedTime:  utils.GetCurrentTime(),
+		Organization: organization,
+		ClientIp:     clientIp,
+		User:         username,
+		Method:       "POST",
+		RequestUri:   requestUri,
+		Action:       action,
+	}
+	go AddRecord(record)
+}
@@ -0,0 +1,74 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetSigninDelay(t *testing.T) {
+	tests := []struct {
+		count int
+		want  time.Duration
+	}{
+		{0, 0},
+		{1, time.Second},
+		{3, 4 * time.Second},
+		{5, 16 * time.Second},
+		{6, maxSigninDelay},
+		{100, maxSigninDelay},
+	}
+	for _, test := range tests {
+		if got := getSigninDelay(test.count); got != test.want {
+			t.Errorf("getSigninDelay(%d) = %s, want %s", test.count, got, test.want)
+		}
+	}
+}
+
+func TestSigninFailuresCheck(t *testing.T) {
+	now := time.Now()
+	duration := 15 * time.Minute
+
+	var failures *signinFailures
+	if msg := failures.check(5, duration, now); msg != "" {
+		t.Errorf("check() without failures = %s", msg)
+	}
+
+	failures = &signinFailures{Count: 2, LastTime: now.Add(-time.Second)}
+	if msg := failures.check(5, duration, now); !strings.Contains(msg, "seconds") {
+		t.Errorf("check() within the delay = %s", msg)
+	}
+	failures.LastTime = now.Add(-time.Minute)
+	if msg := failures.check(5, duration, now); msg != "" {
+		t.Errorf("check() after the delay = %s", msg)
+	}
+
+	failures = &signinFailures{Count: 5, LastTime: now.Add(-time.Minute)}
+	if msg := failures.check(5, duration, now); !strings.Contains(msg, "locked") {
+		t.Errorf("check() after the threshold = %s", msg)
+	}
+	failures.LastTime = now.Add(-duration - time.Second)
+	if msg := failures.check(5, duration, now); msg != "" {
+		t.Errorf("check() after the lockout = %s", msg)
+	}
+}
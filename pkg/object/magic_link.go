@@ -0,0 +1,238 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/dbm/pkg/core"
+	"github.com/bhojpur/iam/pkg/utils"
+	websvr "github.com/bhojpur/web/pkg/engine"
+)
+
+const MagicLinkTokenParam = "magicLinkToken"
+
+// MagicLink is a sign-in link sent by email. The link is signed with the client secret of the
+// application, and can only be used once, from the browser session that requested it and with
+// the same OAuth parameters, so that the login ends the way it was started.
+type MagicLink struct {
+	Owner       string `orm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `orm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `orm:"varchar(100)" json:"createdTime"`
+
+	Application  string `orm:"varchar(100)" json:"application"`
+	Organization string `orm:"varchar(100)" json:"organization"`
+	User         string `orm:"varchar(100) index" json:"user"`
+	Form         string `orm:"varchar(1000)" json:"form"`
+	OAuthParams  string `orm:"varchar(2000)" json:"oAuthParams"`
+	SessionHash  string `orm:"varchar(100)" json:"sessionHash"`
+	RemoteAddr   string `orm:"varchar(100) index" json:"remoteAddr"`
+	ExpireTime   int64  `json:"expireTime"`
+	IsUsed       bool   `json:"isUsed"`
+}
+
+var (
+	magicLinkTimeout     int64
+	magicLinkInterval    int64
+	magicLinkHourlyLimit int64
+)
+
+func init() {
+	var err error
+	magicLinkTimeout, err = websvr.AppConfig.Int64("magicLinkTimeout")
+	if err != nil || magicLinkTimeout <= 0 {
+		magicLinkTimeout = 10
+	}
+	magicLinkInterval, err = websvr.AppConfig.Int64("magicLinkInterval")
+	if err != nil || magicLinkInterval < 0 {
+		magicLinkInterval = 60
+	}
+	magicLinkHourlyLimit, err = websvr.AppConfig.Int64("magicLinkHourlyLimit")
+	if err != nil || magicLinkHourlyLimit <= 0 {
+		magicLinkHourlyLimit = 5
+	}
+}
+
+var errMagicLinkInvalid = errors.New("The sign-in link is invalid, has expired or was requested from another browser")
+
+// NormalizeOAuthParams sorts the OAuth parameters of a login, so that the parameters given when
+// the link is requested and when it is used can be compared
+func NormalizeOAuthParams(rawQuery string) string {
+	values, err := url.ParseQuery(strings.TrimPrefix(rawQuery, "?"))
+	if err != nil {
+		return rawQuery
+	}
+
+	values.Del(MagicLinkTokenParam)
+	return values.Encode()
+}
+
+// IsMagicLinkLoginUrlValid checks that the login page the link points to is a path of the
+// origin, so that the link can't send the token to another site
+func IsMagicLinkLoginUrlValid(loginUrl string) bool {
+	if !strings.HasPrefix(loginUrl, "/") || strings.HasPrefix(loginUrl, "//") || strings.HasPrefix(loginUrl, "/\\") {
+		return false
+	}
+
+	u, err := url.Parse(loginUrl)
+	return err == nil && u.Scheme == "" && u.Host == ""
+}
+
+func getMagicLinkSignature(application *Application, link *MagicLink) string {
+	mac := hmac.New(sha256.New, []byte(application.ClientSecret))
+	mac.Write([]byte(fmt.Sprintf("%s|%s|%s/%s|%s|%d", link.Name, link.Application, link.Organization, link.User, link.SessionHash, link.ExpireTime)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func getMagicLinkUrl(loginUrl string, token string) string {
+	origin, _ := websvr.AppConfig.String("origin")
+	u, _ := url.Parse(loginUrl)
+	query := u.Query()
+	query.Set(MagicLinkTokenParam, token)
+	u.RawQuery = query.Encode()
+	return strings.TrimSuffix(origin, "/") + u.String()
+}
+
+func countMagicLinks(since time.Time, cond *MagicLink) int64 {
+	count, err := adapter.Engine.Where("created_time >= ?", since.Format(time.RFC3339)).Count(cond)
+	if err != nil {
+		panic(err)
+	}
+
+	return count
+}
+
+func checkMagicLinkRate(user *User) error {
+	cond := &MagicLink{Organization: user.Owner, User: user.Name}
+	if countMagicLinks(time.Now().Add(-time.Duration(magicLinkInterval)*time.Second), cond) != 0 {
+		return fmt.Errorf("You can only request a sign-in link once in %ds", magicLinkInterval)
+	}
+	if countMagicLinks(time.Now().Add(-time.Hour), cond) >= magicLinkHourlyLimit {
+		return fmt.Errorf("Too many sign-in links have been requested for the user: %s", user.GetId())
+	}
+
+	return nil
+}
+
+// IsMagicLinkRateLimited checks the sign-in links requested from the address in the last hour,
+// whatever the account they were requested for
+func IsMagicLinkRateLimited(remoteAddr string) bool {
+	return countMagicLinks(time.Now().Add(-time.Hour), &MagicLink{RemoteAddr: remoteAddr}) >= magicLinkHourlyLimit
+}
+
+// SendMagicLink emails a sign-in link to the user. The link is bound to the browser session by
+// sessionNonce, which must be kept in the session, and opens loginUrl on the origin with the
+// token of the link. form is restored when the link is used.
+func SendMagicLink(application *Application, user *User, form string, oAuthParams string, sessionNonce string, loginUrl string, remoteAddr string) error {
+	deleteExpiredMagicLinks()
+	if !IsMagicLinkLoginUrlValid(loginUrl) {
+		return fmt.Errorf("The login URL: %s is invalid", loginUrl)
+	}
+	if user.Email == "" {
+		return fmt.Errorf("The user: %s has no email", user.GetId())
+	}
+	emailProvider := application.GetEmailProvider()
+	if emailProvider == nil {
+		return fmt.Errorf("The application: %s has no Email provider", application.Name)
+	}
+	err := checkMagicLinkRate(user)
+	if err != nil {
+		return err
+	}
+
+	link := &MagicLink{
+		Owner:        "admin",
+		Name:         utils.GenerateId(),
+		CreatedTime:  utils.GetCurrentTime(),
+		Application:  application.Name,
+		Organization: user.Owner,
+		User:         user.Name,
+		Form:         form,
+		OAuthParams:  NormalizeOAuthParams(oAuthParams),
+		SessionHash:  utils.GetSha256Hash(sessionNonce),
+		RemoteAddr:   remoteAddr,
+		ExpireTime:   time.Now().Unix() + magicLinkTimeout*60,
+	}
+	_, err = adapter.Engine.Insert(link)
+	if err != nil {
+		panic(err)
+	}
+
+	organization := GetOrganizationByUser(user)
+	token := fmt.Sprintf("%s.%s", link.Name, getMagicLinkSignature(application, link))
+	linkUrl := getMagicLinkUrl(loginUrl, token)
+	content := fmt.Sprintf("You have requested to sign in to %s. Please open the link below in the same browser in %d minutes to sign in:<br/><br/><a href=\"%s\">%s</a><br/><br/>If you didn't request it, you can ignore this email.",
+		application.DisplayName, magicLinkTimeout, linkUrl, linkUrl)
+	return SendEmail(emailProvider, "Your sign-in link", content, user.Email, organization.DisplayName)
+}
+
+// ConsumeMagicLink returns the link of the token after checking its signature, expiry, browser
+// session and OAuth parameters, and marks it as used
+func ConsumeMagicLink(application *Application, token string, sessionNonce string, oAuthParams string) (*MagicLink, error) {
+	tokens := strings.SplitN(token, ".", 2)
+	if len(tokens) != 2 || sessionNonce == "" {
+		return nil, errMagicLinkInvalid
+	}
+
+	link := MagicLink{Owner: "admin", Name: tokens[0]}
+	existed, err := adapter.Engine.Get(&link)
+	if err != nil {
+		panic(err)
+	}
+	if !existed || link.IsUsed || link.Application != application.Name || link.ExpireTime < time.Now().Unix() {
+		return nil, errMagicLinkInvalid
+	}
+
+	signature := getMagicLinkSignature(application, &link)
+	if subtle.ConstantTimeCompare([]byte(signature), []byte(tokens[1])) != 1 ||
+		subtle.ConstantTimeCompare([]byte(link.SessionHash), []byte(utils.GetSha256Hash(sessionNonce))) != 1 ||
+		link.OAuthParams != NormalizeOAuthParams(oAuthParams) {
+		return nil, errMagicLinkInvalid
+	}
+
+	// only one of concurrent requests with the same link can mark it as used
+	affected, err := adapter.Engine.ID(core.PK{link.Owner, link.Name}).And("is_used = ?", false).Cols("is_used").Update(&MagicLink{IsUsed: true})
+	if err != nil {
+		panic(err)
+	}
+	if affected == 0 {
+		return nil, errMagicLinkInvalid
+	}
+
+	link.IsUsed = true
+	return &link, nil
+}
+
+// deleteExpiredMagicLinks keeps the links of the last hour, which are needed for the rate limits
+func deleteExpiredMagicLinks() {
+	_, err := adapter.Engine.Where("expire_time < ?", time.Now().Add(-time.Hour).Unix()).Delete(&MagicLink{})
+	if err != nil {
+		panic(err)
+	}
+}
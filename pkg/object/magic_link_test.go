@@ -0,0 +1,65 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import "testing"
+
+func TestMagicLinkLoginUrl(t *testing.T) {
+	valid := []string{"/login", "/login/oauth/authorize?clientId=abc&redirectUri=https%3A%2F%2Fexample.com", "/login/built-in"}
+	invalid := []string{"", "login", "//evil.com/login", "/\\evil.com", "https://evil.com/login", "javascript:alert(1)"}
+	for _, loginUrl := range valid {
+		if !IsMagicLinkLoginUrlValid(loginUrl) {
+			t.Errorf("%s should be valid", loginUrl)
+		}
+	}
+	for _, loginUrl := range invalid {
+		if IsMagicLinkLoginUrlValid(loginUrl) {
+			t.Errorf("%s should be invalid", loginUrl)
+		}
+	}
+}
+
+func TestNormalizeOAuthParams(t *testing.T) {
+	a := NormalizeOAuthParams("?clientId=abc&state=xyz&redirectUri=https://example.com/callback")
+	b := NormalizeOAuthParams("state=xyz&redirectUri=https%3A%2F%2Fexample.com%2Fcallback&clientId=abc&magicLinkToken=t")
+	if a != b {
+		t.Errorf("%s and %s should be the same parameters", a, b)
+	}
+	if NormalizeOAuthParams("clientId=abc&state=other") == NormalizeOAuthParams("clientId=abc&state=xyz") {
+		t.Errorf("different parameters should not be the same")
+	}
+}
+
+func TestMagicLinkSignature(t *testing.T) {
+	application := &Application{Name: "app-example", ClientSecret: "secret"}
+	link := &MagicLink{Name: "link-1", Application: "app-example", Organization: "example", User: "alice", SessionHash: "hash", ExpireTime: 1700000000}
+	signature := getMagicLinkSignature(application, link)
+
+	otherSession := *link
+	otherSession.SessionHash = "other"
+	otherUser := *link
+	otherUser.User = "bob"
+	for _, changed := range []*MagicLink{&otherSession, &otherUser} {
+		if getMagicLinkSignature(application, changed) == signature {
+			t.Errorf("the signature must cover %v", changed)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/dbm/pkg/core"
+	"github.com/bhojpur/iam/pkg/utils"
+	websvr "github.com/bhojpur/web/pkg/engine"
+)
+
+const (
+	TotpPeriod        = 30
+	TotpDigits        = 6
+	RecoveryCodeCount = 10
+
+	// a passcode of the previous or the next time step is accepted to allow for clock drift
+	totpSkew = 1
+
+	// the prefix of a TOTP secret encrypted at rest, the secrets stored before have no prefix
+	encryptedTotpSecretPrefix = "enc:"
+)
+
+// IsMfaEnabled returns true when the user has confirmed the enrolment of an authenticator app
+// or has registered a passkey
+func (user *User) IsMfaEnabled() bool {
+	return user.TotpSecret != "" || len(user.WebauthnCredentials) != 0
+}
+
+// GetMfaTypes returns the second factors the user can sign in with
+func (user *User) GetMfaTypes() []string {
+	res := []string{}
+	if user.TotpSecret != "" {
+		res = append(res, "totp", "recovery")
+	}
+	if len(user.WebauthnCredentials) != 0 {
+		res = append(res, "webauthn")
+	}
+	return res
+}
+
+func GenerateTotpSecret() string {
+	secret := make([]byte, 20)
+	_, err := rand.Read(secret)
+	if err != nil {
+		panic(err)
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret)
+}
+
+// GetTotpUri returns the otpauth:// URI (the payload of the QR code) of Key Uri Format used by
+// authenticator apps: https://github.com/google/google-authenticator/wiki/Key-Uri-Format
+func GetTotpUri(issuer string, accountName string, secret string) string {
+	values := url.Values{}
+	values.Set("secret", secret)
+	values.Set("issuer", issuer)
+	values.Set("algorithm", "SHA1")
+	values.Set("digits", fmt.Sprintf("%d", TotpDigits))
+	values.Set("period", fmt.Sprintf("%d", TotpPeriod))
+	label := url.PathEscape(fmt.Sprintf("%s:%s", issuer, accountName))
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, values.Encode())
+}
+
+func decodeTotpSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	return base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(secret, "="))
+}
+
+func getTotpCipher() (cipher.AEAD, error) {
+	encryptionKey, _ := websvr.AppConfig.String("totpEncryptionKey")
+	if encryptionKey == "" {
+		return nil, errors.New("totpEncryptionKey is not configured")
+	}
+
+	key := sha256.Sum256([]byte(encryptionKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// IsTotpEncryptionConfigured tells whether the TOTP secrets can be stored, as they are only
+// stored encrypted
+func IsTotpEncryptionConfigured() bool {
+	_, err := getTotpCipher()
+	return err == nil
+}
+
+// encryptTotpSecret encrypts the secret with AES-GCM under the key of "totpEncryptionKey"
+// before it is stored
+func encryptTotpSecret(secret string) (string, error) {
+	aead, err := getTotpCipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(secret), nil)
+	return encryptedTotpSecretPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptTotpSecret returns the base32 secret of a stored one, the secrets stored before the
+// encryption are returned as they are
+func decryptTotpSecret(stored string) (string, error) {
+	if !strings.HasPrefix(stored, encryptedTotpSecretPrefix) {
+		return stored, nil
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(stored, encryptedTotpSecretPrefix))
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := getTotpCipher()
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("the encrypted TOTP secret is too short")
+	}
+
+	secret, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+// getTotpCode computes the HOTP value of RFC 4226 for the counter
+func getTotpCode(key []byte, counter int64) string {
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < TotpDigits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", TotpDigits, value%modulo)
+}
+
+// validateTotp checks the passcode against the time steps around t and returns the matched
+// time step, so that the caller can reject its reuse
+func validateTotp(secret string, passcode string, t time.Time) (int64, bool) {
+	passcode = strings.ReplaceAll(passcode, " ", "")
+	if len(passcode) != TotpDigits {
+		return 0, false
+	}
+
+	key, err := decodeTotpSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+
+	counter := t.Unix() / TotpPeriod
+	for i := counter - totpSkew; i <= counter+totpSkew; i++ {
+		if subtle.ConstantTimeCompare([]byte(getTotpCode(key, i)), []byte(passcode)) == 1 {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func getRecoveryCodeHash(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	return utils.GetSha256Hash(code)
+}
+
+// generateRecoveryCodes returns the recovery codes to show to the user once, and the hashes
+// of them to store
+func generateRecoveryCodes() ([]string, []string) {
+	codes := []string{}
+	hashes := []string{}
+	for i := 0; i < RecoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		_, err := rand.Read(b)
+		if err != nil {
+			panic(err)
+		}
+
+		code := fmt.Sprintf("%x", b)
+		code = fmt.Sprintf("%s-%s", code[:5], code[5:])
+		codes = append(codes, code)
+		hashes = append(hashes, getRecoveryCodeHash(code))
+	}
+	return codes, hashes
+}
+
+func updateUserMfa(user *User) bool {
+	affected, err := adapter.Engine.ID(core.PK{user.Owner, user.Name}).Cols("totp_secret", "totp_counter", "recovery_codes").Update(user)
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+// EnableTotp confirms the enrolment of the secret with a passcode generated by the
+// authenticator app, and returns the new recovery codes
+func EnableTotp(user *User, secret string, passcode string) ([]string, string) {
+	if user.TotpSecret != "" {
+		return nil, "TOTP has already been enabled for the user"
+	}
+
+	counter, ok := validateTotp(secret, passcode, time.Now())
+	if !ok {
+		return nil, "Invalid passcode"
+	}
+
+	encryptedSecret, err := encryptTotpSecret(secret)
+	if err != nil {
+		return nil, fmt.Sprintf("Failed to enable TOTP: %s", err.Error())
+	}
+
+	codes, hashes := generateRecoveryCodes()
+	user.TotpSecret = encryptedSecret
+	user.TotpCounter = counter
+	user.RecoveryCodes = hashes
+	updateUserMfa(user)
+	return codes, ""
+}
+
+// CheckTotp checks the passcode of the authenticator app. A passcode can only be used once.
+func CheckTotp(user *User, passcode string) string {
+	if user.TotpSecret == "" {
+		return "TOTP is not enabled for the user"
+	}
+
+	secret, err := decryptTotpSecret(user.TotpSecret)
+	if err != nil {
+		return fmt.Sprintf("Failed to read the TOTP secret: %s", err.Error())
+	}
+
+	counter, ok := validateTotp(secret, passcode, time.Now())
+	if !ok || counter <= user.TotpCounter {
+		return "Invalid passcode"
+	}
+
+	// a secret stored before the encryption is encrypted once it has been used
+	if !strings.HasPrefix(user.TotpSecret, encryptedTotpSecretPrefix) {
+		if encryptedSecret, err := encryptTotpSecret(secret); err == nil {
+			user.TotpSecret = encryptedSecret
+		}
+	}
+
+	user.TotpCounter = counter
+	updateUserMfa(user)
+	return ""
+}
+
+// CheckRecoveryCode checks the recovery code and consumes it on success
+func CheckRecoveryCode(user *User, code string) string {
+	if user.TotpSecret == "" {
+		return "TOTP is not enabled for the user"
+	}
+
+	hash := getRecoveryCodeHash(code)
+	for i, recoveryCode := range user.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(recoveryCode), []byte(hash)) == 1 {
+			user.RecoveryCodes = append(user.RecoveryCodes[:i:i], user.RecoveryCodes[i+1:]...)
+			updateUserMfa(user)
+			return ""
+		}
+	}
+	return "Invalid recovery code"
+}
+
+// CheckMfa checks either a passcode of the authenticator app or a recovery code
+func CheckMfa(user *User, passcode string, recoveryCode string) string {
+	if recoveryCode != "" {
+		return CheckRecoveryCode(user, recoveryCode)
+	}
+	return CheckTotp(user, passcode)
+}
+
+func RegenerateRecoveryCodes(user *User) []string {
+	codes, hashes := generateRecoveryCodes()
+	user.RecoveryCodes = hashes
+	updateUserMfa(user)
+	return codes
+}
+
+// DisableTotp removes the authenticator app and the recovery codes of the user
+func DisableTotp(user *User) bool {
+	user.TotpSecret = ""
+	user.TotpCounter = 0
+	user.RecoveryCodes = []string{}
+	return updateUserMfa(user)
+}
+
+// ResetMfa removes all the second factors of the user, including the passkeys
+func ResetMfa(user *User) bool {
+	user.TotpSecret = ""
+	user.TotpCounter = 0
+	user.RecoveryCodes = []string{}
+	user.WebauthnCredentials = []*WebauthnCredential{}
+	affected, err := adapter.Engine.ID(core.PK{user.Owner, user.Name}).Cols("totp_secret", "totp_counter", "recovery_codes", "webauthn_credentials").Update(user)
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
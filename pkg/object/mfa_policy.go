@@ -0,0 +1,139 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"github.com/bhojpur/iam/pkg/utils"
+)
+
+const (
+	MfaPolicyOff           = "Off"
+	MfaPolicyOptional      = "Optional"
+	MfaPolicyRequired      = "Required"
+	MfaPolicyAdminRequired = "Required for admins"
+
+	MfaTypeTotp     = "totp"
+	MfaTypeWebauthn = "webauthn"
+)
+
+var allMfaTypes = []string{MfaTypeTotp, MfaTypeWebauthn}
+
+// MfaPolicy is the effective MFA policy of a login. The policy of the application overrides
+// the one of the organization, and the default is "Optional" with all the factor types.
+type MfaPolicy struct {
+	Policy string
+	Types  []string
+}
+
+func GetMfaPolicy(organization *Organization, application *Application) *MfaPolicy {
+	res := &MfaPolicy{Policy: MfaPolicyOptional, Types: allMfaTypes}
+	if organization != nil {
+		if organization.MfaPolicy != "" {
+			res.Policy = organization.MfaPolicy
+		}
+		if len(organization.MfaTypes) != 0 {
+			res.Types = organization.MfaTypes
+		}
+	}
+	if application != nil {
+		if application.MfaPolicy != "" {
+			res.Policy = application.MfaPolicy
+		}
+		if len(application.MfaTypes) != 0 {
+			res.Types = application.MfaTypes
+		}
+	}
+	return res
+}
+
+func (policy *MfaPolicy) IsTypeAllowed(mfaType string) bool {
+	if mfaType == "recovery" {
+		mfaType = MfaTypeTotp
+	}
+	return utils.ContainsString(policy.Types, mfaType)
+}
+
+// IsRequired returns true when the user must sign in with a second factor, and so must enroll
+// one if the user has none
+func (policy *MfaPolicy) IsRequired(user *User) bool {
+	switch policy.Policy {
+	case MfaPolicyRequired:
+		return true
+	case MfaPolicyAdminRequired:
+		return user.IsAdmin || user.IsGlobalAdmin || user.Owner == "built-in"
+	}
+	return false
+}
+
+// GetUserMfaTypes returns the second factors of the user that are allowed by the policy
+func (policy *MfaPolicy) GetUserMfaTypes(user *User) []string {
+	res := []string{}
+	if policy.Policy == MfaPolicyOff {
+		return res
+	}
+
+	for _, mfaType := range user.GetMfaTypes() {
+		if policy.IsTypeAllowed(mfaType) {
+			res = append(res, mfaType)
+		}
+	}
+	return res
+}
+
+// IsSecondFactorRequired returns true when a password alone is not enough for the user to sign
+// in, for the protocols like LDAP and RADIUS that only check a password
+func IsSecondFactorRequired(user *User, application *Application) bool {
+	policy := GetMfaPolicy(GetOrganizationByUser(user), application)
+	return len(policy.GetUserMfaTypes(user)) != 0 || policy.IsRequired(user)
+}
+
+// GetAmr returns the authentication methods references (RFC 8176) of a second factor
+func GetAmr(mfaType string) []string {
+	switch mfaType {
+	case MfaTypeTotp, "recovery":
+		return []string{"otp"}
+	case MfaTypeWebauthn:
+		return []string{"hwk"}
+	}
+	return []string{}
+}
+
+// AddAmr adds the methods of a factor to the ones used by the login, "mfa" is added once the
+// login has used more than one factor
+func AddAmr(amr []string, methods ...string) []string {
+	res := []string{}
+	for _, method := range append(append([]string{}, amr...), methods...) {
+		if method != "mfa" && !utils.ContainsString(res, method) {
+			res = append(res, method)
+		}
+	}
+
+	factors := 0
+	for _, method := range res {
+		if method != "user" && method != "pin" {
+			factors++
+		}
+	}
+	if factors > 1 || utils.ContainsString(res, "user") {
+		res = append(res, "mfa")
+	}
+	return res
+}
@@ -0,0 +1,72 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetMfaPolicy(t *testing.T) {
+	organization := &Organization{MfaPolicy: MfaPolicyRequired, MfaTypes: []string{MfaTypeTotp}}
+	application := &Application{MfaTypes: []string{MfaTypeWebauthn}}
+
+	policy := GetMfaPolicy(organization, application)
+	if policy.Policy != MfaPolicyRequired || !reflect.DeepEqual(policy.Types, []string{MfaTypeWebauthn}) {
+		t.Errorf("GetMfaPolicy() = %v", policy)
+	}
+	if policy.IsTypeAllowed("recovery") {
+		t.Errorf("recovery codes must not be allowed without TOTP")
+	}
+
+	policy = GetMfaPolicy(nil, nil)
+	if policy.Policy != MfaPolicyOptional || !policy.IsTypeAllowed(MfaTypeTotp) || !policy.IsTypeAllowed(MfaTypeWebauthn) {
+		t.Errorf("GetMfaPolicy() = %v", policy)
+	}
+}
+
+func TestMfaPolicyIsRequired(t *testing.T) {
+	policy := &MfaPolicy{Policy: MfaPolicyAdminRequired}
+	if policy.IsRequired(&User{Owner: "acme"}) {
+		t.Errorf("MFA must not be required for normal users")
+	}
+	if !policy.IsRequired(&User{Owner: "acme", IsAdmin: true}) || !policy.IsRequired(&User{Owner: "built-in"}) {
+		t.Errorf("MFA must be required for admins")
+	}
+}
+
+func TestAddAmr(t *testing.T) {
+	tests := []struct {
+		amr     []string
+		methods []string
+		want    []string
+	}{
+		{nil, []string{"pwd"}, []string{"pwd"}},
+		{[]string{"pwd"}, []string{"otp"}, []string{"pwd", "otp", "mfa"}},
+		{[]string{"pwd", "otp", "mfa"}, []string{"otp"}, []string{"pwd", "otp", "mfa"}},
+		{nil, []string{"hwk", "user"}, []string{"hwk", "user", "mfa"}},
+	}
+	for _, test := range tests {
+		if got := AddAmr(test.amr, test.methods...); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("AddAmr(%v, %v) = %v, want %v", test.amr, test.methods, got, test.want)
+		}
+	}
+}
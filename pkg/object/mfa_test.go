@@ -0,0 +1,131 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	websvr "github.com/bhojpur/web/pkg/engine"
+)
+
+func TestGetTotpCode(t *testing.T) {
+	// the SHA1 test vectors of RFC 6238 appendix B, truncated to 6 digits
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		time int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		if got := getTotpCode(key, tt.time/TotpPeriod); got != tt.want {
+			t.Errorf("getTotpCode() at %d = %s, want %s", tt.time, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTotp(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111109, 0)
+
+	counter, ok := validateTotp(secret, "081804", now)
+	if !ok || counter != 1111111109/TotpPeriod {
+		t.Errorf("validateTotp() = %d, %v", counter, ok)
+	}
+	if _, ok = validateTotp(secret, "081804", now.Add(TotpPeriod*time.Second)); !ok {
+		t.Errorf("the passcode of the previous time step should be accepted")
+	}
+	if _, ok = validateTotp(secret, "081804", now.Add(3*TotpPeriod*time.Second)); ok {
+		t.Errorf("an expired passcode should be rejected")
+	}
+	if _, ok = validateTotp(secret, "81804", now); ok {
+		t.Errorf("a passcode of the wrong length should be rejected")
+	}
+}
+
+func TestGetTotpUri(t *testing.T) {
+	uri := GetTotpUri("Bhojpur IAM", "alice", "JBSWY3DPEHPK3PXP")
+	if !strings.HasPrefix(uri, "otpauth://totp/Bhojpur%20IAM:alice?") {
+		t.Errorf("unexpected URI: %s", uri)
+	}
+	if !strings.Contains(uri, "secret=JBSWY3DPEHPK3PXP") || !strings.Contains(uri, "issuer=Bhojpur+IAM") {
+		t.Errorf("unexpected URI: %s", uri)
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, hashes := generateRecoveryCodes()
+	if len(codes) != RecoveryCodeCount || len(hashes) != RecoveryCodeCount {
+		t.Fatalf("got %d codes and %d hashes", len(codes), len(hashes))
+	}
+	for i, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("unexpected recovery code: %s", code)
+		}
+		if getRecoveryCodeHash(strings.ToUpper(strings.ReplaceAll(code, "-", ""))) != hashes[i] {
+			t.Errorf("the hash of the recovery code should ignore case and dashes")
+		}
+	}
+}
+
+func TestEncryptTotpSecret(t *testing.T) {
+	err := websvr.AppConfig.Set("totpEncryptionKey", "test-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer websvr.AppConfig.Set("totpEncryptionKey", "")
+
+	secret := GenerateTotpSecret()
+	encryptedSecret, err := encryptTotpSecret(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(encryptedSecret, encryptedTotpSecretPrefix) || strings.Contains(encryptedSecret, secret) {
+		t.Errorf("the secret should be stored encrypted: %s", encryptedSecret)
+	}
+	if len(encryptedSecret) > 200 {
+		t.Errorf("the encrypted secret doesn't fit the column: %d", len(encryptedSecret))
+	}
+
+	if got, err := decryptTotpSecret(encryptedSecret); err != nil || got != secret {
+		t.Errorf("decryptTotpSecret() = %s, %v, want %s", got, err, secret)
+	}
+	if got, err := decryptTotpSecret(secret); err != nil || got != secret {
+		t.Errorf("a secret stored before the encryption should be returned as it is, got %s, %v", got, err)
+	}
+
+	websvr.AppConfig.Set("totpEncryptionKey", "another-key")
+	if _, err = decryptTotpSecret(encryptedSecret); err == nil {
+		t.Errorf("the secret should not be decrypted with another key")
+	}
+
+	websvr.AppConfig.Set("totpEncryptionKey", "")
+	if _, err = encryptTotpSecret(secret); err == nil {
+		t.Errorf("the secret should not be stored without totpEncryptionKey")
+	}
+}
@@ -0,0 +1,52 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import "github.com/bhojpur/iam/pkg/utils"
+
+// Migration is a data migration that has been run on the database, so that it isn't run again
+// by the next start
+type Migration struct {
+	Name        string `orm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `orm:"varchar(100)" json:"createdTime"`
+}
+
+func isMigrationDone(name string) bool {
+	existed, err := adapter.Engine.Get(&Migration{Name: name})
+	if err != nil {
+		panic(err)
+	}
+
+	return existed
+}
+
+func runMigration(name string, migrate func()) {
+	if isMigrationDone(name) {
+		return
+	}
+
+	migrate()
+
+	_, err := adapter.Engine.Insert(&Migration{Name: name, CreatedTime: utils.GetCurrentTime()})
+	if err != nil {
+		panic(err)
+	}
+}
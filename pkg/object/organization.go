@@ -42,6 +42,26 @@ type Organization struct {
 	DefaultAvatar      string `orm:"varchar(100)" json:"defaultAvatar"`
 	MasterPassword     string `orm:"varchar(100)" json:"masterPassword"`
 	EnableSoftDeletion bool   `json:"enableSoftDeletion"`
+	ScimToken          string `orm:"varchar(100)" json:"scimToken"`
+	Domain             string `orm:"varchar(100)" json:"domain"`
+
+	MfaPolicy string   `orm:"varchar(100)" json:"mfaPolicy"`
+	MfaTypes  []string `orm:"varchar(100)" json:"mfaTypes"`
+
+	LockoutThreshold int `json:"lockoutThreshold"`
+	LockoutDuration  int `json:"lockoutDuration"`
+	CaptchaThreshold int `json:"captchaThreshold"`
+
+	PasswordMinLength    int      `json:"passwordMinLength"`
+	PasswordComplexity   []string `orm:"varchar(100)" json:"passwordComplexity"`
+	PasswordBannedWords  []string `orm:"varchar(1000)" json:"passwordBannedWords"`
+	PasswordHistoryCount int      `json:"passwordHistoryCount"`
+	PasswordMaxAge       int      `json:"passwordMaxAge"`
+	PasswordMinAge       int      `json:"passwordMinAge"`
+
+	BreachedPasswordCheck string `orm:"varchar(100)" json:"breachedPasswordCheck"`
+
+	RiskRules []*RiskRule `orm:"mediumtext" json:"riskRules"`
 }
 
 func GetOrganizationCount(owner, field, value string) int {
@@ -109,6 +129,9 @@ func GetMaskedOrganization(organization *Organization) *Organization {
 	if organization.MasterPassword != "" {
 		organization.MasterPassword = "***"
 	}
+	if organization.ScimToken != "" {
+		organization.ScimToken = "***"
+	}
 	return organization
 }
 
@@ -121,10 +144,17 @@ func GetMaskedOrganizations(organizations []*Organization) []*Organization {
 
 func UpdateOrganization(id string, organization *Organization) bool {
 	owner, name := utils.GetOwnerAndNameFromId(id)
-	if getOrganization(owner, name) == nil {
+	oldOrganization := getOrganization(owner, name)
+	if oldOrganization == nil {
 		return false
 	}
 
+	if organization.ScimToken == "***" {
+		organization.ScimToken = oldOrganization.ScimToken
+	} else if organization.ScimToken != "" {
+		organization.ScimToken = getScimTokenHash(organization.ScimToken)
+	}
+
 	if name == "built-in" {
 		organization.Name = name
 	}
@@ -154,6 +184,10 @@ func UpdateOrganization(id string, organization *Organization) bool {
 }
 
 func AddOrganization(organization *Organization) bool {
+	if organization.ScimToken != "" {
+		organization.ScimToken = getScimTokenHash(organization.ScimToken)
+	}
+
 	affected, err := adapter.Engine.Insert(organization)
 	if err != nil {
 		panic(err)
@@ -178,3 +212,26 @@ func DeleteOrganization(organization *Organization) bool {
 func GetOrganizationByUser(user *User) *Organization {
 	return getOrganization("admin", user.Owner)
 }
+
+// SCIM tokens are only stored as hashes, so they cannot be read back after being saved
+func getScimTokenHash(token string) string {
+	return utils.GetSha256Hash(token)
+}
+
+func GetOrganizationByScimToken(token string) *Organization {
+	if token == "" {
+		return nil
+	}
+
+	organization := Organization{ScimToken: getScimTokenHash(token)}
+	existed, err := adapter.Engine.Get(&organization)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &organization
+	}
+
+	return nil
+}
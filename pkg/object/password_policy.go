@@ -0,0 +1,224 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bhojpur/dbm/pkg/core"
+	"github.com/bhojpur/iam/pkg/cred"
+	"github.com/bhojpur/iam/pkg/utils"
+)
+
+const (
+	DefaultPasswordMinLength = 6
+	MaxPasswordHistoryCount  = 24
+
+	PasswordComplexityUppercase = "uppercase"
+	PasswordComplexityLowercase = "lowercase"
+	PasswordComplexityDigit     = "digit"
+	PasswordComplexitySpecial   = "special"
+)
+
+// PasswordViolation is a rule of the password policy that a password breaks. The code and the
+// parameter let the UI show its own message.
+type PasswordViolation struct {
+	Code  string      `json:"code"`
+	Param interface{} `json:"param,omitempty"`
+	Msg   string      `json:"msg"`
+}
+
+func GetPasswordViolationMsg(violations []*PasswordViolation) string {
+	msgs := []string{}
+	for _, violation := range violations {
+		msgs = append(msgs, violation.Msg)
+	}
+	return strings.Join(msgs, ", ")
+}
+
+func hasRune(s string, f func(rune) bool) bool {
+	for _, r := range s {
+		if f(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSpecialRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r)
+}
+
+// CheckPasswordPolicy checks the password against the policy of the organization. user is the
+// user whose password is set, it can be nil for a new user, and then the password history is
+// not checked.
+func CheckPasswordPolicy(organization *Organization, user *User, password string) []*PasswordViolation {
+	res := []*PasswordViolation{}
+	if organization == nil {
+		organization = &Organization{}
+	}
+
+	minLength := organization.PasswordMinLength
+	if minLength <= 0 {
+		minLength = DefaultPasswordMinLength
+	}
+	if len([]rune(password)) < minLength {
+		res = append(res, &PasswordViolation{Code: "minLength", Param: minLength, Msg: fmt.Sprintf("password must have at least %d characters", minLength)})
+	}
+
+	for _, complexity := range organization.PasswordComplexity {
+		switch complexity {
+		case PasswordComplexityUppercase:
+			if !hasRune(password, unicode.IsUpper) {
+				res = append(res, &PasswordViolation{Code: complexity, Msg: "password must contain an uppercase letter"})
+			}
+		case PasswordComplexityLowercase:
+			if !hasRune(password, unicode.IsLower) {
+				res = append(res, &PasswordViolation{Code: complexity, Msg: "password must contain a lowercase letter"})
+			}
+		case PasswordComplexityDigit:
+			if !hasRune(password, unicode.IsDigit) {
+				res = append(res, &PasswordViolation{Code: complexity, Msg: "password must contain a digit"})
+			}
+		case PasswordComplexitySpecial:
+			if !hasRune(password, isSpecialRune) {
+				res = append(res, &PasswordViolation{Code: complexity, Msg: "password must contain a special character"})
+			}
+		}
+	}
+
+	lowerPassword := strings.ToLower(password)
+	for _, word := range organization.PasswordBannedWords {
+		word = strings.TrimSpace(word)
+		if word != "" && strings.Contains(lowerPassword, strings.ToLower(word)) {
+			res = append(res, &PasswordViolation{Code: "bannedWord", Param: word, Msg: fmt.Sprintf("password must not contain: %s", word)})
+			break
+		}
+	}
+
+	if organization.BreachedPasswordCheck == BreachedPasswordCheckBlock && IsPasswordBreached(password) {
+		res = append(res, &PasswordViolation{Code: "breached", Msg: "password has appeared in a data breach"})
+	}
+
+	if user != nil {
+		if len(user.Name) > 2 && strings.Contains(lowerPassword, strings.ToLower(user.Name)) {
+			res = append(res, &PasswordViolation{Code: "username", Msg: "password must not contain the username"})
+		}
+		if count := getPasswordHistoryCount(organization); count != 0 && isPasswordInHistory(organization, user, password) {
+			res = append(res, &PasswordViolation{Code: "reused", Param: count, Msg: fmt.Sprintf("password must not be one of the last %d passwords", count)})
+		}
+	}
+
+	return res
+}
+
+func getPasswordHistoryCount(organization *Organization) int {
+	if organization.PasswordHistoryCount > MaxPasswordHistoryCount {
+		return MaxPasswordHistoryCount
+	}
+	return organization.PasswordHistoryCount
+}
+
+// isPasswordInHistory checks the password against the last passwords of the user: the current
+// one and the previous ones kept in the history
+func isPasswordInHistory(organization *Organization, user *User, password string) bool {
+	hashes := append([]string{user.Password}, user.PasswordHistory...)
+	for i, hash := range hashes {
+		if i >= getPasswordHistoryCount(organization) {
+			break
+		}
+		if hash == "" {
+			continue
+		}
+
+		credManager := cred.GetCredManager(getPasswordTypeByHash(organization, hash))
+		if credManager == nil {
+			if hash == password {
+				return true
+			}
+		} else if credManager.IsPasswordCorrect(password, hash, user.PasswordSalt, organization.PasswordSalt) {
+			return true
+		}
+	}
+	return false
+}
+
+func getPasswordChangedTime(user *User) (time.Time, bool) {
+	changedTime := user.PasswordChangedTime
+	if changedTime == "" {
+		// the users created before the password policy have never changed their password
+		changedTime = user.CreatedTime
+	}
+
+	res, err := time.Parse(time.RFC3339, changedTime)
+	return res, err == nil
+}
+
+// IsPasswordExpired returns true when the password of the user is older than the maximum age of
+// the organization, the user must then change it at the next sign-in
+func IsPasswordExpired(organization *Organization, user *User) bool {
+	if organization == nil || organization.PasswordMaxAge <= 0 || user.Password == "" || user.Ldap != "" {
+		return false
+	}
+
+	changedTime, ok := getPasswordChangedTime(user)
+	if !ok {
+		return false
+	}
+	return time.Since(changedTime) > time.Duration(organization.PasswordMaxAge)*24*time.Hour
+}
+
+// CheckPasswordMinAge returns the violation when the user changes the password again before the
+// minimum age of the organization
+func CheckPasswordMinAge(organization *Organization, user *User) *PasswordViolation {
+	if organization == nil || organization.PasswordMinAge <= 0 || user.PasswordChangedTime == "" {
+		return nil
+	}
+
+	changedTime, ok := getPasswordChangedTime(user)
+	if !ok || time.Since(changedTime) > time.Duration(organization.PasswordMinAge)*24*time.Hour {
+		return nil
+	}
+	return &PasswordViolation{Code: "minAge", Param: organization.PasswordMinAge, Msg: fmt.Sprintf("password can only be changed once in %d days", organization.PasswordMinAge)}
+}
+
+// updateUserPasswordHistory keeps the replaced password hash in the history of the user and
+// records the time of the change. With the new password, the last passwords are still the
+// number of the organization.
+func updateUserPasswordHistory(organization *Organization, user *User, oldPassword string, oldHistory []string) {
+	history := []string{}
+	if count := getPasswordHistoryCount(organization); count > 1 && oldPassword != "" {
+		history = append([]string{oldPassword}, oldHistory...)
+		if len(history) > count-1 {
+			history = history[:count-1]
+		}
+	}
+
+	user.PasswordHistory = history
+	user.PasswordChangedTime = utils.GetCurrentTime()
+	_, err := adapter.Engine.ID(core.PK{user.Owner, user.Name}).Cols("password_history", "password_changed_time").Update(user)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,141 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bhojpur/iam/pkg/breach"
+)
+
+func getViolationCodes(violations []*PasswordViolation) []string {
+	res := []string{}
+	for _, violation := range violations {
+		res = append(res, violation.Code)
+	}
+	return res
+}
+
+func TestCheckPasswordPolicy(t *testing.T) {
+	organization := &Organization{
+		PasswordType:        "plain",
+		PasswordMinLength:   8,
+		PasswordComplexity:  []string{PasswordComplexityUppercase, PasswordComplexityDigit, PasswordComplexitySpecial},
+		PasswordBannedWords: []string{"Bhojpur"},
+	}
+
+	tests := []struct {
+		password string
+		want     []string
+	}{
+		{"Abcdef1!", []string{}},
+		{"abc", []string{"minLength", PasswordComplexityUppercase, PasswordComplexityDigit, PasswordComplexitySpecial}},
+		{"abcdefgh1!", []string{PasswordComplexityUppercase}},
+		{"Mybhojpur1!", []string{"bannedWord"}},
+		{"Pässwörd1§", []string{}},
+	}
+	for _, test := range tests {
+		got := getViolationCodes(CheckPasswordPolicy(organization, nil, test.password))
+		if len(got) != len(test.want) {
+			t.Errorf("CheckPasswordPolicy(%s) = %v, want %v", test.password, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("CheckPasswordPolicy(%s) = %v, want %v", test.password, got, test.want)
+				break
+			}
+		}
+	}
+
+	if got := getViolationCodes(CheckPasswordPolicy(nil, nil, "12345")); len(got) != 1 || got[0] != "minLength" {
+		t.Errorf("CheckPasswordPolicy() without organization = %v", got)
+	}
+}
+
+func TestPasswordHistory(t *testing.T) {
+	organization := &Organization{PasswordType: "plain", PasswordHistoryCount: 3}
+	user := &User{Name: "alice", Password: "Current1!", PasswordHistory: []string{"Previous1!", "Older1!", "Oldest1!"}}
+
+	for _, password := range []string{"Current1!", "Previous1!", "Older1!"} {
+		if got := getViolationCodes(CheckPasswordPolicy(organization, user, password)); len(got) != 1 || got[0] != "reused" {
+			t.Errorf("CheckPasswordPolicy(%s) = %v, want reused", password, got)
+		}
+	}
+	if got := CheckPasswordPolicy(organization, user, "Oldest1!"); len(got) != 0 {
+		t.Errorf("CheckPasswordPolicy(Oldest1!) = %v, the password is older than the last 3", getViolationCodes(got))
+	}
+	if got := getViolationCodes(CheckPasswordPolicy(organization, user, "xAlice123")); len(got) != 1 || got[0] != "username" {
+		t.Errorf("CheckPasswordPolicy(xAlice123) = %v, want username", got)
+	}
+}
+
+func TestPasswordAge(t *testing.T) {
+	organization := &Organization{PasswordMaxAge: 90, PasswordMinAge: 1}
+	user := &User{Password: "***", CreatedTime: time.Now().Add(-100 * 24 * time.Hour).Format(time.RFC3339)}
+
+	if !IsPasswordExpired(organization, user) {
+		t.Errorf("the password of a user created 100 days ago must be expired")
+	}
+	if CheckPasswordMinAge(organization, user) != nil {
+		t.Errorf("the minimum age must not apply before the first change")
+	}
+
+	user.PasswordChangedTime = time.Now().Add(-time.Hour).Format(time.RFC3339)
+	if IsPasswordExpired(organization, user) {
+		t.Errorf("the password changed an hour ago must not be expired")
+	}
+	if CheckPasswordMinAge(organization, user) == nil {
+		t.Errorf("the password changed an hour ago must not be changed again")
+	}
+}
+
+func TestBreachedPassword(t *testing.T) {
+	filter := breach.NewFilter(2, 0.001)
+	for _, line := range []string{"Password1!", "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824"} {
+		hash, _ := breach.ParseLine(line)
+		filter.Add(hash)
+	}
+	breachedPasswordFilter = filter
+	defer func() { breachedPasswordFilter = nil }()
+
+	organization := &Organization{BreachedPasswordCheck: BreachedPasswordCheckBlock}
+	for _, password := range []string{"Password1!", "password"} {
+		if got := getViolationCodes(CheckPasswordPolicy(organization, nil, password)); len(got) != 1 || got[0] != "breached" {
+			t.Errorf("CheckPasswordPolicy(%s) = %v, want breached", password, got)
+		}
+	}
+	if GetBreachedPasswordWarning(organization, "Password1!") != "" {
+		t.Errorf("a blocking organization must not warn")
+	}
+
+	organization.BreachedPasswordCheck = BreachedPasswordCheckWarn
+	if got := CheckPasswordPolicy(organization, nil, "Password1!"); len(got) != 0 {
+		t.Errorf("CheckPasswordPolicy() = %v, a warning organization must not block", getViolationCodes(got))
+	}
+	if GetBreachedPasswordWarning(organization, "Password1!") == "" {
+		t.Errorf("a warning organization must warn")
+	}
+	if GetBreachedPasswordWarning(organization, "Unbreached1!") != "" {
+		t.Errorf("an unbreached password must not be warned about")
+	}
+}
@@ -0,0 +1,275 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/dbm/pkg/core"
+	"github.com/bhojpur/iam/pkg/utils"
+	websvr "github.com/bhojpur/web/pkg/engine"
+)
+
+const (
+	PasswordResetTypeEmail = "email"
+	PasswordResetTypePhone = "phone"
+
+	passwordResetCodeLength   = 6
+	passwordResetMaxAttempts  = 5
+	passwordResetResendSecond = 60
+)
+
+// PasswordReset is a pending password reset. The link sent by email is signed with the client
+// secret of the application, the code sent by SMS is stored hashed. A reset is deleted when it
+// is used, so that each link or code can only reset the password once.
+type PasswordReset struct {
+	Owner       string `orm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `orm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `orm:"varchar(100)" json:"createdTime"`
+
+	Application  string `orm:"varchar(100)" json:"application"`
+	Organization string `orm:"varchar(100)" json:"organization"`
+	User         string `orm:"varchar(100) index" json:"user"`
+	Type         string `orm:"varchar(100)" json:"type"`
+	Receiver     string `orm:"varchar(100)" json:"receiver"`
+	CodeHash     string `orm:"varchar(100)" json:"codeHash"`
+	FailedTimes  int    `json:"failedTimes"`
+	RemoteAddr   string `orm:"varchar(100)" json:"remoteAddr"`
+	ExpireTime   int64  `json:"expireTime"`
+}
+
+var passwordResetTimeout int64
+
+func init() {
+	var err error
+	passwordResetTimeout, err = websvr.AppConfig.Int64("passwordResetTimeout")
+	if err != nil || passwordResetTimeout <= 0 {
+		passwordResetTimeout = 15
+	}
+}
+
+var errPasswordResetInvalid = errors.New("The password reset link or code is invalid or has expired")
+
+func getPasswordResets(organization string, username string) []*PasswordReset {
+	resets := []*PasswordReset{}
+	err := adapter.Engine.Desc("created_time").Find(&resets, &PasswordReset{Organization: organization, User: username})
+	if err != nil {
+		panic(err)
+	}
+
+	return resets
+}
+
+func deletePasswordResets(organization string, username string) {
+	_, err := adapter.Engine.Delete(&PasswordReset{Organization: organization, User: username})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// consumePasswordReset deletes the reset, so that concurrent confirmations of the same link or
+// code can't both succeed
+func consumePasswordReset(reset *PasswordReset) bool {
+	affected, err := adapter.Engine.Delete(&PasswordReset{Owner: reset.Owner, Name: reset.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func getPasswordResetSignature(application *Application, reset *PasswordReset) string {
+	mac := hmac.New(sha256.New, []byte(application.ClientSecret))
+	mac.Write([]byte(fmt.Sprintf("%s|%s|%s/%s|%d", reset.Name, reset.Application, reset.Organization, reset.User, reset.ExpireTime)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func getPasswordResetCode() string {
+	max := big.NewInt(1)
+	for i := 0; i < passwordResetCodeLength; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		panic(err)
+	}
+
+	return fmt.Sprintf("%0*d", passwordResetCodeLength, n)
+}
+
+func getPasswordResetLink(application *Application, token string) string {
+	origin, _ := websvr.AppConfig.String("origin")
+	return fmt.Sprintf("%s/forget/%s?token=%s", strings.TrimSuffix(origin, "/"), application.Name, url.QueryEscape(token))
+}
+
+// SendPasswordReset sends a password reset link to the email of the user, or a code to its
+// phone when it has no email or the application has no Email provider. The previous resets of
+// the user are replaced. A new reset can only be sent once a minute.
+func SendPasswordReset(application *Application, user *User, remoteAddr string) error {
+	deleteExpiredPasswordResets()
+	for _, reset := range getPasswordResets(user.Owner, user.Name) {
+		createdTime, err := time.Parse(time.RFC3339, reset.CreatedTime)
+		if err == nil && time.Since(createdTime) < passwordResetResendSecond*time.Second {
+			return fmt.Errorf("You can only request a password reset once in %ds", passwordResetResendSecond)
+		}
+	}
+
+	organization := GetOrganizationByUser(user)
+	emailProvider := application.GetEmailProvider()
+	smsProvider := application.GetSmsProvider()
+
+	reset := &PasswordReset{
+		Owner:        "admin",
+		Name:         utils.GenerateId(),
+		CreatedTime:  utils.GetCurrentTime(),
+		Application:  application.Name,
+		Organization: user.Owner,
+		User:         user.Name,
+		RemoteAddr:   remoteAddr,
+		ExpireTime:   time.Now().Unix() + passwordResetTimeout*60,
+	}
+	code := ""
+	if user.Email != "" && emailProvider != nil {
+		reset.Type = PasswordResetTypeEmail
+		reset.Receiver = user.Email
+	} else if user.Phone != "" && smsProvider != nil {
+		reset.Type = PasswordResetTypePhone
+		reset.Receiver = fmt.Sprintf("+%s%s", organization.PhonePrefix, user.Phone)
+		code = getPasswordResetCode()
+		reset.CodeHash = utils.GetSha256Hash(code)
+	} else {
+		return fmt.Errorf("The user: %s has no email or phone to send a password reset to", user.GetId())
+	}
+
+	deletePasswordResets(user.Owner, user.Name)
+	_, err := adapter.Engine.Insert(reset)
+	if err != nil {
+		panic(err)
+	}
+
+	if reset.Type == PasswordResetTypePhone {
+		return SendSms(smsProvider, code, reset.Receiver)
+	}
+
+	token := fmt.Sprintf("%s.%s", reset.Name, getPasswordResetSignature(application, reset))
+	link := getPasswordResetLink(application, token)
+	content := fmt.Sprintf("You have requested to reset your password at %s. Please open the link below in %d minutes to choose a new password:<br/><br/><a href=\"%s\">%s</a><br/><br/>If you didn't request it, you can ignore this email.",
+		organization.DisplayName, passwordResetTimeout, link, link)
+	return SendEmail(emailProvider, "Reset your password", content, reset.Receiver, organization.DisplayName)
+}
+
+// GetPasswordResetByToken returns the reset of the link sent by email, after checking its
+// signature and expiry. The reset is not consumed.
+func GetPasswordResetByToken(application *Application, token string) (*PasswordReset, error) {
+	tokens := strings.SplitN(token, ".", 2)
+	if len(tokens) != 2 {
+		return nil, errPasswordResetInvalid
+	}
+
+	reset := PasswordReset{Owner: "admin", Name: tokens[0]}
+	existed, err := adapter.Engine.Get(&reset)
+	if err != nil {
+		panic(err)
+	}
+	if !existed || reset.Type != PasswordResetTypeEmail || reset.Application != application.Name || reset.ExpireTime < time.Now().Unix() {
+		return nil, errPasswordResetInvalid
+	}
+
+	signature := getPasswordResetSignature(application, &reset)
+	if subtle.ConstantTimeCompare([]byte(signature), []byte(tokens[1])) != 1 {
+		return nil, errPasswordResetInvalid
+	}
+
+	return &reset, nil
+}
+
+// GetPasswordResetByCode returns the reset of the code sent by SMS to the user. The reset is not
+// consumed, but it is deleted after too many wrong codes.
+func GetPasswordResetByCode(application *Application, user *User, code string) (*PasswordReset, error) {
+	if user == nil {
+		return nil, errPasswordResetInvalid
+	}
+
+	for _, reset := range getPasswordResets(user.Owner, user.Name) {
+		if reset.Type != PasswordResetTypePhone || reset.Application != application.Name || reset.ExpireTime < time.Now().Unix() {
+			continue
+		}
+
+		if subtle.ConstantTimeCompare([]byte(reset.CodeHash), []byte(utils.GetSha256Hash(strings.TrimSpace(code)))) == 1 {
+			return reset, nil
+		}
+
+		reset.FailedTimes += 1
+		if reset.FailedTimes >= passwordResetMaxAttempts {
+			consumePasswordReset(reset)
+		} else {
+			_, err := adapter.Engine.ID(core.PK{reset.Owner, reset.Name}).Cols("failed_times").Update(reset)
+			if err != nil {
+				panic(err)
+			}
+		}
+		break
+	}
+
+	return nil, errPasswordResetInvalid
+}
+
+// ResetUserPassword sets the new password of the user with the reset, after checking the
+// password policy. The reset is consumed, and the tokens and sessions of the user are revoked.
+func ResetUserPassword(reset *PasswordReset, password string, clientIp string) ([]*PasswordViolation, error) {
+	user := GetUser(fmt.Sprintf("%s/%s", reset.Organization, reset.User))
+	if user == nil || user.IsForbidden || user.IsDeleted {
+		consumePasswordReset(reset)
+		return nil, errPasswordResetInvalid
+	}
+
+	if violations := CheckPasswordPolicy(GetOrganizationByUser(user), user, password); len(violations) != 0 {
+		return violations, errors.New(GetPasswordViolationMsg(violations))
+	}
+
+	if !consumePasswordReset(reset) {
+		return nil, errPasswordResetInvalid
+	}
+
+	user.Password = password
+	SetUserField(user, "password", user.Password)
+	ResetSigninFailures(user)
+	RevokeUserSessions(user)
+	addSigninRecord(user.Owner, user.Name, clientIp, "reset-password", "")
+	return nil, nil
+}
+
+func deleteExpiredPasswordResets() {
+	_, err := adapter.Engine.Where("expire_time < ?", time.Now().Unix()).Delete(&PasswordReset{})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,82 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestPasswordResetSignature(t *testing.T) {
+	application := &Application{Name: "app-example", ClientSecret: "secret"}
+	reset := &PasswordReset{Name: "reset-1", Application: "app-example", Organization: "example", User: "alice", ExpireTime: 1700000000}
+	signature := getPasswordResetSignature(application, reset)
+	if signature != getPasswordResetSignature(application, reset) {
+		t.Errorf("the signature must be stable")
+	}
+
+	expired := *reset
+	expired.ExpireTime += 3600
+	other := *reset
+	other.User = "bob"
+	for _, changed := range []*PasswordReset{&expired, &other} {
+		if getPasswordResetSignature(application, changed) == signature {
+			t.Errorf("the signature must cover %v", changed)
+		}
+	}
+
+	if getPasswordResetSignature(&Application{Name: "app-example", ClientSecret: "rotated"}, reset) == signature {
+		t.Errorf("the signature must depend on the client secret")
+	}
+}
+
+func TestPasswordResetCode(t *testing.T) {
+	codes := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		code := getPasswordResetCode()
+		if !regexp.MustCompile(`^[0-9]{6}$`).MatchString(code) {
+			t.Fatalf("invalid code: %s", code)
+		}
+		codes[code] = true
+	}
+	if len(codes) < 15 {
+		t.Errorf("the codes must be random, got %d distinct codes out of 20", len(codes))
+	}
+}
+
+func TestRevokeUserSessions(t *testing.T) {
+	InitConfig()
+
+	user := &User{Owner: "built-in", Name: "test-revoke-sessions"}
+	_, token, err := AddPersonalAccessToken(user, "test", []string{PersonalAccessTokenScopeRead}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	RevokeUserSessions(user)
+
+	if pats := GetPersonalAccessTokens(user); len(pats) != 0 {
+		t.Errorf("the personal access tokens should be revoked, got %d", len(pats))
+	}
+	if GetPersonalAccessTokenByToken(token) != nil {
+		t.Errorf("the personal access token should no longer authenticate")
+	}
+}
@@ -0,0 +1,228 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/dbm/pkg/core"
+	"github.com/bhojpur/iam/pkg/utils"
+)
+
+const (
+	PersonalAccessTokenPrefix = "iam_pat_"
+
+	PersonalAccessTokenScopeRead  = "read"
+	PersonalAccessTokenScopeWrite = "write"
+
+	personalAccessTokenTouchSeconds = 60
+)
+
+var personalAccessTokenScopes = []string{PersonalAccessTokenScopeRead, PersonalAccessTokenScopeWrite}
+
+// PersonalAccessToken is a token of a user for scripting against the API, it is used as a bearer
+// token. Only the hash of the token is stored, the token itself is shown once when it is created.
+type PersonalAccessToken struct {
+	Owner       string `orm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `orm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `orm:"varchar(100)" json:"createdTime"`
+
+	User         string   `orm:"varchar(100) index" json:"user"`
+	DisplayName  string   `orm:"varchar(100)" json:"displayName"`
+	Scopes       []string `orm:"varchar(100)" json:"scopes"`
+	ExpireTime   string   `orm:"varchar(100)" json:"expireTime"`
+	TokenPrefix  string   `orm:"varchar(100)" json:"tokenPrefix"`
+	TokenHash    string   `orm:"varchar(100) index" json:"-"`
+	LastUsedTime string   `orm:"varchar(100)" json:"lastUsedTime"`
+	LastUsedIp   string   `orm:"varchar(100)" json:"lastUsedIp"`
+}
+
+func (pat *PersonalAccessToken) GetId() string {
+	return fmt.Sprintf("%s/%s", pat.Owner, pat.Name)
+}
+
+// IsExpired checks the expiry of the token, a token without expiry never expires
+func (pat *PersonalAccessToken) IsExpired() bool {
+	if pat.ExpireTime == "" {
+		return false
+	}
+
+	expireTime, err := time.Parse(time.RFC3339, pat.ExpireTime)
+	return err != nil || time.Now().After(expireTime)
+}
+
+// IsMethodAllowed checks the HTTP method of a request against the scopes of the token: "read"
+// allows reading with GET, "write" allows every method
+func (pat *PersonalAccessToken) IsMethodAllowed(method string) bool {
+	if utils.ContainsString(pat.Scopes, PersonalAccessTokenScopeWrite) {
+		return true
+	}
+	return utils.ContainsString(pat.Scopes, PersonalAccessTokenScopeRead) && (method == "GET" || method == "HEAD")
+}
+
+// IsPersonalAccessToken tells the personal access tokens apart from the access tokens of the
+// applications
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+func getPersonalAccessTokenHash(token string) string {
+	return utils.GetSha256Hash(token)
+}
+
+func generatePersonalAccessToken() string {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(err)
+	}
+
+	return PersonalAccessTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+}
+
+func GetPersonalAccessTokens(user *User) []*PersonalAccessToken {
+	pats := []*PersonalAccessToken{}
+	err := adapter.Engine.Desc("created_time").Find(&pats, &PersonalAccessToken{Owner: user.Owner, User: user.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	return pats
+}
+
+func GetPersonalAccessToken(id string) *PersonalAccessToken {
+	if !strings.Contains(id, "/") {
+		return nil
+	}
+
+	owner, name := utils.GetOwnerAndNameFromId(id)
+	pat := PersonalAccessToken{Owner: owner, Name: name}
+	existed, err := adapter.Engine.Get(&pat)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &pat
+	}
+	return nil
+}
+
+// GetPersonalAccessTokenByToken returns the personal access token of the bearer token
+func GetPersonalAccessTokenByToken(token string) *PersonalAccessToken {
+	if !IsPersonalAccessToken(token) {
+		return nil
+	}
+
+	pat := PersonalAccessToken{TokenHash: getPersonalAccessTokenHash(token)}
+	existed, err := adapter.Engine.Get(&pat)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &pat
+	}
+	return nil
+}
+
+// AddPersonalAccessToken creates a token for the user, and returns the token that must be shown
+// to the user: it can't be retrieved later
+func AddPersonalAccessToken(user *User, displayName string, scopes []string, expireTime string) (*PersonalAccessToken, string, error) {
+	displayName = strings.TrimSpace(displayName)
+	if displayName == "" {
+		return nil, "", fmt.Errorf("The name of the token can't be empty")
+	}
+	if len(scopes) == 0 {
+		return nil, "", fmt.Errorf("The token must have at least one scope")
+	}
+	for _, scope := range scopes {
+		if !utils.ContainsString(personalAccessTokenScopes, scope) {
+			return nil, "", fmt.Errorf("Unknown scope: %s", scope)
+		}
+	}
+	if expireTime != "" {
+		t, err := time.Parse(time.RFC3339, expireTime)
+		if err != nil {
+			return nil, "", fmt.Errorf("Invalid expire time: %s", expireTime)
+		}
+		if t.Before(time.Now()) {
+			return nil, "", fmt.Errorf("The expire time: %s has already passed", expireTime)
+		}
+	}
+
+	token := generatePersonalAccessToken()
+	pat := &PersonalAccessToken{
+		Owner:       user.Owner,
+		Name:        utils.GenerateId(),
+		CreatedTime: utils.GetCurrentTime(),
+		User:        user.Name,
+		DisplayName: displayName,
+		Scopes:      scopes,
+		ExpireTime:  expireTime,
+		TokenPrefix: token[:len(PersonalAccessTokenPrefix)+4],
+		TokenHash:   getPersonalAccessTokenHash(token),
+	}
+	_, err := adapter.Engine.Insert(pat)
+	if err != nil {
+		panic(err)
+	}
+
+	return pat, token, nil
+}
+
+// TouchPersonalAccessToken records the last use of the token
+func TouchPersonalAccessToken(pat *PersonalAccessToken, clientIp string) {
+	lastUsedTime, err := time.Parse(time.RFC3339, pat.LastUsedTime)
+	if err == nil && time.Since(lastUsedTime) < personalAccessTokenTouchSeconds*time.Second && pat.LastUsedIp == clientIp {
+		return
+	}
+
+	pat.LastUsedTime = utils.GetCurrentTime()
+	pat.LastUsedIp = clientIp
+	_, err = adapter.Engine.ID(core.PK{pat.Owner, pat.Name}).Cols("last_used_time", "last_used_ip").Update(pat)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func DeletePersonalAccessToken(pat *PersonalAccessToken) bool {
+	affected, err := adapter.Engine.ID(core.PK{pat.Owner, pat.Name}).Delete(&PersonalAccessToken{})
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+// deleteUserPersonalAccessTokens deletes all the tokens of the user
+func deleteUserPersonalAccessTokens(user *User) bool {
+	affected, err := adapter.Engine.Delete(&PersonalAccessToken{Owner: user.Owner, User: user.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
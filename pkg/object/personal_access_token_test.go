@@ -0,0 +1,85 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPersonalAccessTokenIsMethodAllowed(t *testing.T) {
+	readToken := &PersonalAccessToken{Scopes: []string{PersonalAccessTokenScopeRead}}
+	writeToken := &PersonalAccessToken{Scopes: []string{PersonalAccessTokenScopeWrite}}
+	noScopeToken := &PersonalAccessToken{}
+
+	cases := []struct {
+		pat     *PersonalAccessToken
+		method  string
+		allowed bool
+	}{
+		{readToken, "GET", true},
+		{readToken, "HEAD", true},
+		{readToken, "POST", false},
+		{readToken, "DELETE", false},
+		{writeToken, "GET", true},
+		{writeToken, "POST", true},
+		{noScopeToken, "GET", false},
+	}
+	for _, c := range cases {
+		if c.pat.IsMethodAllowed(c.method) != c.allowed {
+			t.Errorf("scopes %v, method %s: allowed should be %v", c.pat.Scopes, c.method, c.allowed)
+		}
+	}
+}
+
+func TestPersonalAccessTokenIsExpired(t *testing.T) {
+	cases := []struct {
+		expireTime string
+		expired    bool
+	}{
+		{"", false},
+		{time.Now().Add(time.Hour).Format(time.RFC3339), false},
+		{time.Now().Add(-time.Hour).Format(time.RFC3339), true},
+		{"not a time", true},
+	}
+	for _, c := range cases {
+		pat := &PersonalAccessToken{ExpireTime: c.expireTime}
+		if pat.IsExpired() != c.expired {
+			t.Errorf("expire time %q: expired should be %v", c.expireTime, c.expired)
+		}
+	}
+}
+
+func TestGeneratePersonalAccessToken(t *testing.T) {
+	token := generatePersonalAccessToken()
+	if !IsPersonalAccessToken(token) {
+		t.Errorf("the token %s doesn't have the prefix %s", token, PersonalAccessTokenPrefix)
+	}
+	if len(token) != len(PersonalAccessTokenPrefix)+43 {
+		t.Errorf("unexpected length of the token: %d", len(token))
+	}
+	if token == generatePersonalAccessToken() {
+		t.Errorf("the tokens should be random")
+	}
+	if IsPersonalAccessToken("3f9a0c1e2b") {
+		t.Errorf("an access token of an application isn't a personal access token")
+	}
+}
@@ -47,6 +47,10 @@ type Provider struct {
 	Title   string `orm:"varchar(100)" json:"title"`
 	Content string `orm:"varchar(1000)" json:"content"`
 
+	CodeLength     int     `json:"codeLength"`
+	CodeTimeout    int     `json:"codeTimeout"`
+	ScoreThreshold float64 `json:"scoreThreshold"`
+
 	RegionId     string `orm:"varchar(100)" json:"regionId"`
 	SignName     string `orm:"varchar(100)" json:"signName"`
 	TemplateCode string `orm:"varchar(100)" json:"templateCode"`
@@ -62,6 +66,15 @@ type Provider struct {
 	IssuerUrl              string `orm:"varchar(100)" json:"issuerUrl"`
 	EnableSignAuthnRequest bool   `json:"enableSignAuthnRequest"`
 
+	CustomAuthUrl     string `orm:"varchar(200)" json:"customAuthUrl"`
+	CustomTokenUrl    string `orm:"varchar(200)" json:"customTokenUrl"`
+	CustomUserInfoUrl string `orm:"varchar(200)" json:"customUserInfoUrl"`
+	AuthStyle         string `orm:"varchar(100)" json:"authStyle"`
+
+	Scopes      string            `orm:"varchar(200)" json:"scopes"`
+	EnablePkce  bool              `json:"enablePkce"`
+	UserMapping map[string]string `orm:"varchar(1000)" json:"userMapping"`
+
 	ProviderUrl string `orm:"varchar(200)" json:"providerUrl"`
 }
 
@@ -0,0 +1,550 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/bhojpur/dbm/pkg/core"
+	"github.com/bhojpur/iam/pkg/utils"
+	logsvr "github.com/bhojpur/logger/pkg/engine"
+)
+
+// ProvisionedObject maps a user (by its id) or a role (by "<owner>/<name>") to the id of the
+// resource created for it on the SCIM endpoint of an application
+type ProvisionedObject struct {
+	Owner       string `orm:"varchar(100) notnull pk" json:"owner"`
+	Application string `orm:"varchar(100) notnull pk" json:"application"`
+	Type        string `orm:"varchar(100) notnull pk" json:"type"`
+	Name        string `orm:"varchar(100) notnull pk" json:"name"`
+
+	RemoteId    string `orm:"varchar(100)" json:"remoteId"`
+	UpdatedTime string `orm:"varchar(100)" json:"updatedTime"`
+}
+
+type ProvisioningStatus struct {
+	Owner string `orm:"varchar(100) notnull pk" json:"owner"`
+	Name  string `orm:"varchar(100) notnull pk" json:"name"`
+
+	State         string `orm:"varchar(100)" json:"state"`
+	LastSyncTime  string `orm:"varchar(100)" json:"lastSyncTime"`
+	LastError     string `orm:"mediumtext" json:"lastError"`
+	LastErrorTime string `orm:"varchar(100)" json:"lastErrorTime"`
+	UpdatedTime   string `orm:"varchar(100)" json:"updatedTime"`
+}
+
+type ProvisioningLog struct {
+	Id int `orm:"int notnull pk autoincr" json:"id"`
+
+	Owner       string `orm:"varchar(100) index" json:"owner"`
+	Application string `orm:"varchar(100) index" json:"application"`
+	CreatedTime string `orm:"varchar(100)" json:"createdTime"`
+
+	Action      string `orm:"varchar(100)" json:"action"`
+	Type        string `orm:"varchar(100)" json:"type"`
+	Object      string `orm:"varchar(100)" json:"object"`
+	IsSucceeded bool   `json:"isSucceeded"`
+	Error       string `orm:"mediumtext" json:"error"`
+}
+
+type provisioningJob struct {
+	// "User", "Group" or "Sync"
+	Type string
+	// the organization and name of the user or role, or the owner and name of the application to sync
+	Owner string
+	Name  string
+	// the id of the user, which stays the same when the user is renamed or deleted
+	UserId string
+	// the previous name of a renamed user
+	OldName string
+}
+
+var provisioningQueue = make(chan *provisioningJob, 10000)
+
+// the size of the queue of the jobs of each application
+const provisioningWorkerQueueSize = 1000
+
+func getProvisionedObject(application *Application, typ string, name string) *ProvisionedObject {
+	object := ProvisionedObject{Owner: application.Owner, Application: application.Name, Type: typ, Name: name}
+	existed, err := adapter.Engine.Get(&object)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &object
+	} else {
+		return nil
+	}
+}
+
+func getProvisionedObjects(application *Application, typ string) []*ProvisionedObject {
+	objects := []*ProvisionedObject{}
+	err := adapter.Engine.Find(&objects, &ProvisionedObject{Owner: application.Owner, Application: application.Name, Type: typ})
+	if err != nil {
+		panic(err)
+	}
+
+	return objects
+}
+
+func setProvisionedObject(application *Application, typ string, name string, remoteId string) {
+	object := &ProvisionedObject{
+		Owner:       application.Owner,
+		Application: application.Name,
+		Type:        typ,
+		Name:        name,
+		RemoteId:    remoteId,
+		UpdatedTime: utils.GetCurrentTime(),
+	}
+
+	var err error
+	if getProvisionedObject(application, typ, name) == nil {
+		_, err = adapter.Engine.Insert(object)
+	} else {
+		_, err = adapter.Engine.ID(core.PK{object.Owner, object.Application, object.Type, object.Name}).AllCols().Update(object)
+	}
+	if err != nil {
+		panic(err)
+	}
+}
+
+func deleteProvisionedObject(object *ProvisionedObject) {
+	_, err := adapter.Engine.ID(core.PK{object.Owner, object.Application, object.Type, object.Name}).Delete(&ProvisionedObject{})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func deleteProvisioningData(application *Application) {
+	_, err := adapter.Engine.Delete(&ProvisionedObject{Owner: application.Owner, Application: application.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = adapter.Engine.ID(core.PK{application.Owner, application.Name}).Delete(&ProvisioningStatus{})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func GetProvisioningStatus(application *Application) *ProvisioningStatus {
+	status := ProvisioningStatus{Owner: application.Owner, Name: application.Name}
+	existed, err := adapter.Engine.Get(&status)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &status
+	} else {
+		return nil
+	}
+}
+
+func setProvisioningStatus(application *Application, state string, isSync bool, provisioningErr error) {
+	status := GetProvisioningStatus(application)
+	isNew := status == nil
+	if isNew {
+		status = &ProvisioningStatus{Owner: application.Owner, Name: application.Name}
+	}
+
+	currentTime := utils.GetCurrentTime()
+	status.State = state
+	status.UpdatedTime = currentTime
+	if isSync && state != "Syncing" {
+		status.LastSyncTime = currentTime
+	}
+	if provisioningErr != nil {
+		status.LastError = provisioningErr.Error()
+		status.LastErrorTime = currentTime
+	}
+
+	var err error
+	if isNew {
+		_, err = adapter.Engine.Insert(status)
+	} else {
+		_, err = adapter.Engine.ID(core.PK{status.Owner, status.Name}).AllCols().Update(status)
+	}
+	if err != nil {
+		panic(err)
+	}
+}
+
+func GetProvisioningLogs(application *Application, limit int) []*ProvisioningLog {
+	logs := []*ProvisioningLog{}
+	err := adapter.Engine.Desc("id").Limit(limit).Find(&logs, &ProvisioningLog{Owner: application.Owner, Application: application.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	return logs
+}
+
+func addProvisioningLog(application *Application, action string, typ string, object string, provisioningErr error) {
+	log := &ProvisioningLog{
+		Owner:       application.Owner,
+		Application: application.Name,
+		CreatedTime: utils.GetCurrentTime(),
+		Action:      action,
+		Type:        typ,
+		Object:      object,
+		IsSucceeded: provisioningErr == nil,
+	}
+	if provisioningErr != nil {
+		log.Error = provisioningErr.Error()
+		logsvr.Warning(fmt.Sprintf("provisioning %s [%s] to application [%s] failed: %s", typ, object, application.GetId(), log.Error))
+	}
+
+	_, err := adapter.Engine.Insert(log)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (application *Application) isProvisioningEnabled() bool {
+	return application.EnableProvisioning && application.ProvisioningUrl != ""
+}
+
+func (application *Application) getScimClient() *scimClient {
+	return newScimClient(application.ProvisioningUrl, application.ProvisioningToken)
+}
+
+func getProvisioningApplications(organization string) []*Application {
+	res := []*Application{}
+	for _, application := range GetApplicationsByOrganizationName("admin", organization) {
+		if application.isProvisioningEnabled() {
+			res = append(res, application)
+		}
+	}
+	return res
+}
+
+func enqueueProvisioningJob(job *provisioningJob) {
+	select {
+	case provisioningQueue <- job:
+	default:
+		// the next full sync of the applications will catch up with the change
+		logsvr.Warning(fmt.Sprintf("provisioning queue is full, dropped the %s job of [%s/%s]", job.Type, job.Owner, job.Name))
+	}
+}
+
+func provisionUser(user *User) {
+	enqueueProvisioningJob(&provisioningJob{Type: "User", Owner: user.Owner, Name: user.Name, UserId: user.Id})
+}
+
+// provisionUpdatedUser queues the update of the user. A renamed user is renamed on the targets,
+// and a user moved to another organization is deprovisioned from the applications of the old one.
+func provisionUpdatedUser(oldUser *User, user *User) {
+	owner := user.Owner
+	if owner == "" {
+		owner = oldUser.Owner
+	}
+
+	newUser := getUserById(owner, oldUser.Id)
+	if newUser == nil || newUser.Owner != oldUser.Owner {
+		provisionUser(oldUser)
+	}
+	if newUser != nil {
+		job := &provisioningJob{Type: "User", Owner: newUser.Owner, Name: newUser.Name, UserId: newUser.Id}
+		if newUser.Owner == oldUser.Owner && newUser.Name != oldUser.Name {
+			job.OldName = oldUser.Name
+		}
+		enqueueProvisioningJob(job)
+	}
+}
+
+func provisionRole(owner string, name string) {
+	enqueueProvisioningJob(&provisioningJob{Type: "Group", Owner: owner, Name: name})
+}
+
+// SyncProvisioning queues a full sync of the users and roles of the application's organization
+// to the application's SCIM endpoint
+func SyncProvisioning(application *Application) bool {
+	if !application.isProvisioningEnabled() {
+		return false
+	}
+
+	setProvisioningStatus(application, "Syncing", true, nil)
+	enqueueProvisioningJob(&provisioningJob{Type: "Sync", Owner: application.Owner, Name: application.Name})
+	return true
+}
+
+// RunProvisioningJob dispatches the provisioning queue to a worker per application, so that the
+// retries of an unreachable endpoint don't hold up the other applications. The jobs of an
+// application are processed one by one, so that a full sync never races with the incremental
+// updates of the same objects.
+func RunProvisioningJob() {
+	workers := map[string]chan *provisioningJob{}
+	for job := range provisioningQueue {
+		for _, applicationId := range getProvisioningJobApplications(job) {
+			worker, ok := workers[applicationId]
+			if !ok {
+				worker = make(chan *provisioningJob, provisioningWorkerQueueSize)
+				workers[applicationId] = worker
+				go runProvisioningWorker(applicationId, worker)
+			}
+
+			select {
+			case worker <- job:
+			default:
+				logsvr.Warning(fmt.Sprintf("provisioning queue of application [%s] is full, dropped the %s job of [%s/%s]", applicationId, job.Type, job.Owner, job.Name))
+			}
+		}
+	}
+}
+
+// getProvisioningJobApplications returns the ids of the applications the job is pushed to
+func getProvisioningJobApplications(job *provisioningJob) []string {
+	res := []string{}
+	defer func() {
+		if r := recover(); r != nil {
+			logsvr.Error(fmt.Sprintf("dispatching the provisioning %s [%s/%s] panicked: %v", job.Type, job.Owner, job.Name, r))
+		}
+	}()
+
+	if job.Type == "Sync" {
+		return []string{fmt.Sprintf("%s/%s", job.Owner, job.Name)}
+	}
+
+	for _, application := range getProvisioningApplications(job.Owner) {
+		res = append(res, application.GetId())
+	}
+	return res
+}
+
+func runProvisioningWorker(applicationId string, jobs chan *provisioningJob) {
+	for job := range jobs {
+		runProvisioningJob(applicationId, job)
+	}
+}
+
+func runProvisioningJob(applicationId string, job *provisioningJob) {
+	defer func() {
+		if r := recover(); r != nil {
+			logsvr.Error(fmt.Sprintf("provisioning %s [%s/%s] to application [%s] panicked: %v", job.Type, job.Owner, job.Name, applicationId, r))
+		}
+	}()
+
+	// the application is read again, as it may have been changed since the job was queued
+	owner, name := utils.GetOwnerAndNameFromId(applicationId)
+	application := getApplication(owner, name)
+	if application == nil || !application.isProvisioningEnabled() {
+		return
+	}
+
+	if job.Type == "Sync" {
+		application.syncProvisioning()
+		return
+	}
+	if application.Organization != job.Owner {
+		return
+	}
+
+	var action string
+	var err error
+	if job.Type == "User" {
+		action, err = application.provisionUser(application.getScimClient(), job.Owner, job.Name, job.UserId, job.OldName)
+	} else {
+		action, err = application.provisionRole(application.getScimClient(), job.Owner, job.Name)
+	}
+
+	if action == "" {
+		return
+	}
+	addProvisioningLog(application, action, job.Type, fmt.Sprintf("%s/%s", job.Owner, job.Name), err)
+	if err != nil {
+		setProvisioningStatus(application, "Failed", false, err)
+	} else if status := GetProvisioningStatus(application); status != nil && status.State == "Failed" {
+		// the endpoint has recovered, the last error is kept for reference
+		setProvisioningStatus(application, "Synced", false, nil)
+	}
+}
+
+// provisionUser pushes the current state of the user to the application. A renamed user (with
+// its old name) is renamed with a PATCH of its "userName" first. The returned action is empty
+// when there was nothing to do.
+func (application *Application) provisionUser(client *scimClient, owner string, name string, userId string, oldName string) (string, error) {
+	var user *User
+	if userId != "" {
+		user = getUserById(owner, userId)
+	} else {
+		user = getUser(owner, name)
+	}
+	if user == nil || user.IsDeleted {
+		object := getProvisionedObject(application, "User", userId)
+		if object == nil {
+			return "", nil
+		}
+
+		err := client.deleteResource("/Users", object.RemoteId)
+		if err != nil {
+			return "Delete", err
+		}
+		deleteProvisionedObject(object)
+		return "Delete", nil
+	}
+
+	action := "Add"
+	remoteId := ""
+	if object := getProvisionedObject(application, "User", user.Id); object != nil {
+		action = "Update"
+		remoteId = object.RemoteId
+
+		if oldName != "" && oldName != user.Name {
+			action = "Rename"
+			operations := []interface{}{map[string]interface{}{"op": "replace", "path": "userName", "value": user.Name}}
+			err := client.patchResource("/Users", remoteId, operations)
+			// a resource deleted on the target is created again below
+			if err != nil && !isScimStatus(err, http.StatusNotFound) {
+				return action, err
+			}
+		}
+	}
+
+	newRemoteId, err := client.provisionResource("/Users", remoteId, getScimUserResource(user), fmt.Sprintf("userName eq %q", user.Name))
+	if err != nil {
+		return action, err
+	}
+	if newRemoteId != remoteId {
+		setProvisionedObject(application, "User", user.Id, newRemoteId)
+	}
+	return action, nil
+}
+
+// provisionRole pushes the role as a group with the users of the role that have been
+// provisioned to the application. Roles in the role are not pushed as members.
+func (application *Application) provisionRole(client *scimClient, owner string, name string) (string, error) {
+	roleId := fmt.Sprintf("%s/%s", owner, name)
+	role := getRole(owner, name)
+	if role == nil {
+		object := getProvisionedObject(application, "Group", roleId)
+		if object == nil {
+			return "", nil
+		}
+
+		err := client.deleteResource("/Groups", object.RemoteId)
+		if err != nil {
+			return "Delete", err
+		}
+		deleteProvisionedObject(object)
+		return "Delete", nil
+	}
+
+	memberIds := []string{}
+	for _, userId := range role.Users {
+		user := GetUser(userId)
+		if user == nil {
+			continue
+		}
+		if object := getProvisionedObject(application, "User", user.Id); object != nil {
+			memberIds = append(memberIds, object.RemoteId)
+		}
+	}
+
+	action := "Add"
+	remoteId := ""
+	if object := getProvisionedObject(application, "Group", roleId); object != nil {
+		action = "Update"
+		remoteId = object.RemoteId
+	}
+
+	displayName := role.DisplayName
+	if displayName == "" {
+		displayName = role.Name
+	}
+	newRemoteId, err := client.provisionResource("/Groups", remoteId, getScimGroupResource(role, memberIds), fmt.Sprintf("displayName eq %q", displayName))
+	if err != nil {
+		return action, err
+	}
+	if newRemoteId != remoteId {
+		setProvisionedObject(application, "Group", roleId, newRemoteId)
+	}
+	return action, nil
+}
+
+// syncProvisioning pushes all the users and roles of the organization to the application and
+// deletes the resources provisioned for users and roles that no longer exist
+func (application *Application) syncProvisioning() {
+	client := application.getScimClient()
+	count := 0
+	failedCount := 0
+	var lastErr error
+
+	handle := func(action string, typ string, object string, err error) {
+		if action == "" {
+			return
+		}
+
+		count += 1
+		if err != nil {
+			failedCount += 1
+			lastErr = err
+			addProvisioningLog(application, action, typ, object, err)
+		}
+	}
+
+	userIds := map[string]bool{}
+	for _, user := range GetUsers(application.Organization) {
+		if user.IsDeleted {
+			continue
+		}
+
+		userIds[user.Id] = true
+		action, err := application.provisionUser(client, user.Owner, user.Name, user.Id, "")
+		handle(action, "User", user.GetId(), err)
+	}
+	for _, object := range getProvisionedObjects(application, "User") {
+		if !userIds[object.Name] {
+			err := client.deleteResource("/Users", object.RemoteId)
+			if err == nil {
+				deleteProvisionedObject(object)
+			}
+			handle("Delete", "User", object.Name, err)
+		}
+	}
+
+	roleIds := map[string]bool{}
+	for _, role := range GetRoles(application.Organization) {
+		roleIds[role.GetId()] = true
+		action, err := application.provisionRole(client, role.Owner, role.Name)
+		handle(action, "Group", role.GetId(), err)
+	}
+	for _, object := range getProvisionedObjects(application, "Group") {
+		if !roleIds[object.Name] {
+			err := client.deleteResource("/Groups", object.RemoteId)
+			if err == nil {
+				deleteProvisionedObject(object)
+			}
+			handle("Delete", "Group", object.Name, err)
+		}
+	}
+
+	if failedCount != 0 {
+		err := fmt.Errorf("%d of %d objects failed to be provisioned, the last error: %s", failedCount, count, lastErr.Error())
+		addProvisioningLog(application, "Sync", "", application.Organization, err)
+		setProvisioningStatus(application, "Failed", true, err)
+	} else {
+		addProvisioningLog(application, "Sync", "", application.Organization, nil)
+		setProvisioningStatus(application, "Synced", true, nil)
+	}
+}
@@ -0,0 +1,277 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	scimUserSchema  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimGroupSchema = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimPatchSchema = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+
+	scimMaxBackoff = time.Minute
+)
+
+type scimStatusError struct {
+	Status int
+	Detail string
+}
+
+func (e *scimStatusError) Error() string {
+	return fmt.Sprintf("SCIM request failed with status %d: %s", e.Status, e.Detail)
+}
+
+func isScimStatus(err error, status int) bool {
+	e, ok := err.(*scimStatusError)
+	return ok && e.Status == status
+}
+
+// scimClient pushes resources to the SCIM 2.0 endpoint of a downstream application
+type scimClient struct {
+	url         string
+	token       string
+	httpClient  *http.Client
+	maxAttempts int
+	backoff     time.Duration
+}
+
+func newScimClient(endpoint string, token string) *scimClient {
+	return &scimClient{
+		url:         strings.TrimSuffix(endpoint, "/"),
+		token:       token,
+		httpClient:  &http.Client{Timeout: 30 * time.Second},
+		maxAttempts: 5,
+		backoff:     time.Second,
+	}
+}
+
+func (c *scimClient) doRequest(method string, path string, body interface{}) (map[string]interface{}, error) {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequest(method, c.url+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/scim+json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/scim+json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 300 {
+		detail := strings.TrimSpace(string(data))
+		scimError := struct {
+			Detail string `json:"detail"`
+		}{}
+		if json.Unmarshal(data, &scimError) == nil && scimError.Detail != "" {
+			detail = scimError.Detail
+		}
+		if len(detail) > 500 {
+			detail = detail[:500]
+		}
+		return nil, &scimStatusError{Status: resp.StatusCode, Detail: detail}
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	res := map[string]interface{}{}
+	err = json.Unmarshal(data, &res)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// request sends the request and retries it with exponential backoff on network errors,
+// rate limiting and server errors. Other client errors are returned immediately.
+func (c *scimClient) request(method string, path string, body interface{}) (map[string]interface{}, error) {
+	var res map[string]interface{}
+	var err error
+	backoff := c.backoff
+	for attempt := 1; attempt <= c.maxAttempts; attempt++ {
+		res, err = c.doRequest(method, path, body)
+		if err == nil {
+			return res, nil
+		}
+
+		if e, ok := err.(*scimStatusError); ok && e.Status != http.StatusTooManyRequests && e.Status < 500 {
+			return nil, err
+		}
+
+		if attempt < c.maxAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+			if backoff > scimMaxBackoff {
+				backoff = scimMaxBackoff
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%s (gave up after %d attempts)", err.Error(), c.maxAttempts)
+}
+
+// findResource returns the id of the first resource of the endpoint matching the filter,
+// or "" when there is none
+func (c *scimClient) findResource(endpoint string, filter string) (string, error) {
+	res, err := c.request("GET", fmt.Sprintf("%s?filter=%s", endpoint, url.QueryEscape(filter)), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resources, _ := res["Resources"].([]interface{})
+	if len(resources) == 0 {
+		return "", nil
+	}
+	resource, _ := resources[0].(map[string]interface{})
+	id, _ := resource["id"].(string)
+	return id, nil
+}
+
+// provisionResource creates or replaces the resource on the target and returns its id there.
+// When the resource has not been provisioned yet (remoteId is empty), an existing resource
+// matching the filter is taken over instead of creating a duplicate.
+func (c *scimClient) provisionResource(endpoint string, remoteId string, resource map[string]interface{}, filter string) (string, error) {
+	var err error
+	if remoteId == "" {
+		remoteId, err = c.findResource(endpoint, filter)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if remoteId != "" {
+		_, err = c.request("PUT", fmt.Sprintf("%s/%s", endpoint, url.PathEscape(remoteId)), resource)
+		if !isScimStatus(err, http.StatusNotFound) {
+			return remoteId, err
+		}
+		// the resource has been deleted on the target, so create it again
+	}
+
+	res, err := c.request("POST", endpoint, resource)
+	if err != nil {
+		return "", err
+	}
+
+	remoteId, _ = res["id"].(string)
+	if remoteId == "" {
+		return "", fmt.Errorf("the SCIM response of creating the resource has no id")
+	}
+	return remoteId, nil
+}
+
+// patchResource applies the operations of RFC 7644 section 3.5.2 to the resource on the target
+func (c *scimClient) patchResource(endpoint string, remoteId string, operations []interface{}) error {
+	body := map[string]interface{}{
+		"schemas":    []interface{}{scimPatchSchema},
+		"Operations": operations,
+	}
+	_, err := c.request("PATCH", fmt.Sprintf("%s/%s", endpoint, url.PathEscape(remoteId)), body)
+	return err
+}
+
+func (c *scimClient) deleteResource(endpoint string, remoteId string) error {
+	_, err := c.request("DELETE", fmt.Sprintf("%s/%s", endpoint, url.PathEscape(remoteId)), nil)
+	if isScimStatus(err, http.StatusNotFound) {
+		return nil
+	}
+	return err
+}
+
+func getScimUserResource(user *User) map[string]interface{} {
+	displayName := user.DisplayName
+	if displayName == "" {
+		displayName = user.Name
+	}
+
+	resource := map[string]interface{}{
+		"schemas":     []interface{}{scimUserSchema},
+		"userName":    user.Name,
+		"externalId":  user.Id,
+		"active":      !user.IsForbidden,
+		"displayName": displayName,
+		"name":        map[string]interface{}{"formatted": displayName},
+	}
+	if user.Email != "" {
+		resource["emails"] = []interface{}{map[string]interface{}{"value": user.Email, "type": "work", "primary": true}}
+	}
+	if user.Phone != "" {
+		resource["phoneNumbers"] = []interface{}{map[string]interface{}{"value": user.Phone, "type": "work", "primary": true}}
+	}
+	if user.Title != "" {
+		resource["title"] = user.Title
+	}
+	if user.Language != "" {
+		resource["preferredLanguage"] = user.Language
+	}
+	return resource
+}
+
+func getScimGroupResource(role *Role, memberIds []string) map[string]interface{} {
+	displayName := role.DisplayName
+	if displayName == "" {
+		displayName = role.Name
+	}
+
+	members := []interface{}{}
+	for _, memberId := range memberIds {
+		members = append(members, map[string]interface{}{"value": memberId})
+	}
+
+	return map[string]interface{}{
+		"schemas":     []interface{}{scimGroupSchema},
+		"displayName": displayName,
+		"externalId":  role.GetId(),
+		"members":     members,
+	}
+}
@@ -0,0 +1,258 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scimStub is a minimal in-memory SCIM endpoint
+type scimStub struct {
+	mutex     sync.Mutex
+	resources map[string]map[string]interface{}
+	nextId    int
+	failures  int
+	requests  []string
+}
+
+func newScimStub() *scimStub {
+	return &scimStub{resources: map[string]map[string]interface{}{}}
+}
+
+func (s *scimStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.requests = append(s.requests, fmt.Sprintf("%s %s", r.Method, r.URL.Path))
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if s.failures > 0 {
+		s.failures -= 1
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	writeJson := func(status int, data interface{}) {
+		w.Header().Set("Content-Type", "application/scim+json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(data)
+	}
+
+	tokens := strings.Split(strings.TrimPrefix(r.URL.Path, "/scim/v2/"), "/")
+	endpoint := tokens[0]
+	if len(tokens) == 1 {
+		switch r.Method {
+		case "GET":
+			// only supports "userName eq" and "displayName eq" filters
+			filter := r.URL.Query().Get("filter")
+			value := filter[strings.Index(filter, "\"")+1 : len(filter)-1]
+			resources := []interface{}{}
+			for key, resource := range s.resources {
+				if strings.HasPrefix(key, endpoint+"/") && (resource["userName"] == value || resource["displayName"] == value) {
+					resources = append(resources, resource)
+				}
+			}
+			writeJson(http.StatusOK, map[string]interface{}{"totalResults": len(resources), "Resources": resources})
+		case "POST":
+			resource := map[string]interface{}{}
+			_ = json.NewDecoder(r.Body).Decode(&resource)
+			s.nextId += 1
+			resource["id"] = fmt.Sprintf("remote-%d", s.nextId)
+			s.resources[fmt.Sprintf("%s/%s", endpoint, resource["id"])] = resource
+			writeJson(http.StatusCreated, resource)
+		}
+		return
+	}
+
+	key := fmt.Sprintf("%s/%s", endpoint, tokens[1])
+	if _, ok := s.resources[key]; !ok {
+		writeJson(http.StatusNotFound, map[string]interface{}{"status": "404", "detail": "resource not found"})
+		return
+	}
+	switch r.Method {
+	case "PUT":
+		resource := map[string]interface{}{}
+		_ = json.NewDecoder(r.Body).Decode(&resource)
+		resource["id"] = tokens[1]
+		s.resources[key] = resource
+		writeJson(http.StatusOK, resource)
+	case "PATCH":
+		// only supports the replace operations with a path
+		request := struct {
+			Operations []struct {
+				Op    string      `json:"op"`
+				Path  string      `json:"path"`
+				Value interface{} `json:"value"`
+			}
+		}{}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		for _, operation := range request.Operations {
+			s.resources[key][operation.Path] = operation.Value
+		}
+		writeJson(http.StatusOK, s.resources[key])
+	case "DELETE":
+		delete(s.resources, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func newTestScimClient(stub *scimStub) (*scimClient, func()) {
+	server := httptest.NewServer(stub)
+	client := newScimClient(server.URL+"/scim/v2/", "secret")
+	client.backoff = time.Millisecond
+	client.maxAttempts = 3
+	return client, server.Close
+}
+
+func TestScimClientProvisionUser(t *testing.T) {
+	stub := newScimStub()
+	client, closeServer := newTestScimClient(stub)
+	defer closeServer()
+
+	user := &User{Owner: "org", Name: "alice", Id: "id-alice", DisplayName: "Alice", Email: "alice@example.com"}
+	remoteId, err := client.provisionResource("/Users", "", getScimUserResource(user), fmt.Sprintf("userName eq %q", user.Name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remoteId != "remote-1" || stub.resources["Users/remote-1"]["externalId"] != "id-alice" {
+		t.Fatalf("unexpected remote user: %s, %v", remoteId, stub.resources)
+	}
+
+	user.IsForbidden = true
+	remoteId, err = client.provisionResource("/Users", remoteId, getScimUserResource(user), fmt.Sprintf("userName eq %q", user.Name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remoteId != "remote-1" || stub.resources["Users/remote-1"]["active"] != false {
+		t.Fatalf("unexpected remote user: %s, %v", remoteId, stub.resources)
+	}
+
+	// a user deleted on the target is created again
+	delete(stub.resources, "Users/remote-1")
+	remoteId, err = client.provisionResource("/Users", remoteId, getScimUserResource(user), fmt.Sprintf("userName eq %q", user.Name))
+	if err != nil || remoteId != "remote-2" {
+		t.Fatalf("unexpected result: %s, %v", remoteId, err)
+	}
+
+	// an existing user on the target is taken over instead of being duplicated
+	remoteId, err = client.provisionResource("/Users", "", getScimUserResource(user), fmt.Sprintf("userName eq %q", user.Name))
+	if err != nil || remoteId != "remote-2" || len(stub.resources) != 1 {
+		t.Fatalf("unexpected result: %s, %v, %v", remoteId, err, stub.resources)
+	}
+
+	err = client.deleteResource("/Users", remoteId)
+	if err != nil || len(stub.resources) != 0 {
+		t.Fatalf("unexpected result: %v, %v", err, stub.resources)
+	}
+
+	// deleting a user that no longer exists on the target is not an error
+	err = client.deleteResource("/Users", remoteId)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScimClientProvisionGroup(t *testing.T) {
+	stub := newScimStub()
+	client, closeServer := newTestScimClient(stub)
+	defer closeServer()
+
+	role := &Role{Owner: "org", Name: "admins", DisplayName: "Admins"}
+	remoteId, err := client.provisionResource("/Groups", "", getScimGroupResource(role, []string{"remote-7", "remote-8"}), `displayName eq "Admins"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	members, _ := stub.resources["Groups/"+remoteId]["members"].([]interface{})
+	if len(members) != 2 || members[1].(map[string]interface{})["value"] != "remote-8" {
+		t.Errorf("unexpected members: %v", members)
+	}
+}
+
+func TestScimClientRetry(t *testing.T) {
+	stub := newScimStub()
+	client, closeServer := newTestScimClient(stub)
+	defer closeServer()
+
+	// recovers from transient failures
+	stub.failures = 2
+	user := &User{Owner: "org", Name: "bob", Id: "id-bob"}
+	_, err := client.provisionResource("/Users", "", getScimUserResource(user), `userName eq "bob"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// gives up after the max attempts
+	stub.failures = 3
+	stub.requests = nil
+	_, err = client.provisionResource("/Users", "", getScimUserResource(user), `userName eq "bob"`)
+	if err == nil || !strings.Contains(err.Error(), "gave up after 3 attempts") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(stub.requests) != 3 {
+		t.Errorf("unexpected requests: %v", stub.requests)
+	}
+
+	// client errors are not retried
+	client.token = "wrong"
+	stub.requests = nil
+	err = client.deleteResource("/Users", "remote-1")
+	if err == nil || len(stub.requests) != 1 {
+		t.Errorf("unexpected result: %v, %v", err, stub.requests)
+	}
+}
+
+func TestScimClientPatchUser(t *testing.T) {
+	stub := newScimStub()
+	client, closeServer := newTestScimClient(stub)
+	defer closeServer()
+
+	remoteId, err := client.provisionResource("/Users", "", getScimUserResource(&User{Id: "u1", Name: "alice"}), `userName eq "alice"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operations := []interface{}{map[string]interface{}{"op": "replace", "path": "userName", "value": "alice2"}}
+	err = client.patchResource("/Users", remoteId, operations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userName := stub.resources["Users/"+remoteId]["userName"]; userName != "alice2" {
+		t.Errorf("unexpected userName: %v", userName)
+	}
+	if len(stub.resources) != 1 {
+		t.Errorf("unexpected resources: %v", stub.resources)
+	}
+
+	err = client.patchResource("/Users", "missing", operations)
+	if !isScimStatus(err, http.StatusNotFound) {
+		t.Errorf("expected a 404 error, got %v", err)
+	}
+}
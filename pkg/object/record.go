@@ -52,6 +52,7 @@ type Record struct {
 	Method       string `orm:"varchar(100)" json:"method"`
 	RequestUri   string `orm:"varchar(1000)" json:"requestUri"`
 	Action       string `orm:"varchar(1000)" json:"action"`
+	Impersonator string `orm:"varchar(100)" json:"impersonator"`
 
 	ExtendedUser *User `orm:"-" json:"extendedUser"`
 
@@ -61,7 +62,7 @@ type Record struct {
 func NewRecord(ctx *ctxsvr.Context) *Record {
 	ip := strings.Replace(utils.GetIPFromRequest(ctx.Request), ": ", "", -1)
 	action := strings.Replace(ctx.Request.URL.Path, "/api/", "", -1)
-	requestUri := utils.FilterQuery(ctx.Request.RequestURI, []string{"accessToken"})
+	requestUri := utils.FilterCredentialQuery(ctx.Request.RequestURI)
 	if len(requestUri) > 1000 {
 		requestUri = requestUri[0:1000]
 	}
@@ -76,6 +77,11 @@ func NewRecord(ctx *ctxsvr.Context) *Record {
 		Action:      action,
 		IsTriggered: false,
 	}
+
+	// the requests of an impersonation are recorded with the admin impersonating the user
+	if ctx.Input.CruSession != nil {
+		record.Impersonator, _ = ctx.Input.Session("impersonator").(string)
+	}
 	return &record
 }
 
@@ -0,0 +1,45 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ctxsvr "github.com/bhojpur/web/pkg/context"
+)
+
+func TestNewRecordRequestUri(t *testing.T) {
+	request := httptest.NewRequest("POST", "/api/login/oauth/access_token?grant_type=authorization_code&client_id=abc&client_secret=456&code=789&code_verifier=verifier&refresh_token=refresh", nil)
+	ctx := ctxsvr.NewContext()
+	ctx.Reset(httptest.NewRecorder(), request)
+
+	record := NewRecord(ctx)
+	if record.RequestUri != "/api/login/oauth/access_token?client_id=abc&grant_type=authorization_code" {
+		t.Errorf("unexpected request URI: %s", record.RequestUri)
+	}
+	for _, value := range []string{"456", "789", "verifier", "refresh"} {
+		if strings.Contains(record.RequestUri, value) {
+			t.Errorf("the request URI should not have the credential: %s", value)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/dbm/pkg/core"
+	"github.com/bhojpur/iam/pkg/utils"
+	websvr "github.com/bhojpur/web/pkg/engine"
+)
+
+const (
+	RiskActionAllow   = "Allow"
+	RiskActionNotify  = "Notify"
+	RiskActionCaptcha = "Require captcha"
+	RiskActionMfa     = "Require MFA"
+	RiskActionBlock   = "Block"
+
+	RiskSignalNewDevice        = "new-device"
+	RiskSignalNewIp            = "new-ip"
+	RiskSignalNewAsn           = "new-asn"
+	RiskSignalNewCountry       = "new-country"
+	RiskSignalImpossibleTravel = "impossible-travel"
+	RiskSignalFailures         = "failures"
+
+	// the travel between two sign-ins faster than this speed in km/h is impossible
+	maxTravelSpeed = 1000
+	// the failed sign-ins of the user are counted over this period
+	riskFailureHours = 24
+)
+
+// riskSignalScores are the scores added by the signals of a login, the failures are scored per failure
+var riskSignalScores = map[string]int{
+	RiskSignalNewDevice:        30,
+	RiskSignalNewIp:            10,
+	RiskSignalNewAsn:           20,
+	RiskSignalNewCountry:       20,
+	RiskSignalImpossibleTravel: 50,
+	RiskSignalFailures:         10,
+}
+
+const maxRiskFailureScore = 30
+
+var riskHistoryDays int64
+
+func init() {
+	var err error
+	riskHistoryDays, err = websvr.AppConfig.Int64("riskHistoryDays")
+	if err != nil || riskHistoryDays <= 0 {
+		riskHistoryDays = 90
+	}
+}
+
+// RiskRule is a rule of the risk policy of an organization: the action is taken for the logins
+// whose risk score reaches the score of the rule
+type RiskRule struct {
+	Score  int    `json:"score"`
+	Action string `json:"action"`
+}
+
+// LoginHistory is a successful login of a user, the devices, addresses and networks of the past
+// logins are the familiar ones of the user
+type LoginHistory struct {
+	Owner       string `orm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `orm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `orm:"varchar(100) index" json:"createdTime"`
+
+	User     string `orm:"varchar(100) index" json:"user"`
+	Device   string `orm:"varchar(100)" json:"device"`
+	ClientIp string `orm:"varchar(100)" json:"clientIp"`
+	Country  string `orm:"varchar(100)" json:"country"`
+	Asn      string `orm:"varchar(100)" json:"asn"`
+}
+
+// RiskAssessment is the risk of a login: the signals that were raised, their total score and the
+// actions of the rules of the organization that the score reaches
+type RiskAssessment struct {
+	Score   int      `json:"score"`
+	Signals []string `json:"signals"`
+	Actions []string `json:"actions"`
+}
+
+func (assessment *RiskAssessment) HasAction(action string) bool {
+	return assessment != nil && utils.ContainsString(assessment.Actions, action)
+}
+
+// riskFacts are what is known of a login compared to the past logins of the user
+type riskFacts struct {
+	HasHistory       bool
+	IsKnownDevice    bool
+	IsKnownIp        bool
+	IsKnownAsn       bool
+	IsKnownCountry   bool
+	IsTravelPossible bool
+	Failures         int
+}
+
+func (facts *riskFacts) getSignals() []string {
+	signals := []string{}
+	// the first login of a user has nothing to be compared with
+	if facts.HasHistory {
+		if !facts.IsKnownDevice {
+			signals = append(signals, RiskSignalNewDevice)
+		}
+		if !facts.IsKnownIp {
+			signals = append(signals, RiskSignalNewIp)
+		}
+		if !facts.IsKnownAsn {
+			signals = append(signals, RiskSignalNewAsn)
+		}
+		if !facts.IsKnownCountry {
+			signals = append(signals, RiskSignalNewCountry)
+		}
+	}
+	if !facts.IsTravelPossible {
+		signals = append(signals, RiskSignalImpossibleTravel)
+	}
+	if facts.Failures > 0 {
+		signals = append(signals, RiskSignalFailures)
+	}
+	return signals
+}
+
+func (facts *riskFacts) getScore() int {
+	score := 0
+	for _, signal := range facts.getSignals() {
+		if signal == RiskSignalFailures {
+			failureScore := facts.Failures * riskSignalScores[RiskSignalFailures]
+			if failureScore > maxRiskFailureScore {
+				failureScore = maxRiskFailureScore
+			}
+			score += failureScore
+		} else {
+			score += riskSignalScores[signal]
+		}
+	}
+	return score
+}
+
+// getRiskActions returns the actions of the rules reached by the score, "Allow" when there is none
+func getRiskActions(rules []*RiskRule, score int) []string {
+	actions := []string{}
+	for _, rule := range rules {
+		if rule.Action == "" || rule.Action == RiskActionAllow || score < rule.Score {
+			continue
+		}
+		if !utils.ContainsString(actions, rule.Action) {
+			actions = append(actions, rule.Action)
+		}
+	}
+
+	if len(actions) == 0 {
+		actions = append(actions, RiskActionAllow)
+	}
+	return actions
+}
+
+// isTravelPossible checks whether the user could have traveled from the location of the last
+// sign-in to the current one in the time between them
+func isTravelPossible(from *GeoIpRecord, to *GeoIpRecord, duration time.Duration) bool {
+	if from == nil || to == nil || !from.HasLocation || !to.HasLocation {
+		return true
+	}
+
+	distance := getGeoDistance(from, to)
+	// the locations of the GeoIP databases are approximate
+	if distance < 100 {
+		return true
+	}
+	hours := duration.Hours()
+	if hours <= 0 {
+		return false
+	}
+	return distance/hours <= maxTravelSpeed
+}
+
+func getLoginHistories(user *User) []*LoginHistory {
+	histories := []*LoginHistory{}
+	err := adapter.Engine.Desc("created_time").Find(&histories, &LoginHistory{Owner: user.Owner, User: user.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	return histories
+}
+
+func getRecentSigninFailureCount(user *User) int {
+	since := time.Now().Add(-riskFailureHours * time.Hour).Format(time.RFC3339)
+	count, err := adapter.Engine.Where("created_time > ?", since).Count(&Record{Organization: user.Owner, User: user.Name, Action: "signin-failure"})
+	if err != nil {
+		panic(err)
+	}
+
+	return int(count)
+}
+
+func getRiskFacts(user *User, device string, clientIp string) *riskFacts {
+	geoIp := GetGeoIpRecord(clientIp)
+	facts := &riskFacts{IsTravelPossible: true}
+
+	histories := getLoginHistories(user)
+	facts.HasHistory = len(histories) != 0
+	for _, history := range histories {
+		if device != "" && history.Device == device {
+			facts.IsKnownDevice = true
+		}
+		if history.ClientIp == clientIp {
+			facts.IsKnownIp = true
+		}
+		// without the GeoIP database, the networks and the countries aren't known
+		if geoIp == nil || (geoIp.Asn != "" && history.Asn == geoIp.Asn) {
+			facts.IsKnownAsn = true
+		}
+		if geoIp == nil || (geoIp.Country != "" && history.Country == geoIp.Country) {
+			facts.IsKnownCountry = true
+		}
+	}
+
+	if user.LastSigninIp != "" && user.LastSigninIp != clientIp {
+		lastSigninTime, err := time.Parse(time.RFC3339, user.LastSigninTime)
+		if err == nil {
+			facts.IsTravelPossible = isTravelPossible(GetGeoIpRecord(user.LastSigninIp), geoIp, time.Since(lastSigninTime))
+		}
+	}
+
+	policy := GetLockoutPolicy(GetOrganizationByUser(user))
+	facts.Failures = getRecentSigninFailureCount(user) + getIpFailures(clientIp).getCount(policy.Duration, time.Now())
+	return facts
+}
+
+// AssessLoginRisk scores the risk of a login of the user from the device and the address, and
+// returns the actions of the risk rules of the organization. The logins of the organizations
+// without risk rules are allowed without being scored.
+func AssessLoginRisk(user *User, device string, clientIp string) *RiskAssessment {
+	organization := GetOrganizationByUser(user)
+	if organization == nil || len(organization.RiskRules) == 0 {
+		return &RiskAssessment{Signals: []string{}, Actions: []string{RiskActionAllow}}
+	}
+
+	facts := getRiskFacts(user, device, clientIp)
+	assessment := &RiskAssessment{Signals: facts.getSignals(), Score: facts.getScore()}
+	assessment.Actions = getRiskActions(organization.RiskRules, assessment.Score)
+
+	if !assessment.HasAction(RiskActionAllow) {
+		action := "risk"
+		if assessment.HasAction(RiskActionBlock) {
+			action = "risk-block"
+		}
+		msg := fmt.Sprintf("score=%d signals=%s actions=%s", assessment.Score, strings.Join(assessment.Signals, ","), strings.Join(assessment.Actions, ","))
+		addSigninRecord(user.Owner, user.Name, clientIp, action, msg)
+	}
+
+	return assessment
+}
+
+// AddLoginHistory records a successful login of the user, together with its last sign-in time
+// and address, and forgets the logins older than riskHistoryDays
+func AddLoginHistory(user *User, device string, clientIp string) {
+	history := &LoginHistory{
+		Owner:       user.Owner,
+		Name:        utils.GenerateId(),
+		CreatedTime: utils.GetCurrentTime(),
+		User:        user.Name,
+		Device:      device,
+		ClientIp:    clientIp,
+	}
+	if geoIp := GetGeoIpRecord(clientIp); geoIp != nil {
+		history.Country = geoIp.Country
+		history.Asn = geoIp.Asn
+	}
+	_, err := adapter.Engine.Insert(history)
+	if err != nil {
+		panic(err)
+	}
+
+	since := time.Now().Add(-time.Duration(riskHistoryDays) * 24 * time.Hour).Format(time.RFC3339)
+	_, err = adapter.Engine.Where("created_time < ?", since).Delete(&LoginHistory{Owner: user.Owner, User: user.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	user.LastSigninTime = history.CreatedTime
+	user.LastSigninIp = clientIp
+	_, err = adapter.Engine.ID(core.PK{user.Owner, user.Name}).Cols("last_signin_time", "last_signin_ip").Update(user)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// SendRiskNotification tells the user by email about an unusual login to the account
+func SendRiskNotification(application *Application, user *User, clientIp string, assessment *RiskAssessment) error {
+	if user.Email == "" {
+		return fmt.Errorf("The user: %s has no email", user.GetId())
+	}
+	emailProvider := application.GetEmailProvider()
+	if emailProvider == nil {
+		return fmt.Errorf("The application: %s has no Email provider", application.Name)
+	}
+
+	location := clientIp
+	if geoIp := GetGeoIpRecord(clientIp); geoIp != nil && geoIp.Country != "" {
+		location = fmt.Sprintf("%s (%s)", clientIp, geoIp.Country)
+	}
+	organization := GetOrganizationByUser(user)
+	content := fmt.Sprintf("An unusual sign-in to your account %s of %s has happened at %s from %s.<br/><br/>If it wasn't you, please change your password and revoke your sessions right away.",
+		user.Name, application.DisplayName, utils.GetCurrentTime(), location)
+	return SendEmail(emailProvider, "Unusual sign-in to your account", content, user.Email, organization.DisplayName)
+}
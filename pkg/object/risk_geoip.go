@@ -0,0 +1,174 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	websvr "github.com/bhojpur/web/pkg/engine"
+)
+
+// GeoIpRecord is the country, the autonomous system and the location of an IP address
+type GeoIpRecord struct {
+	Country     string
+	Asn         string
+	Latitude    float64
+	Longitude   float64
+	HasLocation bool
+}
+
+type geoIpRange struct {
+	Start  net.IP
+	End    net.IP
+	Record *GeoIpRecord
+}
+
+var (
+	geoIpRanges     []*geoIpRange
+	geoIpRangesLock sync.RWMutex
+)
+
+// InitGeoIpDatabase loads the offline GeoIP/ASN database from the file set in riskGeoIpFile. It
+// is a CSV file of IP address ranges, one per line: "start_ip,end_ip,country,asn[,latitude,longitude]",
+// the lines starting with "#" are comments. Without it, the risk of a login isn't scored by the
+// network and the location of the client.
+func InitGeoIpDatabase() {
+	path, err := websvr.AppConfig.String("riskGeoIpFile")
+	if err != nil || path == "" {
+		return
+	}
+
+	err = LoadGeoIpDatabase(path)
+	if err != nil {
+		panic(fmt.Errorf("failed to load the GeoIP database %s: %s", path, err.Error()))
+	}
+}
+
+func LoadGeoIpDatabase(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	ranges, err := parseGeoIpDatabase(file)
+	if err != nil {
+		return err
+	}
+
+	geoIpRangesLock.Lock()
+	geoIpRanges = ranges
+	geoIpRangesLock.Unlock()
+	return nil
+}
+
+func parseGeoIpDatabase(reader io.Reader) ([]*geoIpRange, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.Comment = '#'
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	ranges := []*geoIpRange{}
+	for {
+		line, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(line) < 4 {
+			return nil, fmt.Errorf("invalid GeoIP line: %s", strings.Join(line, ","))
+		}
+
+		start := net.ParseIP(line[0])
+		end := net.ParseIP(line[1])
+		if start == nil || end == nil {
+			return nil, fmt.Errorf("invalid GeoIP range: %s - %s", line[0], line[1])
+		}
+
+		record := &GeoIpRecord{Country: strings.ToUpper(line[2]), Asn: strings.TrimPrefix(strings.ToUpper(line[3]), "AS")}
+		if len(line) >= 6 && line[4] != "" && line[5] != "" {
+			latitude, err1 := strconv.ParseFloat(line[4], 64)
+			longitude, err2 := strconv.ParseFloat(line[5], 64)
+			if err1 == nil && err2 == nil {
+				record.Latitude = latitude
+				record.Longitude = longitude
+				record.HasLocation = true
+			}
+		}
+		ranges = append(ranges, &geoIpRange{Start: start.To16(), End: end.To16(), Record: record})
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return bytes.Compare(ranges[i].Start, ranges[j].Start) < 0
+	})
+	return ranges, nil
+}
+
+func lookupGeoIp(ranges []*geoIpRange, clientIp string) *GeoIpRecord {
+	ip := net.ParseIP(clientIp)
+	if ip == nil {
+		return nil
+	}
+	ip = ip.To16()
+
+	i := sort.Search(len(ranges), func(i int) bool {
+		return bytes.Compare(ranges[i].Start, ip) > 0
+	}) - 1
+	if i < 0 || bytes.Compare(ip, ranges[i].End) > 0 {
+		return nil
+	}
+	return ranges[i].Record
+}
+
+// GetGeoIpRecord looks up the IP address in the offline GeoIP/ASN database, it returns nil when
+// the address is unknown or no database is loaded
+func GetGeoIpRecord(clientIp string) *GeoIpRecord {
+	geoIpRangesLock.RLock()
+	defer geoIpRangesLock.RUnlock()
+
+	return lookupGeoIp(geoIpRanges, clientIp)
+}
+
+// getGeoDistance returns the great-circle distance in kilometers between two locations
+func getGeoDistance(from *GeoIpRecord, to *GeoIpRecord) float64 {
+	const earthRadius = 6371.0
+	toRadians := func(degree float64) float64 {
+		return degree * math.Pi / 180
+	}
+
+	dLatitude := toRadians(to.Latitude - from.Latitude)
+	dLongitude := toRadians(to.Longitude - from.Longitude)
+	a := math.Sin(dLatitude/2)*math.Sin(dLatitude/2) +
+		math.Cos(toRadians(from.Latitude))*math.Cos(toRadians(to.Latitude))*math.Sin(dLongitude/2)*math.Sin(dLongitude/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
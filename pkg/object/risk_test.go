@@ -0,0 +1,131 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testGeoIpDatabase = `# start_ip,end_ip,country,asn,latitude,longitude
+10.0.0.0,10.0.0.255,IN,AS64500,28.61,77.20
+10.0.1.0,10.0.1.255,US,64501,40.71,-74.00
+2001:db8::,2001:db8::ffff,DE,64502,,
+`
+
+func TestLookupGeoIp(t *testing.T) {
+	ranges, err := parseGeoIpDatabase(strings.NewReader(testGeoIpDatabase))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		ip          string
+		country     string
+		asn         string
+		hasLocation bool
+	}{
+		{"10.0.0.1", "IN", "64500", true},
+		{"10.0.1.255", "US", "64501", true},
+		{"2001:db8::1", "DE", "64502", false},
+	}
+	for _, c := range cases {
+		record := lookupGeoIp(ranges, c.ip)
+		if record == nil || record.Country != c.country || record.Asn != c.asn || record.HasLocation != c.hasLocation {
+			t.Errorf("unexpected GeoIP record of %s: %+v", c.ip, record)
+		}
+	}
+
+	for _, ip := range []string{"10.0.2.1", "9.255.255.255", "", "not an ip"} {
+		if record := lookupGeoIp(ranges, ip); record != nil {
+			t.Errorf("%s shouldn't be found: %+v", ip, record)
+		}
+	}
+
+	if _, err := parseGeoIpDatabase(strings.NewReader("10.0.0.0,IN,64500\n")); err == nil {
+		t.Errorf("a line without a range should be rejected")
+	}
+}
+
+func TestIsTravelPossible(t *testing.T) {
+	delhi := &GeoIpRecord{Latitude: 28.61, Longitude: 77.20, HasLocation: true}
+	newYork := &GeoIpRecord{Latitude: 40.71, Longitude: -74.00, HasLocation: true}
+	unknown := &GeoIpRecord{Country: "DE"}
+
+	if isTravelPossible(delhi, newYork, time.Hour) {
+		t.Errorf("traveling from Delhi to New York in an hour should be impossible")
+	}
+	if !isTravelPossible(delhi, newYork, 24*time.Hour) {
+		t.Errorf("traveling from Delhi to New York in a day should be possible")
+	}
+	if !isTravelPossible(delhi, delhi, 0) {
+		t.Errorf("staying at the same place should be possible")
+	}
+	if !isTravelPossible(delhi, unknown, time.Minute) || !isTravelPossible(nil, newYork, time.Minute) {
+		t.Errorf("the travel from or to an unknown location should be possible")
+	}
+}
+
+func TestRiskFactsScore(t *testing.T) {
+	familiar := &riskFacts{HasHistory: true, IsKnownDevice: true, IsKnownIp: true, IsKnownAsn: true, IsKnownCountry: true, IsTravelPossible: true}
+	if signals := familiar.getSignals(); len(signals) != 0 || familiar.getScore() != 0 {
+		t.Errorf("a familiar login shouldn't raise signals: %v", signals)
+	}
+
+	firstLogin := &riskFacts{IsTravelPossible: true}
+	if signals := firstLogin.getSignals(); len(signals) != 0 {
+		t.Errorf("the first login of a user shouldn't raise signals: %v", signals)
+	}
+
+	unusual := &riskFacts{HasHistory: true, IsTravelPossible: false, Failures: 5}
+	expected := []string{RiskSignalNewDevice, RiskSignalNewIp, RiskSignalNewAsn, RiskSignalNewCountry, RiskSignalImpossibleTravel, RiskSignalFailures}
+	if signals := unusual.getSignals(); !reflect.DeepEqual(signals, expected) {
+		t.Errorf("unexpected signals: %v", signals)
+	}
+	if score := unusual.getScore(); score != 30+10+20+20+50+maxRiskFailureScore {
+		t.Errorf("unexpected score: %d", score)
+	}
+}
+
+func TestGetRiskActions(t *testing.T) {
+	rules := []*RiskRule{
+		{Score: 30, Action: RiskActionNotify},
+		{Score: 50, Action: RiskActionMfa},
+		{Score: 100, Action: RiskActionBlock},
+	}
+
+	cases := []struct {
+		score   int
+		actions []string
+	}{
+		{0, []string{RiskActionAllow}},
+		{30, []string{RiskActionNotify}},
+		{60, []string{RiskActionNotify, RiskActionMfa}},
+		{150, []string{RiskActionNotify, RiskActionMfa, RiskActionBlock}},
+	}
+	for _, c := range cases {
+		if actions := getRiskActions(rules, c.score); !reflect.DeepEqual(actions, c.actions) {
+			t.Errorf("score %d: actions should be %v, got %v", c.score, c.actions, actions)
+		}
+	}
+}
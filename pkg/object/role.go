@@ -36,6 +36,8 @@ type Role struct {
 	Users     []string `orm:"mediumtext" json:"users"`
 	Roles     []string `orm:"mediumtext" json:"roles"`
 	IsEnabled bool     `json:"isEnabled"`
+
+	RadiusAttributes []string `orm:"varchar(1000)" json:"radiusAttributes"`
 }
 
 func GetRoleCount(owner, field, value string) int {
@@ -61,6 +63,22 @@ func GetRoles(owner string) []*Role {
 	return roles
 }
 
+// GetRolesByUser returns the roles of the user's organization that have the user as a member
+func GetRolesByUser(userId string) []*Role {
+	owner, _ := utils.GetOwnerAndNameFromIdNoCheck(userId)
+
+	res := []*Role{}
+	for _, role := range GetRoles(owner) {
+		for _, roleUser := range role.Users {
+			if roleUser == userId {
+				res = append(res, role)
+				break
+			}
+		}
+	}
+	return res
+}
+
 func GetPaginationRoles(owner string, offset, limit int, field, value, sortField, sortOrder string) []*Role {
 	roles := []*Role{}
 	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
@@ -106,6 +124,13 @@ func UpdateRole(id string, role *Role) bool {
 		panic(err)
 	}
 
+	if affected != 0 {
+		provisionRole(owner, name)
+		if role.Owner != owner || role.Name != name {
+			provisionRole(role.Owner, role.Name)
+		}
+	}
+
 	return affected != 0
 }
 
@@ -115,6 +140,10 @@ func AddRole(role *Role) bool {
 		panic(err)
 	}
 
+	if affected != 0 {
+		provisionRole(role.Owner, role.Name)
+	}
+
 	return affected != 0
 }
 
@@ -124,6 +153,10 @@ func DeleteRole(role *Role) bool {
 		panic(err)
 	}
 
+	if affected != 0 {
+		provisionRole(role.Owner, role.Name)
+	}
+
 	return affected != 0
 }
 
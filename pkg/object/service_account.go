@@ -0,0 +1,393 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/dbm/pkg/core"
+	"github.com/bhojpur/iam/pkg/utils"
+	websvr "github.com/bhojpur/web/pkg/engine"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	UserTypeServiceAccount = "service-account"
+
+	ServiceAccountCredentialTypeSecret    = "secret"
+	ServiceAccountCredentialTypePublicKey = "public-key"
+
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypeJwtBearer         = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+
+	// serviceAccountAssertionMaxSeconds is the longest lifetime accepted for a JWT assertion
+	serviceAccountAssertionMaxSeconds = 3600
+)
+
+// ServiceAccountCredential is a credential of a service account: a client secret, of which only
+// the hash is stored, or the public key of a key pair that signs JWT assertions. A service account
+// can have several credentials, so that they can be rotated without downtime.
+type ServiceAccountCredential struct {
+	Owner       string `orm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `orm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `orm:"varchar(100)" json:"createdTime"`
+
+	User         string `orm:"varchar(100) index" json:"user"`
+	Type         string `orm:"varchar(100)" json:"type"`
+	SecretPrefix string `orm:"varchar(100)" json:"secretPrefix"`
+	SecretHash   string `orm:"varchar(100)" json:"-"`
+	PublicKey    string `orm:"text" json:"publicKey"`
+	LastUsedTime string `orm:"varchar(100)" json:"lastUsedTime"`
+	LastUsedIp   string `orm:"varchar(100)" json:"lastUsedIp"`
+}
+
+// ServiceAccountAssertion records a JWT assertion of a service account by the hash of its "jti"
+// until it expires, so that each assertion can only be used once
+type ServiceAccountAssertion struct {
+	Owner      string `orm:"varchar(100) notnull pk" json:"owner"`
+	Name       string `orm:"varchar(100) notnull pk" json:"name"`
+	ExpireTime int64  `orm:"index" json:"expireTime"`
+}
+
+func (credential *ServiceAccountCredential) GetId() string {
+	return fmt.Sprintf("%s/%s", credential.Owner, credential.Name)
+}
+
+// IsServiceAccount tells the non-human users, which can't sign in interactively
+func (user *User) IsServiceAccount() bool {
+	return user.Type == UserTypeServiceAccount
+}
+
+// normalizeServiceAccount removes what a service account can't have: a password to sign in with,
+// and the global admin role, its permissions come from its roles and permissions like a user
+func normalizeServiceAccount(user *User) {
+	if !user.IsServiceAccount() {
+		return
+	}
+
+	user.Password = ""
+	user.IsGlobalAdmin = false
+}
+
+func getServiceAccountSecretHash(secret string) string {
+	return utils.GetSha256Hash(secret)
+}
+
+func generateServiceAccountSecret() string {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// parseServiceAccountPublicKey parses a PEM encoded RSA or ECDSA public key
+func parseServiceAccountPublicKey(publicKey string) (interface{}, error) {
+	if key, err := jwt.ParseRSAPublicKeyFromPEM([]byte(publicKey)); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseECPublicKeyFromPEM([]byte(publicKey)); err == nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("The public key should be a PEM encoded RSA or ECDSA public key")
+}
+
+func GetServiceAccountCredentials(user *User) []*ServiceAccountCredential {
+	credentials := []*ServiceAccountCredential{}
+	err := adapter.Engine.Desc("created_time").Find(&credentials, &ServiceAccountCredential{Owner: user.Owner, User: user.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	return credentials
+}
+
+func GetServiceAccountCredential(id string) *ServiceAccountCredential {
+	if !strings.Contains(id, "/") {
+		return nil
+	}
+
+	owner, name := utils.GetOwnerAndNameFromId(id)
+	credential := ServiceAccountCredential{Owner: owner, Name: name}
+	existed, err := adapter.Engine.Get(&credential)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &credential
+	}
+	return nil
+}
+
+// AddServiceAccountCredential adds a credential to the service account. For a secret, the secret
+// is generated and returned, it must be shown to the admin as it can't be retrieved later.
+func AddServiceAccountCredential(user *User, credentialType string, publicKey string) (*ServiceAccountCredential, string, error) {
+	if !user.IsServiceAccount() {
+		return nil, "", fmt.Errorf("The user: %s is not a service account", user.GetId())
+	}
+
+	credential := &ServiceAccountCredential{
+		Owner:       user.Owner,
+		Name:        utils.GenerateId(),
+		CreatedTime: utils.GetCurrentTime(),
+		User:        user.Name,
+		Type:        credentialType,
+	}
+
+	secret := ""
+	switch credentialType {
+	case ServiceAccountCredentialTypeSecret:
+		secret = generateServiceAccountSecret()
+		credential.SecretPrefix = secret[:4]
+		credential.SecretHash = getServiceAccountSecretHash(secret)
+	case ServiceAccountCredentialTypePublicKey:
+		publicKey = strings.TrimSpace(publicKey)
+		if _, err := parseServiceAccountPublicKey(publicKey); err != nil {
+			return nil, "", err
+		}
+		credential.PublicKey = publicKey
+	default:
+		return nil, "", fmt.Errorf("Unknown credential type: %s", credentialType)
+	}
+
+	_, err := adapter.Engine.Insert(credential)
+	if err != nil {
+		panic(err)
+	}
+
+	return credential, secret, nil
+}
+
+func DeleteServiceAccountCredential(credential *ServiceAccountCredential) bool {
+	affected, err := adapter.Engine.ID(core.PK{credential.Owner, credential.Name}).Delete(&ServiceAccountCredential{})
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func touchServiceAccountCredential(credential *ServiceAccountCredential, clientIp string) {
+	credential.LastUsedTime = utils.GetCurrentTime()
+	credential.LastUsedIp = clientIp
+	_, err := adapter.Engine.ID(core.PK{credential.Owner, credential.Name}).Cols("last_used_time", "last_used_ip").Update(credential)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func getServiceAccount(id string) (*User, error) {
+	if !strings.Contains(id, "/") {
+		return nil, fmt.Errorf("invalid client_id")
+	}
+
+	user := GetUser(id)
+	if user == nil || !user.IsServiceAccount() {
+		return nil, fmt.Errorf("invalid client_id")
+	}
+	if user.IsForbidden || user.IsDeleted {
+		return nil, fmt.Errorf("the service account is forbidden, please contact the administrator")
+	}
+
+	return user, nil
+}
+
+// checkServiceAccountSecret returns the secret credential of the service account matching the secret
+func checkServiceAccountSecret(user *User, secret string) (*ServiceAccountCredential, error) {
+	if secret != "" {
+		credential := ServiceAccountCredential{Owner: user.Owner, User: user.Name, Type: ServiceAccountCredentialTypeSecret, SecretHash: getServiceAccountSecretHash(secret)}
+		existed, err := adapter.Engine.Get(&credential)
+		if err != nil {
+			panic(err)
+		}
+
+		if existed {
+			return &credential, nil
+		}
+	}
+
+	return nil, fmt.Errorf("invalid client_secret")
+}
+
+// checkServiceAccountAssertion verifies a JWT assertion (RFC 7523) signed by a key pair of the
+// service account: the "kid" header is the name of the public key credential, the issuer and
+// subject are the id of the service account, and the audience is the token endpoint
+func checkServiceAccountAssertion(clientId string, assertion string) (*User, *ServiceAccountCredential, error) {
+	var user *User
+	var credential *ServiceAccountCredential
+	claims := jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(assertion, &claims, func(token *jwt.Token) (interface{}, error) {
+		subject := token.Claims.(*jwt.RegisteredClaims).Subject
+		if clientId != "" && clientId != subject {
+			return nil, fmt.Errorf("the subject of the assertion doesn't match the client_id")
+		}
+
+		var err error
+		user, err = getServiceAccount(subject)
+		if err != nil {
+			return nil, err
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		credential = GetServiceAccountCredential(fmt.Sprintf("%s/%s", user.Owner, kid))
+		if credential == nil || credential.User != user.Name || credential.Type != ServiceAccountCredentialTypePublicKey {
+			return nil, fmt.Errorf("unknown key id: %s", kid)
+		}
+
+		key, err := parseServiceAccountPublicKey(credential.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS, *jwt.SigningMethodECDSA:
+		default:
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key, nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid assertion: %s", err.Error())
+	}
+
+	if claims.Issuer != claims.Subject {
+		return nil, nil, fmt.Errorf("the issuer of the assertion should be the service account")
+	}
+	origin, _ := websvr.AppConfig.String("origin")
+	if !claims.VerifyAudience(origin, true) && !claims.VerifyAudience(fmt.Sprintf("%s/api/login/oauth/access_token", origin), true) {
+		return nil, nil, fmt.Errorf("the audience of the assertion should be the token endpoint")
+	}
+	if claims.ExpiresAt == nil || time.Until(claims.ExpiresAt.Time) > serviceAccountAssertionMaxSeconds*time.Second {
+		return nil, nil, fmt.Errorf("the assertion should expire within %d seconds", serviceAccountAssertionMaxSeconds)
+	}
+	if claims.ID == "" {
+		return nil, nil, fmt.Errorf("the assertion should have a jti claim")
+	}
+	if !addServiceAccountAssertion(user, claims.ID, claims.ExpiresAt.Unix()) {
+		return nil, nil, fmt.Errorf("the assertion has already been used")
+	}
+
+	return user, credential, nil
+}
+
+// addServiceAccountAssertion records the jti of an assertion of the service account, it returns
+// false when the assertion has already been used
+func addServiceAccountAssertion(user *User, jti string, expireTime int64) bool {
+	// the expired assertions are rejected anyway, so they don't need to be kept
+	_, err := adapter.Engine.Where("expire_time < ?", time.Now().Unix()).Delete(&ServiceAccountAssertion{})
+	if err != nil {
+		panic(err)
+	}
+
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s/%s", user.Name, jti)))
+	assertion := &ServiceAccountAssertion{Owner: user.Owner, Name: hex.EncodeToString(hash[:]), ExpireTime: expireTime}
+
+	// the primary key makes the insert fail when the assertion is used concurrently
+	_, err = adapter.Engine.Insert(assertion)
+	if err != nil {
+		existed, getErr := adapter.Engine.Get(&ServiceAccountAssertion{Owner: assertion.Owner, Name: assertion.Name})
+		if getErr != nil {
+			panic(getErr)
+		}
+		if existed {
+			return false
+		}
+		panic(err)
+	}
+	return true
+}
+
+// GetServiceAccountToken issues an access token to a service account, authenticated by a client
+// secret with the "client_credentials" grant, or by a JWT assertion with the "jwt-bearer" grant.
+// The client_id is the id of the service account, no refresh token is issued.
+func GetServiceAccountToken(grantType string, clientId string, clientSecret string, assertion string, scope string, clientIp string) *TokenWrapper {
+	var user *User
+	var credential *ServiceAccountCredential
+	var err error
+	switch grantType {
+	case GrantTypeClientCredentials:
+		user, err = getServiceAccount(clientId)
+		if err == nil {
+			credential, err = checkServiceAccountSecret(user, clientSecret)
+		}
+	case GrantTypeJwtBearer:
+		user, credential, err = checkServiceAccountAssertion(clientId, assertion)
+	default:
+		err = fmt.Errorf("grant_type should be \"%s\" or \"%s\"", GrantTypeClientCredentials, GrantTypeJwtBearer)
+	}
+	if err != nil {
+		return &TokenWrapper{
+			AccessToken: fmt.Sprintf("error: %s", err.Error()),
+			TokenType:   "",
+			ExpiresIn:   0,
+			Scope:       "",
+		}
+	}
+
+	application := GetApplicationByUser(user)
+	if application == nil {
+		return &TokenWrapper{
+			AccessToken: "error: the service account doesn't belong to any application",
+			TokenType:   "",
+			ExpiresIn:   0,
+			Scope:       "",
+		}
+	}
+
+	touchServiceAccountCredential(credential, clientIp)
+
+	accessToken, _, err := generateJwtToken(application, user, "", scope, nil, "", 0)
+	if err != nil {
+		panic(err)
+	}
+
+	token := &Token{
+		Owner:        application.Owner,
+		Name:         utils.GenerateId(),
+		CreatedTime:  utils.GetCurrentTime(),
+		Application:  application.Name,
+		Organization: user.Owner,
+		User:         user.Name,
+		Code:         utils.GenerateClientId(),
+		AccessToken:  accessToken,
+		ExpiresIn:    application.ExpireInHours * 60,
+		Scope:        scope,
+		TokenType:    "Bearer",
+		CodeIsUsed:   true,
+	}
+	AddToken(token)
+
+	return &TokenWrapper{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		ExpiresIn:   token.ExpiresIn,
+		Scope:       token.Scope,
+	}
+}
@@ -0,0 +1,85 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeServiceAccount(t *testing.T) {
+	user := &User{Type: "normal-user", Password: "123", IsGlobalAdmin: true}
+	normalizeServiceAccount(user)
+	if user.Password != "123" || !user.IsGlobalAdmin {
+		t.Errorf("a normal user shouldn't be changed")
+	}
+
+	serviceAccount := &User{Type: UserTypeServiceAccount, Password: "123", IsGlobalAdmin: true, IsAdmin: true}
+	normalizeServiceAccount(serviceAccount)
+	if serviceAccount.Password != "" || serviceAccount.IsGlobalAdmin {
+		t.Errorf("a service account can't have a password or be a global admin")
+	}
+	if !serviceAccount.IsAdmin {
+		t.Errorf("a service account can be an admin of its organization")
+	}
+}
+
+func getPublicKeyPem(t *testing.T, key interface{}) string {
+	b, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: b}))
+}
+
+func TestParseServiceAccountPublicKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := parseServiceAccountPublicKey(getPublicKeyPem(t, &rsaKey.PublicKey)); err != nil {
+		t.Errorf("the RSA public key should be accepted: %v", err)
+	}
+	if _, err := parseServiceAccountPublicKey(getPublicKeyPem(t, &ecKey.PublicKey)); err != nil {
+		t.Errorf("the ECDSA public key should be accepted: %v", err)
+	}
+	if _, err := parseServiceAccountPublicKey("not a key"); err == nil {
+		t.Errorf("an invalid public key should be rejected")
+	}
+}
+
+func TestGetServiceAccountTokenGrantType(t *testing.T) {
+	tokenWrapper := GetServiceAccountToken("password", "example/robot", "secret", "", "", "127.0.0.1")
+	if !strings.HasPrefix(tokenWrapper.AccessToken, "error: grant_type") {
+		t.Errorf("unexpected response for an unsupported grant type: %s", tokenWrapper.AccessToken)
+	}
+}
@@ -131,6 +131,10 @@ func (syncer *Syncer) updateUserForOriginalFields(user *User) (bool, error) {
 		return false, err
 	}
 
+	if affected != 0 {
+		provisionUser(oldUser)
+	}
+
 	return affected != 0, nil
 }
 
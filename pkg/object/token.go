@@ -210,7 +210,7 @@ func CheckOAuthLogin(clientId string, responseType string, redirectUri string, s
 	return "", application
 }
 
-func GetOAuthCode(userId string, clientId string, responseType string, redirectUri string, scope string, state string, nonce string, challenge string) *Code {
+func GetOAuthCode(userId string, clientId string, responseType string, redirectUri string, scope string, state string, nonce string, challenge string, amr []string, actor string, actorExpireTime int64) *Code {
 	user := GetUser(userId)
 	if user == nil {
 		return &Code{
@@ -233,7 +233,7 @@ func GetOAuthCode(userId string, clientId string, responseType string, redirectU
 		}
 	}
 
-	accessToken, refreshToken, err := generateJwtToken(application, user, nonce, scope)
+	accessToken, refreshToken, err := generateJwtToken(application, user, nonce, scope, amr, actor, actorExpireTime)
 	if err != nil {
 		panic(err)
 	}
@@ -242,6 +242,18 @@ func GetOAuthCode(userId string, clientId string, responseType string, redirectU
 		challenge = ""
 	}
 
+	expiresIn := application.ExpireInHours * 60
+	if getActorClaim(actor) != nil {
+		// the minutes left in the impersonation
+		remaining := (actorExpireTime - time.Now().Unix()) / 60
+		if remaining < 0 {
+			remaining = 0
+		}
+		if int64(expiresIn) > remaining {
+			expiresIn = int(remaining)
+		}
+	}
+
 	token := &Token{
 		Owner:         application.Owner,
 		Name:          utils.GenerateId(),
@@ -252,7 +264,7 @@ func GetOAuthCode(userId string, clientId string, responseType string, redirectU
 		Code:          utils.GenerateClientId(),
 		AccessToken:   accessToken,
 		RefreshToken:  refreshToken,
-		ExpiresIn:     application.ExpireInHours * 60,
+		ExpiresIn:     expiresIn,
 		Scope:         scope,
 		TokenType:     "Bearer",
 		CodeChallenge: challenge,
@@ -406,7 +418,7 @@ func RefreshToken(grantType string, refreshToken string, scope string, clientId
 	}
 
 	cert := getCertByApplication(application)
-	_, err = ParseJwtToken(refreshToken, cert)
+	claims, err := ParseJwtToken(refreshToken, cert)
 	if err != nil {
 		return &TokenWrapper{
 			AccessToken: fmt.Sprintf("error: %s", err.Error()),
@@ -415,6 +427,15 @@ func RefreshToken(grantType string, refreshToken string, scope string, clientId
 			Scope:       "",
 		}
 	}
+	// the tokens of an impersonation can't be extended beyond it
+	if claims.Act != nil {
+		return &TokenWrapper{
+			AccessToken: "error: the tokens of an impersonation can't be refreshed",
+			TokenType:   "",
+			ExpiresIn:   0,
+			Scope:       "",
+		}
+	}
 	// generate a new token
 	user := getUser(application.Organization, token.User)
 	if user.IsForbidden {
@@ -425,7 +446,7 @@ func RefreshToken(grantType string, refreshToken string, scope string, clientId
 			Scope:       "",
 		}
 	}
-	newAccessToken, newRefreshToken, err := generateJwtToken(application, user, "", scope)
+	newAccessToken, newRefreshToken, err := generateJwtToken(application, user, "", scope, claims.Amr, "", 0)
 	if err != nil {
 		panic(err)
 	}
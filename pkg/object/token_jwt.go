@@ -31,9 +31,11 @@ import (
 
 type Claims struct {
 	*User
-	Nonce string `json:"nonce,omitempty"`
-	Tag   string `json:"tag,omitempty"`
-	Scope string `json:"scope,omitempty"`
+	Nonce string      `json:"nonce,omitempty"`
+	Tag   string      `json:"tag,omitempty"`
+	Scope string      `json:"scope,omitempty"`
+	Amr   []string    `json:"amr,omitempty"`
+	Act   *ActorClaim `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -44,8 +46,10 @@ type UserShort struct {
 
 type ClaimsShort struct {
 	*UserShort
-	Nonce string `json:"nonce,omitempty"`
-	Scope string `json:"scope,omitempty"`
+	Nonce string      `json:"nonce,omitempty"`
+	Scope string      `json:"scope,omitempty"`
+	Amr   []string    `json:"amr,omitempty"`
+	Act   *ActorClaim `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -62,17 +66,45 @@ func getShortClaims(claims Claims) ClaimsShort {
 		UserShort:        getShortUser(claims.User),
 		Nonce:            claims.Nonce,
 		Scope:            claims.Scope,
+		Amr:              claims.Amr,
+		Act:              claims.Act,
 		RegisteredClaims: claims.RegisteredClaims,
 	}
 	return res
 }
 
-func generateJwtToken(application *Application, user *User, nonce string, scope string) (string, string, error) {
+// removeUserSecrets clears what must not be in the claims: the credentials of the user and the
+// state of its password and lockout
+func removeUserSecrets(user *User) {
+	user.Password = ""
+	user.PasswordHistory = nil
+	user.PasswordChangedTime = ""
+	user.TotpSecret = ""
+	user.RecoveryCodes = nil
+	user.WebauthnCredentials = nil
+	user.SigninWrongTimes = 0
+	user.LastSigninWrongTime = ""
+}
+
+// generateJwtToken issues the tokens of the user, amr is the list of the authentication
+// methods used to sign in (RFC 8176). actor is the user impersonating the user if any, the
+// tokens of an impersonation don't outlive it, which ends at actorExpireTime (in Unix seconds).
+func generateJwtToken(application *Application, user *User, nonce string, scope string, amr []string, actor string, actorExpireTime int64) (string, string, error) {
 	nowTime := time.Now()
 	expireTime := nowTime.Add(time.Duration(application.ExpireInHours) * time.Hour)
 	refreshExpireTime := nowTime.Add(time.Duration(application.RefreshExpireInHours) * time.Hour)
+	act := getActorClaim(actor)
+	if act != nil {
+		impersonationExpireTime := time.Unix(actorExpireTime, 0)
+		if expireTime.After(impersonationExpireTime) {
+			expireTime = impersonationExpireTime
+		}
+		if refreshExpireTime.After(impersonationExpireTime) {
+			refreshExpireTime = impersonationExpireTime
+		}
+	}
 
-	user.Password = ""
+	removeUserSecrets(user)
 
 	origin, err := websvr.AppConfig.String("origin")
 	claims := Claims{
@@ -81,6 +113,8 @@ func generateJwtToken(application *Application, user *User, nonce string, scope
 		// FIXME: A workaround for custom claim by reusing `tag` in user info
 		Tag:   user.Tag,
 		Scope: scope,
+		Amr:   amr,
+		Act:   act,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    origin,
 			Subject:   user.Id,
@@ -0,0 +1,79 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func TestRemoveUserSecrets(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &User{
+		Owner:               "built-in",
+		Name:                "alice",
+		Password:            "123",
+		PasswordHistory:     []string{"hash1", "hash2"},
+		PasswordChangedTime: "2022-01-01T00:00:00+08:00",
+		TotpSecret:          "JBSWY3DPEHPK3PXP",
+		RecoveryCodes:       []string{"hash3"},
+		SigninWrongTimes:    3,
+		LastSigninWrongTime: "2022-01-01T00:00:00+08:00",
+	}
+	removeUserSecrets(user)
+
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodRS256, Claims{User: user}).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return &key.PublicKey, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims["name"] != "alice" {
+		t.Errorf("the claims should have the user: %v", claims)
+	}
+	for _, field := range []string{"passwordHistory", "passwordChangedTime", "signinWrongTimes", "lastSigninWrongTime"} {
+		if _, ok := claims[field]; ok {
+			t.Errorf("the claims should not have %s: %v", field, claims[field])
+		}
+	}
+	for _, field := range []string{"password", "totpSecret"} {
+		if claims[field] != "" {
+			t.Errorf("the claims should not have %s: %v", field, claims[field])
+		}
+	}
+	if claims["recoveryCodes"] != nil {
+		t.Errorf("the claims should not have recoveryCodes: %v", claims["recoveryCodes"])
+	}
+}
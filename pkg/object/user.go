@@ -35,8 +35,9 @@ type User struct {
 	UpdatedTime string `orm:"varchar(100)" json:"updatedTime"`
 
 	Id                string   `orm:"varchar(100) index" json:"id"`
+	ExternalId        string   `orm:"varchar(100) index" json:"externalId"`
 	Type              string   `orm:"varchar(100)" json:"type"`
-	Password          string   `orm:"varchar(100)" json:"password"`
+	Password          string   `orm:"varchar(1000)" json:"password"`
 	PasswordSalt      string   `orm:"varchar(100)" json:"passwordSalt"`
 	DisplayName       string   `orm:"varchar(100)" json:"displayName"`
 	Avatar            string   `orm:"varchar(500)" json:"avatar"`
@@ -73,24 +74,23 @@ type User struct {
 	LastSigninTime string `orm:"varchar(100)" json:"lastSigninTime"`
 	LastSigninIp   string `orm:"varchar(100)" json:"lastSigninIp"`
 
-	Github   string `orm:"varchar(100)" json:"github"`
-	Google   string `orm:"varchar(100)" json:"google"`
-	QQ       string `orm:"qq varchar(100)" json:"qq"`
-	WeChat   string `orm:"wechat varchar(100)" json:"wechat"`
-	Facebook string `orm:"facebook varchar(100)" json:"facebook"`
-	DingTalk string `orm:"dingtalk varchar(100)" json:"dingtalk"`
-	Weibo    string `orm:"weibo varchar(100)" json:"weibo"`
-	Gitee    string `orm:"gitee varchar(100)" json:"gitee"`
-	LinkedIn string `orm:"linkedin varchar(100)" json:"linkedin"`
-	Wecom    string `orm:"wecom varchar(100)" json:"wecom"`
-	Lark     string `orm:"lark varchar(100)" json:"lark"`
-	Gitlab   string `orm:"gitlab varchar(100)" json:"gitlab"`
-	Apple    string `orm:"apple varchar(100)" json:"apple"`
-	AzureAD  string `orm:"azuread varchar(100)" json:"azuread"`
-	Slack    string `orm:"slack varchar(100)" json:"slack"`
+	SigninWrongTimes    int    `json:"signinWrongTimes,omitempty"`
+	LastSigninWrongTime string `orm:"varchar(100)" json:"lastSigninWrongTime,omitempty"`
+	SessionsRevokedTime int64  `json:"sessionsRevokedTime"`
+
+	PasswordHistory     []string `orm:"text" json:"passwordHistory,omitempty"`
+	PasswordChangedTime string   `orm:"varchar(100)" json:"passwordChangedTime,omitempty"`
+
+	TotpSecret    string   `orm:"varchar(200)" json:"totpSecret"`
+	TotpCounter   int64    `json:"totpCounter"`
+	RecoveryCodes []string `orm:"varchar(1000)" json:"recoveryCodes"`
+
+	WebauthnCredentials []*WebauthnCredential `orm:"mediumtext" json:"webauthnCredentials"`
 
 	Ldap       string            `orm:"ldap varchar(100)" json:"ldap"`
 	Properties map[string]string `json:"properties"`
+
+	FederatedIdentities []*FederatedIdentity `orm:"-" json:"federatedIdentities"`
 }
 
 func GetGlobalUserCount(field, value string) int {
@@ -252,6 +252,15 @@ func GetMaskedUser(user *User) *User {
 	if user.Password != "" {
 		user.Password = "***"
 	}
+	if user.TotpSecret != "" {
+		user.TotpSecret = "***"
+	}
+	for i := range user.RecoveryCodes {
+		user.RecoveryCodes[i] = "***"
+	}
+	for i := range user.PasswordHistory {
+		user.PasswordHistory[i] = "***"
+	}
 	return user
 }
 
@@ -283,6 +292,9 @@ func UpdateUser(id string, user *User, columns []string, isGlobalAdmin bool) boo
 		return false
 	}
 
+	// the type of a user isn't updated
+	user.Type = oldUser.Type
+	normalizeServiceAccount(user)
 	user.UpdateUserHash()
 
 	if user.Avatar != oldUser.Avatar && user.Avatar != "" && user.PermanentAvatar != "*" {
@@ -303,6 +315,14 @@ func UpdateUser(id string, user *User, columns []string, isGlobalAdmin bool) boo
 		panic(err)
 	}
 
+	if affected != 0 && isGlobalAdmin && user.Name != "" && user.Name != name {
+		renameFederatedIdentitiesUser(owner, name, user.Name)
+	}
+
+	if affected != 0 {
+		provisionUpdatedUser(oldUser, user)
+	}
+
 	return affected != 0
 }
 
@@ -313,6 +333,7 @@ func UpdateUserForAllFields(id string, user *User) bool {
 		return false
 	}
 
+	normalizeServiceAccount(user)
 	user.UpdateUserHash()
 
 	if user.Avatar != oldUser.Avatar && user.Avatar != "" {
@@ -324,6 +345,10 @@ func UpdateUserForAllFields(id string, user *User) bool {
 		panic(err)
 	}
 
+	if affected != 0 {
+		provisionUpdatedUser(oldUser, user)
+	}
+
 	return affected != 0
 }
 
@@ -338,6 +363,8 @@ func AddUser(user *User) bool {
 
 	organization := GetOrganizationByUser(user)
 	user.UpdateUserPassword(organization)
+	user.PasswordChangedTime = utils.GetCurrentTime()
+	normalizeServiceAccount(user)
 
 	user.UpdateUserHash()
 	user.PreHash = user.Hash
@@ -349,6 +376,10 @@ func AddUser(user *User) bool {
 		panic(err)
 	}
 
+	if affected != 0 {
+		provisionUser(user)
+	}
+
 	return affected != 0
 }
 
@@ -361,6 +392,7 @@ func AddUsers(users []*User) bool {
 	for _, user := range users {
 		// this function is only used for syncer or batch upload, so no need to encrypt the password
 		//user.UpdateUserPassword(organization)
+		normalizeServiceAccount(user)
 
 		user.UpdateUserHash()
 		user.PreHash = user.Hash
@@ -373,6 +405,12 @@ func AddUsers(users []*User) bool {
 		panic(err)
 	}
 
+	if affected != 0 {
+		for _, user := range users {
+			provisionUser(user)
+		}
+	}
+
 	return affected != 0
 }
 
@@ -408,11 +446,12 @@ func DeleteUser(user *User) bool {
 		panic(err)
 	}
 
-	return affected != 0
-}
+	if affected != 0 {
+		deleteFederatedIdentitiesByUser(user.Owner, user.Name)
+		provisionUser(user)
+	}
 
-func LinkUserAccount(user *User, field string, value string) bool {
-	return SetUserField(user, field, value)
+	return affected != 0
 }
 
 func (user *User) GetId() string {
@@ -20,7 +20,10 @@ package object
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
 
-import "github.com/bhojpur/iam/pkg/cred"
+import (
+	"github.com/bhojpur/dbm/pkg/core"
+	"github.com/bhojpur/iam/pkg/cred"
+)
 
 func calculateHash(user *User) string {
 	syncer := getDbSyncerForUser(user)
@@ -43,3 +46,32 @@ func (user *User) UpdateUserPassword(organization *Organization) {
 		user.Password = hashedPassword
 	}
 }
+
+// getPasswordTypeByHash returns the password type of the hash: its own scheme for the hashes
+// that describe it, such as the ones of migrated users, or the type of the organization
+func getPasswordTypeByHash(organization *Organization, hash string) string {
+	if passwordType := cred.GetPasswordType(hash); passwordType != "" {
+		return passwordType
+	}
+	return organization.PasswordType
+}
+
+// rehashUserPassword replaces the hash of the password, already checked by the caller, with a
+// hash of the current password type of the organization when the stored one is weaker
+func rehashUserPassword(organization *Organization, user *User, password string, passwordType string) {
+	if !cred.NeedsRehash(passwordType, user.Password, organization.PasswordType) {
+		return
+	}
+
+	credManager := cred.GetCredManager(organization.PasswordType)
+	hashedPassword := credManager.GetHashedPassword(password, user.PasswordSalt, organization.PasswordSalt)
+	if hashedPassword == "" {
+		return
+	}
+
+	user.Password = hashedPassword
+	_, err := adapter.Engine.ID(core.PK{user.Owner, user.Name}).Cols("password").Update(user)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,184 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/dbm/pkg/core"
+	"github.com/bhojpur/iam/pkg/utils"
+	websvr "github.com/bhojpur/web/pkg/engine"
+)
+
+// userSessionTouchSeconds is how often the last seen time of a session is updated
+const userSessionTouchSeconds = 60
+
+// UserSession is the record of a sign-in to the web session of a browser. The id of the record
+// is kept in the web session, which is signed out by the router once the record is deleted.
+type UserSession struct {
+	Owner       string `orm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `orm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `orm:"varchar(100)" json:"createdTime"`
+
+	User         string `orm:"varchar(100) index" json:"user"`
+	Application  string `orm:"varchar(100)" json:"application"`
+	RemoteAddr   string `orm:"varchar(100)" json:"remoteAddr"`
+	UserAgent    string `orm:"varchar(500)" json:"userAgent"`
+	Amr          string `orm:"varchar(100)" json:"amr"`
+	Impersonator string `orm:"varchar(100)" json:"impersonator"`
+	LastSeenTime string `orm:"varchar(100)" json:"lastSeenTime"`
+
+	IsCurrent bool `orm:"-" json:"isCurrent"`
+}
+
+func (session *UserSession) GetId() string {
+	return fmt.Sprintf("%s/%s", session.Owner, session.Name)
+}
+
+// getUserSessionIdleTime returns the oldest last seen time of the sessions that may still be
+// alive, the web sessions are garbage collected after being idle for longer
+func getUserSessionIdleTime() string {
+	lifetime := websvr.BConfig.WebConfig.Session.SessionGCMaxLifetime
+	return time.Now().Add(-time.Duration(lifetime) * time.Second).Format(time.RFC3339)
+}
+
+// AddUserSession records a sign-in of the user and returns the id of the record, impersonator
+// is the admin impersonating the user if any
+func AddUserSession(user *User, application string, remoteAddr string, userAgent string, amr []string, impersonator string) string {
+	now := utils.GetCurrentTime()
+	if len(userAgent) > 500 {
+		userAgent = userAgent[:500]
+	}
+
+	session := &UserSession{
+		Owner:        user.Owner,
+		Name:         utils.GenerateId(),
+		CreatedTime:  now,
+		User:         user.Name,
+		Application:  application,
+		RemoteAddr:   remoteAddr,
+		UserAgent:    userAgent,
+		Amr:          strings.Join(amr, ","),
+		Impersonator: impersonator,
+		LastSeenTime: now,
+	}
+	_, err := adapter.Engine.Insert(session)
+	if err != nil {
+		panic(err)
+	}
+
+	return session.GetId()
+}
+
+func GetUserSession(id string) *UserSession {
+	if !strings.Contains(id, "/") {
+		return nil
+	}
+
+	owner, name := utils.GetOwnerAndNameFromId(id)
+	session := UserSession{Owner: owner, Name: name}
+	existed, err := adapter.Engine.Get(&session)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &session
+	}
+	return nil
+}
+
+// GetUserSessions returns the sessions of the user that may still be alive, the latest first
+func GetUserSessions(user *User) []*UserSession {
+	deleteIdleUserSessions()
+
+	sessions := []*UserSession{}
+	err := adapter.Engine.Desc("last_seen_time").Find(&sessions, &UserSession{Owner: user.Owner, User: user.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	return sessions
+}
+
+// TouchUserSession checks that the session hasn't been revoked, and updates its last seen time
+// and address
+func TouchUserSession(id string, remoteAddr string) bool {
+	session := GetUserSession(id)
+	if session == nil {
+		return false
+	}
+
+	lastSeenTime, err := time.Parse(time.RFC3339, session.LastSeenTime)
+	if err == nil && time.Since(lastSeenTime) < userSessionTouchSeconds*time.Second && session.RemoteAddr == remoteAddr {
+		return true
+	}
+
+	session.LastSeenTime = utils.GetCurrentTime()
+	session.RemoteAddr = remoteAddr
+	_, err = adapter.Engine.ID(core.PK{session.Owner, session.Name}).Cols("last_seen_time", "remote_addr").Update(session)
+	if err != nil {
+		panic(err)
+	}
+
+	return true
+}
+
+// DeleteUserSession revokes a session, it is signed out on its next request
+func DeleteUserSession(id string) bool {
+	if !strings.Contains(id, "/") {
+		return false
+	}
+
+	owner, name := utils.GetOwnerAndNameFromId(id)
+	affected, err := adapter.Engine.Delete(&UserSession{Owner: owner, Name: name})
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+// DeleteUserSessions revokes all the sessions of the user, including the ones that started
+// before the sessions were recorded
+func DeleteUserSessions(user *User) bool {
+	affected, err := adapter.Engine.Delete(&UserSession{Owner: user.Owner, User: user.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	user.SessionsRevokedTime = time.Now().Unix()
+	_, err = adapter.Engine.ID(core.PK{user.Owner, user.Name}).Cols("sessions_revoked_time").Update(user)
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func deleteIdleUserSessions() {
+	_, err := adapter.Engine.Where("last_seen_time < ?", getUserSessionIdleTime()).Delete(&UserSession{})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -43,12 +43,15 @@ func TestSyncAvatarsFromGitHub(t *testing.T) {
 
 	users := GetGlobalUsers()
 	for _, user := range users {
-		if user.Github == "" {
-			continue
-		}
+		for _, identity := range GetFederatedIdentities(user.Owner, user.Name) {
+			if identity.ProviderType != "GitHub" || identity.Username == "" {
+				continue
+			}
 
-		user.Avatar = fmt.Sprintf("https://avatars.githubusercontent.com/%s", user.Github)
-		updateUserColumn("avatar", user)
+			user.Avatar = fmt.Sprintf("https://avatars.githubusercontent.com/%s", identity.Username)
+			updateUserColumn("avatar", user)
+			break
+		}
 	}
 }
 
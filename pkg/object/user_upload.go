@@ -21,6 +21,8 @@ package object
 // THE SOFTWARE.
 
 import (
+	"fmt"
+
 	"github.com/bhojpur/iam/pkg/utils"
 	"github.com/bhojpur/iam/pkg/xlsx"
 )
@@ -53,7 +55,10 @@ func parseLineItemBool(line *[]string, i int) bool {
 	return parseLineItemInt(line, i) != 0
 }
 
-func UploadUsers(owner string, fileId string) bool {
+// UploadUsers imports the new users of the file. The passwords of an organization with the
+// "plain" password type are checked against its password policy, the other ones are already
+// hashed in the file.
+func UploadUsers(owner string, fileId string) (bool, string) {
 	table := xlsx.ReadXlsxFile(fileId)
 
 	oldUserMap := getUserMap(owner)
@@ -113,8 +118,23 @@ func UploadUsers(owner string, fileId string) bool {
 		}
 	}
 
+	organizations := map[string]*Organization{}
+	for _, user := range newUsers {
+		organization, ok := organizations[user.Owner]
+		if !ok {
+			organization = GetOrganizationByUser(user)
+			organizations[user.Owner] = organization
+		}
+
+		if organization != nil && organization.PasswordType == "plain" && user.Password != "" {
+			if violations := CheckPasswordPolicy(organization, nil, user.Password); len(violations) != 0 {
+				return false, fmt.Sprintf("the password of user %s: %s", user.GetId(), GetPasswordViolationMsg(violations))
+			}
+		}
+	}
+
 	if len(newUsers) == 0 {
-		return false
+		return false, ""
 	}
-	return AddUsersInBatch(newUsers)
+	return AddUsersInBatch(newUsers), ""
 }
@@ -22,8 +22,6 @@ package object
 
 import (
 	"fmt"
-	"reflect"
-	"strings"
 
 	"github.com/bhojpur/dbm/pkg/core"
 	"github.com/bhojpur/iam/pkg/idp"
@@ -82,6 +80,9 @@ func GetUserByFields(organization string, field string) *User {
 func SetUserField(user *User, field string, value string) bool {
 	if field == "password" {
 		organization := GetOrganizationByUser(user)
+		if oldUser := getUser(user.Owner, user.Name); oldUser != nil && organization != nil {
+			updateUserPasswordHistory(organization, user, oldUser.Password, oldUser.PasswordHistory)
+		}
 		user.UpdateUserPassword(organization)
 		value = user.Password
 	}
@@ -98,70 +99,49 @@ func SetUserField(user *User, field string, value string) bool {
 		panic(err)
 	}
 
+	if affected != 0 {
+		provisionUser(user)
+	}
+
 	return affected != 0
 }
 
-func GetUserField(user *User, field string) string {
-	u := reflect.ValueOf(user)
-	f := reflect.Indirect(u).FieldByName(field)
-	return f.String()
+// RevokeUserSessions signs the user out everywhere: its tokens, personal access tokens and
+// sessions are deleted, and the sessions that started before now are rejected by the router
+func RevokeUserSessions(user *User) {
+	_, err := adapter.Engine.Delete(&Token{Organization: user.Owner, User: user.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	deleteUserPersonalAccessTokens(user)
+	DeleteUserSessions(user)
 }
 
-func setUserProperty(user *User, field string, value string) {
-	if value == "" {
-		delete(user.Properties, field)
-	} else {
-		user.Properties[field] = value
+// IsUserSessionRevoked checks whether the session of the user that started at loginTime has
+// been revoked. A revocation in the same second as the login revokes the session too.
+func IsUserSessionRevoked(userId string, loginTime int64) bool {
+	user := GetUser(userId)
+	if user == nil || user.SessionsRevokedTime == 0 {
+		return false
 	}
+
+	return loginTime <= user.SessionsRevokedTime
 }
 
-func SetUserOAuthProperties(organization *Organization, user *User, providerType string, userInfo *idp.UserInfo) bool {
-	if userInfo.Id != "" {
-		propertyName := fmt.Sprintf("oauth_%s_id", providerType)
-		setUserProperty(user, propertyName, userInfo.Id)
-	}
-	if userInfo.Username != "" {
-		propertyName := fmt.Sprintf("oauth_%s_username", providerType)
-		setUserProperty(user, propertyName, userInfo.Username)
-	}
-	if userInfo.DisplayName != "" {
-		propertyName := fmt.Sprintf("oauth_%s_displayName", providerType)
-		setUserProperty(user, propertyName, userInfo.DisplayName)
-		if user.DisplayName == "" {
-			user.DisplayName = userInfo.DisplayName
-		}
+// SyncUserFromUserInfo fills the empty profile fields of the user with the ones from the
+// upstream identity provider
+func SyncUserFromUserInfo(organization *Organization, user *User, userInfo *idp.UserInfo) bool {
+	if userInfo.DisplayName != "" && user.DisplayName == "" {
+		user.DisplayName = userInfo.DisplayName
 	}
-	if userInfo.Email != "" {
-		propertyName := fmt.Sprintf("oauth_%s_email", providerType)
-		setUserProperty(user, propertyName, userInfo.Email)
-		if user.Email == "" {
-			user.Email = userInfo.Email
-		}
+	if userInfo.Email != "" && user.Email == "" {
+		user.Email = userInfo.Email
 	}
-	if userInfo.AvatarUrl != "" {
-		propertyName := fmt.Sprintf("oauth_%s_avatarUrl", providerType)
-		setUserProperty(user, propertyName, userInfo.AvatarUrl)
-		if user.Avatar == "" || user.Avatar == organization.DefaultAvatar {
-			user.Avatar = userInfo.AvatarUrl
-		}
+	if userInfo.AvatarUrl != "" && (user.Avatar == "" || user.Avatar == organization.DefaultAvatar) {
+		user.Avatar = userInfo.AvatarUrl
 	}
 
 	affected := UpdateUserForAllFields(user.GetId(), user)
 	return affected
 }
-
-func ClearUserOAuthProperties(user *User, providerType string) bool {
-	for k := range user.Properties {
-		prefix := fmt.Sprintf("oauth_%s_", providerType)
-		if strings.HasPrefix(k, prefix) {
-			delete(user.Properties, k)
-		}
-	}
-
-	affected, err := adapter.Engine.ID(core.PK{user.Owner, user.Name}).Cols("properties").Update(user)
-	if err != nil {
-		panic(err)
-	}
-
-	return affected != 0
-}
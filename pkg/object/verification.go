@@ -21,9 +21,11 @@ package object
 // THE SOFTWARE.
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
 	"errors"
 	"fmt"
-	"math/rand"
+	"math/big"
 	"time"
 
 	"github.com/bhojpur/dbm/pkg/core"
@@ -31,19 +33,73 @@ import (
 	websvr "github.com/bhojpur/web/pkg/engine"
 )
 
+const (
+	defaultVerificationCodeLength = 6
+	minVerificationCodeLength     = 4
+	maxVerificationCodeLength     = 10
+
+	verificationCodeInterval = 60
+)
+
 type VerificationRecord struct {
 	Owner       string `orm:"varchar(100) notnull pk" json:"owner"`
 	Name        string `orm:"varchar(100) notnull pk" json:"name"`
 	CreatedTime string `orm:"varchar(100)" json:"createdTime"`
 
-	RemoteAddr string `orm:"varchar(100)"`
-	Type       string `orm:"varchar(10)"`
-	User       string `orm:"varchar(100) notnull"`
-	Provider   string `orm:"varchar(100) notnull"`
-	Receiver   string `orm:"varchar(100) notnull"`
-	Code       string `orm:"varchar(10) notnull"`
-	Time       int64  `orm:"notnull"`
-	IsUsed     bool
+	RemoteAddr  string `orm:"varchar(100)"`
+	Type        string `orm:"varchar(10)"`
+	User        string `orm:"varchar(100) notnull"`
+	Provider    string `orm:"varchar(100) notnull"`
+	Receiver    string `orm:"varchar(100) notnull"`
+	Code        string `orm:"varchar(100) notnull"`
+	Time        int64  `orm:"notnull"`
+	ExpireTime  int64
+	FailedTimes int
+	IsUsed      bool
+}
+
+var (
+	verificationCodeTimeout     int64
+	verificationCodeMaxAttempts int
+	verificationCodeHourlyLimit int64
+)
+
+func init() {
+	var err error
+	verificationCodeTimeout, err = websvr.AppConfig.Int64("verificationCodeTimeout")
+	if err != nil || verificationCodeTimeout <= 0 {
+		verificationCodeTimeout = 10
+	}
+	verificationCodeMaxAttempts, err = websvr.AppConfig.Int("verificationCodeMaxAttempts")
+	if err != nil || verificationCodeMaxAttempts <= 0 {
+		verificationCodeMaxAttempts = 5
+	}
+	verificationCodeHourlyLimit, err = websvr.AppConfig.Int64("verificationCodeHourlyLimit")
+	if err != nil || verificationCodeHourlyLimit <= 0 {
+		verificationCodeHourlyLimit = 20
+	}
+}
+
+// GetVerificationCodeLength returns the length of the codes sent by the provider
+func (provider *Provider) GetVerificationCodeLength() int {
+	if provider.CodeLength <= 0 {
+		return defaultVerificationCodeLength
+	}
+	if provider.CodeLength < minVerificationCodeLength {
+		return minVerificationCodeLength
+	}
+	if provider.CodeLength > maxVerificationCodeLength {
+		return maxVerificationCodeLength
+	}
+	return provider.CodeLength
+}
+
+// GetVerificationCodeTimeout returns the minutes the codes sent by the provider can be used in
+func (provider *Provider) GetVerificationCodeTimeout() int64 {
+	if provider.CodeTimeout <= 0 {
+		return verificationCodeTimeout
+	}
+	return int64(provider.CodeTimeout)
 }
 
 func SendVerificationCodeToEmail(organization *Organization, user *User, provider *Provider, remoteAddr string, dest string) error {
@@ -53,7 +109,7 @@ func SendVerificationCodeToEmail(organization *Organization, user *User, provide
 
 	sender := organization.DisplayName
 	title := provider.Title
-	code := getRandomCode(5)
+	code := getRandomCode(provider.GetVerificationCodeLength())
 	// "You have requested a verification code at Bhojpur IAM. Here is your code: %s, please enter in 5 minutes."
 	content := fmt.Sprintf(provider.Content, code)
 
@@ -69,7 +125,7 @@ func SendVerificationCodeToPhone(organization *Organization, user *User, provide
 		return errors.New("Please set a SMS provider first")
 	}
 
-	code := getRandomCode(5)
+	code := getRandomCode(provider.GetVerificationCodeLength())
 	if err := AddToVerificationRecord(user, provider, remoteAddr, provider.Category, dest, code); err != nil {
 		return err
 	}
@@ -77,23 +133,45 @@ func SendVerificationCodeToPhone(organization *Organization, user *User, provide
 	return SendSms(provider, code, dest)
 }
 
-func AddToVerificationRecord(user *User, provider *Provider, remoteAddr, recordType, dest, code string) error {
+// getVerificationCodeHash hashes the code with the name of its record, the code itself is
+// never stored
+func getVerificationCodeHash(record *VerificationRecord, code string) string {
+	return utils.GetSha256Hash(fmt.Sprintf("%s|%s", record.Name, code))
+}
+
+// checkVerificationCodeRate allows one code in a minute to the same receiver, and a limited
+// number of codes in an hour from the same address, whatever the receivers
+func checkVerificationCodeRate(remoteAddr string, dest string, now int64) error {
 	var record VerificationRecord
-	record.RemoteAddr = remoteAddr
-	record.Type = recordType
-	if user != nil {
-		record.User = user.GetId()
+	record.Receiver = dest
+	has, err := adapter.Engine.Desc("time").Get(&record)
+	if err != nil {
+		return err
+	}
+	if has && now-record.Time < verificationCodeInterval {
+		return fmt.Errorf("You can only send one code in %ds.", verificationCodeInterval)
 	}
-	has, err := adapter.Engine.Desc("created_time").Get(&record)
+
+	count, err := adapter.Engine.Where("remote_addr = ? and time > ?", remoteAddr, now-3600).Count(&VerificationRecord{})
 	if err != nil {
 		return err
 	}
+	if count >= verificationCodeHourlyLimit {
+		return errors.New("Too many codes have been sent, please try again later.")
+	}
+
+	return nil
+}
 
+func AddToVerificationRecord(user *User, provider *Provider, remoteAddr, recordType, dest, code string) error {
 	now := time.Now().Unix()
-	if has && now-record.Time < 60 {
-		return errors.New("You can only send one code in 60s.")
+	if err := checkVerificationCodeRate(remoteAddr, dest, now); err != nil {
+		return err
 	}
 
+	var record VerificationRecord
+	record.RemoteAddr = remoteAddr
+	record.Type = recordType
 	record.Owner = provider.Owner
 	record.Name = utils.GenerateId()
 	record.CreatedTime = utils.GetCurrentTime()
@@ -103,10 +181,17 @@ func AddToVerificationRecord(user *User, provider *Provider, remoteAddr, recordT
 	record.Provider = provider.Name
 
 	record.Receiver = dest
-	record.Code = code
+	record.Code = getVerificationCodeHash(&record, code)
 	record.Time = now
+	record.ExpireTime = now + provider.GetVerificationCodeTimeout()*60
 	record.IsUsed = false
 
+	// only the last code sent to the receiver can be used
+	_, err := adapter.Engine.Where("receiver = ? and is_used = ?", dest, false).Cols("is_used").Update(&VerificationRecord{IsUsed: true})
+	if err != nil {
+		return err
+	}
+
 	_, err = adapter.Engine.Insert(record)
 	if err != nil {
 		return err
@@ -128,6 +213,8 @@ func getVerificationRecord(dest string) *VerificationRecord {
 	return &record
 }
 
+// CheckVerificationCode checks the last code sent to dest. The code is invalidated after too
+// many wrong attempts, a new one must then be requested.
 func CheckVerificationCode(dest, code string) string {
 	record := getVerificationRecord(dest)
 
@@ -135,17 +222,28 @@ func CheckVerificationCode(dest, code string) string {
 		return "Code has not been sent yet!"
 	}
 
-	timeout, err := websvr.AppConfig.Int64("verificationCodeTimeout")
-	if err != nil {
-		panic(err)
+	// the records sent before the expiry was stored use the global timeout
+	expireTime := record.ExpireTime
+	if expireTime == 0 {
+		expireTime = record.Time + verificationCodeTimeout*60
 	}
-
-	now := time.Now().Unix()
-	if now-record.Time > timeout*60 {
-		return fmt.Sprintf("You should verify your code in %d min!", timeout)
+	if time.Now().Unix() > expireTime {
+		return fmt.Sprintf("You should verify your code in %d min!", (expireTime-record.Time)/60)
 	}
 
-	if record.Code != code {
+	if subtle.ConstantTimeCompare([]byte(record.Code), []byte(getVerificationCodeHash(record, code))) != 1 {
+		// the counter is updated in the database, so that concurrent guesses are all counted
+		_, err := adapter.Engine.ID(core.PK{record.Owner, record.Name}).Incr("failed_times").Update(&VerificationRecord{})
+		if err != nil {
+			panic(err)
+		}
+		affected, err := adapter.Engine.ID(core.PK{record.Owner, record.Name}).And("failed_times >= ?", verificationCodeMaxAttempts).Cols("is_used").Update(&VerificationRecord{IsUsed: true})
+		if err != nil {
+			panic(err)
+		}
+		if affected != 0 {
+			return "Too many wrong codes, please request a new code!"
+		}
 		return "Wrong code!"
 	}
 
@@ -165,14 +263,14 @@ func DisableVerificationCode(dest string) {
 	}
 }
 
-// from VnfNode/object/validateCode.go line 116
-var stdNums = []byte("0123456789")
-
 func getRandomCode(length int) string {
-	var result []byte
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	for i := 0; i < length; i++ {
-		result = append(result, stdNums[r.Intn(len(stdNums))])
+	result := make([]byte, length)
+	for i := range result {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			panic(err)
+		}
+		result[i] = byte('0' + n.Int64())
 	}
 	return string(result)
 }
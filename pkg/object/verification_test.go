@@ -0,0 +1,64 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestGetRandomCode(t *testing.T) {
+	codes := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		code := getRandomCode(8)
+		if !regexp.MustCompile(`^[0-9]{8}$`).MatchString(code) {
+			t.Fatalf("invalid code: %s", code)
+		}
+		codes[code] = true
+	}
+	if len(codes) < 19 {
+		t.Errorf("the codes must be random, got %d distinct codes out of 20", len(codes))
+	}
+}
+
+func TestVerificationCodeLength(t *testing.T) {
+	cases := map[int]int{0: defaultVerificationCodeLength, 2: minVerificationCodeLength, 8: 8, 20: maxVerificationCodeLength}
+	for length, expected := range cases {
+		provider := &Provider{CodeLength: length}
+		if got := provider.GetVerificationCodeLength(); got != expected {
+			t.Errorf("code length %d: got %d, expected %d", length, got, expected)
+		}
+	}
+}
+
+func TestVerificationCodeHash(t *testing.T) {
+	record := &VerificationRecord{Name: "record-1"}
+	hash := getVerificationCodeHash(record, "123456")
+	if hash == "123456" || hash != getVerificationCodeHash(record, "123456") {
+		t.Errorf("the hash must be stable and not the code")
+	}
+	if hash == getVerificationCodeHash(&VerificationRecord{Name: "record-2"}, "123456") {
+		t.Errorf("the hash must depend on the record")
+	}
+	if hash == getVerificationCodeHash(record, "123457") {
+		t.Errorf("the hash must depend on the code")
+	}
+}
@@ -0,0 +1,165 @@
+package object
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/bhojpur/dbm/pkg/core"
+	"github.com/bhojpur/iam/pkg/utils"
+	"github.com/bhojpur/iam/pkg/webauthn"
+	websvr "github.com/bhojpur/web/pkg/engine"
+)
+
+type WebauthnCredential struct {
+	Id           string   `json:"id"`
+	Name         string   `json:"name"`
+	PublicKey    string   `json:"publicKey"`
+	SignCount    uint32   `json:"signCount"`
+	Transports   []string `json:"transports"`
+	Aaguid       string   `json:"aaguid"`
+	CreatedTime  string   `json:"createdTime"`
+	LastUsedTime string   `json:"lastUsedTime"`
+}
+
+// GetWebauthnRelyingParty returns the relying party of the organization. The RP ID is the
+// domain of the organization if it has one, otherwise the host of the "origin" config.
+func GetWebauthnRelyingParty(organization *Organization) *webauthn.RelyingParty {
+	rp := &webauthn.RelyingParty{Name: "Bhojpur IAM"}
+	if organization != nil {
+		if organization.DisplayName != "" {
+			rp.Name = organization.DisplayName
+		}
+		if organization.Domain != "" {
+			rp.Id = organization.Domain
+			return rp
+		}
+	}
+
+	origin, _ := websvr.AppConfig.String("origin")
+	u, err := url.Parse(origin)
+	if err == nil {
+		rp.Id = u.Hostname()
+	}
+	return rp
+}
+
+func (user *User) GetWebauthnCredential(id string) *WebauthnCredential {
+	for _, credential := range user.WebauthnCredentials {
+		if credential.Id == id {
+			return credential
+		}
+	}
+	return nil
+}
+
+// GetWebauthnCredentialDescriptors returns the credentials of the user for the
+// allowCredentials and excludeCredentials options
+func (user *User) GetWebauthnCredentialDescriptors() []webauthn.CredentialDescriptor {
+	descriptors := []webauthn.CredentialDescriptor{}
+	for _, credential := range user.WebauthnCredentials {
+		descriptors = append(descriptors, webauthn.CredentialDescriptor{Type: "public-key", Id: credential.Id, Transports: credential.Transports})
+	}
+	return descriptors
+}
+
+func updateUserWebauthnCredentials(user *User) bool {
+	affected, err := adapter.Engine.ID(core.PK{user.Owner, user.Name}).Cols("webauthn_credentials").Update(user)
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func AddWebauthnCredential(user *User, credential *webauthn.Credential, name string) (*WebauthnCredential, string) {
+	id := webauthn.Encode(credential.Id)
+	if user.GetWebauthnCredential(id) != nil {
+		return nil, "The passkey has already been registered"
+	}
+
+	if name == "" {
+		name = fmt.Sprintf("Passkey %d", len(user.WebauthnCredentials)+1)
+	}
+	res := &WebauthnCredential{
+		Id:          id,
+		Name:        name,
+		PublicKey:   webauthn.Encode(credential.PublicKey),
+		SignCount:   credential.SignCount,
+		Transports:  credential.Transports,
+		Aaguid:      credential.Aaguid,
+		CreatedTime: utils.GetCurrentTime(),
+	}
+	user.WebauthnCredentials = append(user.WebauthnCredentials, res)
+	updateUserWebauthnCredentials(user)
+	return res, ""
+}
+
+// CheckWebauthnAssertion verifies the assertion with the stored credential of the user and
+// records the new sign count
+func CheckWebauthnAssertion(user *User, pkc *webauthn.PublicKeyCredential, challenge string, requireUserVerification bool) string {
+	credential := user.GetWebauthnCredential(pkc.Id)
+	if credential == nil {
+		return "The passkey is not registered for the user"
+	}
+
+	publicKey, err := webauthn.Decode(credential.PublicKey)
+	if err != nil {
+		return err.Error()
+	}
+
+	rp := GetWebauthnRelyingParty(GetOrganizationByUser(user))
+	signCount, err := rp.VerifyAssertion(pkc, challenge, publicKey, credential.SignCount, requireUserVerification)
+	if err != nil {
+		return err.Error()
+	}
+
+	credential.SignCount = signCount
+	credential.LastUsedTime = utils.GetCurrentTime()
+	updateUserWebauthnCredentials(user)
+	return ""
+}
+
+func RenameWebauthnCredential(user *User, id string, name string) bool {
+	credential := user.GetWebauthnCredential(id)
+	if credential == nil || name == "" {
+		return false
+	}
+
+	credential.Name = name
+	return updateUserWebauthnCredentials(user)
+}
+
+func DeleteWebauthnCredential(user *User, id string) bool {
+	credentials := []*WebauthnCredential{}
+	for _, credential := range user.WebauthnCredentials {
+		if credential.Id != id {
+			credentials = append(credentials, credential)
+		}
+	}
+	if len(credentials) == len(user.WebauthnCredentials) {
+		return false
+	}
+
+	user.WebauthnCredentials = credentials
+	return updateUserWebauthnCredentials(user)
+}
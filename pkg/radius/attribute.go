@@ -0,0 +1,116 @@
+package radius
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type attributeType struct {
+	Code     byte
+	IsInt    bool
+	IsTagged bool
+}
+
+// attributeTypes are the reply attributes that can be configured for roles
+var attributeTypes = map[string]*attributeType{
+	"Service-Type":            {Code: 6, IsInt: true},
+	"Filter-Id":               {Code: 11},
+	"Reply-Message":           {Code: 18},
+	"Class":                   {Code: 25},
+	"Session-Timeout":         {Code: 27, IsInt: true},
+	"Idle-Timeout":            {Code: 28, IsInt: true},
+	"Tunnel-Type":             {Code: 64, IsInt: true, IsTagged: true},
+	"Tunnel-Medium-Type":      {Code: 65, IsInt: true, IsTagged: true},
+	"Tunnel-Private-Group-Id": {Code: 81, IsTagged: true},
+}
+
+func newAttribute(name string, value string) (*Attribute, error) {
+	typ, ok := attributeTypes[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported RADIUS attribute: %s", name)
+	}
+
+	if !typ.IsInt {
+		if value == "" || len(value) > 250 {
+			return nil, fmt.Errorf("invalid length of RADIUS attribute %s", name)
+		}
+
+		data := []byte(value)
+		if typ.IsTagged {
+			// tag 0 means the attribute is not tagged (RFC 2868 section 3.6)
+			data = append([]byte{0}, data...)
+		}
+		return &Attribute{Type: typ.Code, Value: data}, nil
+	}
+
+	i, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("the value of RADIUS attribute %s must be an integer: %s", name, value)
+	}
+
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, uint32(i))
+	if typ.IsTagged {
+		// tagged integers only have 3 bytes for the value (RFC 2868 section 3.1)
+		if i > 0xffffff {
+			return nil, fmt.Errorf("the value of RADIUS attribute %s is too large: %s", name, value)
+		}
+		data[0] = 0
+	}
+	return &Attribute{Type: typ.Code, Value: data}, nil
+}
+
+// ParseAttributes parses reply attributes in the form of "<name>=<value>", for example
+// "Class=staff". "VLAN=<id>" is a shortcut for the tunnel attributes that assign a VLAN
+// (RFC 3580 section 3.31).
+func ParseAttributes(items []string) ([]*Attribute, error) {
+	res := []*Attribute{}
+	for _, item := range items {
+		tokens := strings.SplitN(item, "=", 2)
+		if len(tokens) != 2 {
+			return nil, fmt.Errorf("invalid RADIUS attribute: %s", item)
+		}
+
+		name := strings.TrimSpace(tokens[0])
+		value := strings.TrimSpace(tokens[1])
+		if name == "VLAN" {
+			for _, pair := range [][]string{{"Tunnel-Type", "13"}, {"Tunnel-Medium-Type", "6"}, {"Tunnel-Private-Group-Id", value}} {
+				attribute, err := newAttribute(pair[0], pair[1])
+				if err != nil {
+					return nil, err
+				}
+				res = append(res, attribute)
+			}
+			continue
+		}
+
+		attribute, err := newAttribute(name, value)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, attribute)
+	}
+	return res, nil
+}
@@ -0,0 +1,213 @@
+package radius
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	CodeAccessRequest     = 1
+	CodeAccessAccept      = 2
+	CodeAccessReject      = 3
+	CodeAccountingRequest = 4
+	CodeAccessChallenge   = 11
+	CodeStatusServer      = 12
+
+	AttributeUserName             = 1
+	AttributeUserPassword         = 2
+	AttributeChapPassword         = 3
+	AttributeNasIpAddress         = 4
+	AttributeReplyMessage         = 18
+	AttributeState                = 24
+	AttributeCallingStationId     = 31
+	AttributeNasIdentifier        = 32
+	AttributeMessageAuthenticator = 80
+
+	maxPacketLength = 4096
+)
+
+type Attribute struct {
+	Type  byte
+	Value []byte
+}
+
+// Packet is a RADIUS packet as described in RFC 2865 section 3
+type Packet struct {
+	Code          byte
+	Identifier    byte
+	Authenticator [16]byte
+	Attributes    []*Attribute
+}
+
+func ParsePacket(data []byte) (*Packet, error) {
+	if len(data) < 20 {
+		return nil, fmt.Errorf("packet is too short: %d bytes", len(data))
+	}
+
+	length := int(binary.BigEndian.Uint16(data[2:4]))
+	if length < 20 || length > maxPacketLength || length > len(data) {
+		return nil, fmt.Errorf("invalid packet length: %d", length)
+	}
+
+	packet := &Packet{Code: data[0], Identifier: data[1]}
+	copy(packet.Authenticator[:], data[4:20])
+
+	attributes := data[20:length]
+	for len(attributes) != 0 {
+		if len(attributes) < 2 || attributes[1] < 2 || int(attributes[1]) > len(attributes) {
+			return nil, fmt.Errorf("invalid attribute")
+		}
+
+		attributeLength := int(attributes[1])
+		packet.Attributes = append(packet.Attributes, &Attribute{Type: attributes[0], Value: attributes[2:attributeLength]})
+		attributes = attributes[attributeLength:]
+	}
+
+	return packet, nil
+}
+
+func (p *Packet) Encode() []byte {
+	var buffer bytes.Buffer
+	buffer.WriteByte(p.Code)
+	buffer.WriteByte(p.Identifier)
+	buffer.Write([]byte{0, 0})
+	buffer.Write(p.Authenticator[:])
+	for _, attribute := range p.Attributes {
+		buffer.WriteByte(attribute.Type)
+		buffer.WriteByte(byte(len(attribute.Value) + 2))
+		buffer.Write(attribute.Value)
+	}
+
+	data := buffer.Bytes()
+	binary.BigEndian.PutUint16(data[2:4], uint16(len(data)))
+	return data
+}
+
+func (p *Packet) Get(typ byte) []byte {
+	for _, attribute := range p.Attributes {
+		if attribute.Type == typ {
+			return attribute.Value
+		}
+	}
+	return nil
+}
+
+func (p *Packet) GetString(typ byte) string {
+	return string(p.Get(typ))
+}
+
+// DecodePassword decrypts the User-Password attribute as described in RFC 2865 section 5.2
+func (p *Packet) DecodePassword(secret string) (string, error) {
+	value := p.Get(AttributeUserPassword)
+	if len(value) == 0 || len(value)%16 != 0 || len(value) > 128 {
+		return "", fmt.Errorf("invalid User-Password attribute")
+	}
+
+	res := make([]byte, len(value))
+	last := p.Authenticator[:]
+	for i := 0; i < len(value); i += 16 {
+		hash := md5.Sum(append([]byte(secret), last...))
+		for j := 0; j < 16; j++ {
+			res[i+j] = value[i+j] ^ hash[j]
+		}
+		last = value[i : i+16]
+	}
+
+	return string(bytes.TrimRight(res, "\x00")), nil
+}
+
+// EncodePassword encrypts the password for the User-Password attribute, which is only needed
+// by clients
+func EncodePassword(password string, secret string, authenticator [16]byte) []byte {
+	data := []byte(password)
+	if len(data)%16 != 0 || len(data) == 0 {
+		data = append(data, make([]byte, 16-len(data)%16)...)
+	}
+
+	res := make([]byte, len(data))
+	last := authenticator[:]
+	for i := 0; i < len(data); i += 16 {
+		hash := md5.Sum(append([]byte(secret), last...))
+		for j := 0; j < 16; j++ {
+			res[i+j] = data[i+j] ^ hash[j]
+		}
+		last = res[i : i+16]
+	}
+	return res
+}
+
+func getMessageAuthenticator(data []byte, secret string) []byte {
+	mac := hmac.New(md5.New, []byte(secret))
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// withZeroMessageAuthenticator returns a copy of the encoded packet in which the value of the
+// Message-Authenticator attribute is zeroed, as it is when the HMAC is calculated
+func withZeroMessageAuthenticator(data []byte) ([]byte, bool) {
+	res := append([]byte{}, data...)
+	found := false
+	for i := 20; i+2 <= len(res) && res[i+1] >= 2; i += int(res[i+1]) {
+		if res[i] == AttributeMessageAuthenticator && res[i+1] == 18 && i+18 <= len(res) {
+			copy(res[i+2:i+18], make([]byte, 16))
+			found = true
+		}
+	}
+	return res, found
+}
+
+// VerifyMessageAuthenticator checks the Message-Authenticator attribute of RFC 3579 section
+// 3.2 in the received data. A packet without the attribute is only accepted when it is not
+// required.
+func VerifyMessageAuthenticator(data []byte, packet *Packet, secret string, isRequired bool) bool {
+	value := packet.Get(AttributeMessageAuthenticator)
+	if value == nil {
+		return !isRequired
+	}
+
+	zeroed, _ := withZeroMessageAuthenticator(data[:binary.BigEndian.Uint16(data[2:4])])
+	return hmac.Equal(value, getMessageAuthenticator(zeroed, secret))
+}
+
+// NewResponse builds the encoded response to the request. A Message-Authenticator attribute is
+// always added as the first attribute, followed by the given attributes.
+func NewResponse(request *Packet, code byte, attributes []*Attribute, secret string) []byte {
+	response := &Packet{
+		Code:          code,
+		Identifier:    request.Identifier,
+		Authenticator: request.Authenticator,
+		Attributes:    append([]*Attribute{{Type: AttributeMessageAuthenticator, Value: make([]byte, 16)}}, attributes...),
+	}
+
+	data := response.Encode()
+	copy(data[22:38], getMessageAuthenticator(data, secret))
+
+	hash := md5.New()
+	hash.Write(data)
+	hash.Write([]byte(secret))
+	copy(data[4:20], hash.Sum(nil))
+	return data
+}
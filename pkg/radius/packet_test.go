@@ -0,0 +1,138 @@
+package radius
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"crypto/md5"
+	"testing"
+)
+
+func newTestRequest(secret string, withMessageAuthenticator bool) []byte {
+	request := &Packet{Code: CodeAccessRequest, Identifier: 7}
+	copy(request.Authenticator[:], "0123456789abcdef")
+	request.Attributes = []*Attribute{
+		{Type: AttributeUserName, Value: []byte("alice")},
+		{Type: AttributeUserPassword, Value: EncodePassword("a rather long password!", secret, request.Authenticator)},
+	}
+	if !withMessageAuthenticator {
+		return request.Encode()
+	}
+
+	request.Attributes = append(request.Attributes, &Attribute{Type: AttributeMessageAuthenticator, Value: make([]byte, 16)})
+	data := request.Encode()
+	copy(data[len(data)-16:], getMessageAuthenticator(data, secret))
+	return data
+}
+
+func TestParsePacket(t *testing.T) {
+	data := newTestRequest("s3cret", true)
+	request, err := ParsePacket(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if request.Code != CodeAccessRequest || request.Identifier != 7 || request.GetString(AttributeUserName) != "alice" {
+		t.Errorf("unexpected packet: %v", request)
+	}
+	if !bytes.Equal(request.Encode(), data) {
+		t.Errorf("packet is not encoded back to the same data")
+	}
+
+	password, err := request.DecodePassword("s3cret")
+	if err != nil || password != "a rather long password!" {
+		t.Errorf("unexpected password: %s, %v", password, err)
+	}
+	password, _ = request.DecodePassword("wrong")
+	if password == "a rather long password!" {
+		t.Errorf("password decoded with the wrong secret")
+	}
+
+	if !VerifyMessageAuthenticator(data, request, "s3cret", true) {
+		t.Errorf("valid Message-Authenticator is rejected")
+	}
+	if VerifyMessageAuthenticator(data, request, "wrong", false) {
+		t.Errorf("invalid Message-Authenticator is accepted")
+	}
+
+	data = newTestRequest("s3cret", false)
+	request, _ = ParsePacket(data)
+	if VerifyMessageAuthenticator(data, request, "s3cret", true) {
+		t.Errorf("packet without the required Message-Authenticator is accepted")
+	}
+	if !VerifyMessageAuthenticator(data, request, "s3cret", false) {
+		t.Errorf("packet without the optional Message-Authenticator is rejected")
+	}
+
+	for _, data := range [][]byte{data[:19], append(data[:20:20], 1, 10, 'a')} {
+		if _, err = ParsePacket(data); err == nil {
+			t.Errorf("invalid packet is parsed: %v", data)
+		}
+	}
+}
+
+func TestNewResponse(t *testing.T) {
+	request, err := ParsePacket(newTestRequest("s3cret", false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attributes, err := ParseAttributes([]string{"VLAN=100", "Class=staff", "Session-Timeout=3600"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := NewResponse(request, CodeAccessAccept, attributes, "s3cret")
+
+	response, err := ParsePacket(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Code != CodeAccessAccept || response.Identifier != request.Identifier {
+		t.Errorf("unexpected response: %v", response)
+	}
+
+	// Response Authenticator = MD5(Code + Identifier + Length + Request Authenticator + Attributes + Secret)
+	expected := append([]byte{}, data...)
+	copy(expected[4:20], request.Authenticator[:])
+	hash := md5.Sum(append(expected, []byte("s3cret")...))
+	if !bytes.Equal(response.Authenticator[:], hash[:]) {
+		t.Errorf("invalid Response Authenticator")
+	}
+
+	// the Message-Authenticator of a response is calculated with the Request Authenticator
+	zeroed, found := withZeroMessageAuthenticator(expected)
+	if !found || !bytes.Equal(response.Get(AttributeMessageAuthenticator), getMessageAuthenticator(zeroed, "s3cret")) {
+		t.Errorf("invalid Message-Authenticator")
+	}
+
+	if !bytes.Equal(response.Get(64), []byte{0, 0, 0, 13}) || !bytes.Equal(response.Get(81), []byte("\x00100")) ||
+		response.GetString(25) != "staff" || !bytes.Equal(response.Get(27), []byte{0, 0, 0x0e, 0x10}) {
+		t.Errorf("unexpected attributes: %v", response.Attributes)
+	}
+}
+
+func TestParseAttributes(t *testing.T) {
+	for _, items := range [][]string{{"Class"}, {"Unknown=1"}, {"Session-Timeout=abc"}, {"Filter-Id="}} {
+		if _, err := ParseAttributes(items); err == nil {
+			t.Errorf("invalid attributes are parsed: %v", items)
+		}
+	}
+}
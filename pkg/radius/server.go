@@ -0,0 +1,370 @@
+package radius
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bhojpur/iam/pkg/object"
+	"github.com/bhojpur/iam/pkg/utils"
+	logsvr "github.com/bhojpur/logger/pkg/engine"
+	websvr "github.com/bhojpur/web/pkg/engine"
+)
+
+const (
+	// retransmitted requests are answered from the cache instead of checking the password again
+	duplicateTimeout = 30 * time.Second
+	// the time the user has to answer the challenge of the second factor
+	challengeTimeout = 2 * time.Minute
+)
+
+type cachedResponse struct {
+	Data       []byte
+	ExpireTime time.Time
+}
+
+var responseCache = map[string]*cachedResponse{}
+var responseCacheMutex sync.Mutex
+
+// challenge is a login waiting for the passcode of the user, by the value of its State attribute
+type challenge struct {
+	Application string
+	UserId      string
+	ExpireTime  time.Time
+}
+
+var challenges = map[string]*challenge{}
+var challengesMutex sync.Mutex
+
+// StartRadiusServer authenticates Access-Requests with PAP against the users when
+// "radiusServerPort" is configured. Each application with RADIUS enabled is a client: requests
+// are accepted from its client addresses and authenticated with its shared secret against the
+// users of its organization. Access-Requests must carry a Message-Authenticator, unless the
+// application accepts legacy clients.
+//
+// The users who must sign in with a second factor are sent an Access-Challenge for the passcode
+// of their authenticator app, and rejected when they have no such factor.
+func StartRadiusServer() {
+	port, _ := websvr.AppConfig.String("radiusServerPort")
+	if port == "" {
+		return
+	}
+
+	conn, err := net.ListenPacket("udp", fmt.Sprintf(":%s", port))
+	if err != nil {
+		panic(err)
+	}
+
+	logsvr.Info(fmt.Sprintf("RADIUS server is listening on port: %s", port))
+	go func() {
+		for {
+			buffer := make([]byte, maxPacketLength)
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				logsvr.Error(fmt.Sprintf("RADIUS server failed to read packet: %s", err.Error()))
+				continue
+			}
+
+			go handlePacket(conn, addr, buffer[:n])
+		}
+	}()
+}
+
+func isClientAddress(client string, ip net.IP) bool {
+	if strings.Contains(client, "/") {
+		_, network, err := net.ParseCIDR(client)
+		return err == nil && network.Contains(ip)
+	}
+
+	clientIp := net.ParseIP(client)
+	return clientIp != nil && clientIp.Equal(ip)
+}
+
+// getClientApplication returns the application with RADIUS enabled that lists the address as
+// one of its clients
+func getClientApplication(ip net.IP) *object.Application {
+	for _, application := range object.GetApplications("admin") {
+		if !application.EnableRadius || application.RadiusSecret == "" {
+			continue
+		}
+
+		for _, client := range application.RadiusClients {
+			if isClientAddress(strings.TrimSpace(client), ip) {
+				return application
+			}
+		}
+	}
+	return nil
+}
+
+func getCachedResponse(key string) []byte {
+	responseCacheMutex.Lock()
+	defer responseCacheMutex.Unlock()
+
+	now := time.Now()
+	for k, response := range responseCache {
+		if response.ExpireTime.Before(now) {
+			delete(responseCache, k)
+		}
+	}
+
+	if response, ok := responseCache[key]; ok {
+		return response.Data
+	}
+	return nil
+}
+
+func setCachedResponse(key string, data []byte) {
+	responseCacheMutex.Lock()
+	defer responseCacheMutex.Unlock()
+
+	responseCache[key] = &cachedResponse{Data: data, ExpireTime: time.Now().Add(duplicateTimeout)}
+}
+
+func handlePacket(conn net.PacketConn, addr net.Addr, data []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			logsvr.Error(fmt.Sprintf("RADIUS server failed to handle packet from %s: %v", addr, r))
+		}
+	}()
+
+	udpAddr, ok := addr.(*net.UDPAddr)
+	if !ok {
+		return
+	}
+
+	// packets from unknown clients are silently discarded (RFC 2865 section 3)
+	application := getClientApplication(udpAddr.IP)
+	if application == nil {
+		logsvr.Warning(fmt.Sprintf("RADIUS server discarded packet from unknown client: %s", addr))
+		return
+	}
+
+	request, err := ParsePacket(data)
+	if err != nil {
+		logsvr.Warning(fmt.Sprintf("RADIUS server discarded invalid packet from %s: %s", addr, err.Error()))
+		return
+	}
+
+	// the Message-Authenticator protects Access-Requests against forgery (CVE-2024-3596)
+	isRequired := request.Code == CodeAccessRequest && !application.RadiusLegacyClients
+	if !VerifyMessageAuthenticator(data, request, application.RadiusSecret, isRequired) {
+		logsvr.Warning(fmt.Sprintf("RADIUS server discarded packet with missing or invalid Message-Authenticator from %s", addr))
+		return
+	}
+
+	key := fmt.Sprintf("%s/%d/%x", addr.String(), request.Identifier, request.Authenticator)
+	response := getCachedResponse(key)
+	if response == nil {
+		switch request.Code {
+		case CodeAccessRequest:
+			response = handleAccessRequest(application, udpAddr.IP.String(), request)
+		case CodeStatusServer:
+			// RFC 5997 requires Status-Server packets to be authenticated
+			if request.Get(AttributeMessageAuthenticator) == nil {
+				return
+			}
+			response = NewResponse(request, CodeAccessAccept, nil, application.RadiusSecret)
+		default:
+			return
+		}
+		setCachedResponse(key, response)
+	}
+
+	_, err = conn.WriteTo(response, addr)
+	if err != nil {
+		logsvr.Warning(fmt.Sprintf("RADIUS server failed to reply to %s: %s", addr, err.Error()))
+	}
+}
+
+func handleAccessRequest(application *object.Application, clientIp string, request *Packet) []byte {
+	username := request.GetString(AttributeUserName)
+
+	var user *object.User
+	var msg string
+	if state := request.Get(AttributeState); state != nil {
+		user, msg = authenticateChallenge(application, request, string(state))
+	} else {
+		user, msg = authenticate(application, request)
+		if msg == "" && object.IsSecondFactorRequired(user, application) {
+			if !isTotpAllowed(application, user) {
+				msg = "the user must sign in with a second factor that RADIUS doesn't support"
+			} else {
+				attributes := []*Attribute{
+					{Type: AttributeState, Value: []byte(addChallenge(application, user))},
+					{Type: AttributeReplyMessage, Value: []byte("Enter the passcode of your authenticator app")},
+				}
+				return NewResponse(request, CodeAccessChallenge, attributes, application.RadiusSecret)
+			}
+		}
+	}
+	addRecord(application, clientIp, request, username, user, msg)
+
+	if msg != "" {
+		// the reason is not sent back to the client, so that it doesn't reveal whether the user exists
+		return NewResponse(request, CodeAccessReject, nil, application.RadiusSecret)
+	}
+
+	attributes := []*Attribute{}
+	for _, role := range object.GetRolesByUser(user.GetId()) {
+		if !role.IsEnabled {
+			continue
+		}
+
+		roleAttributes, err := ParseAttributes(role.RadiusAttributes)
+		if err != nil {
+			logsvr.Warning(fmt.Sprintf("RADIUS server skipped the attributes of role %s: %s", role.GetId(), err.Error()))
+			continue
+		}
+		attributes = append(attributes, roleAttributes...)
+	}
+
+	return NewResponse(request, CodeAccessAccept, attributes, application.RadiusSecret)
+}
+
+func authenticate(application *object.Application, request *Packet) (*object.User, string) {
+	username := request.GetString(AttributeUserName)
+	if username == "" {
+		return nil, "missing User-Name"
+	}
+
+	if request.Get(AttributeUserPassword) == nil {
+		if request.Get(AttributeChapPassword) != nil {
+			return nil, "CHAP is not supported"
+		}
+		return nil, "missing User-Password"
+	}
+
+	password, err := request.DecodePassword(application.RadiusSecret)
+	if err != nil {
+		return nil, err.Error()
+	}
+
+	// the source address of the request is the one of the NAS, not of the user, so only the
+	// failures of the user are counted
+	return object.CheckUserPassword(application.Organization, username, password, "")
+}
+
+func isTotpAllowed(application *object.Application, user *object.User) bool {
+	policy := object.GetMfaPolicy(object.GetOrganizationByUser(user), application)
+	return utils.ContainsString(policy.GetUserMfaTypes(user), object.MfaTypeTotp)
+}
+
+func addChallenge(application *object.Application, user *object.User) string {
+	challengesMutex.Lock()
+	defer challengesMutex.Unlock()
+
+	now := time.Now()
+	for state, c := range challenges {
+		if c.ExpireTime.Before(now) {
+			delete(challenges, state)
+		}
+	}
+
+	state := utils.GenerateClientSecret()
+	challenges[state] = &challenge{Application: application.GetId(), UserId: user.GetId(), ExpireTime: now.Add(challengeTimeout)}
+	return state
+}
+
+// takeChallenge returns the challenge of the state and removes it, so that each challenge can
+// only be answered once
+func takeChallenge(state string) *challenge {
+	challengesMutex.Lock()
+	defer challengesMutex.Unlock()
+
+	c, ok := challenges[state]
+	if !ok {
+		return nil
+	}
+
+	delete(challenges, state)
+	if c.ExpireTime.Before(time.Now()) {
+		return nil
+	}
+	return c
+}
+
+// authenticateChallenge checks the passcode sent as the User-Password of the answer to an
+// Access-Challenge
+func authenticateChallenge(application *object.Application, request *Packet, state string) (*object.User, string) {
+	c := takeChallenge(state)
+	if c == nil || c.Application != application.GetId() {
+		return nil, "invalid or expired State"
+	}
+
+	user := object.GetUser(c.UserId)
+	if user == nil || user.Name != request.GetString(AttributeUserName) {
+		return nil, "the User-Name doesn't match the challenge"
+	}
+
+	if msg := object.CheckSigninLockout(object.GetOrganizationByUser(user), user, ""); msg != "" {
+		return nil, msg
+	}
+
+	passcode, err := request.DecodePassword(application.RadiusSecret)
+	if err != nil {
+		return nil, err.Error()
+	}
+
+	msg := object.CheckTotp(user, passcode)
+	if msg != "" {
+		object.AddSigninFailure(user.Owner, user, "", msg)
+		return nil, msg
+	}
+
+	object.ResetSigninFailures(user)
+	return user, ""
+}
+
+func addRecord(application *object.Application, clientIp string, request *Packet, username string, user *object.User, msg string) {
+	action := "radius-access-accept"
+	if msg != "" {
+		action = "radius-access-reject"
+		logsvr.Info(fmt.Sprintf("RADIUS server rejected [%s/%s] from %s: %s", application.Organization, username, clientIp, msg))
+	}
+	if user != nil {
+		username = user.Name
+	}
+
+	requestUri := fmt.Sprintf("radius://%s/%s", clientIp, application.Name)
+	if callingStationId := request.GetString(AttributeCallingStationId); callingStationId != "" {
+		requestUri = fmt.Sprintf("%s?calling_station_id=%s", requestUri, callingStationId)
+	}
+	if len(requestUri) > 1000 {
+		requestUri = requestUri[0:1000]
+	}
+
+	record := &object.Record{
+		Name:         utils.GenerateId(),
+		CreatedTime:  utils.GetCurrentTime(),
+		Organization: application.Organization,
+		ClientIp:     clientIp,
+		User:         username,
+		Method:       "RADIUS",
+		RequestUri:   requestUri,
+		Action:       action,
+	}
+	go object.AddRecord(record)
+}
@@ -42,6 +42,10 @@ func getUsername(ctx *ctxsvr.Context) (username string) {
 		}
 	}()
 
+	if userId := getPersonalAccessTokenUser(ctx); userId != "" {
+		return userId
+	}
+
 	username = ctx.Input.Session("username").(string)
 
 	if username == "" {
@@ -96,6 +100,11 @@ func willLog(subOwner string, subName string, method string, urlPath string, obj
 }
 
 func AuthzFilter(ctx *ctxsvr.Context) {
+	if isScimRequest(ctx) {
+		// authorized by the SCIM token of the organization in the controller
+		return
+	}
+
 	subOwner, subName := getSubject(ctx)
 	method := ctx.Request.Method
 	urlPath := ctx.Request.URL.Path
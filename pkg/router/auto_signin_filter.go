@@ -33,11 +33,63 @@ func AutoSigninFilter(ctx *ctxsvr.Context) {
 	//	return
 	//}
 
-	// GET parameter like "/page?access_token=123" or
-	// HTTP Bearer token like "Authorization: Bearer 123"
-	accessToken := ctx.Input.Query("accessToken")
-	if accessToken == "" {
-		accessToken = parseBearerToken(ctx)
+	// SCIM requests carry the SCIM token of an organization instead of an access token
+	if isScimRequest(ctx) {
+		return
+	}
+
+	endExpiredImpersonation(ctx)
+
+	// the sessions that started before the sessions of the user were revoked, e.g. by a
+	// password reset, and the sessions whose record has been deleted are signed out
+	if userId := getSessionUser(ctx); userId != "" {
+		sessionId := getSessionId(ctx)
+		if object.IsUserSessionRevoked(userId, getSessionLoginTime(ctx)) ||
+			(sessionId != "" && !object.TouchUserSession(sessionId, utils.GetIPFromRequest(ctx.Request))) {
+			setSessionUser(ctx, "")
+		}
+	}
+
+	// HTTP Bearer token like "Authorization: Bearer 123" or,
+	// when enabled, GET parameter like "/page?accessToken=123"
+	accessToken := parseBearerToken(ctx)
+	if isClientIdSecretToken(accessToken) {
+		accessToken = ""
+	}
+	if accessToken == "" && ctx.Input.Query("accessToken") != "" {
+		if !enableQueryAccessToken {
+			responseError(ctx, "The access token in the query string is disabled, please use the header: \"Authorization: Bearer <token>\" instead")
+			return
+		}
+		accessToken = ctx.Input.Query("accessToken")
+	}
+	if object.IsPersonalAccessToken(accessToken) {
+		pat := object.GetPersonalAccessTokenByToken(accessToken)
+		if pat == nil {
+			responseError(ctx, "Personal access token doesn't exist")
+			return
+		}
+		if pat.IsExpired() {
+			responseError(ctx, "Personal access token has expired")
+			return
+		}
+		if !pat.IsMethodAllowed(ctx.Request.Method) {
+			responseError(ctx, "The scopes of the personal access token don't allow this request")
+			return
+		}
+
+		userId := fmt.Sprintf("%s/%s", pat.Owner, pat.User)
+		user := object.GetUser(userId)
+		if user == nil || user.IsForbidden || user.IsDeleted {
+			responseError(ctx, fmt.Sprintf("The user: %s is forbidden to sign in", userId))
+			return
+		}
+
+		// the token only signs in the current request, so that its scopes are checked on every
+		// request and the session doesn't outlive the token
+		object.TouchPersonalAccessToken(pat, utils.GetIPFromRequest(ctx.Request))
+		ctx.Input.SetData("personalAccessTokenUser", userId)
+		return
 	}
 	if accessToken != "" {
 		token := object.GetTokenByAccessToken(accessToken)
@@ -58,19 +110,29 @@ func AutoSigninFilter(ctx *ctxsvr.Context) {
 		return
 	}
 
-	// "/page?clientId=123&clientSecret=456"
+	if !enableQueryClientSecret && ctx.Input.Query("clientSecret") != "" {
+		responseError(ctx, "The client secret in the query string is disabled, please use HTTP Basic or Bearer authentication instead")
+		return
+	}
+
+	// HTTP Basic or Bearer authentication with the client id and secret, or "/page?clientId=123&clientSecret=456" when enabled
 	userId := getUsernameByClientIdSecret(ctx)
 	if userId != "" {
 		setSessionUser(ctx, userId)
 		return
 	}
 
-	// "/page?username=abc&password=123"
+	// "/page?username=abc&password=123", when enabled
 	userId = ctx.Input.Query("username")
 	password := ctx.Input.Query("password")
 	if userId != "" && password != "" {
+		if !enableQueryPassword {
+			responseError(ctx, "The password in the query string is disabled, please sign in instead")
+			return
+		}
+
 		owner, name := utils.GetOwnerAndNameFromId(userId)
-		_, msg := object.CheckUserPassword(owner, name, password)
+		_, msg := object.CheckUserPassword(owner, name, password, utils.GetIPFromRequest(ctx.Request))
 		if msg != "" {
 			responseError(ctx, msg)
 			return
@@ -23,12 +23,28 @@ package router
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/bhojpur/iam/pkg/object"
 	"github.com/bhojpur/iam/pkg/utils"
 	ctxsvr "github.com/bhojpur/web/pkg/context"
+	websvr "github.com/bhojpur/web/pkg/engine"
 )
 
+// the legacy sign-ins with the credentials in the query string, which end up in the logs of the
+// proxies and the history of the browsers, are disabled unless they are enabled in the config
+var (
+	enableQueryAccessToken  bool
+	enableQueryClientSecret bool
+	enableQueryPassword     bool
+)
+
+func init() {
+	enableQueryAccessToken, _ = websvr.AppConfig.Bool("enableQueryAccessToken")
+	enableQueryClientSecret, _ = websvr.AppConfig.Bool("enableQueryClientSecret")
+	enableQueryPassword, _ = websvr.AppConfig.Bool("enableQueryPassword")
+}
+
 type Response struct {
 	Status string      `json:"status"`
 	Msg    string      `json:"msg"`
@@ -56,13 +72,27 @@ func denyRequest(ctx *ctxsvr.Context) {
 	responseError(ctx, "Unauthorized operation")
 }
 
-func getUsernameByClientIdSecret(ctx *ctxsvr.Context) string {
-	clientId, clientSecret, ok := ctx.Request.BasicAuth()
-	if !ok {
-		clientId = ctx.Input.Query("clientId")
-		clientSecret = ctx.Input.Query("clientSecret")
+// getClientIdSecret returns the client credentials of the request, from HTTP Basic like
+// "Authorization: Basic base64(123:456)", from HTTP Bearer like "Authorization: Bearer 123:456"
+// or, when enabled, from the query string like "/page?clientId=123&clientSecret=456"
+func getClientIdSecret(ctx *ctxsvr.Context) (string, string) {
+	if clientId, clientSecret, ok := ctx.Request.BasicAuth(); ok {
+		return clientId, clientSecret
+	}
+
+	tokens := strings.SplitN(parseBearerToken(ctx), ":", 2)
+	if len(tokens) == 2 {
+		return tokens[0], tokens[1]
 	}
 
+	if enableQueryClientSecret {
+		return ctx.Input.Query("clientId"), ctx.Input.Query("clientSecret")
+	}
+	return "", ""
+}
+
+func getUsernameByClientIdSecret(ctx *ctxsvr.Context) string {
+	clientId, clientSecret := getClientIdSecret(ctx)
 	if clientId == "" || clientSecret == "" {
 		return ""
 	}
@@ -85,16 +115,65 @@ func getSessionUser(ctx *ctxsvr.Context) string {
 	return user.(string)
 }
 
+// getPersonalAccessTokenUser returns the user signed in by the personal access token of the
+// request, which is not kept in the session
+func getPersonalAccessTokenUser(ctx *ctxsvr.Context) string {
+	userId, _ := ctx.Input.GetData("personalAccessTokenUser").(string)
+	return userId
+}
+
+func getSessionLoginTime(ctx *ctxsvr.Context) int64 {
+	loginTime, _ := ctx.Input.CruSession.Get(nil, "loginTime").(int64)
+	return loginTime
+}
+
+// getSessionId returns the id of the record of the session, which is empty for the sessions
+// that started before the sessions were recorded
+func getSessionId(ctx *ctxsvr.Context) string {
+	sessionId, _ := ctx.Input.CruSession.Get(nil, "sessionId").(string)
+	return sessionId
+}
+
 func setSessionUser(ctx *ctxsvr.Context, user string) {
 	sessvr := ctx.Input.CruSession
 	err := sessvr.Set(nil, "username", user)
 	if err != nil {
 		panic(err)
 	}
+	if user != "" {
+		// the credentials of the request have just been checked
+		err = sessvr.Set(nil, "loginTime", time.Now().Unix())
+		if err != nil {
+			panic(err)
+		}
+	}
 
 	sessvr.SessionRelease(nil, ctx.ResponseWriter)
 }
 
+// endExpiredImpersonation signs out the impersonation of a user by an admin once its time
+// limit has passed, the admin has to sign in again
+func endExpiredImpersonation(ctx *ctxsvr.Context) {
+	sessvr := ctx.Input.CruSession
+	impersonator, _ := sessvr.Get(nil, "impersonator").(string)
+	expireTime, _ := sessvr.Get(nil, "impersonationExpireTime").(int64)
+	if impersonator == "" || time.Now().Unix() < expireTime {
+		return
+	}
+
+	object.DeleteUserSession(getSessionId(ctx))
+	if sessionId, _ := sessvr.Get(nil, "impersonatorSessionId").(string); sessionId != "" {
+		object.DeleteUserSession(sessionId)
+	}
+	for _, key := range []string{"impersonator", "impersonatorSessionId", "impersonatorLoginTime", "impersonatorAmr", "impersonationExpireTime", "sessionId"} {
+		err := sessvr.Delete(nil, key)
+		if err != nil {
+			panic(err)
+		}
+	}
+	setSessionUser(ctx, "")
+}
+
 func setSessionExpire(ctx *ctxsvr.Context, ExpireTime int64) {
 	SessionData := struct{ ExpireTime int64 }{ExpireTime: ExpireTime}
 	sessvr := ctx.Input.CruSession
@@ -132,3 +211,13 @@ func parseBearerToken(ctx *ctxsvr.Context) string {
 
 	return tokens[1]
 }
+
+// isClientIdSecretToken checks whether a bearer token carries the client credentials "clientId:clientSecret"
+// instead of an access token
+func isClientIdSecretToken(token string) bool {
+	return strings.Contains(token, ":")
+}
+
+func isScimRequest(ctx *ctxsvr.Context) bool {
+	return strings.HasPrefix(ctx.Request.URL.Path, "/scim/")
+}
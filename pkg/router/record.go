@@ -35,6 +35,10 @@ func getUser(ctx *ctxsvr.Context) (username string) {
 		}
 	}()
 
+	if userId := getPersonalAccessTokenUser(ctx); userId != "" {
+		return userId
+	}
+
 	username = ctx.Input.Session("username").(string)
 
 	if username == "" {
@@ -45,8 +49,7 @@ func getUser(ctx *ctxsvr.Context) (username string) {
 }
 
 func getUserByClientIdSecret(ctx *ctxsvr.Context) string {
-	clientId := ctx.Input.Query("clientId")
-	clientSecret := ctx.Input.Query("clientSecret")
+	clientId, clientSecret := getClientIdSecret(ctx)
 	if clientId == "" || clientSecret == "" {
 		return ""
 	}
@@ -55,6 +55,37 @@ func initAPI() {
 	websvr.Router("/api/unlink", &controllers.ApiController{}, "POST:Unlink")
 	websvr.Router("/api/get-saml-login", &controllers.ApiController{}, "GET:GetSamlLogin")
 	websvr.Router("/api/acs", &controllers.ApiController{}, "POST:HandleSamlLogin")
+	websvr.Router("/api/get-oidc-login", &controllers.ApiController{}, "GET:GetOidcLogin")
+	websvr.Router("/api/verify-mfa", &controllers.ApiController{}, "POST:VerifyMfa")
+	websvr.Router("/api/initiate-totp", &controllers.ApiController{}, "POST:InitiateTotp")
+	websvr.Router("/api/enable-totp", &controllers.ApiController{}, "POST:EnableTotp")
+	websvr.Router("/api/disable-totp", &controllers.ApiController{}, "POST:DisableTotp")
+	websvr.Router("/api/regenerate-recovery-codes", &controllers.ApiController{}, "POST:RegenerateRecoveryCodes")
+	websvr.Router("/api/reset-mfa", &controllers.ApiController{}, "POST:ResetMfa")
+	websvr.Router("/api/unlock-user", &controllers.ApiController{}, "POST:UnlockUser")
+	websvr.Router("/api/get-user-sessions", &controllers.ApiController{}, "GET:GetUserSessions")
+	websvr.Router("/api/delete-user-session", &controllers.ApiController{}, "POST:DeleteUserSession")
+	websvr.Router("/api/delete-user-sessions", &controllers.ApiController{}, "POST:DeleteUserSessions")
+	websvr.Router("/api/get-personal-access-tokens", &controllers.ApiController{}, "GET:GetPersonalAccessTokens")
+	websvr.Router("/api/add-personal-access-token", &controllers.ApiController{}, "POST:AddPersonalAccessToken")
+	websvr.Router("/api/delete-personal-access-token", &controllers.ApiController{}, "POST:DeletePersonalAccessToken")
+	websvr.Router("/api/get-service-account-credentials", &controllers.ApiController{}, "GET:GetServiceAccountCredentials")
+	websvr.Router("/api/add-service-account-credential", &controllers.ApiController{}, "POST:AddServiceAccountCredential")
+	websvr.Router("/api/delete-service-account-credential", &controllers.ApiController{}, "POST:DeleteServiceAccountCredential")
+	websvr.Router("/api/impersonate-user", &controllers.ApiController{}, "POST:ImpersonateUser")
+	websvr.Router("/api/end-impersonation", &controllers.ApiController{}, "POST:EndImpersonation")
+	websvr.Router("/api/change-expired-password", &controllers.ApiController{}, "POST:ChangeExpiredPassword")
+	websvr.Router("/api/request-password-reset", &controllers.ApiController{}, "POST:RequestPasswordReset")
+	websvr.Router("/api/reset-password", &controllers.ApiController{}, "POST:ResetPassword")
+	websvr.Router("/api/send-magic-link", &controllers.ApiController{}, "POST:SendMagicLink")
+	websvr.Router("/api/login-magic-link", &controllers.ApiController{}, "POST:LoginWithMagicLink")
+	websvr.Router("/api/begin-webauthn-registration", &controllers.ApiController{}, "POST:BeginWebauthnRegistration")
+	websvr.Router("/api/finish-webauthn-registration", &controllers.ApiController{}, "POST:FinishWebauthnRegistration")
+	websvr.Router("/api/begin-webauthn-login", &controllers.ApiController{}, "POST:BeginWebauthnLogin")
+	websvr.Router("/api/finish-webauthn-login", &controllers.ApiController{}, "POST:FinishWebauthnLogin")
+	websvr.Router("/api/get-webauthn-credentials", &controllers.ApiController{}, "GET:GetWebauthnCredentials")
+	websvr.Router("/api/rename-webauthn-credential", &controllers.ApiController{}, "POST:RenameWebauthnCredential")
+	websvr.Router("/api/delete-webauthn-credential", &controllers.ApiController{}, "POST:DeleteWebauthnCredential")
 
 	websvr.Router("/api/get-organizations", &controllers.ApiController{}, "GET:GetOrganizations")
 	websvr.Router("/api/get-organization", &controllers.ApiController{}, "GET:GetOrganization")
@@ -112,6 +143,8 @@ func initAPI() {
 	websvr.Router("/api/update-application", &controllers.ApiController{}, "POST:UpdateApplication")
 	websvr.Router("/api/add-application", &controllers.ApiController{}, "POST:AddApplication")
 	websvr.Router("/api/delete-application", &controllers.ApiController{}, "POST:DeleteApplication")
+	websvr.Router("/api/sync-provisioning", &controllers.ApiController{}, "POST:SyncProvisioning")
+	websvr.Router("/api/get-provisioning-status", &controllers.ApiController{}, "GET:GetProvisioningStatus")
 
 	websvr.Router("/api/get-resources", &controllers.ApiController{}, "GET:GetResources")
 	websvr.Router("/api/get-resource", &controllers.ApiController{}, "GET:GetResource")
@@ -159,6 +192,23 @@ func initAPI() {
 	websvr.Router("/api/send-email", &controllers.ApiController{}, "POST:SendEmail")
 	websvr.Router("/api/send-sms", &controllers.ApiController{}, "POST:SendSms")
 
+	websvr.Router("/scim/v2/ServiceProviderConfig", &controllers.ApiController{}, "GET:ScimGetServiceProviderConfig")
+	websvr.Router("/scim/v2/ResourceTypes", &controllers.ApiController{}, "GET:ScimGetResourceTypes")
+	websvr.Router("/scim/v2/ResourceTypes/:id", &controllers.ApiController{}, "GET:ScimGetResourceTypes")
+	websvr.Router("/scim/v2/Schemas", &controllers.ApiController{}, "GET:ScimGetSchemas")
+	websvr.Router("/scim/v2/Schemas/:id", &controllers.ApiController{}, "GET:ScimGetSchemas")
+	websvr.Router("/scim/v2/Users", &controllers.ApiController{}, "GET:ScimGetUsers;POST:ScimAddUser")
+	websvr.Router("/scim/v2/Users/:id", &controllers.ApiController{}, "GET:ScimGetUser;PUT:ScimReplaceUser;PATCH:ScimPatchUser;DELETE:ScimDeleteUser")
+	websvr.Router("/scim/v2/Groups", &controllers.ApiController{}, "GET:ScimGetGroups;POST:ScimAddGroup")
+	websvr.Router("/scim/v2/Groups/:id", &controllers.ApiController{}, "GET:ScimGetGroup;PUT:ScimReplaceGroup;PATCH:ScimPatchGroup;DELETE:ScimDeleteGroup")
+
+	websvr.Router("/cas/login", &controllers.ApiController{}, "GET:CasLogin")
+	websvr.Router("/cas/logout", &controllers.ApiController{}, "GET:CasLogout")
+	websvr.Router("/cas/serviceValidate", &controllers.ApiController{}, "GET:CasServiceValidate")
+	websvr.Router("/cas/proxyValidate", &controllers.ApiController{}, "GET:CasProxyValidate")
+	websvr.Router("/cas/p3/serviceValidate", &controllers.ApiController{}, "GET:CasP3ServiceValidate")
+	websvr.Router("/cas/p3/proxyValidate", &controllers.ApiController{}, "GET:CasP3ServiceValidate")
+
 	websvr.Router("/.well-known/openid-configuration", &controllers.RootController{}, "GET:GetOidcDiscovery")
 	websvr.Router("/api/certs", &controllers.RootController{}, "*:GetOidcCert")
 }
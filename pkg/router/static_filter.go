@@ -30,7 +30,7 @@ import (
 
 func StaticFilter(ctx *ctxsvr.Context) {
 	urlPath := ctx.Request.URL.Path
-	if strings.HasPrefix(urlPath, "/api/") || strings.HasPrefix(urlPath, "/.well-known/") {
+	if strings.HasPrefix(urlPath, "/api/") || strings.HasPrefix(urlPath, "/.well-known/") || strings.HasPrefix(urlPath, "/scim/") || strings.HasPrefix(urlPath, "/cas/") {
 		return
 	}
 
@@ -0,0 +1,155 @@
+package scim
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+)
+
+func GetServiceProviderConfig(baseUrl string) map[string]interface{} {
+	return map[string]interface{}{
+		"schemas":        []interface{}{ServiceProviderConfigSchema},
+		"patch":          map[string]interface{}{"supported": true},
+		"bulk":           map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]interface{}{"supported": true, "maxResults": MaxResults},
+		"changePassword": map[string]interface{}{"supported": true},
+		"sort":           map[string]interface{}{"supported": false},
+		"etag":           map[string]interface{}{"supported": true},
+		"authenticationSchemes": []interface{}{
+			map[string]interface{}{
+				"type":        "oauthbearertoken",
+				"name":        "OAuth Bearer Token",
+				"description": "Authentication with the SCIM token of the organization",
+				"primary":     true,
+			},
+		},
+		"meta": map[string]interface{}{
+			"resourceType": "ServiceProviderConfig",
+			"location":     fmt.Sprintf("%s/ServiceProviderConfig", baseUrl),
+		},
+	}
+}
+
+func GetResourceTypes(baseUrl string) []map[string]interface{} {
+	return []map[string]interface{}{
+		{
+			"schemas":     []interface{}{ResourceTypeSchema},
+			"id":          "User",
+			"name":        "User",
+			"endpoint":    "/Users",
+			"description": "User Account",
+			"schema":      UserSchema,
+			"schemaExtensions": []interface{}{
+				map[string]interface{}{"schema": EnterpriseUserSchema, "required": false},
+			},
+			"meta": map[string]interface{}{"resourceType": "ResourceType", "location": fmt.Sprintf("%s/ResourceTypes/User", baseUrl)},
+		},
+		{
+			"schemas":     []interface{}{ResourceTypeSchema},
+			"id":          "Group",
+			"name":        "Group",
+			"endpoint":    "/Groups",
+			"description": "Group, backed by the roles of the organization",
+			"schema":      GroupSchema,
+			"meta":        map[string]interface{}{"resourceType": "ResourceType", "location": fmt.Sprintf("%s/ResourceTypes/Group", baseUrl)},
+		},
+	}
+}
+
+func attribute(name string, typ string, multiValued bool, required bool, mutability string, subAttributes ...map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{
+		"name":        name,
+		"type":        typ,
+		"multiValued": multiValued,
+		"required":    required,
+		"caseExact":   false,
+		"mutability":  mutability,
+		"returned":    "default",
+		"uniqueness":  "none",
+	}
+	if name == "password" {
+		res["returned"] = "never"
+	}
+	if name == "userName" {
+		res["uniqueness"] = "server"
+	}
+	if len(subAttributes) != 0 {
+		res["subAttributes"] = subAttributes
+	}
+	return res
+}
+
+func multiValuedAttribute(name string, mutability string) map[string]interface{} {
+	return attribute(name, "complex", true, false, mutability,
+		attribute("value", "string", false, false, mutability),
+		attribute("display", "string", false, false, mutability),
+		attribute("type", "string", false, false, mutability),
+		attribute("primary", "boolean", false, false, mutability),
+	)
+}
+
+func GetSchemas(baseUrl string) []map[string]interface{} {
+	schema := func(id string, name string, description string, attributes ...map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"schemas":     []interface{}{SchemaSchema},
+			"id":          id,
+			"name":        name,
+			"description": description,
+			"attributes":  attributes,
+			"meta":        map[string]interface{}{"resourceType": "Schema", "location": fmt.Sprintf("%s/Schemas/%s", baseUrl, id)},
+		}
+	}
+
+	return []map[string]interface{}{
+		schema(UserSchema, "User", "User Account",
+			attribute("userName", "string", false, true, "readWrite"),
+			attribute("name", "complex", false, false, "readWrite",
+				attribute("formatted", "string", false, false, "readWrite"),
+				attribute("givenName", "string", false, false, "writeOnly"),
+				attribute("familyName", "string", false, false, "writeOnly"),
+			),
+			attribute("displayName", "string", false, false, "readWrite"),
+			attribute("profileUrl", "reference", false, false, "readWrite"),
+			attribute("title", "string", false, false, "readWrite"),
+			attribute("preferredLanguage", "string", false, false, "readWrite"),
+			attribute("active", "boolean", false, false, "readWrite"),
+			attribute("password", "string", false, false, "writeOnly"),
+			multiValuedAttribute("emails", "readWrite"),
+			multiValuedAttribute("phoneNumbers", "readWrite"),
+			multiValuedAttribute("photos", "readWrite"),
+			multiValuedAttribute("groups", "readOnly"),
+		),
+		schema(GroupSchema, "Group", "Group",
+			attribute("displayName", "string", false, true, "readWrite"),
+			multiValuedAttribute("members", "readWrite"),
+		),
+		schema(EnterpriseUserSchema, "EnterpriseUser", "Enterprise User, stored in the properties of the user",
+			attribute("employeeNumber", "string", false, false, "readWrite"),
+			attribute("costCenter", "string", false, false, "readWrite"),
+			attribute("organization", "string", false, false, "readWrite"),
+			attribute("division", "string", false, false, "readWrite"),
+			attribute("department", "string", false, false, "readWrite"),
+			attribute("manager", "complex", false, false, "readWrite",
+				attribute("value", "string", false, false, "readWrite"),
+			),
+		),
+	}
+}
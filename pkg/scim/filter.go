@@ -0,0 +1,436 @@
+package scim
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Filter is a parsed SCIM filter expression, see RFC 7644 section 3.4.2.2
+type Filter interface {
+	Match(resource map[string]interface{}) bool
+}
+
+type andFilter struct {
+	left, right Filter
+}
+
+type orFilter struct {
+	left, right Filter
+}
+
+type notFilter struct {
+	filter Filter
+}
+
+type presentFilter struct {
+	path string
+}
+
+type compareFilter struct {
+	path  string
+	op    string
+	value interface{}
+}
+
+// valuePathFilter matches when one element of a multi-valued attribute matches the inner
+// filter, e.g. `emails[type eq "work" and value co "@example.com"]`
+type valuePathFilter struct {
+	path   string
+	filter Filter
+}
+
+func (f *andFilter) Match(resource map[string]interface{}) bool {
+	return f.left.Match(resource) && f.right.Match(resource)
+}
+
+func (f *orFilter) Match(resource map[string]interface{}) bool {
+	return f.left.Match(resource) || f.right.Match(resource)
+}
+
+func (f *notFilter) Match(resource map[string]interface{}) bool {
+	return !f.filter.Match(resource)
+}
+
+func (f *presentFilter) Match(resource map[string]interface{}) bool {
+	for _, value := range getValues(resource, f.path) {
+		if value != nil && value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *compareFilter) Match(resource map[string]interface{}) bool {
+	values := getValues(resource, f.path)
+	if len(values) == 0 {
+		return f.op == "ne" && f.value != nil
+	}
+
+	for _, value := range values {
+		if compare(value, f.op, f.value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *valuePathFilter) Match(resource map[string]interface{}) bool {
+	elements, _ := getElements(resource, f.path)
+	for _, element := range elements {
+		if complexValue, ok := element.(map[string]interface{}); ok && f.filter.Match(complexValue) {
+			return true
+		}
+	}
+	return false
+}
+
+func compare(value interface{}, op string, expected interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		s, ok := expected.(string)
+		if !ok {
+			return op == "ne"
+		}
+		v = strings.ToLower(v)
+		s = strings.ToLower(s)
+		switch op {
+		case "eq":
+			return v == s
+		case "ne":
+			return v != s
+		case "co":
+			return strings.Contains(v, s)
+		case "sw":
+			return strings.HasPrefix(v, s)
+		case "ew":
+			return strings.HasSuffix(v, s)
+		case "gt":
+			return v > s
+		case "ge":
+			return v >= s
+		case "lt":
+			return v < s
+		case "le":
+			return v <= s
+		}
+	case float64:
+		n, ok := expected.(float64)
+		if !ok {
+			return op == "ne"
+		}
+		switch op {
+		case "eq":
+			return v == n
+		case "ne":
+			return v != n
+		case "gt":
+			return v > n
+		case "ge":
+			return v >= n
+		case "lt":
+			return v < n
+		case "le":
+			return v <= n
+		}
+	case bool:
+		b, ok := expected.(bool)
+		if !ok {
+			return op == "ne"
+		}
+		switch op {
+		case "eq":
+			return v == b
+		case "ne":
+			return v != b
+		}
+	case nil:
+		return (op == "eq" && expected == nil) || (op == "ne" && expected != nil)
+	}
+	return false
+}
+
+// getElements returns the value of a top-level attribute, or its elements if it is multi-valued
+func getElements(resource map[string]interface{}, path string) ([]interface{}, bool) {
+	container, attribute := resolveContainer(resource, path)
+	if container == nil {
+		return nil, false
+	}
+
+	value, ok := getAttribute(container, attribute)
+	if !ok {
+		return nil, false
+	}
+
+	if array, ok := value.([]interface{}); ok {
+		return array, true
+	}
+	return []interface{}{value}, false
+}
+
+// getValues returns the values at the attribute path, flattening multi-valued attributes.
+// A multi-valued complex attribute without sub-attribute yields the "value" of its elements.
+func getValues(resource map[string]interface{}, path string) []interface{} {
+	subAttribute := ""
+	if i := strings.LastIndex(path, "."); i > strings.LastIndex(path, ":") {
+		path, subAttribute = path[:i], path[i+1:]
+	}
+
+	elements, isMultiValued := getElements(resource, path)
+	res := []interface{}{}
+	for _, element := range elements {
+		complexValue, isComplex := element.(map[string]interface{})
+		switch {
+		case subAttribute != "":
+			if isComplex {
+				if value, ok := getAttribute(complexValue, subAttribute); ok {
+					res = append(res, value)
+				}
+			}
+		case isMultiValued && isComplex:
+			if value, ok := getAttribute(complexValue, "value"); ok {
+				res = append(res, value)
+			}
+		default:
+			res = append(res, element)
+		}
+	}
+	return res
+}
+
+// getAttribute looks up an attribute name case-insensitively, as required by RFC 7643 section 2.1
+func getAttribute(m map[string]interface{}, name string) (interface{}, bool) {
+	key := findKey(m, name)
+	value, ok := m[key]
+	return value, ok
+}
+
+func findKey(m map[string]interface{}, name string) string {
+	if _, ok := m[name]; ok {
+		return name
+	}
+	for key := range m {
+		if strings.EqualFold(key, name) {
+			return key
+		}
+	}
+	return name
+}
+
+// resolveContainer splits a schema URN prefix from the attribute path. Attributes of the core
+// schemas live at the top level of the resource, extension attributes in the object named
+// after the extension URN.
+func resolveContainer(resource map[string]interface{}, path string) (map[string]interface{}, string) {
+	for _, schema := range []string{UserSchema, GroupSchema, EnterpriseUserSchema} {
+		if len(path) > len(schema) && strings.EqualFold(path[:len(schema)+1], schema+":") {
+			attribute := path[len(schema)+1:]
+			if schema != EnterpriseUserSchema {
+				return resource, attribute
+			}
+
+			extension, _ := getAttribute(resource, schema)
+			container, _ := extension.(map[string]interface{})
+			return container, attribute
+		}
+	}
+	return resource, path
+}
+
+type filterParser struct {
+	tokens []string
+	pos    int
+}
+
+// ParseFilter parses the "filter" query parameter of a list request
+func ParseFilter(filter string) (Filter, error) {
+	tokens, err := tokenize(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &filterParser{tokens: tokens}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("unexpected token in filter: %s", p.tokens[p.pos])
+	}
+	return f, nil
+}
+
+func tokenize(s string) ([]string, error) {
+	tokens := []string{}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')' || c == '[' || c == ']':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			j := i + 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				if s[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unterminated string in filter")
+			}
+			tokens = append(tokens, s[i:j+1])
+			i = j + 1
+		default:
+			j := i
+			for ; j < len(s) && !strings.ContainsRune(" \t()[]\"", rune(s[j])); j++ {
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+func (p *filterParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *filterParser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+func (p *filterParser) expect(token string) error {
+	if p.next() != token {
+		return fmt.Errorf("expected \"%s\" in filter", token)
+	}
+	return nil
+}
+
+func (p *filterParser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for strings.EqualFold(p.peek(), "or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orFilter{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (Filter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for strings.EqualFold(p.peek(), "and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andFilter{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (Filter, error) {
+	token := p.next()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of filter")
+	case strings.EqualFold(token, "not"):
+		err := p.expect("(")
+		if err != nil {
+			return nil, err
+		}
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return &notFilter{filter: f}, p.expect(")")
+	case token == "(":
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return f, p.expect(")")
+	case strings.ContainsAny(token, "()[]\""):
+		return nil, fmt.Errorf("unexpected token in filter: %s", token)
+	}
+
+	path := token
+	if p.peek() == "[" {
+		p.next()
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return &valuePathFilter{path: path, filter: f}, p.expect("]")
+	}
+
+	op := strings.ToLower(p.next())
+	switch op {
+	case "pr":
+		return &presentFilter{path: path}, nil
+	case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+		value, err := parseFilterValue(p.next())
+		if err != nil {
+			return nil, err
+		}
+		return &compareFilter{path: path, op: op, value: value}, nil
+	default:
+		return nil, fmt.Errorf("invalid operator in filter: %s", op)
+	}
+}
+
+func parseFilterValue(token string) (interface{}, error) {
+	if token == "" {
+		return nil, fmt.Errorf("missing value in filter")
+	}
+
+	var value interface{}
+	err := json.Unmarshal([]byte(token), &value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value in filter: %s", token)
+	}
+
+	switch value.(type) {
+	case string, float64, bool, nil:
+		return value, nil
+	default:
+		return nil, fmt.Errorf("invalid value in filter: %s", token)
+	}
+}
@@ -0,0 +1,106 @@
+package scim
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func parseResource(t *testing.T, s string) map[string]interface{} {
+	resource := map[string]interface{}{}
+	err := json.Unmarshal([]byte(s), &resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resource
+}
+
+const testUser = `{
+	"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+	"id": "7e3a2c1b",
+	"userName": "alice",
+	"active": true,
+	"displayName": "Alice Smith",
+	"emails": [
+		{"value": "alice@example.com", "type": "work", "primary": true},
+		{"value": "alice@home.example.org", "type": "home"}
+	],
+	"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {
+		"department": "Sales",
+		"manager": {"value": "bob"}
+	},
+	"meta": {"resourceType": "User", "lastModified": "2022-03-01T10:00:00Z"}
+}`
+
+func TestMatchFilter(t *testing.T) {
+	resource := parseResource(t, testUser)
+
+	tests := map[string]bool{
+		`userName eq "alice"`:                      true,
+		`USERNAME eq "ALICE"`:                      true,
+		`userName ne "alice"`:                      false,
+		`displayName co "smith"`:                   true,
+		`displayName sw "Bob"`:                     false,
+		`displayName ew "Smith"`:                   true,
+		`title pr`:                                 false,
+		`emails pr`:                                true,
+		`active eq true`:                           true,
+		`emails.value eq "alice@home.example.org"`: true,
+		`emails[type eq "work" and value ew "example.com"]`:                                 true,
+		`emails[type eq "home" and value ew "example.com"]`:                                 false,
+		`userName eq "bob" or displayName co "Alice"`:                                       true,
+		`not (userName eq "alice")`:                                                         false,
+		`userName eq "alice" and (active eq false or emails pr)`:                            true,
+		`meta.lastModified gt "2022-01-01T00:00:00Z"`:                                       true,
+		`meta.lastModified lt "2022-01-01T00:00:00Z"`:                                       false,
+		`urn:ietf:params:scim:schemas:core:2.0:User:userName eq "alice"`:                    true,
+		`urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department eq "sales"`:  true,
+		`urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:manager.value eq "bob"`: true,
+		`urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:costCenter pr`:          false,
+	}
+
+	for s, expected := range tests {
+		filter, err := ParseFilter(s)
+		if err != nil {
+			t.Errorf("%s: %s", s, err.Error())
+			continue
+		}
+		if ok := filter.Match(resource); ok != expected {
+			t.Errorf("%s: expected %v, got %v", s, expected, ok)
+		}
+	}
+}
+
+func TestParseFilterError(t *testing.T) {
+	for _, s := range []string{
+		`userName eq`,
+		`userName xx "alice"`,
+		`(userName eq "alice"`,
+		`emails[type eq "work"`,
+		`userName eq "alice`,
+		`userName eq "alice" and`,
+	} {
+		if _, err := ParseFilter(s); err == nil {
+			t.Errorf("%s: expected an error", s)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package scim
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bhojpur/iam/pkg/object"
+)
+
+func getRoleDisplayName(role *object.Role) string {
+	if role.DisplayName != "" {
+		return role.DisplayName
+	}
+	return role.Name
+}
+
+// GetGroupResource converts the role to a SCIM Group resource. users maps the ids of the
+// organization's users ("<owner>/<name>") to the users, so that members can be returned by
+// their SCIM id.
+func GetGroupResource(role *object.Role, users map[string]*object.User, baseUrl string) map[string]interface{} {
+	members := []interface{}{}
+	for _, userId := range role.Users {
+		user, ok := users[userId]
+		if !ok {
+			continue
+		}
+
+		members = append(members, map[string]interface{}{
+			"value":   user.Id,
+			"display": user.Name,
+			"$ref":    fmt.Sprintf("%s/Users/%s", baseUrl, user.Id),
+			"type":    "User",
+		})
+	}
+	for _, roleId := range role.Roles {
+		_, name := splitId(roleId)
+		members = append(members, map[string]interface{}{
+			"value": name,
+			"$ref":  fmt.Sprintf("%s/Groups/%s", baseUrl, name),
+			"type":  "Group",
+		})
+	}
+
+	resource := map[string]interface{}{
+		"schemas":     []interface{}{GroupSchema},
+		"id":          role.Name,
+		"displayName": getRoleDisplayName(role),
+		"members":     members,
+	}
+	SetMeta(resource, "Group", role.CreatedTime, "", fmt.Sprintf("%s/Groups/%s", baseUrl, role.Name))
+	return resource
+}
+
+func splitId(id string) (string, string) {
+	tokens := strings.SplitN(id, "/", 2)
+	if len(tokens) != 2 {
+		return "", id
+	}
+	return tokens[0], tokens[1]
+}
+
+// ApplyGroupResource replaces the display name and members of the role with the ones of the
+// resource. getMember resolves the "value" of a member to the id of a user or a role.
+func ApplyGroupResource(resource map[string]interface{}, role *object.Role, getMember func(value string) (string, bool, error)) error {
+	displayName := strings.TrimSpace(getString(resource, "displayName"))
+	if displayName == "" {
+		return NewError(400, "invalidValue", "displayName is required")
+	}
+	role.DisplayName = displayName
+
+	role.Users = []string{}
+	role.Roles = []string{}
+	members, _ := getElements(resource, "members")
+	for _, member := range members {
+		complexValue, ok := member.(map[string]interface{})
+		if !ok {
+			return NewError(400, "invalidValue", "members must be objects")
+		}
+
+		value, _ := getAttribute(complexValue, "value")
+		s, _ := value.(string)
+		if s == "" {
+			return NewError(400, "invalidValue", "the value of a member is required")
+		}
+
+		id, isGroup, err := getMember(s)
+		if err != nil {
+			return err
+		}
+		if isGroup {
+			role.Roles = append(role.Roles, id)
+		} else {
+			role.Users = append(role.Users, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package scim
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strings"
+)
+
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+type PatchRequest struct {
+	Schemas    []string          `json:"schemas"`
+	Operations []*PatchOperation `json:"Operations"`
+}
+
+// patchPath is a parsed PATCH path: "attr", "attr.sub", "attr[filter]", "attr[filter].sub",
+// each optionally prefixed by a schema URN
+type patchPath struct {
+	container    string
+	attribute    string
+	filter       Filter
+	subAttribute string
+}
+
+func parsePatchPath(path string) (*patchPath, error) {
+	res := &patchPath{}
+	for _, schema := range []string{UserSchema, GroupSchema, EnterpriseUserSchema} {
+		if strings.EqualFold(path, schema) {
+			return &patchPath{attribute: schema}, nil
+		}
+		if len(path) > len(schema) && strings.EqualFold(path[:len(schema)+1], schema+":") {
+			if schema == EnterpriseUserSchema {
+				res.container = schema
+			}
+			path = path[len(schema)+1:]
+			break
+		}
+	}
+
+	if i := strings.Index(path, "["); i >= 0 {
+		j := strings.LastIndex(path, "]")
+		if j < i {
+			return nil, fmt.Errorf("invalid path: %s", path)
+		}
+
+		filter, err := ParseFilter(path[i+1 : j])
+		if err != nil {
+			return nil, err
+		}
+
+		res.attribute = path[:i]
+		res.filter = filter
+		rest := path[j+1:]
+		if rest != "" {
+			if !strings.HasPrefix(rest, ".") {
+				return nil, fmt.Errorf("invalid path: %s", path)
+			}
+			res.subAttribute = rest[1:]
+		}
+	} else if i := strings.Index(path, "."); i >= 0 {
+		res.attribute = path[:i]
+		res.subAttribute = path[i+1:]
+	} else {
+		res.attribute = path
+	}
+
+	if res.attribute == "" {
+		return nil, fmt.Errorf("invalid path: %s", path)
+	}
+	return res, nil
+}
+
+// ApplyPatch applies the operations of RFC 7644 section 3.5.2 to the resource in place
+func ApplyPatch(resource map[string]interface{}, operations []*PatchOperation) error {
+	for _, operation := range operations {
+		op := strings.ToLower(operation.Op)
+		if op != "add" && op != "replace" && op != "remove" {
+			return NewError(400, "invalidSyntax", fmt.Sprintf("invalid operation: %s", operation.Op))
+		}
+
+		if operation.Path == "" {
+			if op == "remove" {
+				return NewError(400, "noTarget", "the path is required for remove operations")
+			}
+
+			values, ok := operation.Value.(map[string]interface{})
+			if !ok {
+				return NewError(400, "invalidValue", "the value must be an object when the path is omitted")
+			}
+			for key, value := range values {
+				err := applyOperation(resource, op, key, value)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		err := applyOperation(resource, op, operation.Path, operation.Value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyOperation(resource map[string]interface{}, op string, rawPath string, value interface{}) error {
+	path, err := parsePatchPath(rawPath)
+	if err != nil {
+		return NewError(400, "invalidPath", err.Error())
+	}
+
+	container := resource
+	if path.container != "" {
+		key := findKey(resource, path.container)
+		extension, ok := resource[key].(map[string]interface{})
+		if !ok {
+			if op == "remove" {
+				return nil
+			}
+			extension = map[string]interface{}{}
+			resource[key] = extension
+		}
+		container = extension
+	}
+
+	key := findKey(container, path.attribute)
+	current, exists := container[key]
+
+	if path.filter != nil {
+		array, _ := current.([]interface{})
+		matched := false
+		res := []interface{}{}
+		for _, element := range array {
+			complexValue, ok := element.(map[string]interface{})
+			if !ok || !path.filter.Match(complexValue) {
+				res = append(res, element)
+				continue
+			}
+
+			matched = true
+			switch {
+			case op == "remove" && path.subAttribute == "":
+				// drop the element
+			case op == "remove":
+				delete(complexValue, findKey(complexValue, path.subAttribute))
+				res = append(res, complexValue)
+			case path.subAttribute != "":
+				complexValue[findKey(complexValue, path.subAttribute)] = value
+				res = append(res, complexValue)
+			default:
+				newValue, ok := value.(map[string]interface{})
+				if !ok {
+					return NewError(400, "invalidValue", fmt.Sprintf("the value of %s must be an object", rawPath))
+				}
+				for k, v := range newValue {
+					complexValue[findKey(complexValue, k)] = v
+				}
+				res = append(res, complexValue)
+			}
+		}
+
+		if !matched && op != "remove" {
+			return NewError(400, "noTarget", fmt.Sprintf("no value matches the path: %s", rawPath))
+		}
+		container[key] = res
+		return nil
+	}
+
+	if path.subAttribute != "" {
+		complexValue, ok := current.(map[string]interface{})
+		if !ok {
+			if op == "remove" {
+				return nil
+			}
+			complexValue = map[string]interface{}{}
+			container[key] = complexValue
+		}
+
+		subKey := findKey(complexValue, path.subAttribute)
+		if op == "remove" {
+			delete(complexValue, subKey)
+		} else {
+			complexValue[subKey] = value
+		}
+		return nil
+	}
+
+	switch op {
+	case "remove":
+		if array, ok := current.([]interface{}); ok && value != nil {
+			// some clients (e.g. Azure AD) send the members to remove as the value instead of a filter
+			container[key] = removeElements(array, value)
+		} else if path.container != "" {
+			// the attributes of an extension that are absent are left unchanged by ApplyUserResource,
+			// so a removed one is kept as null
+			container[key] = nil
+		} else {
+			delete(container, key)
+		}
+	case "add", "replace":
+		if array, ok := current.([]interface{}); ok && op == "add" {
+			container[key] = addElements(array, value)
+		} else if complexValue, ok := current.(map[string]interface{}); ok && exists {
+			// sub-attributes that are not specified are left unchanged for both add and replace
+			newValue, ok := value.(map[string]interface{})
+			if !ok {
+				return NewError(400, "invalidValue", fmt.Sprintf("the value of %s must be an object", rawPath))
+			}
+			for k, v := range newValue {
+				complexValue[findKey(complexValue, k)] = v
+			}
+		} else {
+			container[key] = value
+		}
+	}
+	return nil
+}
+
+func toArray(value interface{}) []interface{} {
+	if array, ok := value.([]interface{}); ok {
+		return array
+	}
+	return []interface{}{value}
+}
+
+func getElementValue(element interface{}) string {
+	if complexValue, ok := element.(map[string]interface{}); ok {
+		element, _ = getAttribute(complexValue, "value")
+	}
+	value, _ := element.(string)
+	return value
+}
+
+func addElements(array []interface{}, value interface{}) []interface{} {
+	for _, newElement := range toArray(value) {
+		duplicated := false
+		for _, element := range array {
+			if getElementValue(element) != "" && getElementValue(element) == getElementValue(newElement) {
+				duplicated = true
+				break
+			}
+		}
+		if !duplicated {
+			array = append(array, newElement)
+		}
+	}
+	return array
+}
+
+func removeElements(array []interface{}, value interface{}) []interface{} {
+	res := []interface{}{}
+	for _, element := range array {
+		removed := false
+		for _, target := range toArray(value) {
+			if getElementValue(element) != "" && getElementValue(element) == getElementValue(target) {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			res = append(res, element)
+		}
+	}
+	return res
+}
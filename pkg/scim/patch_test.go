@@ -0,0 +1,114 @@
+package scim
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+)
+
+const testGroup = `{
+	"schemas": ["urn:ietf:params:scim:schemas:core:2.0:Group"],
+	"id": "admins",
+	"displayName": "Admins",
+	"members": [{"value": "u1"}, {"value": "u2"}]
+}`
+
+func getMemberValues(resource map[string]interface{}) []string {
+	res := []string{}
+	members, _ := getElements(resource, "members")
+	for _, member := range members {
+		res = append(res, getElementValue(member))
+	}
+	return res
+}
+
+func TestApplyPatchMembers(t *testing.T) {
+	resource := parseResource(t, testGroup)
+
+	err := ApplyPatch(resource, []*PatchOperation{
+		{Op: "add", Path: "members", Value: []interface{}{map[string]interface{}{"value": "u3"}, map[string]interface{}{"value": "u1"}}},
+		{Op: "remove", Path: `members[value eq "u2"]`},
+		{Op: "Replace", Path: "displayName", Value: "Administrators"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if members := getMemberValues(resource); len(members) != 2 || members[0] != "u1" || members[1] != "u3" {
+		t.Errorf("unexpected members: %v", members)
+	}
+	if displayName := getString(resource, "displayName"); displayName != "Administrators" {
+		t.Errorf("unexpected displayName: %s", displayName)
+	}
+
+	// Azure AD removes members by value instead of with a filter
+	err = ApplyPatch(resource, []*PatchOperation{
+		{Op: "Remove", Path: "members", Value: []interface{}{map[string]interface{}{"value": "u1"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if members := getMemberValues(resource); len(members) != 1 || members[0] != "u3" {
+		t.Errorf("unexpected members: %v", members)
+	}
+}
+
+func TestApplyPatchUser(t *testing.T) {
+	resource := parseResource(t, testUser)
+
+	err := ApplyPatch(resource, []*PatchOperation{
+		{Op: "Replace", Path: "active", Value: "False"},
+		{Op: "replace", Path: `emails[type eq "work"].value`, Value: "alice@example.net"},
+		{Op: "add", Path: "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department", Value: "Marketing"},
+		{Op: "replace", Value: map[string]interface{}{"title": "Manager", "name.formatted": "Alice S."}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if active, err := getBool(resource, "active", true); err != nil || active {
+		t.Errorf("unexpected active: %v, %v", active, err)
+	}
+	if email := getPrimaryValue(resource, "emails"); email != "alice@example.net" {
+		t.Errorf("unexpected email: %s", email)
+	}
+	if department := getString(resource, EnterpriseUserSchema+":department"); department != "Marketing" {
+		t.Errorf("unexpected department: %s", department)
+	}
+	if title := getString(resource, "title"); title != "Manager" {
+		t.Errorf("unexpected title: %s", title)
+	}
+	if formatted := getString(resource, "name.formatted"); formatted != "Alice S." {
+		t.Errorf("unexpected name.formatted: %s", formatted)
+	}
+
+	err = ApplyPatch(resource, []*PatchOperation{
+		{Op: "replace", Path: `emails[type eq "other"].value`, Value: "x@example.com"},
+	})
+	if e, ok := err.(*Error); !ok || e.ScimType != "noTarget" {
+		t.Errorf("expected a noTarget error, got %v", err)
+	}
+
+	err = ApplyPatch(resource, []*PatchOperation{{Op: "move", Path: "title"}})
+	if err == nil {
+		t.Errorf("expected an error for an invalid operation")
+	}
+}
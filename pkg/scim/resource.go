@@ -0,0 +1,190 @@
+package scim
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bhojpur/iam/pkg/utils"
+)
+
+const (
+	UserSchema                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	GroupSchema                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	EnterpriseUserSchema        = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	ListResponseSchema          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	PatchOpSchema               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	ErrorSchema                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	ServiceProviderConfigSchema = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	ResourceTypeSchema          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	SchemaSchema                = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+
+	MaxResults = 1000
+)
+
+type Error struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
+
+func NewError(status int, scimType string, detail string) *Error {
+	return &Error{
+		Schemas:  []string{ErrorSchema},
+		Status:   fmt.Sprintf("%d", status),
+		ScimType: scimType,
+		Detail:   detail,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+func (e *Error) GetStatus() int {
+	return utils.ParseInt(e.Status)
+}
+
+type ListResponse struct {
+	Schemas      []string                 `json:"schemas"`
+	TotalResults int                      `json:"totalResults"`
+	StartIndex   int                      `json:"startIndex"`
+	ItemsPerPage int                      `json:"itemsPerPage"`
+	Resources    []map[string]interface{} `json:"Resources"`
+}
+
+// NewListResponse filters and pages the resources. startIndex is 1-based as in RFC 7644
+// section 3.4.2.4, and count is capped by MaxResults.
+func NewListResponse(resources []map[string]interface{}, filter Filter, startIndex int, count int) *ListResponse {
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if count < 0 {
+		count = 0
+	}
+	if count > MaxResults {
+		count = MaxResults
+	}
+
+	matched := []map[string]interface{}{}
+	for _, resource := range resources {
+		if filter == nil || filter.Match(resource) {
+			matched = append(matched, resource)
+		}
+	}
+
+	page := []map[string]interface{}{}
+	for i := startIndex - 1; i < len(matched) && len(page) < count; i++ {
+		page = append(page, matched[i])
+	}
+
+	return &ListResponse{
+		Schemas:      []string{ListResponseSchema},
+		TotalResults: len(matched),
+		StartIndex:   startIndex,
+		ItemsPerPage: len(page),
+		Resources:    page,
+	}
+}
+
+// SetMeta fills in the "meta" attribute. The version is a weak ETag over the rest of the
+// resource, so it changes whenever any attribute does.
+func SetMeta(resource map[string]interface{}, resourceType string, created string, lastModified string, location string) {
+	delete(resource, "meta")
+	data, _ := json.Marshal(resource)
+	version := fmt.Sprintf("W/\"%s\"", utils.GetSha256Hash(string(data))[:16])
+
+	if lastModified == "" {
+		lastModified = created
+	}
+	resource["meta"] = map[string]interface{}{
+		"resourceType": resourceType,
+		"created":      created,
+		"lastModified": lastModified,
+		"location":     location,
+		"version":      version,
+	}
+}
+
+func GetVersion(resource map[string]interface{}) string {
+	meta, _ := resource["meta"].(map[string]interface{})
+	version, _ := meta["version"].(string)
+	return version
+}
+
+// MatchETag checks an If-Match or If-None-Match header value against the version
+func MatchETag(header string, version string) bool {
+	for _, etag := range strings.Split(header, ",") {
+		etag = strings.TrimSpace(etag)
+		if etag == "*" || strings.TrimPrefix(etag, "W/") == strings.TrimPrefix(version, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Project applies the "attributes" and "excludedAttributes" parameters of RFC 7644 section
+// 3.4.2.5 to the top-level attributes. "id", "schemas" and "meta" are always returned.
+func Project(resource map[string]interface{}, attributes string, excludedAttributes string) map[string]interface{} {
+	getNames := func(s string) []string {
+		names := []string{}
+		for _, name := range strings.Split(s, ",") {
+			name = strings.TrimSpace(name)
+			if i := strings.Index(name, "."); i >= 0 && !strings.HasPrefix(name, "urn:") {
+				name = name[:i]
+			}
+			if name != "" {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+
+	res := map[string]interface{}{}
+	if attributes != "" {
+		for _, key := range []string{"id", "schemas", "meta"} {
+			if value, ok := resource[key]; ok {
+				res[key] = value
+			}
+		}
+		for _, name := range getNames(attributes) {
+			key := findKey(resource, name)
+			if value, ok := resource[key]; ok {
+				res[key] = value
+			}
+		}
+		return res
+	}
+
+	for key, value := range resource {
+		res[key] = value
+	}
+	for _, name := range getNames(excludedAttributes) {
+		key := findKey(res, name)
+		if key != "id" && key != "schemas" && key != "meta" {
+			delete(res, key)
+		}
+	}
+	return res
+}
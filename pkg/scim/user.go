@@ -0,0 +1,221 @@
+package scim
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bhojpur/iam/pkg/object"
+)
+
+// GetUserResource converts the user to a SCIM User resource. The roles that list the user as
+// a member are returned in the read-only "groups" attribute.
+func GetUserResource(user *object.User, roles []*object.Role, baseUrl string) map[string]interface{} {
+	resource := map[string]interface{}{
+		"schemas":  []interface{}{UserSchema, EnterpriseUserSchema},
+		"id":       user.Id,
+		"userName": user.Name,
+		"active":   !user.IsForbidden,
+	}
+
+	setString := func(key string, value string) {
+		if value != "" {
+			resource[key] = value
+		}
+	}
+	setString("externalId", user.ExternalId)
+	setString("displayName", user.DisplayName)
+	setString("title", user.Title)
+	setString("preferredLanguage", user.Language)
+	setString("profileUrl", user.Homepage)
+
+	if user.DisplayName != "" {
+		resource["name"] = map[string]interface{}{"formatted": user.DisplayName}
+	}
+	if user.Email != "" {
+		resource["emails"] = []interface{}{map[string]interface{}{"value": user.Email, "type": "work", "primary": true}}
+	}
+	if user.Phone != "" {
+		resource["phoneNumbers"] = []interface{}{map[string]interface{}{"value": user.Phone, "type": "work", "primary": true}}
+	}
+	if user.Avatar != "" {
+		resource["photos"] = []interface{}{map[string]interface{}{"value": user.Avatar, "type": "photo", "primary": true}}
+	}
+
+	groups := []interface{}{}
+	userId := user.GetId()
+	for _, role := range roles {
+		for _, roleUser := range role.Users {
+			if roleUser == userId {
+				groups = append(groups, map[string]interface{}{
+					"value":   role.Name,
+					"display": getRoleDisplayName(role),
+					"$ref":    fmt.Sprintf("%s/Groups/%s", baseUrl, role.Name),
+					"type":    "direct",
+				})
+				break
+			}
+		}
+	}
+	if len(groups) != 0 {
+		resource["groups"] = groups
+	}
+
+	enterprise := map[string]interface{}{}
+	for key, value := range user.Properties {
+		if key == "manager" {
+			enterprise[key] = map[string]interface{}{"value": value}
+		} else {
+			enterprise[key] = value
+		}
+	}
+	resource[EnterpriseUserSchema] = enterprise
+
+	SetMeta(resource, "User", user.CreatedTime, user.UpdatedTime, fmt.Sprintf("%s/Users/%s", baseUrl, user.Id))
+	return resource
+}
+
+func getString(resource map[string]interface{}, path string) string {
+	values := getValues(resource, path)
+	if len(values) == 0 {
+		return ""
+	}
+	s, _ := values[0].(string)
+	return s
+}
+
+// getPrimaryValue returns the "value" of the primary element of a multi-valued attribute,
+// or of the first element when none is marked as primary
+func getPrimaryValue(resource map[string]interface{}, name string) string {
+	elements, _ := getElements(resource, name)
+	res := ""
+	for _, element := range elements {
+		complexValue, ok := element.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		value, _ := getAttribute(complexValue, "value")
+		s, _ := value.(string)
+		primary, _ := getAttribute(complexValue, "primary")
+		if primary == true || primary == "true" || primary == "True" {
+			return s
+		}
+		if res == "" {
+			res = s
+		}
+	}
+	return res
+}
+
+func getBool(resource map[string]interface{}, name string, defaultValue bool) (bool, error) {
+	value, ok := getAttribute(resource, name)
+	if !ok || value == nil {
+		return defaultValue, nil
+	}
+
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		// Azure AD sends booleans as strings in PATCH requests
+		switch strings.ToLower(v) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return false, NewError(400, "invalidValue", fmt.Sprintf("the value of %s must be a boolean", name))
+}
+
+// ApplyUserResource replaces the attributes of the user with the ones of the resource.
+// Read-only attributes ("id", "groups", "meta") are ignored, and so are the properties that are
+// absent from the Enterprise User extension. The returned password is non-empty when the
+// resource sets a new password.
+func ApplyUserResource(resource map[string]interface{}, user *object.User) (string, error) {
+	userName := getString(resource, "userName")
+	if userName == "" {
+		return "", NewError(400, "invalidValue", "userName is required")
+	}
+	if strings.ContainsAny(userName, "/ ") {
+		return "", NewError(400, "invalidValue", fmt.Sprintf("invalid userName: %s", userName))
+	}
+	if user.Name != "" && user.Name != userName {
+		return "", NewError(400, "mutability", "userName cannot be changed")
+	}
+	user.Name = userName
+
+	active, err := getBool(resource, "active", true)
+	if err != nil {
+		return "", err
+	}
+	user.IsForbidden = !active
+
+	user.ExternalId = getString(resource, "externalId")
+	user.DisplayName = getString(resource, "displayName")
+	if user.DisplayName == "" {
+		user.DisplayName = getString(resource, "name.formatted")
+	}
+	if user.DisplayName == "" {
+		user.DisplayName = strings.TrimSpace(fmt.Sprintf("%s %s", getString(resource, "name.givenName"), getString(resource, "name.familyName")))
+	}
+	user.Title = getString(resource, "title")
+	user.Language = getString(resource, "preferredLanguage")
+	user.Homepage = getString(resource, "profileUrl")
+	user.Email = getPrimaryValue(resource, "emails")
+	user.Phone = getPrimaryValue(resource, "phoneNumbers")
+	if avatar := getPrimaryValue(resource, "photos"); avatar != "" {
+		user.Avatar = avatar
+	}
+
+	// the properties are not only set by SCIM, so only the attributes of the extension that are
+	// present are changed, and a null or empty one removes the property
+	if user.Properties == nil {
+		user.Properties = map[string]string{}
+	}
+	extension, _ := getAttribute(resource, EnterpriseUserSchema)
+	if attributes, ok := extension.(map[string]interface{}); ok {
+		for key, value := range attributes {
+			propertyValue := ""
+			switch v := value.(type) {
+			case string:
+				propertyValue = v
+			case map[string]interface{}:
+				// complex attributes such as "manager" are stored by their "value"
+				propertyValue, _ = v["value"].(string)
+			case float64, bool:
+				propertyValue = fmt.Sprintf("%v", v)
+			}
+
+			if propertyValue == "" {
+				delete(user.Properties, key)
+			} else {
+				user.Properties[key] = propertyValue
+			}
+		}
+	}
+
+	password, _ := getAttribute(resource, "password")
+	passwordString, _ := password.(string)
+	return passwordString, nil
+}
@@ -0,0 +1,59 @@
+package scim
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+
+	"github.com/bhojpur/iam/pkg/object"
+)
+
+func TestApplyUserResourceProperties(t *testing.T) {
+	user := &object.User{
+		Name:       "alice",
+		Properties: map[string]string{"costCenter": "4130", "division": "EMEA", "location": "Paris"},
+	}
+
+	resource := parseResource(t, testUser)
+	err := ApplyPatch(resource, []*PatchOperation{
+		{Op: "remove", Path: EnterpriseUserSchema + ":department"},
+		{Op: "add", Path: EnterpriseUserSchema + ":division", Value: ""},
+		{Op: "add", Path: EnterpriseUserSchema + ":employeeNumber", Value: "42"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ApplyUserResource(resource, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"costCenter": "4130", "location": "Paris", "manager": "bob", "employeeNumber": "42"}
+	if len(user.Properties) != len(expected) {
+		t.Errorf("unexpected properties: %v", user.Properties)
+	}
+	for key, value := range expected {
+		if user.Properties[key] != value {
+			t.Errorf("unexpected property %s: %q, want %q", key, user.Properties[key], value)
+		}
+	}
+}
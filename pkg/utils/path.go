@@ -95,3 +95,18 @@ func FilterQuery(urlString string, blackList []string) string {
 		return urlData.Path
 	}
 }
+
+// credentialQueryKeys are the query parameters which carry credentials: access, refresh and ID
+// tokens, client secrets and assertions, passwords, authorization codes with their PKCE verifiers
+// and CAS tickets
+var credentialQueryKeys = []string{
+	"accessToken", "access_token", "refreshToken", "refresh_token", "id_token_hint", "token",
+	"clientSecret", "client_secret", "client_assertion", "assertion",
+	"password", "code", "code_verifier", "ticket",
+}
+
+// FilterCredentialQuery removes the credentials from the query string of the URL, before it is
+// recorded or logged
+func FilterCredentialQuery(urlString string) string {
+	return FilterQuery(urlString, credentialQueryKeys)
+}
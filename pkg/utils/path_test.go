@@ -0,0 +1,74 @@
+package utils
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FilterCredentialQuery(t *testing.T) {
+	for _, scenario := range []struct {
+		description string
+		input       string
+		expected    string
+	}{
+		{
+			description: "The access token is removed",
+			input:       "/api/get-account?accessToken=123",
+			expected:    "/api/get-account",
+		},
+		{
+			description: "The client secret is removed but not the client id",
+			input:       "/api/get-users?owner=built-in&clientId=abc&clientSecret=456",
+			expected:    "/api/get-users?clientId=abc&owner=built-in",
+		},
+		{
+			description: "The password is removed but not the username",
+			input:       "/api/get-account?username=built-in/admin&password=123",
+			expected:    "/api/get-account?username=built-in/admin",
+		},
+		{
+			description: "The authorization code and the client secret of the token request are removed",
+			input:       "/api/login/oauth/access_token?grant_type=authorization_code&client_id=abc&client_secret=456&code=789",
+			expected:    "/api/login/oauth/access_token?client_id=abc&grant_type=authorization_code",
+		},
+		{
+			description: "The URL without credentials is kept",
+			input:       "/api/get-users?owner=built-in",
+			expected:    "/api/get-users?owner=built-in",
+		},
+	} {
+		actual := FilterCredentialQuery(scenario.input)
+		assert.Equal(t, scenario.expected, actual, scenario.description)
+	}
+}
+
+func Test_FilterCredentialQueryKeys(t *testing.T) {
+	assert.Contains(t, credentialQueryKeys, "code_verifier")
+
+	for _, key := range credentialQueryKeys {
+		actual := FilterCredentialQuery(fmt.Sprintf("/api/login/oauth/access_token?client_id=abc&%s=secret", key))
+		assert.Equal(t, "/api/login/oauth/access_token?client_id=abc", actual, key)
+	}
+}
@@ -23,6 +23,7 @@ package utils
 import (
 	"bytes"
 	"crypto/md5"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -103,6 +104,11 @@ func GetMd5Hash(text string) string {
 	return hex.EncodeToString(hash[:])
 }
 
+func GetSha256Hash(text string) string {
+	hash := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(hash[:])
+}
+
 func IsStrsEmpty(strs ...string) bool {
 	for _, str := range strs {
 		if len(str) == 0 {
@@ -112,6 +118,15 @@ func IsStrsEmpty(strs ...string) bool {
 	return false
 }
 
+func ContainsString(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
+
 func GetMaxLenStr(strs ...string) string {
 	m := 0
 	i := 0
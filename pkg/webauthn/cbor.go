@@ -0,0 +1,177 @@
+package webauthn
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// The authenticators encode the attestation objects and the public keys with the CTAP2
+// canonical CBOR encoding (RFC 8949), so the decoder below doesn't support indefinite lengths.
+
+const maxCborDepth = 16
+
+var errCborTruncated = errors.New("cbor: unexpected end of data")
+
+// decodeCbor decodes the first data item and returns the remaining bytes. Unsigned and negative
+// integers are returned as int64, byte strings as []byte, text strings as string, arrays as
+// []interface{} and maps as map[interface{}]interface{}.
+func decodeCbor(data []byte) (interface{}, []byte, error) {
+	return decodeCborItem(data, 0)
+}
+
+func decodeCborLength(info byte, data []byte) (uint64, []byte, error) {
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info == 24:
+		if len(data) < 1 {
+			return 0, nil, errCborTruncated
+		}
+		return uint64(data[0]), data[1:], nil
+	case info == 25:
+		if len(data) < 2 {
+			return 0, nil, errCborTruncated
+		}
+		return uint64(binary.BigEndian.Uint16(data)), data[2:], nil
+	case info == 26:
+		if len(data) < 4 {
+			return 0, nil, errCborTruncated
+		}
+		return uint64(binary.BigEndian.Uint32(data)), data[4:], nil
+	case info == 27:
+		if len(data) < 8 {
+			return 0, nil, errCborTruncated
+		}
+		return binary.BigEndian.Uint64(data), data[8:], nil
+	default:
+		return 0, nil, fmt.Errorf("cbor: unsupported additional information: %d", info)
+	}
+}
+
+func decodeCborItem(data []byte, depth int) (interface{}, []byte, error) {
+	if depth > maxCborDepth {
+		return nil, nil, errors.New("cbor: nested too deeply")
+	}
+	if len(data) == 0 {
+		return nil, nil, errCborTruncated
+	}
+
+	majorType := data[0] >> 5
+	info := data[0] & 0x1f
+	data = data[1:]
+
+	if majorType == 7 {
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22, 23:
+			return nil, data, nil
+		case 25, 26, 27:
+			bits, rest, err := decodeCborLength(info, data)
+			if err != nil {
+				return nil, nil, err
+			}
+			if info == 26 {
+				return float64(math.Float32frombits(uint32(bits))), rest, nil
+			} else if info == 27 {
+				return math.Float64frombits(bits), rest, nil
+			}
+			// half-precision floats are not used by WebAuthn
+			return nil, rest, nil
+		default:
+			return nil, nil, fmt.Errorf("cbor: unsupported simple value: %d", info)
+		}
+	}
+
+	length, data, err := decodeCborLength(info, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch majorType {
+	case 0:
+		if length > math.MaxInt64 {
+			return nil, nil, errors.New("cbor: integer overflow")
+		}
+		return int64(length), data, nil
+	case 1:
+		if length > math.MaxInt64 {
+			return nil, nil, errors.New("cbor: integer overflow")
+		}
+		return -1 - int64(length), data, nil
+	case 2, 3:
+		if uint64(len(data)) < length {
+			return nil, nil, errCborTruncated
+		}
+		value := data[:length]
+		if majorType == 3 {
+			return string(value), data[length:], nil
+		}
+		return append([]byte{}, value...), data[length:], nil
+	case 4:
+		if uint64(len(data)) < length {
+			return nil, nil, errCborTruncated
+		}
+		array := make([]interface{}, 0, length)
+		for i := uint64(0); i < length; i++ {
+			var element interface{}
+			element, data, err = decodeCborItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			array = append(array, element)
+		}
+		return array, data, nil
+	case 5:
+		if uint64(len(data)) < length {
+			return nil, nil, errCborTruncated
+		}
+		m := make(map[interface{}]interface{}, length)
+		for i := uint64(0); i < length; i++ {
+			var key, value interface{}
+			key, data, err = decodeCborItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, fmt.Errorf("cbor: unsupported map key type: %T", key)
+			}
+			value, data, err = decodeCborItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			m[key] = value
+		}
+		return m, data, nil
+	case 6:
+		// tags are ignored, the tagged item is returned as is
+		return decodeCborItem(data, depth+1)
+	}
+	return nil, nil, fmt.Errorf("cbor: unsupported major type: %d", majorType)
+}
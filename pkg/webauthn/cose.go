@@ -0,0 +1,149 @@
+package webauthn
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithm identifiers of https://www.iana.org/assignments/cose/cose.xhtml
+const (
+	AlgES256 = -7
+	AlgEdDSA = -8
+	AlgRS256 = -257
+)
+
+const (
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+)
+
+type coseKey struct {
+	alg       int64
+	publicKey crypto.PublicKey
+}
+
+func getCoseInt(m map[interface{}]interface{}, label int64) (int64, bool) {
+	value, ok := m[label].(int64)
+	return value, ok
+}
+
+func getCoseBytes(m map[interface{}]interface{}, label int64) []byte {
+	value, _ := m[label].([]byte)
+	return value
+}
+
+// parseCoseKey parses a COSE_Key (RFC 8152 section 7) of one of the supported algorithms
+func parseCoseKey(data []byte) (*coseKey, error) {
+	item, rest, err := decodeCbor(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("unexpected data after the public key")
+	}
+	m, ok := item.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("the public key is not a COSE key")
+	}
+
+	kty, _ := getCoseInt(m, 1)
+	alg, ok := getCoseInt(m, 3)
+	if !ok {
+		return nil, errors.New("the algorithm of the public key is missing")
+	}
+
+	switch alg {
+	case AlgES256:
+		crv, _ := getCoseInt(m, -1)
+		x := getCoseBytes(m, -2)
+		y := getCoseBytes(m, -3)
+		if kty != coseKeyTypeEC2 || crv != coseCurveP256 || len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("invalid ES256 public key")
+		}
+		publicKey := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !publicKey.Curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, errors.New("the ES256 public key is not on the curve")
+		}
+		return &coseKey{alg: alg, publicKey: publicKey}, nil
+	case AlgRS256:
+		n := getCoseBytes(m, -1)
+		e := getCoseBytes(m, -2)
+		if kty != coseKeyTypeRSA || len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RS256 public key")
+		}
+		exponent := 0
+		for _, b := range e {
+			exponent = exponent<<8 | int(b)
+		}
+		return &coseKey{alg: alg, publicKey: &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}}, nil
+	case AlgEdDSA:
+		crv, _ := getCoseInt(m, -1)
+		x := getCoseBytes(m, -2)
+		if kty != coseKeyTypeOKP || crv != coseCurveEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid EdDSA public key")
+		}
+		return &coseKey{alg: alg, publicKey: ed25519.PublicKey(x)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key algorithm: %d", alg)
+	}
+}
+
+func verifySignature(alg int64, publicKey crypto.PublicKey, data []byte, signature []byte) error {
+	ok := false
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if alg == AlgES256 {
+			digest := sha256.Sum256(data)
+			ok = ecdsa.VerifyASN1(key, digest[:], signature)
+		}
+	case *rsa.PublicKey:
+		if alg == AlgRS256 {
+			digest := sha256.Sum256(data)
+			ok = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+		}
+	case ed25519.PublicKey:
+		if alg == AlgEdDSA {
+			ok = ed25519.Verify(key, data, signature)
+		}
+	}
+
+	if !ok {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func (key *coseKey) verify(data []byte, signature []byte) error {
+	return verifySignature(key.alg, key.publicKey, data, signature)
+}
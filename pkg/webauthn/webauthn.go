@@ -0,0 +1,445 @@
+package webauthn
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// The registration and authentication ceremonies of https://www.w3.org/TR/webauthn-2/.
+// Attestation is not used for trust decisions: the credentials are requested with the "none"
+// conveyance, and only the signature of "packed" attestation statements is checked.
+
+const (
+	Timeout = 300000
+
+	flagUserPresent      = 0x01
+	flagUserVerified     = 0x04
+	flagAttestedCredData = 0x40
+	flagExtensionData    = 0x80
+)
+
+var encoding = base64.RawURLEncoding
+
+func Encode(data []byte) string {
+	return encoding.EncodeToString(data)
+}
+
+// Decode decodes base64url, with or without padding
+func Decode(s string) ([]byte, error) {
+	return encoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+func NewChallenge() string {
+	challenge := make([]byte, 32)
+	_, err := rand.Read(challenge)
+	if err != nil {
+		panic(err)
+	}
+	return Encode(challenge)
+}
+
+type RelyingParty struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	Id         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey        string `json:"residentKey"`
+	RequireResidentKey bool   `json:"requireResidentKey"`
+	UserVerification   string `json:"userVerification"`
+}
+
+// CreationOptions is the PublicKeyCredentialCreationOptions for navigator.credentials.create(),
+// the binary members are base64url encoded
+type CreationOptions struct {
+	Rp                     RelyingParty           `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	Challenge              string                 `json:"challenge"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int                    `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions is the PublicKeyCredentialRequestOptions for navigator.credentials.get()
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int                    `json:"timeout"`
+	RpId             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+func (rp *RelyingParty) GetCreationOptions(challenge string, user UserEntity, excludeCredentials []CredentialDescriptor) *CreationOptions {
+	return &CreationOptions{
+		Rp:        *rp,
+		User:      user,
+		Challenge: challenge,
+		PubKeyCredParams: []CredentialParameter{
+			{Type: "public-key", Alg: AlgES256},
+			{Type: "public-key", Alg: AlgEdDSA},
+			{Type: "public-key", Alg: AlgRS256},
+		},
+		Timeout:            Timeout,
+		ExcludeCredentials: excludeCredentials,
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: "preferred",
+		},
+		Attestation: "none",
+	}
+}
+
+func (rp *RelyingParty) GetRequestOptions(challenge string, allowCredentials []CredentialDescriptor, userVerification string) *RequestOptions {
+	return &RequestOptions{
+		Challenge:        challenge,
+		Timeout:          Timeout,
+		RpId:             rp.Id,
+		AllowCredentials: allowCredentials,
+		UserVerification: userVerification,
+	}
+}
+
+// PublicKeyCredential is the credential returned by the browser, the binary members are
+// base64url encoded
+type PublicKeyCredential struct {
+	Id       string `json:"id"`
+	RawId    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON string `json:"clientDataJSON"`
+
+		// registration
+		AttestationObject string   `json:"attestationObject"`
+		Transports        []string `json:"transports"`
+
+		// authentication
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
+
+// Credential is a verified credential to store for the user
+type Credential struct {
+	Id         []byte
+	PublicKey  []byte
+	SignCount  uint32
+	Aaguid     string
+	Transports []string
+}
+
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+type authenticatorData struct {
+	rpIdHash     []byte
+	flags        byte
+	signCount    uint32
+	aaguid       []byte
+	credentialId []byte
+	publicKey    []byte
+}
+
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, errors.New("the authenticator data is too short")
+	}
+
+	res := &authenticatorData{
+		rpIdHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	rest := data[37:]
+
+	if res.flags&flagAttestedCredData != 0 {
+		if len(rest) < 18 {
+			return nil, errors.New("the attested credential data is too short")
+		}
+		res.aaguid = rest[:16]
+		length := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if length == 0 || length > 1023 || len(rest) < length {
+			return nil, errors.New("invalid credential id")
+		}
+		res.credentialId = rest[:length]
+		rest = rest[length:]
+
+		_, afterKey, err := decodeCbor(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %s", err.Error())
+		}
+		res.publicKey = rest[:len(rest)-len(afterKey)]
+		rest = afterKey
+	}
+
+	if res.flags&flagExtensionData != 0 {
+		_, afterExtensions, err := decodeCbor(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid extensions: %s", err.Error())
+		}
+		rest = afterExtensions
+	}
+
+	if len(rest) != 0 {
+		return nil, errors.New("unexpected data after the authenticator data")
+	}
+	return res, nil
+}
+
+// checkOrigin accepts the origins whose host is the RP ID or a subdomain of it, as the
+// browsers do when they scope the credentials. Plain HTTP is only accepted for localhost.
+func (rp *RelyingParty) checkOrigin(origin string) error {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return fmt.Errorf("invalid origin: %s", origin)
+	}
+
+	host := u.Hostname()
+	if host != rp.Id && !strings.HasSuffix(host, "."+rp.Id) {
+		return fmt.Errorf("the origin: %s doesn't belong to the relying party: %s", origin, rp.Id)
+	}
+	if u.Scheme != "https" && !(u.Scheme == "http" && host == "localhost") {
+		return fmt.Errorf("the origin: %s must use HTTPS", origin)
+	}
+	return nil
+}
+
+// verifyClientData checks the collected client data and returns its hash
+func (rp *RelyingParty) verifyClientData(clientDataJSON string, ceremony string, challenge string) ([]byte, error) {
+	data, err := Decode(clientDataJSON)
+	if err != nil {
+		return nil, errors.New("invalid client data")
+	}
+
+	var cd clientData
+	err = json.Unmarshal(data, &cd)
+	if err != nil {
+		return nil, errors.New("invalid client data")
+	}
+	if cd.Type != ceremony {
+		return nil, fmt.Errorf("unexpected ceremony: %s", cd.Type)
+	}
+	if challenge == "" || cd.Challenge != challenge {
+		return nil, errors.New("the challenge doesn't match")
+	}
+	if cd.CrossOrigin {
+		return nil, errors.New("cross-origin requests are not allowed")
+	}
+	err = rp.checkOrigin(cd.Origin)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256(data)
+	return hash[:], nil
+}
+
+func (rp *RelyingParty) verifyAuthenticatorData(authData *authenticatorData, requireUserVerification bool) error {
+	rpIdHash := sha256.Sum256([]byte(rp.Id))
+	if !bytes.Equal(authData.rpIdHash, rpIdHash[:]) {
+		return errors.New("the RP ID hash doesn't match")
+	}
+	if authData.flags&flagUserPresent == 0 {
+		return errors.New("the user is not present")
+	}
+	if requireUserVerification && authData.flags&flagUserVerified == 0 {
+		return errors.New("the user is not verified")
+	}
+	return nil
+}
+
+func formatAaguid(aaguid []byte) string {
+	s := hex.EncodeToString(aaguid)
+	if len(s) != 32 {
+		return s
+	}
+	return fmt.Sprintf("%s-%s-%s-%s-%s", s[:8], s[8:12], s[12:16], s[16:20], s[20:])
+}
+
+// verifyPackedAttestation checks the signature of a "packed" attestation statement, signed
+// either by the attestation certificate or by the credential itself (self attestation)
+func verifyPackedAttestation(attStmt map[interface{}]interface{}, authData []byte, clientDataHash []byte, credentialKey *coseKey) error {
+	alg, _ := attStmt["alg"].(int64)
+	sig, _ := attStmt["sig"].([]byte)
+	signed := append(append([]byte{}, authData...), clientDataHash...)
+
+	x5c, ok := attStmt["x5c"].([]interface{})
+	if !ok || len(x5c) == 0 {
+		if alg != credentialKey.alg {
+			return errors.New("the algorithm of the self attestation doesn't match the credential")
+		}
+		return credentialKey.verify(signed, sig)
+	}
+
+	der, _ := x5c[0].([]byte)
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return fmt.Errorf("invalid attestation certificate: %s", err.Error())
+	}
+	return verifySignature(alg, certificate.PublicKey, signed, sig)
+}
+
+// VerifyRegistration verifies the response of navigator.credentials.create() to the challenge
+func (rp *RelyingParty) VerifyRegistration(pkc *PublicKeyCredential, challenge string, requireUserVerification bool) (*Credential, error) {
+	if pkc.Type != "public-key" {
+		return nil, fmt.Errorf("unsupported credential type: %s", pkc.Type)
+	}
+
+	clientDataHash, err := rp.verifyClientData(pkc.Response.ClientDataJSON, "webauthn.create", challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := Decode(pkc.Response.AttestationObject)
+	if err != nil {
+		return nil, errors.New("invalid attestation object")
+	}
+	item, rest, err := decodeCbor(data)
+	if err != nil || len(rest) != 0 {
+		return nil, errors.New("invalid attestation object")
+	}
+	attestationObject, ok := item.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("invalid attestation object")
+	}
+	format, _ := attestationObject["fmt"].(string)
+	attStmt, _ := attestationObject["attStmt"].(map[interface{}]interface{})
+	rawAuthData, _ := attestationObject["authData"].([]byte)
+
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	err = rp.verifyAuthenticatorData(authData, requireUserVerification)
+	if err != nil {
+		return nil, err
+	}
+	if authData.credentialId == nil {
+		return nil, errors.New("the attested credential data is missing")
+	}
+
+	credentialKey, err := parseCoseKey(authData.publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == "packed" {
+		err = verifyPackedAttestation(attStmt, rawAuthData, clientDataHash, credentialKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rawId, err := Decode(pkc.RawId)
+	if err != nil || !bytes.Equal(rawId, authData.credentialId) {
+		return nil, errors.New("the credential id doesn't match")
+	}
+
+	return &Credential{
+		Id:         authData.credentialId,
+		PublicKey:  authData.publicKey,
+		SignCount:  authData.signCount,
+		Aaguid:     formatAaguid(authData.aaguid),
+		Transports: pkc.Response.Transports,
+	}, nil
+}
+
+// VerifyAssertion verifies the response of navigator.credentials.get() to the challenge with
+// the stored public key and sign count of the credential, and returns the new sign count
+func (rp *RelyingParty) VerifyAssertion(pkc *PublicKeyCredential, challenge string, publicKey []byte, signCount uint32, requireUserVerification bool) (uint32, error) {
+	if pkc.Type != "public-key" {
+		return 0, fmt.Errorf("unsupported credential type: %s", pkc.Type)
+	}
+
+	clientDataHash, err := rp.verifyClientData(pkc.Response.ClientDataJSON, "webauthn.get", challenge)
+	if err != nil {
+		return 0, err
+	}
+
+	rawAuthData, err := Decode(pkc.Response.AuthenticatorData)
+	if err != nil {
+		return 0, errors.New("invalid authenticator data")
+	}
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return 0, err
+	}
+	err = rp.verifyAuthenticatorData(authData, requireUserVerification)
+	if err != nil {
+		return 0, err
+	}
+
+	credentialKey, err := parseCoseKey(publicKey)
+	if err != nil {
+		return 0, err
+	}
+	signature, err := Decode(pkc.Response.Signature)
+	if err != nil {
+		return 0, errors.New("invalid signature")
+	}
+	err = credentialKey.verify(append(append([]byte{}, rawAuthData...), clientDataHash...), signature)
+	if err != nil {
+		return 0, err
+	}
+
+	// a sign count that doesn't increase means that the authenticator may have been cloned,
+	// authenticators that don't implement the counter always return 0
+	if (authData.signCount != 0 || signCount != 0) && authData.signCount <= signCount {
+		return 0, errors.New("the sign count of the credential has not increased, the authenticator may have been cloned")
+	}
+	return authData.signCount, nil
+}
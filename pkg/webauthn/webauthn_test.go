@@ -0,0 +1,212 @@
+package webauthn
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"testing"
+)
+
+// encodeCbor is a minimal CBOR encoder to build the authenticator responses of the tests
+func encodeCbor(value interface{}) []byte {
+	header := func(majorType byte, length int) []byte {
+		switch {
+		case length < 24:
+			return []byte{majorType<<5 | byte(length)}
+		case length < 256:
+			return []byte{majorType<<5 | 24, byte(length)}
+		default:
+			return []byte{majorType<<5 | 25, byte(length >> 8), byte(length)}
+		}
+	}
+
+	switch v := value.(type) {
+	case int:
+		if v < 0 {
+			return header(1, -1-v)
+		}
+		return header(0, v)
+	case []byte:
+		return append(header(2, len(v)), v...)
+	case string:
+		return append(header(3, len(v)), v...)
+	case map[interface{}]interface{}:
+		keys := []string{}
+		encoded := map[string][]byte{}
+		for key, element := range v {
+			k := string(encodeCbor(key))
+			keys = append(keys, k)
+			encoded[k] = encodeCbor(element)
+		}
+		sort.Strings(keys)
+		res := header(5, len(v))
+		for _, k := range keys {
+			res = append(res, k...)
+			res = append(res, encoded[k]...)
+		}
+		return res
+	}
+	panic("unsupported type")
+}
+
+type testAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialId []byte
+	signCount    uint32
+}
+
+func newTestAuthenticator(t *testing.T) *testAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testAuthenticator{key: key, credentialId: []byte("test-credential-id")}
+}
+
+func (a *testAuthenticator) getPublicKey() []byte {
+	return encodeCbor(map[interface{}]interface{}{
+		1:  coseKeyTypeEC2,
+		3:  AlgES256,
+		-1: coseCurveP256,
+		-2: a.key.X.FillBytes(make([]byte, 32)),
+		-3: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+}
+
+func (a *testAuthenticator) getAuthData(rpId string, flags byte, withCredential bool) []byte {
+	rpIdHash := sha256.Sum256([]byte(rpId))
+	data := append([]byte{}, rpIdHash[:]...)
+	data = append(data, flags)
+	data = append(data, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[len(data)-4:], a.signCount)
+	if withCredential {
+		data = append(data, make([]byte, 16)...)
+		data = append(data, 0, 0)
+		binary.BigEndian.PutUint16(data[len(data)-2:], uint16(len(a.credentialId)))
+		data = append(data, a.credentialId...)
+		data = append(data, a.getPublicKey()...)
+	}
+	return data
+}
+
+func getClientDataJSON(ceremony string, challenge string, origin string) []byte {
+	data, _ := json.Marshal(map[string]interface{}{"type": ceremony, "challenge": challenge, "origin": origin})
+	return data
+}
+
+func (a *testAuthenticator) create(rpId string, challenge string, origin string) *PublicKeyCredential {
+	pkc := &PublicKeyCredential{Id: Encode(a.credentialId), RawId: Encode(a.credentialId), Type: "public-key"}
+	pkc.Response.ClientDataJSON = Encode(getClientDataJSON("webauthn.create", challenge, origin))
+	pkc.Response.AttestationObject = Encode(encodeCbor(map[interface{}]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[interface{}]interface{}{},
+		"authData": a.getAuthData(rpId, flagUserPresent|flagUserVerified|flagAttestedCredData, true),
+	}))
+	pkc.Response.Transports = []string{"internal"}
+	return pkc
+}
+
+func (a *testAuthenticator) get(t *testing.T, rpId string, challenge string, origin string, flags byte) *PublicKeyCredential {
+	a.signCount++
+	authData := a.getAuthData(rpId, flags, false)
+	clientDataJSON := getClientDataJSON("webauthn.get", challenge, origin)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkc := &PublicKeyCredential{Id: Encode(a.credentialId), RawId: Encode(a.credentialId), Type: "public-key"}
+	pkc.Response.ClientDataJSON = Encode(clientDataJSON)
+	pkc.Response.AuthenticatorData = Encode(authData)
+	pkc.Response.Signature = Encode(signature)
+	return pkc
+}
+
+func TestRegistrationAndAssertion(t *testing.T) {
+	rp := &RelyingParty{Id: "iam.example.com", Name: "Example"}
+	authenticator := newTestAuthenticator(t)
+
+	challenge := NewChallenge()
+	credential, err := rp.VerifyRegistration(authenticator.create(rp.Id, challenge, "https://iam.example.com"), challenge, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(credential.Id) != string(authenticator.credentialId) || credential.Aaguid != "00000000-0000-0000-0000-000000000000" {
+		t.Errorf("unexpected credential: %v", credential)
+	}
+
+	challenge = NewChallenge()
+	pkc := authenticator.get(t, rp.Id, challenge, "https://login.iam.example.com", flagUserPresent|flagUserVerified)
+	signCount, err := rp.VerifyAssertion(pkc, challenge, credential.PublicKey, credential.SignCount, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signCount != 1 {
+		t.Errorf("signCount = %d, want 1", signCount)
+	}
+
+	// the same assertion can't be replayed because the sign count doesn't increase
+	if _, err = rp.VerifyAssertion(pkc, challenge, credential.PublicKey, signCount, true); err == nil {
+		t.Errorf("a replayed assertion should be rejected")
+	}
+}
+
+func TestAssertionErrors(t *testing.T) {
+	rp := &RelyingParty{Id: "iam.example.com", Name: "Example"}
+	authenticator := newTestAuthenticator(t)
+	publicKey := authenticator.getPublicKey()
+	challenge := NewChallenge()
+
+	tests := []struct {
+		name      string
+		pkc       *PublicKeyCredential
+		challenge string
+		requireUv bool
+	}{
+		{"wrong challenge", authenticator.get(t, rp.Id, NewChallenge(), "https://iam.example.com", flagUserPresent), challenge, false},
+		{"phishing origin", authenticator.get(t, rp.Id, challenge, "https://iam.example.com.evil.net", flagUserPresent), challenge, false},
+		{"plain HTTP", authenticator.get(t, rp.Id, challenge, "http://iam.example.com", flagUserPresent), challenge, false},
+		{"wrong RP ID", authenticator.get(t, "evil.net", challenge, "https://iam.example.com", flagUserPresent), challenge, false},
+		{"user not verified", authenticator.get(t, rp.Id, challenge, "https://iam.example.com", flagUserPresent), challenge, true},
+		{"user not present", authenticator.get(t, rp.Id, challenge, "https://iam.example.com", 0), challenge, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := rp.VerifyAssertion(tt.pkc, tt.challenge, publicKey, 0, tt.requireUv); err == nil {
+				t.Errorf("VerifyAssertion() should fail")
+			}
+		})
+	}
+
+	pkc := authenticator.get(t, rp.Id, challenge, "https://iam.example.com", flagUserPresent)
+	pkc.Response.Signature = Encode([]byte("invalid"))
+	if _, err := rp.VerifyAssertion(pkc, challenge, publicKey, 0, false); err == nil {
+		t.Errorf("an invalid signature should be rejected")
+	}
+}
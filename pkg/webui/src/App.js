@@ -23,7 +23,7 @@ import './App.less';
 import {Helmet} from "react-helmet";
 import * as Setting from "./Setting";
 import {DownOutlined, LogoutOutlined, SettingOutlined} from '@ant-design/icons';
-import {Avatar, BackTop, Dropdown, Layout, Menu, Card, Result, Button} from 'antd';
+import {Alert, Avatar, BackTop, Dropdown, Layout, Menu, Card, Result, Button} from 'antd';
 import {Link, Redirect, Route, Switch, withRouter} from 'react-router-dom'
 import OrganizationListPage from "./OrganizationListPage";
 import OrganizationEditPage from "./OrganizationEditPage";
@@ -79,6 +79,7 @@ class App extends Component {
       classes: props,
       selectedMenuKey: 0,
       account: undefined,
+      impersonation: null,
       uri: null,
     };
 
@@ -147,11 +148,11 @@ class App extends Component {
     }
   }
 
-  getAccessTokenParam() {
-    // "/page?access_token=123"
+  getAccessToken() {
+    // "/page?access_token=123", sent to the server in the "Authorization: Bearer" header
     const params = new URLSearchParams(this.props.location.search);
     const accessToken = params.get("access_token");
-    return accessToken === null ? "" : `?accessToken=${accessToken}`;
+    return accessToken === null ? "" : accessToken;
   }
 
   getCredentialParams() {
@@ -176,14 +177,15 @@ class App extends Component {
   }
 
   getAccount() {
-    let query = this.getAccessTokenParam();
-    if (query === "") {
+    const accessToken = this.getAccessToken();
+    let query = "";
+    if (accessToken === "") {
       query = this.getCredentialParams();
     }
-    if (query !== "") {
+    if (accessToken !== "" || query !== "") {
       window.history.replaceState({}, document.title, this.getUrlWithoutQuery());
     }
-    AuthBackend.getAccount(query)
+    AuthBackend.getAccount(query, accessToken)
       .then((res) => {
         let account = null;
         if (res.status === "ok") {
@@ -199,10 +201,40 @@ class App extends Component {
 
         this.setState({
           account: account,
+          impersonation: res.impersonation ?? null,
         });
       });
   }
 
+  endImpersonation() {
+    AuthBackend.endImpersonation()
+      .then((res) => {
+        if (res.status === 'ok') {
+          Setting.goToLink("/");
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
+  }
+
+  renderImpersonationBanner() {
+    if (this.state.impersonation === null || this.state.account === null || this.state.account === undefined) {
+      return null;
+    }
+
+    const message = i18next.t("general:{impersonator} is signed in as {user} until {time}")
+      .replace("{impersonator}", this.state.impersonation.impersonator)
+      .replace("{user}", `${this.state.account.owner}/${this.state.account.name}`)
+      .replace("{time}", new Date(this.state.impersonation.expireTime * 1000).toLocaleTimeString());
+    return (
+      <Alert type="warning" banner message={message} action={
+        <Button size="small" type="primary" onClick={() => this.endImpersonation()}>
+          {i18next.t("general:End impersonation")}
+        </Button>
+      } />
+    )
+  }
+
   logout() {
     this.setState({
       expired: false,
@@ -615,6 +647,7 @@ class App extends Component {
           <Route exact path="/login" render={(props) => this.renderHomeIfLoggedIn(<SelfLoginPage account={this.state.account} {...props} />)}/>
           <Route exact path="/signup/oauth/authorize" render={(props) => <LoginPage account={this.state.account} type={"code"} mode={"signup"} {...props} onUpdateAccount={(account) => {this.onUpdateAccount(account)}} />}/>
           <Route exact path="/login/oauth/authorize" render={(props) => <LoginPage account={this.state.account} type={"code"} mode={"signin"} {...props} onUpdateAccount={(account) => {this.onUpdateAccount(account)}} />}/>
+          <Route exact path="/login/cas/:applicationName" render={(props) => <LoginPage account={this.state.account} type={"login"} mode={"signin"} {...props} onUpdateAccount={(account) => {this.onUpdateAccount(account)}} />}/>
           <Route exact path="/callback" component={AuthCallback}/>
           <Route exact path="/callback/saml" component={SamlCallback}/>
           <Route exact path="/forget" render={(props) => this.renderHomeIfLoggedIn(<SelfForgetPage {...props} />)}/>
@@ -632,6 +665,9 @@ class App extends Component {
         <BackTop />
         <CustomGithubCorner />
         <div id="content-wrap" style={{flexDirection: "column"}}>
+          {
+            this.renderImpersonationBanner()
+          }
           {
             this.renderContent()
           }
@@ -51,11 +51,13 @@ class ApplicationEditPage extends React.Component {
       organizations: [],
       providers: [],
       uploading: false,
+      provisioningStatus: null,
     };
   }
 
   UNSAFE_componentWillMount() {
     this.getApplication();
+    this.getProvisioningStatus();
     this.getOrganizations();
     this.getProviders();
   }
@@ -69,6 +71,29 @@ class ApplicationEditPage extends React.Component {
       });
   }
 
+  getProvisioningStatus() {
+    ApplicationBackend.getProvisioningStatus("admin", this.state.applicationName)
+      .then((res) => {
+        if (res.status === "ok") {
+          this.setState({
+            provisioningStatus: res.data,
+          });
+        }
+      });
+  }
+
+  syncProvisioning() {
+    ApplicationBackend.syncProvisioning("admin", this.state.applicationName)
+      .then((res) => {
+        if (res.status === "ok") {
+          Setting.showMessage("success", i18next.t("application:Sync started"));
+          this.getProvisioningStatus();
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
+  }
+
   getOrganizations() {
     OrganizationBackend.getOrganizations("admin")
       .then((res) => {
@@ -317,6 +342,163 @@ class ApplicationEditPage extends React.Component {
             }} />
           </Col>
         </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 19 : 2}>
+            {Setting.getLabel(i18next.t("application:Enable magic link"), i18next.t("application:Enable magic link - Tooltip"))} :
+          </Col>
+          <Col span={1} >
+            <Switch checked={this.state.application.enableMagicLink} onChange={checked => {
+              this.updateApplicationField('enableMagicLink', checked);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("application:MFA policy"), i18next.t("application:MFA policy - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Select virtual={false} style={{width: '100%'}} value={this.state.application.mfaPolicy} onChange={(value => {this.updateApplicationField('mfaPolicy', value);})}>
+              {
+                [{id: "", name: i18next.t("application:Same as the organization")}, {id: "Off", name: "Off"}, {id: "Optional", name: "Optional"}, {id: "Required", name: "Required"}, {id: "Required for admins", name: "Required for admins"}]
+                  .map((item, index) => <Option key={index} value={item.id}>{item.name}</Option>)
+              }
+            </Select>
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("application:MFA types"), i18next.t("application:MFA types - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Select virtual={false} mode="multiple" style={{width: '100%'}} value={this.state.application.mfaTypes} onChange={(value => {this.updateApplicationField('mfaTypes', value);})}>
+              {
+                [{id: "totp", name: "Authenticator app"}, {id: "webauthn", name: "Passkey"}]
+                  .map((item, index) => <Option key={index} value={item.id}>{item.name}</Option>)
+              }
+            </Select>
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 19 : 2}>
+            {Setting.getLabel(i18next.t("application:Enable provisioning"), i18next.t("application:Enable provisioning - Tooltip"))} :
+          </Col>
+          <Col span={1} >
+            <Switch checked={this.state.application.enableProvisioning} onChange={checked => {
+              this.updateApplicationField('enableProvisioning', checked);
+            }} />
+          </Col>
+        </Row>
+        {
+          !this.state.application.enableProvisioning ? null : (
+            <React.Fragment>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("application:SCIM endpoint"), i18next.t("application:SCIM endpoint - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input prefix={<LinkOutlined/>} value={this.state.application.provisioningUrl} onChange={e => {
+                    this.updateApplicationField('provisioningUrl', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("application:SCIM token"), i18next.t("application:SCIM token - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.application.provisioningToken} onChange={e => {
+                    this.updateApplicationField('provisioningToken', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("application:Provisioning status"), i18next.t("application:Provisioning status - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  {
+                    this.state.provisioningStatus === null ? i18next.t("application:Not synced yet") : (
+                      `${this.state.provisioningStatus.state} (${this.state.provisioningStatus.lastSyncTime})` +
+                      (this.state.provisioningStatus.lastError === "" ? "" : `, ${this.state.provisioningStatus.lastErrorTime}: ${this.state.provisioningStatus.lastError}`)
+                    )
+                  }
+                  <Button style={{marginLeft: '20px'}} size="small" onClick={() => this.syncProvisioning()}>{i18next.t("application:Sync now")}</Button>
+                </Col>
+              </Row>
+            </React.Fragment>
+          )
+        }
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 19 : 2}>
+            {Setting.getLabel(i18next.t("application:Enable RADIUS"), i18next.t("application:Enable RADIUS - Tooltip"))} :
+          </Col>
+          <Col span={1} >
+            <Switch checked={this.state.application.enableRadius} onChange={checked => {
+              this.updateApplicationField('enableRadius', checked);
+            }} />
+          </Col>
+        </Row>
+        {
+          !this.state.application.enableRadius ? null : (
+            <React.Fragment>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("application:RADIUS secret"), i18next.t("application:RADIUS secret - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.application.radiusSecret} onChange={e => {
+                    this.updateApplicationField('radiusSecret', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("application:RADIUS clients"), i18next.t("application:RADIUS clients - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Select virtual={false} mode="tags" style={{width: '100%'}} placeholder="192.168.1.1, 10.0.0.0/8" value={this.state.application.radiusClients} onChange={(value => {this.updateApplicationField('radiusClients', value);})} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 19 : 2}>
+                  {Setting.getLabel(i18next.t("application:Allow legacy RADIUS clients"), i18next.t("application:Allow legacy RADIUS clients - Tooltip"))} :
+                </Col>
+                <Col span={1} >
+                  <Switch checked={this.state.application.radiusLegacyClients} onChange={checked => {
+                    this.updateApplicationField('radiusLegacyClients', checked);
+                  }} />
+                </Col>
+              </Row>
+            </React.Fragment>
+          )
+        }
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 19 : 2}>
+            {Setting.getLabel(i18next.t("application:Enable CAS"), i18next.t("application:Enable CAS - Tooltip"))} :
+          </Col>
+          <Col span={1} >
+            <Switch checked={this.state.application.enableCas} onChange={checked => {
+              this.updateApplicationField('enableCas', checked);
+            }} />
+          </Col>
+        </Row>
+        {
+          !this.state.application.enableCas ? null : (
+            <Row style={{marginTop: '20px'}} >
+              <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                {Setting.getLabel(i18next.t("application:CAS attributes"), i18next.t("application:CAS attributes - Tooltip"))} :
+              </Col>
+              <Col span={22} >
+                <Select virtual={false} mode="tags" style={{width: '100%'}} value={this.state.application.casAttributes} onChange={(value => {this.updateApplicationField('casAttributes', value);})}>
+                  {
+                    ["name", "id", "organization", "displayName", "email", "phone", "avatar", "affiliation", "title", "tag", "language", "homepage", "roles"]
+                      .map((item, index) => <Option key={index} value={item}>{item}</Option>)
+                  }
+                </Select>
+              </Col>
+            </Row>
+          )
+        }
         <Row style={{marginTop: '20px'}} >
           <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
             {Setting.getLabel(i18next.t("general:Signup URL"), i18next.t("general:Signup URL - Tooltip"))} :
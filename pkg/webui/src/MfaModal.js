@@ -0,0 +1,207 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import {Button, Col, Input, Modal, Row, Space, Typography} from "antd";
+import i18next from "i18next";
+import React from "react";
+import * as UserBackend from "./backend/UserBackend";
+import * as Setting from "./Setting";
+
+export const MfaModal = (props) => {
+  const [visible, setVisible] = React.useState(false);
+  const [confirmLoading, setConfirmLoading] = React.useState(false);
+  const [secret, setSecret] = React.useState("");
+  const [uri, setUri] = React.useState("");
+  const [passcode, setPasscode] = React.useState("");
+  const [recoveryCode, setRecoveryCode] = React.useState("");
+  const [recoveryCodes, setRecoveryCodes] = React.useState(null);
+  const {user} = props;
+  const {account} = props;
+
+  const isSelf = user.id === account?.id;
+  const isMfaEnabled = user.totpSecret !== undefined && user.totpSecret !== "";
+
+  const showModal = () => {
+    setPasscode("");
+    setRecoveryCode("");
+    setRecoveryCodes(null);
+    if (!isMfaEnabled) {
+      UserBackend.initiateTotp().then((res) => {
+        if (res.status === "ok") {
+          setSecret(res.data);
+          setUri(res.data2);
+          setVisible(true);
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
+    } else {
+      setVisible(true);
+    }
+  };
+
+  const handleCancel = () => {
+    setVisible(false);
+    if (recoveryCodes !== null && props.onUpdate !== undefined) {
+      props.onUpdate();
+    }
+  };
+
+  const handleResponse = (res, successMsg) => {
+    setConfirmLoading(false);
+    if (res.status === "ok") {
+      Setting.showMessage("success", successMsg);
+      if (Array.isArray(res.data)) {
+        setRecoveryCodes(res.data);
+      } else {
+        setVisible(false);
+        if (props.onUpdate !== undefined) {
+          props.onUpdate();
+        }
+      }
+    } else {
+      Setting.showMessage("error", res.msg);
+    }
+  };
+
+  const handleEnable = () => {
+    setConfirmLoading(true);
+    UserBackend.enableTotp(passcode).then((res) => handleResponse(res, i18next.t("user:MFA enabled")));
+  };
+
+  const handleDisable = () => {
+    setConfirmLoading(true);
+    UserBackend.disableTotp(passcode, recoveryCode).then((res) => handleResponse(res, i18next.t("user:MFA disabled")));
+  };
+
+  const handleRegenerate = () => {
+    setConfirmLoading(true);
+    UserBackend.regenerateRecoveryCodes(passcode, recoveryCode).then((res) => handleResponse(res, i18next.t("user:Recovery codes regenerated")));
+  };
+
+  const handleReset = () => {
+    Modal.confirm({
+      title: i18next.t("user:Are you sure to reset the MFA of the user?"),
+      onOk: () => {
+        UserBackend.resetMfa(user.owner, user.name).then((res) => handleResponse(res, i18next.t("user:MFA reset")));
+      },
+    });
+  };
+
+  const renderRecoveryCodes = () => {
+    return (
+      <Col>
+        <Row style={{width: "100%", marginBottom: "20px"}}>
+          {i18next.t("user:Save these recovery codes in a safe place. Each of them can be used once to sign in when the authenticator app is not available. They will not be shown again.")}
+        </Row>
+        <Typography.Paragraph copyable={{text: recoveryCodes.join("\n")}}>
+          <pre>{recoveryCodes.join("\n")}</pre>
+        </Typography.Paragraph>
+      </Col>
+    );
+  };
+
+  const renderEnable = () => {
+    return (
+      <Col>
+        <Row style={{width: "100%", marginBottom: "20px"}}>
+          {i18next.t("user:Add the account to your authenticator app with the link or the secret below, then enter the passcode shown by the app.")}
+        </Row>
+        <Row style={{width: "100%", marginBottom: "20px"}}>
+          <a href={uri}>{uri}</a>
+        </Row>
+        <Row style={{width: "100%", marginBottom: "20px"}}>
+          <Input addonBefore={i18next.t("user:Secret")} value={secret} readOnly />
+        </Row>
+        <Row style={{width: "100%", marginBottom: "20px"}}>
+          <Input addonBefore={i18next.t("user:Passcode")} value={passcode} maxLength={6} onChange={(e) => setPasscode(e.target.value)} />
+        </Row>
+      </Col>
+    );
+  };
+
+  const renderVerify = () => {
+    return (
+      <Col>
+        <Row style={{width: "100%", marginBottom: "20px"}}>
+          <Input addonBefore={i18next.t("user:Passcode")} value={passcode} maxLength={6} onChange={(e) => setPasscode(e.target.value)} />
+        </Row>
+        <Row style={{width: "100%", marginBottom: "20px"}}>
+          <Input addonBefore={i18next.t("user:Recovery code")} value={recoveryCode} onChange={(e) => setRecoveryCode(e.target.value)} />
+        </Row>
+      </Col>
+    );
+  };
+
+  const getFooter = () => {
+    if (recoveryCodes !== null) {
+      return [<Button key="close" type="primary" onClick={handleCancel}>{i18next.t("general:OK")}</Button>];
+    } else if (!isMfaEnabled) {
+      return [
+        <Button key="cancel" onClick={handleCancel}>{i18next.t("user:Cancel")}</Button>,
+        <Button key="enable" type="primary" loading={confirmLoading} onClick={handleEnable}>{i18next.t("user:Enable")}</Button>,
+      ];
+    } else {
+      return [
+        <Button key="cancel" onClick={handleCancel}>{i18next.t("user:Cancel")}</Button>,
+        <Button key="regenerate" loading={confirmLoading} onClick={handleRegenerate}>{i18next.t("user:Regenerate recovery codes")}</Button>,
+        <Button key="disable" type="primary" danger loading={confirmLoading} onClick={handleDisable}>{i18next.t("user:Disable")}</Button>,
+      ];
+    }
+  };
+
+  return (
+    <Row>
+      <Space>
+        {
+          isSelf ? (
+            <Button type="default" disabled={props.disabled} onClick={showModal}>
+              {isMfaEnabled ? i18next.t("user:Manage MFA...") : i18next.t("user:Enable MFA...")}
+            </Button>
+          ) : null
+        }
+        {
+          (!isSelf && isMfaEnabled && Setting.isAdminUser(account)) ? (
+            <Button type="default" danger disabled={props.disabled} onClick={handleReset}>
+              {i18next.t("user:Reset MFA")}
+            </Button>
+          ) : null
+        }
+        {
+          (!isSelf && !isMfaEnabled) ? i18next.t("user:Not enabled") : null
+        }
+      </Space>
+      <Modal
+        maskClosable={false}
+        title={i18next.t("user:Multi-factor authentication")}
+        visible={visible}
+        onCancel={handleCancel}
+        footer={getFooter()}
+        width={600}
+      >
+        {
+          recoveryCodes !== null ? renderRecoveryCodes() : (isMfaEnabled ? renderVerify() : renderEnable())
+        }
+      </Modal>
+    </Row>
+  )
+}
+
+export default MfaModal;
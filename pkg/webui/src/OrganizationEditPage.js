@@ -19,13 +19,14 @@
 // THE SOFTWARE.
 
 import React from "react";
-import {Button, Card, Col, Input, Row, Select, Switch} from 'antd';
+import {Button, Card, Col, Input, InputNumber, Row, Select, Switch} from 'antd';
 import * as OrganizationBackend from "./backend/OrganizationBackend";
 import * as LdapBackend from "./backend/LdapBackend";
 import * as Setting from "./Setting";
 import i18next from "i18next";
 import {LinkOutlined} from "@ant-design/icons";
 import LdapTable from "./LdapTable";
+import RiskRuleTable from "./RiskRuleTable";
 
 const { Option } = Select;
 
@@ -76,6 +77,12 @@ class OrganizationEditPage extends React.Component {
     return value;
   }
 
+  generateScimToken() {
+    const bytes = new Uint8Array(32);
+    window.crypto.getRandomValues(bytes);
+    return Array.from(bytes, b => b.toString(16).padStart(2, "0")).join("");
+  }
+
   updateOrganizationField(key, value) {
     value = this.parseOrganizationField(key, value);
 
@@ -159,7 +166,7 @@ class OrganizationEditPage extends React.Component {
           <Col span={22} >
             <Select virtual={false} style={{width: '100%'}} value={this.state.organization.passwordType} onChange={(value => {this.updateOrganizationField('passwordType', value);})}>
               {
-                ['plain', 'salt', 'md5-salt', 'bcrypt']
+                ['plain', 'salt', 'md5-salt', 'bcrypt', 'pbkdf2', 'scrypt', 'argon2id']
                   .map((item, index) => <Option key={index} value={item}>{item}</Option>)
               }
             </Select>
@@ -222,6 +229,172 @@ class OrganizationEditPage extends React.Component {
             }} />
           </Col>
         </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("organization:Domain"), i18next.t("organization:Domain - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Input placeholder="login.example.com" value={this.state.organization.domain} onChange={e => {
+              this.updateOrganizationField('domain', e.target.value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("organization:MFA policy"), i18next.t("organization:MFA policy - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Select virtual={false} style={{width: '100%'}} value={this.state.organization.mfaPolicy} onChange={(value => {this.updateOrganizationField('mfaPolicy', value);})}>
+              {
+                [{id: "", name: i18next.t("organization:Default (Optional)")}, {id: "Off", name: "Off"}, {id: "Optional", name: "Optional"}, {id: "Required", name: "Required"}, {id: "Required for admins", name: "Required for admins"}]
+                  .map((item, index) => <Option key={index} value={item.id}>{item.name}</Option>)
+              }
+            </Select>
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("organization:MFA types"), i18next.t("organization:MFA types - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Select virtual={false} mode="multiple" style={{width: '100%'}} value={this.state.organization.mfaTypes} onChange={(value => {this.updateOrganizationField('mfaTypes', value);})}>
+              {
+                [{id: "totp", name: "Authenticator app"}, {id: "webauthn", name: "Passkey"}]
+                  .map((item, index) => <Option key={index} value={item.id}>{item.name}</Option>)
+              }
+            </Select>
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("organization:Lockout threshold"), i18next.t("organization:Lockout threshold - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <InputNumber min={0} placeholder={5} value={this.state.organization.lockoutThreshold} onChange={value => {
+              this.updateOrganizationField('lockoutThreshold', value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("organization:Lockout duration (minutes)"), i18next.t("organization:Lockout duration (minutes) - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <InputNumber min={0} placeholder={15} value={this.state.organization.lockoutDuration} onChange={value => {
+              this.updateOrganizationField('lockoutDuration', value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("organization:Captcha threshold"), i18next.t("organization:Captcha threshold - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <InputNumber min={0} placeholder={3} value={this.state.organization.captchaThreshold} onChange={value => {
+              this.updateOrganizationField('captchaThreshold', value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("organization:Password min length"), i18next.t("organization:Password min length - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <InputNumber min={0} placeholder={6} value={this.state.organization.passwordMinLength} onChange={value => {
+              this.updateOrganizationField('passwordMinLength', value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("organization:Password complexity"), i18next.t("organization:Password complexity - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Select virtual={false} mode="multiple" style={{width: '100%'}} value={this.state.organization.passwordComplexity} onChange={(value => {this.updateOrganizationField('passwordComplexity', value);})}>
+              {
+                [{id: "uppercase", name: "Uppercase letter"}, {id: "lowercase", name: "Lowercase letter"}, {id: "digit", name: "Digit"}, {id: "special", name: "Special character"}]
+                  .map((item, index) => <Option key={index} value={item.id}>{item.name}</Option>)
+              }
+            </Select>
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("organization:Password banned words"), i18next.t("organization:Password banned words - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Select virtual={false} mode="tags" style={{width: '100%'}} value={this.state.organization.passwordBannedWords} onChange={(value => {this.updateOrganizationField('passwordBannedWords', value);})} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("organization:Password history count"), i18next.t("organization:Password history count - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <InputNumber min={0} placeholder={0} value={this.state.organization.passwordHistoryCount} onChange={value => {
+              this.updateOrganizationField('passwordHistoryCount', value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("organization:Password max age (days)"), i18next.t("organization:Password max age (days) - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <InputNumber min={0} placeholder={0} value={this.state.organization.passwordMaxAge} onChange={value => {
+              this.updateOrganizationField('passwordMaxAge', value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("organization:Password min age (days)"), i18next.t("organization:Password min age (days) - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <InputNumber min={0} placeholder={0} value={this.state.organization.passwordMinAge} onChange={value => {
+              this.updateOrganizationField('passwordMinAge', value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("organization:Breached password check"), i18next.t("organization:Breached password check - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Select virtual={false} style={{width: '100%'}} value={this.state.organization.breachedPasswordCheck} onChange={(value => {this.updateOrganizationField('breachedPasswordCheck', value);})}>
+              {
+                [{id: "", name: i18next.t("organization:Default (Off)")}, {id: "Off", name: "Off"}, {id: "Warn", name: "Warn"}, {id: "Block", name: "Block"}]
+                  .map((item, index) => <Option key={index} value={item.id}>{item.name}</Option>)
+              }
+            </Select>
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("organization:Risk rules"), i18next.t("organization:Risk rules - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <RiskRuleTable
+              title={i18next.t("organization:Risk rules")}
+              table={this.state.organization.riskRules}
+              onUpdateTable={(value) => { this.updateOrganizationField('riskRules', value)}}
+            />
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("organization:SCIM token"), i18next.t("organization:SCIM token - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Input value={this.state.organization.scimToken} onChange={e => {
+              this.updateOrganizationField('scimToken', e.target.value);
+            }} addonAfter={
+              <Button type="link" size="small" onClick={() => this.updateOrganizationField('scimToken', this.generateScimToken())}>
+                {i18next.t("organization:Generate")}
+              </Button>
+            } />
+          </Col>
+        </Row>
         <Row style={{marginTop: '20px'}} >
           <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 19 : 2}>
             {Setting.getLabel(i18next.t("organization:Soft deletion"), i18next.t("organization:Soft deletion - Tooltip"))} :
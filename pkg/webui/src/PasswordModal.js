@@ -55,6 +55,9 @@ export const PasswordModal = (props) => {
       setConfirmLoading(false);
       if (res.status === "ok") {
         Setting.showMessage("success", i18next.t("user:Password Set"));
+        if (res.msg) {
+          Setting.showMessage("warning", res.msg);
+        }
         setVisible(false);
       }
       else Setting.showMessage("error", i18next.t(`user:${res.msg}`));
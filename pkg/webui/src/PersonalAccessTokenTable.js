@@ -0,0 +1,245 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import React from "react";
+import {CopyOutlined, DeleteOutlined} from '@ant-design/icons';
+import {Alert, Button, Checkbox, Input, Modal, Popconfirm, Select, Table, Tag} from 'antd';
+import copy from "copy-to-clipboard";
+import * as UserBackend from "./backend/UserBackend";
+import * as Setting from "./Setting";
+import i18next from "i18next";
+
+const { Option } = Select;
+
+// PersonalAccessTokenTable lists the personal access tokens of a user, the owner of the tokens
+// can create new ones, whose value is only shown once
+class PersonalAccessTokenTable extends React.Component {
+  constructor(props) {
+    super(props);
+    this.state = {
+      classes: props,
+      tokens: [],
+      modalVisible: false,
+      displayName: "",
+      scopes: ["read"],
+      expireDays: 30,
+      token: "",
+    };
+  }
+
+  UNSAFE_componentWillMount() {
+    this.getTokens();
+  }
+
+  getTokens() {
+    UserBackend.getPersonalAccessTokens(this.props.user.owner, this.props.user.name)
+      .then((res) => {
+        if (res.status === "ok") {
+          this.setState({
+            tokens: res.data,
+          });
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
+  }
+
+  openModal() {
+    this.setState({
+      modalVisible: true,
+      displayName: "",
+      scopes: ["read"],
+      expireDays: 30,
+      token: "",
+    });
+  }
+
+  addToken() {
+    let expireTime = "";
+    if (this.state.expireDays > 0) {
+      expireTime = new Date(Date.now() + this.state.expireDays * 24 * 3600 * 1000).toISOString().split(".")[0] + "Z";
+    }
+
+    UserBackend.addPersonalAccessToken({displayName: this.state.displayName, scopes: this.state.scopes, expireTime: expireTime})
+      .then((res) => {
+        if (res.status === "ok") {
+          this.setState({
+            token: res.data2,
+          });
+          this.getTokens();
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
+  }
+
+  deleteToken(token) {
+    UserBackend.deletePersonalAccessToken(`${token.owner}/${token.name}`)
+      .then((res) => {
+        if (res.status === "ok") {
+          Setting.showMessage("success", i18next.t("user:Token revoked"));
+          this.getTokens();
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
+  }
+
+  renderModal() {
+    if (this.state.token !== "") {
+      return (
+        <Modal title={i18next.t("user:New personal access token")} visible={this.state.modalVisible}
+               onCancel={() => this.setState({modalVisible: false})}
+               footer={<Button type="primary" onClick={() => this.setState({modalVisible: false})}>{i18next.t("general:OK")}</Button>}>
+          <Alert type="warning" showIcon message={i18next.t("user:Copy the token now, it won't be shown again")} />
+          <Input.Group compact style={{marginTop: "10px"}}>
+            <Input style={{width: "calc(100% - 32px)"}} value={this.state.token} readOnly />
+            <Button icon={<CopyOutlined />} onClick={() => {
+              copy(this.state.token);
+              Setting.showMessage("success", i18next.t("user:Token copied to clipboard"));
+            }} />
+          </Input.Group>
+        </Modal>
+      );
+    }
+
+    return (
+      <Modal title={i18next.t("user:New personal access token")} visible={this.state.modalVisible}
+             okText={i18next.t("general:Add")} onOk={() => this.addToken()}
+             onCancel={() => this.setState({modalVisible: false})}>
+        <div>
+          {i18next.t("general:Name")}:
+          <Input style={{marginTop: "5px"}} value={this.state.displayName} onChange={e => this.setState({displayName: e.target.value})} />
+        </div>
+        <div style={{marginTop: "10px"}}>
+          {i18next.t("user:Scopes")}:&nbsp;&nbsp;
+          <Checkbox.Group options={[
+            {label: i18next.t("user:Read"), value: "read"},
+            {label: i18next.t("user:Write"), value: "write"},
+          ]} value={this.state.scopes} onChange={value => this.setState({scopes: value})} />
+        </div>
+        <div style={{marginTop: "10px"}}>
+          {i18next.t("user:Expiration")}:&nbsp;&nbsp;
+          <Select virtual={false} style={{width: "200px"}} value={this.state.expireDays} onChange={value => this.setState({expireDays: value})}>
+            <Option key={7} value={7}>{i18next.t("user:7 days")}</Option>
+            <Option key={30} value={30}>{i18next.t("user:30 days")}</Option>
+            <Option key={90} value={90}>{i18next.t("user:90 days")}</Option>
+            <Option key={365} value={365}>{i18next.t("user:1 year")}</Option>
+            <Option key={0} value={0}>{i18next.t("user:No expiration")}</Option>
+          </Select>
+        </div>
+      </Modal>
+    );
+  }
+
+  render() {
+    const columns = [
+      {
+        title: i18next.t("general:Name"),
+        dataIndex: 'displayName',
+        key: 'displayName',
+        width: '150px',
+      },
+      {
+        title: i18next.t("user:Token"),
+        dataIndex: 'tokenPrefix',
+        key: 'tokenPrefix',
+        width: '150px',
+        render: (text, record, index) => {
+          return `${text}...`;
+        }
+      },
+      {
+        title: i18next.t("user:Scopes"),
+        dataIndex: 'scopes',
+        key: 'scopes',
+        width: '120px',
+        render: (text, record, index) => {
+          return record.scopes.map(scope => <Tag key={scope}>{scope}</Tag>);
+        }
+      },
+      {
+        title: i18next.t("general:Created time"),
+        dataIndex: 'createdTime',
+        key: 'createdTime',
+        width: '180px',
+        render: (text, record, index) => {
+          return Setting.getFormattedDate(text);
+        }
+      },
+      {
+        title: i18next.t("user:Expire time"),
+        dataIndex: 'expireTime',
+        key: 'expireTime',
+        width: '180px',
+        render: (text, record, index) => {
+          return text === "" ? i18next.t("user:Never") : Setting.getFormattedDate(text);
+        }
+      },
+      {
+        title: i18next.t("user:Last used time"),
+        dataIndex: 'lastUsedTime',
+        key: 'lastUsedTime',
+        width: '180px',
+        render: (text, record, index) => {
+          return text === "" ? "-" : Setting.getFormattedDate(text);
+        }
+      },
+      {
+        title: i18next.t("user:IP"),
+        dataIndex: 'lastUsedIp',
+        key: 'lastUsedIp',
+        width: '140px',
+      },
+      {
+        title: i18next.t("general:Action"),
+        key: 'action',
+        width: '70px',
+        render: (text, record, index) => {
+          return (
+            <Popconfirm title={i18next.t("user:Are you sure to revoke the token?")} onConfirm={() => this.deleteToken(record)}>
+              <Button icon={<DeleteOutlined />} size="small" />
+            </Popconfirm>
+          );
+        }
+      },
+    ];
+
+    return (
+      <div>
+        <Table rowKey="name" columns={columns} dataSource={this.state.tokens} size="middle" bordered pagination={false}
+               title={() => (
+                 <div>
+                   {i18next.t("user:Personal access tokens")}&nbsp;&nbsp;&nbsp;&nbsp;
+                   {
+                     !this.props.isSelf ? null : (
+                       <Button style={{marginRight: "5px"}} type="primary" size="small" onClick={() => this.openModal()}>{i18next.t("general:Add")}</Button>
+                     )
+                   }
+                 </div>
+               )}
+        />
+        {this.renderModal()}
+      </div>
+    );
+  }
+}
+
+export default PersonalAccessTokenTable;
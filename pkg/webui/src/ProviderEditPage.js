@@ -54,7 +54,7 @@ class ProviderEditPage extends React.Component {
   }
 
   parseProviderField(key, value) {
-    if (["port"].includes(key)) {
+    if (["port", "codeLength", "codeTimeout"].includes(key)) {
       value = Setting.myParseInt(value);
     }
     return value;
@@ -70,6 +70,37 @@ class ProviderEditPage extends React.Component {
     });
   }
 
+  updateUserMappingField(key, value) {
+    let provider = this.state.provider;
+    if (provider.userMapping === null || provider.userMapping === undefined) {
+      provider.userMapping = {};
+    }
+    provider.userMapping[key] = value;
+    this.setState({
+      provider: provider,
+    });
+  }
+
+  renderUserMapping() {
+    const userMapping = this.state.provider.userMapping ?? {};
+    return (
+      ["id", "username", "displayName", "email", "avatarUrl"].map((key, index) => {
+        return (
+          <Row key={index} style={{marginTop: '20px'}} >
+            <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+              {Setting.getLabel(i18next.t(`provider:User mapping - ${key}`), i18next.t("provider:User mapping - Tooltip"))} :
+            </Col>
+            <Col span={22} >
+              <Input value={userMapping[key]} onChange={e => {
+                this.updateUserMappingField(key, e.target.value);
+              }} />
+            </Col>
+          </Row>
+        )
+      })
+    )
+  }
+
   getClientIdLabel() {
     switch (this.state.provider.category) {
       case "Email":
@@ -77,6 +108,8 @@ class ProviderEditPage extends React.Component {
       case "SMS":
         if (this.state.provider.type === "Volc Engine SMS")
           return Setting.getLabel(i18next.t("provider:Access key"), i18next.t("provider:Access key - Tooltip"));
+      case "HumanCheck":
+        return Setting.getLabel(i18next.t("provider:Site key"), i18next.t("provider:Site key - Tooltip"));
       default:
         return Setting.getLabel(i18next.t("provider:Client ID"), i18next.t("provider:Client ID - Tooltip"));
     }
@@ -89,6 +122,8 @@ class ProviderEditPage extends React.Component {
       case "SMS":
         if (this.state.provider.type === "Volc Engine SMS")
           return Setting.getLabel(i18next.t("provider:Secret access key"), i18next.t("provider:SecretAccessKey - Tooltip"));
+      case "HumanCheck":
+        return Setting.getLabel(i18next.t("provider:Secret key"), i18next.t("provider:Secret key - Tooltip"));
       default:
         return Setting.getLabel(i18next.t("provider:Client secret"), i18next.t("provider:Client secret - Tooltip"));
     }
@@ -124,6 +159,33 @@ class ProviderEditPage extends React.Component {
     </Row>;
   }
 
+  renderVerificationCodeConfig() {
+    return (
+      <React.Fragment>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("provider:Code length"), i18next.t("provider:Code length - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <InputNumber min={0} max={10} value={this.state.provider.codeLength} onChange={value => {
+              this.updateProviderField('codeLength', value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("provider:Code timeout (minutes)"), i18next.t("provider:Code timeout - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <InputNumber min={0} value={this.state.provider.codeTimeout} onChange={value => {
+              this.updateProviderField('codeTimeout', value);
+            }} />
+          </Col>
+        </Row>
+      </React.Fragment>
+    )
+  }
+
   loadSamlConfiguration() {
     var parser = new DOMParser();
     var xmlDoc = parser.parseFromString(this.state.provider.metadata, "text/xml");
@@ -184,6 +246,8 @@ class ProviderEditPage extends React.Component {
                 this.updateProviderField('domain', Setting.getFullServerUrl());
               } else if (value === "SAML") {
                 this.updateProviderField('type', 'Aliyun IDaaS');
+              } else if (value === "HumanCheck") {
+                this.updateProviderField('type', 'reCAPTCHA v2');
               }
             })}>
               {
@@ -194,6 +258,7 @@ class ProviderEditPage extends React.Component {
                   {id: 'Storage', name: 'Storage'},
                   {id: 'SAML', name: 'SAML'},
                   {id: 'Payment', name: 'Payment'},
+                  {id: 'HumanCheck', name: 'HumanCheck'},
                 ].map((providerCategory, index) => <Option key={index} value={providerCategory.id}>{providerCategory.name}</Option>)
               }
             </Select>
@@ -299,6 +364,142 @@ class ProviderEditPage extends React.Component {
             </React.Fragment>
           )
         }
+        {
+          this.state.provider.type !== "OpenID" ? null : (
+            <React.Fragment>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("provider:Issuer URL"), i18next.t("provider:Issuer URL - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.provider.issuerUrl} onChange={e => {
+                    this.updateProviderField('issuerUrl', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("provider:Scopes"), i18next.t("provider:Scopes - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.provider.scopes} onChange={e => {
+                    this.updateProviderField('scopes', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("provider:Enable PKCE"), i18next.t("provider:Enable PKCE - Tooltip"))} :
+                </Col>
+                <Col span={1} >
+                  <Switch checked={this.state.provider.enablePkce} onChange={checked => {
+                    this.updateProviderField('enablePkce', checked);
+                  }} />
+                </Col>
+              </Row>
+              {this.renderUserMapping()}
+            </React.Fragment>
+          )
+        }
+        {
+          this.state.provider.type !== "Custom" ? null : (
+            <React.Fragment>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("provider:Auth URL"), i18next.t("provider:Auth URL - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.provider.customAuthUrl} onChange={e => {
+                    this.updateProviderField('customAuthUrl', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("provider:Token URL"), i18next.t("provider:Token URL - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.provider.customTokenUrl} onChange={e => {
+                    this.updateProviderField('customTokenUrl', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("provider:UserInfo URL"), i18next.t("provider:UserInfo URL - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.provider.customUserInfoUrl} onChange={e => {
+                    this.updateProviderField('customUserInfoUrl', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("provider:Scopes"), i18next.t("provider:Scopes - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.provider.scopes} onChange={e => {
+                    this.updateProviderField('scopes', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("provider:Auth style"), i18next.t("provider:Auth style - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Select virtual={false} style={{width: '100%'}} value={this.state.provider.authStyle} onChange={value => {
+                    this.updateProviderField('authStyle', value);
+                  }}>
+                    {
+                      [{id: "", name: "Auto"}, {id: "Header", name: "Header"}, {id: "Params", name: "Params"}].map((authStyle, index) => <Option key={index} value={authStyle.id}>{authStyle.name}</Option>)
+                    }
+                  </Select>
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("provider:Enable PKCE"), i18next.t("provider:Enable PKCE - Tooltip"))} :
+                </Col>
+                <Col span={1} >
+                  <Switch checked={this.state.provider.enablePkce} onChange={checked => {
+                    this.updateProviderField('enablePkce', checked);
+                  }} />
+                </Col>
+              </Row>
+              {this.renderUserMapping()}
+            </React.Fragment>
+          )
+        }
+        {this.state.provider.category !== "HumanCheck" ? null : (
+          <React.Fragment>
+            <Row style={{marginTop: '20px'}} >
+              <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                {Setting.getLabel(i18next.t("provider:Endpoint"), i18next.t("provider:Siteverify endpoint - Tooltip"))} :
+              </Col>
+              <Col span={22} >
+                <Input value={this.state.provider.endpoint} placeholder={Setting.getHumanCheckVerifyUrl(this.state.provider.type)} onChange={e => {
+                  this.updateProviderField('endpoint', e.target.value);
+                }} />
+              </Col>
+            </Row>
+            {
+              this.state.provider.type !== "reCAPTCHA v3" ? null : (
+                <Row style={{marginTop: '20px'}} >
+                  <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                    {Setting.getLabel(i18next.t("provider:Score threshold"), i18next.t("provider:Score threshold - Tooltip"))} :
+                  </Col>
+                  <Col span={22} >
+                    <InputNumber min={0} max={1} step={0.1} placeholder={0.5} value={this.state.provider.scoreThreshold} onChange={value => {
+                      this.updateProviderField('scoreThreshold', value);
+                    }} />
+                  </Col>
+                </Row>
+              )
+            }
+          </React.Fragment>
+        )}
         {this.state.provider.category === "Storage" ? (
           <div>
             <Row style={{marginTop: '20px'}} >
@@ -398,6 +599,7 @@ class ProviderEditPage extends React.Component {
                   }} />
                 </Col>
               </Row>
+              {this.renderVerificationCodeConfig()}
             </React.Fragment>
           ) : this.state.provider.category === "SMS" ? (
             <React.Fragment>
@@ -421,6 +623,7 @@ class ProviderEditPage extends React.Component {
                   }} />
                 </Col>
               </Row>
+              {this.renderVerificationCodeConfig()}
             </React.Fragment>
           ) : this.state.provider.category === "SAML" ? (
             <React.Fragment>
@@ -123,6 +123,25 @@ class RecordListPage extends BaseListPage {
           )
         }
       },
+      {
+        title: i18next.t("record:Impersonator"),
+        dataIndex: 'impersonator',
+        key: 'impersonator',
+        width: '120px',
+        sorter: true,
+        ...this.getColumnSearchProps('impersonator'),
+        render: (text, record, index) => {
+          if (text === "") {
+            return null;
+          }
+
+          return (
+            <Link to={`/users/${text}`}>
+              {text}
+            </Link>
+          )
+        }
+      },
       {
         title: i18next.t("general:Method"),
         dataIndex: 'method',
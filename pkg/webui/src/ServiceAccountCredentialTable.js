@@ -0,0 +1,194 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import React from "react";
+import {CopyOutlined, DeleteOutlined} from '@ant-design/icons';
+import {Alert, Button, Input, Modal, Popconfirm, Table} from 'antd';
+import copy from "copy-to-clipboard";
+import * as UserBackend from "./backend/UserBackend";
+import * as Setting from "./Setting";
+import i18next from "i18next";
+
+const { TextArea } = Input;
+
+// ServiceAccountCredentialTable lists the client secrets and public keys of a service account. A
+// credential is rotated by adding a new one, and deleting the old one once it isn't used anymore.
+class ServiceAccountCredentialTable extends React.Component {
+  constructor(props) {
+    super(props);
+    this.state = {
+      classes: props,
+      credentials: [],
+      secret: "",
+      publicKeyModalVisible: false,
+      publicKey: "",
+    };
+  }
+
+  UNSAFE_componentWillMount() {
+    this.getCredentials();
+  }
+
+  getCredentials() {
+    UserBackend.getServiceAccountCredentials(this.props.user.owner, this.props.user.name)
+      .then((res) => {
+        if (res.status === "ok") {
+          this.setState({
+            credentials: res.data,
+          });
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
+  }
+
+  addCredential(type, publicKey) {
+    UserBackend.addServiceAccountCredential(this.props.user.owner, this.props.user.name, type, publicKey)
+      .then((res) => {
+        if (res.status === "ok") {
+          this.setState({
+            secret: res.data2,
+            publicKeyModalVisible: false,
+            publicKey: "",
+          });
+          this.getCredentials();
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
+  }
+
+  deleteCredential(credential) {
+    UserBackend.deleteServiceAccountCredential(`${credential.owner}/${credential.name}`)
+      .then((res) => {
+        if (res.status === "ok") {
+          Setting.showMessage("success", i18next.t("user:Credential deleted"));
+          this.getCredentials();
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
+  }
+
+  renderSecretModal() {
+    return (
+      <Modal title={i18next.t("user:New client secret")} visible={this.state.secret !== ""}
+             onCancel={() => this.setState({secret: ""})}
+             footer={<Button type="primary" onClick={() => this.setState({secret: ""})}>{i18next.t("general:OK")}</Button>}>
+        <Alert type="warning" showIcon message={i18next.t("user:Copy the secret now, it won't be shown again")} />
+        <div style={{marginTop: "10px"}}>
+          {i18next.t("provider:Client ID")}: {`${this.props.user.owner}/${this.props.user.name}`}
+        </div>
+        <Input.Group compact style={{marginTop: "10px"}}>
+          <Input style={{width: "calc(100% - 32px)"}} value={this.state.secret} readOnly />
+          <Button icon={<CopyOutlined />} onClick={() => {
+            copy(this.state.secret);
+            Setting.showMessage("success", i18next.t("user:Secret copied to clipboard"));
+          }} />
+        </Input.Group>
+      </Modal>
+    );
+  }
+
+  renderPublicKeyModal() {
+    return (
+      <Modal title={i18next.t("user:Add public key")} visible={this.state.publicKeyModalVisible}
+             okText={i18next.t("general:Add")} onOk={() => this.addCredential("public-key", this.state.publicKey)}
+             onCancel={() => this.setState({publicKeyModalVisible: false})}>
+        <TextArea autoSize={{minRows: 6, maxRows: 12}} placeholder="-----BEGIN PUBLIC KEY-----" value={this.state.publicKey}
+                  onChange={e => this.setState({publicKey: e.target.value})} />
+      </Modal>
+    );
+  }
+
+  render() {
+    const columns = [
+      {
+        title: i18next.t("general:Type"),
+        dataIndex: 'type',
+        key: 'type',
+        width: '120px',
+      },
+      {
+        title: i18next.t("user:Key ID"),
+        dataIndex: 'name',
+        key: 'name',
+        width: '300px',
+        render: (text, record, index) => {
+          return record.type === "secret" ? `${record.secretPrefix}...` : text;
+        }
+      },
+      {
+        title: i18next.t("general:Created time"),
+        dataIndex: 'createdTime',
+        key: 'createdTime',
+        width: '180px',
+        render: (text, record, index) => {
+          return Setting.getFormattedDate(text);
+        }
+      },
+      {
+        title: i18next.t("user:Last used time"),
+        dataIndex: 'lastUsedTime',
+        key: 'lastUsedTime',
+        width: '180px',
+        render: (text, record, index) => {
+          return text === "" ? "-" : Setting.getFormattedDate(text);
+        }
+      },
+      {
+        title: i18next.t("user:IP"),
+        dataIndex: 'lastUsedIp',
+        key: 'lastUsedIp',
+        width: '140px',
+      },
+      {
+        title: i18next.t("general:Action"),
+        key: 'action',
+        width: '70px',
+        render: (text, record, index) => {
+          return (
+            <Popconfirm title={i18next.t("user:Are you sure to delete the credential?")} onConfirm={() => this.deleteCredential(record)}>
+              <Button icon={<DeleteOutlined />} size="small" />
+            </Popconfirm>
+          );
+        }
+      },
+    ];
+
+    return (
+      <div>
+        <Table rowKey="name" columns={columns} dataSource={this.state.credentials} size="middle" bordered pagination={false}
+               title={() => (
+                 <div>
+                   {i18next.t("user:Credentials")}&nbsp;&nbsp;&nbsp;&nbsp;
+                   <Button style={{marginRight: "5px"}} type="primary" size="small" onClick={() => this.addCredential("secret", "")}>{i18next.t("user:Add client secret")}</Button>
+                   <Button style={{marginRight: "5px"}} size="small" onClick={() => this.setState({publicKeyModalVisible: true})}>{i18next.t("user:Add public key")}</Button>
+                 </div>
+               )}
+        />
+        {this.renderSecretModal()}
+        {this.renderPublicKeyModal()}
+      </div>
+    );
+  }
+}
+
+export default ServiceAccountCredentialTable;
@@ -163,9 +163,15 @@ function isProviderItemAnswered(user, application, providerItem) {
     return false;
   }
 
-  const provider = providerItem.provider;
-  const linkedValue = user[provider.type.toLowerCase()];
-  return linkedValue !== undefined && linkedValue !== "";
+  return getFederatedIdentities(user, providerItem.provider).length > 0;
+}
+
+export function getFederatedIdentities(user, provider) {
+  if (user === null || !user.federatedIdentities) {
+    return [];
+  }
+
+  return user.federatedIdentities.filter(identity => identity.provider === provider.name);
 }
 
 export function isPromptAnswered(user, application) {
@@ -215,6 +221,8 @@ export function showMessage(type, text) {
     message.success(text);
   } else if (type === "error") {
     message.error(text);
+  } else if (type === "warning") {
+    message.warning(text);
   }
 }
 
@@ -406,6 +414,8 @@ export function getProviderTypeOptions(category) {
         {id: 'Apple', name: 'Apple'},
         {id: 'AzureAD', name: 'AzureAD'},
         {id: 'Slack', name: 'Slack'},
+        {id: 'OpenID', name: 'OpenID'},
+        {id: 'Custom', name: 'Custom OAuth'},
       ]
     );
   } else if (category === "Email") {
@@ -442,11 +452,30 @@ export function getProviderTypeOptions(category) {
       {id: 'WeChat Pay', name: 'WeChat Pay'},
       {id: 'PayPal', name: 'PayPal'},
     ]);
+  } else if (category === "HumanCheck") {
+    return ([
+      {id: 'reCAPTCHA v2', name: 'reCAPTCHA v2'},
+      {id: 'reCAPTCHA v3', name: 'reCAPTCHA v3'},
+      {id: 'hCaptcha', name: 'hCaptcha'},
+      {id: 'Turnstile', name: 'Cloudflare Turnstile'},
+    ]);
   } else {
     return [];
   }
 }
 
+export function getHumanCheckVerifyUrl(type) {
+  if (type === "reCAPTCHA v2" || type === "reCAPTCHA v3") {
+    return "https://www.google.com/recaptcha/api/siteverify";
+  } else if (type === "hCaptcha") {
+    return "https://api.hcaptcha.com/siteverify";
+  } else if (type === "Turnstile") {
+    return "https://challenges.cloudflare.com/turnstile/v0/siteverify";
+  } else {
+    return "";
+  }
+}
+
 export function getProviderSubTypeOptions(type) {
   if (type === "WeCom" || type === "Infoflow") {
     return (
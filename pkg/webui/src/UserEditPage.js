@@ -19,7 +19,7 @@
 // THE SOFTWARE.
 
 import React from "react";
-import {Button, Card, Col, Input, Row, Select, Switch} from 'antd';
+import {Button, Card, Col, Input, Popconfirm, Row, Select, Switch} from 'antd';
 import * as UserBackend from "./backend/UserBackend";
 import * as OrganizationBackend from "./backend/OrganizationBackend";
 import * as Setting from "./Setting";
@@ -28,6 +28,11 @@ import i18next from "i18next";
 import CropperDiv from "./CropperDiv.js";
 import * as ApplicationBackend from "./backend/ApplicationBackend";
 import PasswordModal from "./PasswordModal";
+import MfaModal from "./MfaModal";
+import WebauthnCredentialTable from "./WebauthnCredentialTable";
+import UserSessionTable from "./UserSessionTable";
+import PersonalAccessTokenTable from "./PersonalAccessTokenTable";
+import ServiceAccountCredentialTable from "./ServiceAccountCredentialTable";
 import ResetModal from "./ResetModal";
 import AffiliationSelect from "./common/AffiliationSelect";
 import OAuthWidget from "./common/OAuthWidget";
@@ -71,6 +76,29 @@ class UserEditPage extends React.Component {
       });
   }
 
+  unlockUser() {
+    UserBackend.unlockUser(this.state.user.owner, this.state.user.name)
+      .then((res) => {
+        if (res.status === "ok") {
+          Setting.showMessage("success", i18next.t("user:User unlocked"));
+          this.getUser();
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
+  }
+
+  impersonateUser() {
+    UserBackend.impersonateUser(this.state.user.owner, this.state.user.name)
+      .then((res) => {
+        if (res.status === "ok") {
+          Setting.goToLink("/");
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
+  }
+
   getOrganizations() {
     OrganizationBackend.getOrganizations("admin")
       .then((res) => {
@@ -207,22 +235,112 @@ class UserEditPage extends React.Component {
             {Setting.getLabel(i18next.t("general:User type"), i18next.t("general:User type - Tooltip"))} :
           </Col>
           <Col span={22} >
-            <Select virtual={false} style={{width: '100%'}} value={this.state.user.type} onChange={(value => {this.updateUserField('type', value);})}>
+            <Select virtual={false} style={{width: '100%'}} value={this.state.user.type} disabled={true} onChange={(value => {this.updateUserField('type', value);})}>
               {
-                ['normal-user']
+                ['normal-user', 'service-account']
                   .map((item, index) => <Option key={index} value={item}>{item}</Option>)
               }
             </Select>
           </Col>
         </Row>
+        {
+          this.state.user.type === "service-account" ? (
+            <Row style={{marginTop: '20px'}} >
+              <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                {Setting.getLabel(i18next.t("user:Credentials"), i18next.t("user:Credentials - Tooltip"))} :
+              </Col>
+              <Col span={22} >
+                <ServiceAccountCredentialTable user={this.state.user} />
+              </Col>
+            </Row>
+          ) : (
+            <Row style={{marginTop: '20px'}} >
+              <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                {Setting.getLabel(i18next.t("general:Password"), i18next.t("general:Password - Tooltip"))} :
+              </Col>
+              <Col span={22} >
+                <PasswordModal user={this.state.user} account={this.props.account} disabled={this.state.userName !== this.state.user.name} />
+              </Col>
+            </Row>
+          )
+        }
         <Row style={{marginTop: '20px'}} >
           <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
-            {Setting.getLabel(i18next.t("general:Password"), i18next.t("general:Password - Tooltip"))} :
+            {Setting.getLabel(i18next.t("user:MFA"), i18next.t("user:MFA - Tooltip"))} :
           </Col>
           <Col span={22} >
-            <PasswordModal user={this.state.user} account={this.props.account} disabled={this.state.userName !== this.state.user.name} />
+            <MfaModal user={this.state.user} account={this.props.account} disabled={this.state.userName !== this.state.user.name} onUpdate={() => this.getUser()} />
           </Col>
         </Row>
+        {
+          (!this.state.user.signinWrongTimes || !Setting.isAdminUser(this.props.account)) ? null : (
+            <Row style={{marginTop: '20px'}} >
+              <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                {Setting.getLabel(i18next.t("user:Failed sign-ins"), i18next.t("user:Failed sign-ins - Tooltip"))} :
+              </Col>
+              <Col span={22} >
+                <span style={{marginRight: "10px"}}>
+                  {`${this.state.user.signinWrongTimes} (${Setting.getFormattedDate(this.state.user.lastSigninWrongTime)})`}
+                </span>
+                <Button type="default" onClick={() => this.unlockUser()}>
+                  {i18next.t("user:Unlock")}
+                </Button>
+              </Col>
+            </Row>
+          )
+        }
+        {
+          (this.state.user.id === this.props.account?.id || !Setting.isAdminUser(this.props.account)) ? null : (
+            <Row style={{marginTop: '20px'}} >
+              <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                {Setting.getLabel(i18next.t("user:Impersonate"), i18next.t("user:Impersonate - Tooltip"))} :
+              </Col>
+              <Col span={22} >
+                <Popconfirm title={i18next.t("user:Are you sure to sign in as this user? Your actions will be recorded")} onConfirm={() => this.impersonateUser()}>
+                  <Button type="default">
+                    {i18next.t("user:Sign in as the user")}
+                  </Button>
+                </Popconfirm>
+              </Col>
+            </Row>
+          )
+        }
+        {
+          this.state.user.id !== this.props.account?.id ? null : (
+            <Row style={{marginTop: '20px'}} >
+              <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                {Setting.getLabel(i18next.t("user:Passkeys"), i18next.t("user:Passkeys - Tooltip"))} :
+              </Col>
+              <Col span={22} >
+                <WebauthnCredentialTable />
+              </Col>
+            </Row>
+          )
+        }
+        {
+          (this.state.user.id !== this.props.account?.id && !Setting.isAdminUser(this.props.account)) ? null : (
+            <Row style={{marginTop: '20px'}} >
+              <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                {Setting.getLabel(i18next.t("user:Sessions"), i18next.t("user:Sessions - Tooltip"))} :
+              </Col>
+              <Col span={22} >
+                <UserSessionTable user={this.state.user} />
+              </Col>
+            </Row>
+          )
+        }
+        {
+          (this.state.user.id !== this.props.account?.id && !Setting.isAdminUser(this.props.account)) ? null : (
+            <Row style={{marginTop: '20px'}} >
+              <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                {Setting.getLabel(i18next.t("user:Personal access tokens"), i18next.t("user:Personal access tokens - Tooltip"))} :
+              </Col>
+              <Col span={22} >
+                <PersonalAccessTokenTable user={this.state.user} isSelf={this.state.user.id === this.props.account?.id} />
+              </Col>
+            </Row>
+          )
+        }
         <Row style={{marginTop: '20px'}} >
           <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
             {Setting.getLabel(i18next.t("general:Email"), i18next.t("general:Email - Tooltip"))} :
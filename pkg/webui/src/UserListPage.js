@@ -84,6 +84,26 @@ class UserListPage extends BaseListPage {
       });
   }
 
+  addServiceAccount() {
+    const newUser = this.newUser();
+    newUser.name = `service_${Setting.getRandomName()}`;
+    newUser.type = "service-account";
+    newUser.password = "";
+    newUser.displayName = `New Service Account - ${newUser.name}`;
+    newUser.email = "";
+    newUser.phone = "";
+    newUser.tag = "";
+    UserBackend.addUser(newUser)
+      .then((res) => {
+          Setting.showMessage("success", `Service account added successfully`);
+          this.props.history.push(`/users/${newUser.owner}/${newUser.name}`);
+        }
+      )
+      .catch(error => {
+        Setting.showMessage("error", `Service account failed to add: ${error}`);
+      });
+  }
+
   deleteUser(i) {
     UserBackend.deleteUser(this.state.data[i])
       .then((res) => {
@@ -362,6 +382,7 @@ class UserListPage extends BaseListPage {
                  <div>
                    {i18next.t("general:Users")}&nbsp;&nbsp;&nbsp;&nbsp;
                    <Button style={{marginRight: "5px"}} type="primary" size="small" onClick={this.addUser.bind(this)}>{i18next.t("general:Add")}</Button>
+                   <Button style={{marginRight: "5px"}} size="small" onClick={this.addServiceAccount.bind(this)}>{i18next.t("user:Add service account")}</Button>
                    {
                      this.renderUpload()
                    }
@@ -0,0 +1,168 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import React from "react";
+import {DeleteOutlined} from '@ant-design/icons';
+import {Button, Popconfirm, Table, Tag} from 'antd';
+import * as UserBackend from "./backend/UserBackend";
+import * as Setting from "./Setting";
+import i18next from "i18next";
+
+// UserSessionTable lists the active sessions of a user, each of them can be revoked
+class UserSessionTable extends React.Component {
+  constructor(props) {
+    super(props);
+    this.state = {
+      classes: props,
+      sessions: [],
+    };
+  }
+
+  UNSAFE_componentWillMount() {
+    this.getSessions();
+  }
+
+  getSessions() {
+    UserBackend.getUserSessions(this.props.user.owner, this.props.user.name)
+      .then((res) => {
+        if (res.status === "ok") {
+          this.setState({
+            sessions: res.data,
+          });
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
+  }
+
+  deleteSession(session) {
+    UserBackend.deleteUserSession(`${session.owner}/${session.name}`)
+      .then((res) => {
+        if (res.status === "ok") {
+          Setting.showMessage("success", i18next.t("user:Session revoked"));
+          if (session.isCurrent) {
+            Setting.goToLink("/");
+          } else {
+            this.getSessions();
+          }
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
+  }
+
+  deleteSessions() {
+    UserBackend.deleteUserSessions(this.props.user.owner, this.props.user.name)
+      .then((res) => {
+        if (res.status === "ok") {
+          Setting.showMessage("success", i18next.t("user:Sessions revoked"));
+          if (this.state.sessions.some(session => session.isCurrent)) {
+            Setting.goToLink("/");
+          } else {
+            this.getSessions();
+          }
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
+  }
+
+  render() {
+    const columns = [
+      {
+        title: i18next.t("general:Application"),
+        dataIndex: 'application',
+        key: 'application',
+        width: '150px',
+        render: (text, record, index) => {
+          return (
+            <div>
+              {text === "" ? "-" : text}
+              {!record.isCurrent ? null : <Tag style={{marginLeft: "5px"}} color="green">{i18next.t("user:Current")}</Tag>}
+            </div>
+          );
+        }
+      },
+      {
+        title: i18next.t("user:IP"),
+        dataIndex: 'remoteAddr',
+        key: 'remoteAddr',
+        width: '140px',
+      },
+      {
+        title: i18next.t("user:User agent"),
+        dataIndex: 'userAgent',
+        key: 'userAgent',
+        ellipsis: true,
+      },
+      {
+        title: i18next.t("user:Auth methods"),
+        dataIndex: 'amr',
+        key: 'amr',
+        width: '120px',
+      },
+      {
+        title: i18next.t("general:Created time"),
+        dataIndex: 'createdTime',
+        key: 'createdTime',
+        width: '180px',
+        render: (text, record, index) => {
+          return Setting.getFormattedDate(text);
+        }
+      },
+      {
+        title: i18next.t("user:Last seen time"),
+        dataIndex: 'lastSeenTime',
+        key: 'lastSeenTime',
+        width: '180px',
+        render: (text, record, index) => {
+          return Setting.getFormattedDate(text);
+        }
+      },
+      {
+        title: i18next.t("general:Action"),
+        key: 'action',
+        width: '70px',
+        render: (text, record, index) => {
+          return (
+            <Popconfirm title={i18next.t("user:Are you sure to revoke the session?")} onConfirm={() => this.deleteSession(record)}>
+              <Button icon={<DeleteOutlined />} size="small" />
+            </Popconfirm>
+          );
+        }
+      },
+    ];
+
+    return (
+      <Table rowKey="name" columns={columns} dataSource={this.state.sessions} size="middle" bordered pagination={false}
+             title={() => (
+               <div>
+                 {i18next.t("user:Sessions")}&nbsp;&nbsp;&nbsp;&nbsp;
+                 <Popconfirm title={i18next.t("user:Are you sure to revoke all the sessions?")} onConfirm={() => this.deleteSessions()}>
+                   <Button style={{marginRight: "5px"}} type="primary" size="small">{i18next.t("user:Revoke all")}</Button>
+                 </Popconfirm>
+               </div>
+             )}
+      />
+    );
+  }
+}
+
+export default UserSessionTable;
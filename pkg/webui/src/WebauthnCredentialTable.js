@@ -0,0 +1,171 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import React from "react";
+import {DeleteOutlined, SaveOutlined} from '@ant-design/icons';
+import {Button, Input, Popconfirm, Table, Tooltip} from 'antd';
+import * as UserBackend from "./backend/UserBackend";
+import * as WebAuthn from "./auth/WebAuthn";
+import * as Setting from "./Setting";
+import i18next from "i18next";
+
+// WebauthnCredentialTable lists the passkeys of the current user
+class WebauthnCredentialTable extends React.Component {
+  constructor(props) {
+    super(props);
+    this.state = {
+      classes: props,
+      credentials: [],
+      names: {},
+    };
+  }
+
+  UNSAFE_componentWillMount() {
+    this.getCredentials();
+  }
+
+  getCredentials() {
+    UserBackend.getWebauthnCredentials()
+      .then((res) => {
+        if (res.status === "ok") {
+          this.setState({
+            credentials: res.data,
+            names: {},
+          });
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
+  }
+
+  addCredential() {
+    UserBackend.beginWebauthnRegistration()
+      .then((res) => {
+        if (res.status !== "ok") {
+          Setting.showMessage("error", res.msg);
+          return;
+        }
+
+        WebAuthn.createCredential(res.data)
+          .then((credential) => UserBackend.finishWebauthnRegistration("", credential))
+          .then((res) => {
+            if (res.status === "ok") {
+              Setting.showMessage("success", i18next.t("user:Passkey added"));
+              this.getCredentials();
+            } else {
+              Setting.showMessage("error", res.msg);
+            }
+          })
+          .catch((error) => {
+            Setting.showMessage("error", error.message);
+          });
+      });
+  }
+
+  renameCredential(id) {
+    UserBackend.renameWebauthnCredential(id, this.state.names[id])
+      .then((res) => {
+        if (res.status === "ok") {
+          Setting.showMessage("success", i18next.t("general:Successfully saved"));
+          this.getCredentials();
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
+  }
+
+  deleteCredential(id) {
+    UserBackend.deleteWebauthnCredential(id)
+      .then((res) => {
+        if (res.status === "ok") {
+          Setting.showMessage("success", i18next.t("general:Successfully deleted"));
+          this.getCredentials();
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
+  }
+
+  render() {
+    const columns = [
+      {
+        title: i18next.t("general:Name"),
+        dataIndex: 'name',
+        key: 'name',
+        render: (text, record, index) => {
+          const name = this.state.names[record.id];
+          return (
+            <Input value={name !== undefined ? name : text} onChange={e => {
+              this.setState({names: {...this.state.names, [record.id]: e.target.value}});
+            }} />
+          )
+        }
+      },
+      {
+        title: i18next.t("general:Created time"),
+        dataIndex: 'createdTime',
+        key: 'createdTime',
+        width: '180px',
+        render: (text, record, index) => {
+          return Setting.getFormattedDate(text);
+        }
+      },
+      {
+        title: i18next.t("user:Last used time"),
+        dataIndex: 'lastUsedTime',
+        key: 'lastUsedTime',
+        width: '180px',
+        render: (text, record, index) => {
+          return text === "" ? "-" : Setting.getFormattedDate(text);
+        }
+      },
+      {
+        title: i18next.t("general:Action"),
+        key: 'action',
+        width: '100px',
+        render: (text, record, index) => {
+          return (
+            <div>
+              <Tooltip placement="topLeft" title={i18next.t("general:Save")}>
+                <Button style={{marginRight: "5px"}} disabled={this.state.names[record.id] === undefined} icon={<SaveOutlined />} size="small" onClick={() => this.renameCredential(record.id)} />
+              </Tooltip>
+              <Popconfirm title={i18next.t("user:Are you sure to delete the passkey?")} onConfirm={() => this.deleteCredential(record.id)}>
+                <Button icon={<DeleteOutlined />} size="small" />
+              </Popconfirm>
+            </div>
+          );
+        }
+      },
+    ];
+
+    return (
+      <Table rowKey="id" columns={columns} dataSource={this.state.credentials} size="middle" bordered pagination={false}
+             title={() => (
+               <div>
+                 {i18next.t("user:Passkeys")}&nbsp;&nbsp;&nbsp;&nbsp;
+                 <Button style={{marginRight: "5px"}} type="primary" size="small" disabled={!WebAuthn.isWebAuthnSupported()} onClick={() => this.addCredential()}>{i18next.t("general:Add")}</Button>
+               </div>
+             )}
+      />
+    );
+  }
+}
+
+export default WebauthnCredentialTable;
@@ -20,10 +20,11 @@
 
 import {authConfig} from "./Auth";
 
-export function getAccount(query) {
+export function getAccount(query, accessToken = "") {
   return fetch(`${authConfig.serverUrl}/api/get-account${query}`, {
     method: 'GET',
-    credentials: 'include'
+    credentials: 'include',
+    headers: accessToken === "" ? {} : {"Authorization": `Bearer ${accessToken}`},
   }).then(res => res.json());
 }
 
@@ -68,6 +69,85 @@ export function login(values, oAuthParams) {
   }).then(res => res.json());
 }
 
+export function verifyMfa(values, oAuthParams) {
+  return fetch(`${authConfig.serverUrl}/api/verify-mfa${oAuthParamsToQuery(oAuthParams)}`, {
+    method: 'POST',
+    credentials: "include",
+    body: JSON.stringify(values),
+  }).then(res => res.json());
+}
+
+export function changeExpiredPassword(newPassword, oAuthParams) {
+  let formData = new FormData();
+  formData.append("newPassword", newPassword);
+  return fetch(`${authConfig.serverUrl}/api/change-expired-password${oAuthParamsToQuery(oAuthParams)}`, {
+    method: 'POST',
+    credentials: "include",
+    body: formData,
+  }).then(res => res.json());
+}
+
+export function sendMagicLink(values, oAuthParams) {
+  return fetch(`${authConfig.serverUrl}/api/send-magic-link${oAuthParamsToQuery(oAuthParams)}`, {
+    method: 'POST',
+    credentials: "include",
+    body: JSON.stringify(values),
+  }).then(res => res.json());
+}
+
+export function loginWithMagicLink(values, oAuthParams) {
+  return fetch(`${authConfig.serverUrl}/api/login-magic-link${oAuthParamsToQuery(oAuthParams)}`, {
+    method: 'POST',
+    credentials: "include",
+    body: JSON.stringify(values),
+  }).then(res => res.json());
+}
+
+export function requestPasswordReset(application, username, captchaCode = "") {
+  let formData = new FormData();
+  formData.append("application", application);
+  formData.append("username", username);
+  formData.append("captchaCode", captchaCode);
+  return fetch(`${authConfig.serverUrl}/api/request-password-reset`, {
+    method: 'POST',
+    credentials: "include",
+    body: formData,
+  }).then(res => res.json());
+}
+
+export function resetPassword(values) {
+  let formData = new FormData();
+  for (const key of ["application", "token", "username", "code", "newPassword"]) {
+    if (values[key] !== undefined) {
+      formData.append(key, values[key]);
+    }
+  }
+  return fetch(`${authConfig.serverUrl}/api/reset-password`, {
+    method: 'POST',
+    credentials: "include",
+    body: formData,
+  }).then(res => res.json());
+}
+
+export function beginWebauthnLogin(application, username) {
+  let formData = new FormData();
+  formData.append("application", application);
+  formData.append("username", username);
+  return fetch(`${authConfig.serverUrl}/api/begin-webauthn-login`, {
+    method: 'POST',
+    credentials: "include",
+    body: formData,
+  }).then(res => res.json());
+}
+
+export function finishWebauthnLogin(values, oAuthParams) {
+  return fetch(`${authConfig.serverUrl}/api/finish-webauthn-login${oAuthParamsToQuery(oAuthParams)}`, {
+    method: 'POST',
+    credentials: "include",
+    body: JSON.stringify(values),
+  }).then(res => res.json());
+}
+
 export function logout() {
   return fetch(`${authConfig.serverUrl}/api/logout`, {
     method: 'POST',
@@ -75,6 +155,13 @@ export function logout() {
   }).then(res => res.json());
 }
 
+export function endImpersonation() {
+  return fetch(`${authConfig.serverUrl}/api/end-impersonation`, {
+    method: 'POST',
+    credentials: "include",
+  }).then(res => res.json());
+}
+
 export function unlink(values) {
   return fetch(`${authConfig.serverUrl}/api/unlink`, {
     method: 'POST',
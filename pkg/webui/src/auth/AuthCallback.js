@@ -26,6 +26,8 @@ import * as Util from "./Util";
 import {authConfig} from "./Auth";
 import * as Setting from "../Setting";
 import i18next from "i18next";
+import MfaVerifyForm from "./MfaVerifyForm";
+import MfaEnrollForm from "./MfaEnrollForm";
 
 class AuthCallback extends React.Component {
   constructor(props) {
@@ -33,6 +35,9 @@ class AuthCallback extends React.Component {
     this.state = {
       classes: props,
       msg: null,
+      isMfaRequired: false,
+      mfaTypes: [],
+      isMfaEnrollRequired: false,
     };
   }
 
@@ -101,33 +106,59 @@ class AuthCallback extends React.Component {
     const oAuthParams = Util.getOAuthGetParameters(innerParams);
     AuthBackend.login(body, oAuthParams)
       .then((res) => {
-        if (res.status === 'ok') {
-          const responseType = this.getResponseType();
-          if (responseType === "login") {
-            Util.showMessage("success", `Logged in successfully`);
-            // Setting.goToLinkSoft(this, "/");
-            Setting.goToLink("/");
-          } else if (responseType === "code") {
-            const code = res.data;
-            Setting.goToLink(`${oAuthParams.redirectUri}?code=${code}&state=${oAuthParams.state}`);
-            // Util.showMessage("success", `Authorization code: ${res.data}`);
-          } else if (responseType === "link") {
-            const from = innerParams.get("from");
-            Setting.goToLinkSoft(this, from);
-          }
-        } else {
-          this.setState({
-            msg: res.msg,
-          });
-        }
+        this.handleLoginResponse(res);
       });
   }
 
+  handleLoginResponse(res) {
+    const innerParams = this.getInnerParams();
+    const oAuthParams = Util.getOAuthGetParameters(innerParams);
+
+    if (res.status === "mfa" || res.status === "mfaEnroll") {
+      this.setState({
+        isMfaRequired: true,
+        isMfaEnrollRequired: res.status === "mfaEnroll",
+        mfaTypes: res.data,
+      });
+    } else if (res.status === 'ok') {
+      const responseType = this.getResponseType();
+      if (responseType === "login") {
+        Util.showMessage("success", `Logged in successfully`);
+        // Setting.goToLinkSoft(this, "/");
+        const casLoginUrl = Util.getCasLoginUrl(innerParams);
+        Setting.goToLink(casLoginUrl !== null ? casLoginUrl : "/");
+      } else if (responseType === "code") {
+        const code = res.data;
+        Setting.goToLink(`${oAuthParams.redirectUri}?code=${code}&state=${oAuthParams.state}`);
+        // Util.showMessage("success", `Authorization code: ${res.data}`);
+      } else if (responseType === "link") {
+        const from = innerParams.get("from");
+        Setting.goToLinkSoft(this, from);
+      }
+    } else if (this.state.isMfaRequired) {
+      Util.showMessage("error", res.msg);
+    } else {
+      this.setState({
+        msg: res.msg,
+      });
+    }
+  }
+
   render() {
     return (
       <div style={{textAlign: "center"}}>
         {
-          (this.state.msg === null) ? (
+          (this.state.msg === null && this.state.isMfaRequired) ? (
+            <div style={{display: "inline-block", paddingTop: "10%"}}>
+              {
+                this.state.isMfaEnrollRequired ? (
+                  <MfaEnrollForm mfaTypes={this.state.mfaTypes} oAuthParams={Util.getOAuthGetParameters(this.getInnerParams())} onResponse={(res) => this.handleLoginResponse(res)} />
+                ) : (
+                  <MfaVerifyForm mfaTypes={this.state.mfaTypes} oAuthParams={Util.getOAuthGetParameters(this.getInnerParams())} onResponse={(res) => this.handleLoginResponse(res)} />
+                )
+              }
+            </div>
+          ) : (this.state.msg === null) ? (
             <Spin size="large" tip={i18next.t("login:Signing in...")} style={{paddingTop: "10%"}} />
           ) : (
             Util.renderMessageLarge(this, this.state.msg)
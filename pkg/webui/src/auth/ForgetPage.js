@@ -19,19 +19,18 @@
 // THE SOFTWARE.
 
 import React from "react";
-import {Button, Col, Form, Select, Input, Row, Steps} from "antd";
+import {Button, Col, Form, Input, Result, Row, Steps} from "antd";
 import * as AuthBackend from "./AuthBackend";
 import * as ApplicationBackend from "../backend/ApplicationBackend";
+import * as UserBackend from "../backend/UserBackend";
 import * as Util from "./Util";
 import * as Setting from "../Setting";
 import i18next from "i18next";
-import {CountDownInput} from "../common/CountDownInput";
-import * as UserBackend from "../backend/UserBackend";
-import {CheckCircleOutlined, KeyOutlined, LockOutlined, SolutionOutlined, UserOutlined} from "@ant-design/icons";
+import {CheckCircleOutlined, KeyOutlined, LockOutlined, SafetyOutlined, SolutionOutlined, UserOutlined} from "@ant-design/icons";
 import CustomGithubCorner from "../CustomGithubCorner";
+import {HumanCheckWidget, isHumanCheckWidget} from "../common/HumanCheckWidget";
 
 const { Step } = Steps;
-const { Option } = Select;
 
 class ForgetPage extends React.Component {
   constructor(props) {
@@ -47,21 +46,25 @@ class ForgetPage extends React.Component {
               : props.match.params.applicationName,
       application: null,
       msg: null,
-      userId: "",
       username: "",
-      email: "",
-      isFixed: false,
-      fixedContent: "",
-      token: "",
-      phone: "",
-      emailCode: "",
-      phoneCode: "",
-      verifyType: null, // "email" or "phone"
+      // the token of the password reset link sent by email
+      token: new URLSearchParams(window.location.search).get("token"),
+      requestMsg: "",
       current: 0,
+      humanCheckType: "",
+      humanCheckSiteKey: "",
+      humanCheckToken: "",
+      humanCheckKey: 0,
     };
   }
 
   UNSAFE_componentWillMount() {
+    if (this.state.token !== null) {
+      this.setState({current: 1});
+    } else {
+      this.loadHumanCheckWidget();
+    }
+
     if (this.state.applicationName !== undefined) {
       this.getApplication();
     } else {
@@ -94,386 +97,205 @@ class ForgetPage extends React.Component {
     }
   }
 
-  onFormFinish(name, info, forms) {
-      switch (name) {
-          case "step1":
-              const username = forms.step1.getFieldValue("username")
-              AuthBackend.getEmailAndPhone({
-                  application: forms.step1.getFieldValue("application"),
-                  organization: forms.step1.getFieldValue("organization"),
-                  username: username
-              }).then((res) => {
-                  if (res.status === "ok") {
-                    const phone = res.data.phone;
-                    const email = res.data.email;
-                    this.setState({phone: phone, email: email, username: res.data.name});
-
-                    if (phone !== "" && email === "") {
-                      this.setState({
-                        verifyType: "phone",
-                      });
-                    } else if (phone === "" && email !== "") {
-                      this.setState({
-                        verifyType: "email",
-                      });
-                    }
-
-                    switch (res.data2) {
-                      case "email":
-                        this.setState({isFixed: true, fixedContent: email, verifyType: "email"});
-                        break
-                      case "phone":
-                        this.setState({isFixed: true, fixedContent: phone, verifyType: "phone"});
-                        break
-                      default:
-                        break
-                    }
-                    if (this.state.isFixed) {
-                      forms.step2.setFieldsValue({email: this.state.fixedContent})
-                    }
-                    this.setState({current: 1})
-                  } else {
-                    Setting.showMessage("error", i18next.t(`signup:${res.msg}`));
-                  }
-              });
-              break;
-          case "step2":
-              const oAuthParams = Util.getOAuthGetParameters();
-              AuthBackend.login({
-                  application: forms.step2.getFieldValue("application"),
-                  organization: forms.step2.getFieldValue("organization"),
-                  username: forms.step2.getFieldValue("email"),
-                  code: forms.step2.getFieldValue("emailCode"),
-                  phonePrefix: this.state.application?.organizationObj.phonePrefix,
-                  type: "login"
-              }, oAuthParams).then(res => {
-                  if (res.status === "ok") {
-                      this.setState({current: 2, userId: res.data, username: res.data.split("/")[1]})
-                  } else {
-                      Setting.showMessage("error", i18next.t(`signup:${res.msg}`));
-                  }
-              })
-              break
-          default:
-              break
+  // a password reset is only sent with the token of the human check provider, if there is one
+  loadHumanCheckWidget() {
+    UserBackend.getHumanCheck().then((res) => {
+      if (isHumanCheckWidget(res.type)) {
+        this.setState({
+          humanCheckType: res.type,
+          humanCheckSiteKey: res.appKey,
+          humanCheckToken: "",
+          humanCheckKey: this.state.humanCheckKey + 1,
+        });
       }
+    });
   }
 
-  onFinish(values) {
-    values.username = this.state.username;
-    values.userOwner = this.state.application?.organizationObj.name
-    UserBackend.setPassword(values.userOwner, values.username, "", values?.newPassword).then(res => {
+  onRequest(values) {
+    const application = this.getApplicationObj();
+    AuthBackend.requestPasswordReset(application.name, values.username, this.state.humanCheckToken)
+      .then((res) => {
         if (res.status === "ok") {
-            Setting.goToLogin(this, this.state.application);
+          this.setState({username: values.username, requestMsg: res.msg, current: 1});
         } else {
-            Setting.showMessage("error", i18next.t(`signup:${res.msg}`));
+          Setting.showMessage("error", res.msg);
+          if (this.state.humanCheckType !== "") {
+            this.loadHumanCheckWidget();
+          }
         }
-    })
+      });
   }
 
-  onFinishFailed(values, errorFields) {}
-
-  renderOptions() {
-    let options = [];
-
-    if (this.state.phone !== "") {
-      options.push(
-        <Option key={"phone"} value={"phone"}>
-          &nbsp;&nbsp;{Setting.getMaskedPhone(this.state.phone)}
-        </Option>
-      );
-    }
-
-    if (this.state.email !== "") {
-      options.push(
-        <Option key={"email"} value={"email"}>
-          &nbsp;&nbsp;{Setting.getMaskedEmail(this.state.email)}
-        </Option>
-      );
+  onReset(values) {
+    const application = this.getApplicationObj();
+    const resetValues = {application: application.name, newPassword: values.newPassword};
+    if (this.state.token !== null) {
+      resetValues.token = this.state.token;
+    } else {
+      resetValues.username = this.state.username;
+      resetValues.code = values.code;
     }
 
-    return options;
+    AuthBackend.resetPassword(resetValues)
+      .then((res) => {
+        if (res.status === "ok") {
+          if (res.msg) {
+            Setting.showMessage("warning", res.msg);
+          }
+          this.setState({current: 2});
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
   }
 
-  renderForm(application) {
+  renderRequestForm() {
     return (
-        <Form.Provider onFormFinish={(name, {info, forms}) => {
-            this.onFormFinish(name, info, forms);
-        }}>
-          {/* STEP 1: input username -> get email & phone */}
-          <Form
-              hidden={this.state.current !== 0}
-              ref={this.form}
-              name="step1"
-              onFinishFailed={(errorInfo) => console.log(errorInfo)}
-              initialValues={{
-                application: application.name,
-                organization: application.organization,
-              }}
-              style={{ width: "300px" }}
-              size="large"
-          >
-            <Form.Item
-                style={{ height: 0, visibility: "hidden" }}
-                name="application"
-                rules={[
-                  {
-                    required: true,
-                    message: i18next.t(
-                        `forget:Please input your application!`
-                    ),
-                  },
-                ]}
-            />
-            <Form.Item
-                style={{ height: 0, visibility: "hidden" }}
-                name="organization"
-                rules={[
-                  {
-                    required: true,
-                    message: i18next.t(
-                        `forget:Please input your organization!`
-                    ),
-                  },
-                ]}
-            />
-            <Form.Item
-                name="username"
-                rules={[
-                  {
-                    required: true,
-                    message: i18next.t(
-                        "forget:Please input your username!"
-                    ),
-                    whitespace: true,
-                  },
-                ]}
-            >
-              <Input
-                  onChange={(e) => {
-                    this.setState({
-                      username: e.target.value,
-                    });
-                  }}
-                  prefix={<UserOutlined />}
-                  placeholder={i18next.t("login:username, Email or phone")}
-              />
-            </Form.Item>
-            <br />
+      <Form
+        name="request"
+        onFinish={(values) => this.onRequest(values)}
+        style={{width: "300px"}}
+        size="large"
+      >
+        <Form.Item
+          name="username"
+          rules={[
+            {
+              required: true,
+              message: i18next.t("forget:Please input your username!"),
+              whitespace: true,
+            },
+          ]}
+        >
+          <Input
+            prefix={<UserOutlined />}
+            placeholder={i18next.t("login:username, Email or phone")}
+          />
+        </Form.Item>
+        {
+          this.state.humanCheckType === "" ? null : (
             <Form.Item>
-              <Button block type="primary" htmlType="submit">
-                {i18next.t("forget:Next Step")}
-              </Button>
+              <HumanCheckWidget
+                key={this.state.humanCheckKey}
+                type={this.state.humanCheckType}
+                siteKey={this.state.humanCheckSiteKey}
+                onChange={token => this.setState({humanCheckToken: token})}
+              />
             </Form.Item>
-          </Form>
+          )
+        }
+        <br />
+        <Form.Item>
+          <Button block type="primary" htmlType="submit">
+            {i18next.t("forget:Next Step")}
+          </Button>
+        </Form.Item>
+      </Form>
+    );
+  }
 
-          {/* STEP 2: verify email or phone */}
-          <Form
-              hidden={this.state.current !== 1}
-              ref={this.form}
-              name="step2"
-              onFinishFailed={(errorInfo) =>
-                  this.onFinishFailed(
-                      errorInfo.values,
-                      errorInfo.errorFields,
-                      errorInfo.outOfDate
-                  )
-              }
-              initialValues={{
-                application: application.name,
-                organization: application.organization,
-              }}
-              style={{ width: "300px" }}
-              size="large"
-          >
-            <Form.Item
-                style={{ height: 0, visibility: "hidden" }}
-                name="application"
-                rules={[
-                  {
-                    required: true,
-                    message: i18next.t(
-                        `forget:Please input your application!`
-                    ),
-                  },
-                ]}
-            />
-            <Form.Item
-                style={{ height: 0, visibility: "hidden" }}
-                name="organization"
-                rules={[
-                  {
-                    required: true,
-                    message: i18next.t(
-                        `forget:Please input your organization!`
-                    ),
-                  },
-                ]}
-            />
-            <Form.Item
-                name="email" //use email instead of email/phone to adapt to RequestForm in account.go
-                validateFirst
-                hasFeedback
-            >
-               {
-                  this.state.isFixed ? <Input disabled/> :
-                     <Select
-                       key={this.state.verifyType}
-                       virtual={false} style={{textAlign: 'left'}}
-                       defaultValue={this.state.verifyType}
-                       disabled={this.state.username === ""}
-                       placeholder={i18next.t("forget:Choose email or phone")}
-                       onChange={(value) => {
-                         this.setState({
-                           verifyType: value,
-                         });
-                       }}
-                     >
-                       {
-                         this.renderOptions()
-                       }
-                     </Select>
-               }
-            </Form.Item>
-            <Form.Item
-                name="emailCode" //use emailCode instead of email/phoneCode to adapt to RequestForm in account.go
+  renderResetForm() {
+    return (
+      <Form
+        name="reset"
+        onFinish={(values) => this.onReset(values)}
+        style={{width: "300px"}}
+        size="large"
+      >
+        {
+          this.state.token !== null ? null : (
+            <React.Fragment>
+              <div style={{marginBottom: "20px", textAlign: "left"}}>
+                {this.state.requestMsg}. {i18next.t("forget:Open the link in the email, or input the code sent by SMS below.")}
+              </div>
+              <Form.Item
+                name="code"
                 rules={[
                   {
                     required: true,
-                    message: i18next.t(
-                        "code:Please input your verification code!"
-                    ),
+                    message: i18next.t("code:Please input your verification code!"),
                   },
                 ]}
-            >
-              {this.state.verifyType === "email" ? (
-                  <CountDownInput
-                    disabled={this.state.username === "" || this.state.verifyType === ""}
-                    onButtonClickArgs={[this.state.email, "email", Setting.getApplicationOrgName(this.state.application)]}
-                  />
-              ) : (
-                  <CountDownInput
-                    disabled={this.state.username === "" || this.state.verifyType === ""}
-                    onButtonClickArgs={[this.state.phone, "phone", Setting.getApplicationOrgName(this.state.application)]}
-                  />
-              )}
-            </Form.Item>
-            <br />
-            <Form.Item>
-              <Button
-                  block
-                  type="primary"
-                  htmlType="submit"
               >
-                {i18next.t("forget:Next Step")}
-              </Button>
-            </Form.Item>
-          </Form>
+                <Input
+                  prefix={<SafetyOutlined />}
+                  placeholder={i18next.t("code:Code You Received")}
+                />
+              </Form.Item>
+            </React.Fragment>
+          )
+        }
+        <Form.Item
+          name="newPassword"
+          rules={[
+            {
+              required: true,
+              message: i18next.t("forget:Please input your password!"),
+            },
+          ]}
+          hasFeedback
+        >
+          <Input.Password
+            prefix={<LockOutlined />}
+            placeholder={i18next.t("forget:Password")}
+          />
+        </Form.Item>
+        <Form.Item
+          name="confirm"
+          dependencies={["newPassword"]}
+          hasFeedback
+          rules={[
+            {
+              required: true,
+              message: i18next.t("forget:Please confirm your password!"),
+            },
+            ({getFieldValue}) => ({
+              validator(rule, value) {
+                if (!value || getFieldValue("newPassword") === value) {
+                  return Promise.resolve();
+                }
+                return Promise.reject(
+                  i18next.t("forget:Your confirmed password is inconsistent with the password!")
+                );
+              },
+            }),
+          ]}
+        >
+          <Input.Password
+            prefix={<CheckCircleOutlined />}
+            placeholder={i18next.t("forget:Confirm")}
+          />
+        </Form.Item>
+        <br />
+        <Form.Item>
+          <Button block type="primary" htmlType="submit">
+            {i18next.t("forget:Change Password")}
+          </Button>
+        </Form.Item>
+      </Form>
+    );
+  }
 
-          {/* STEP 3 */}
-          <Form
-              hidden={this.state.current !== 2}
-              ref={this.form}
-              name="step3"
-              onFinish={(values) => this.onFinish(values)}
-              onFinishFailed={(errorInfo) =>
-                  this.onFinishFailed(
-                      errorInfo.values,
-                      errorInfo.errorFields,
-                      errorInfo.outOfDate
-                  )
-              }
-              initialValues={{
-                application: application.name,
-                organization: application.organization,
-              }}
-              style={{ width: "300px" }}
-              size="large"
-          >
-            <Form.Item
-                style={{ height: 0, visibility: "hidden" }}
-                name="application"
-                rules={[
-                  {
-                    required: true,
-                    message: i18next.t(
-                        `forget:Please input your application!`
-                    ),
-                  },
-                ]}
-            />
-            <Form.Item
-                style={{ height: 0, visibility: "hidden" }}
-                name="organization"
-                rules={[
-                  {
-                    required: true,
-                    message: i18next.t(
-                        `forget:Please input your organization!`
-                    ),
-                  },
-                ]}
-            />
-            <Form.Item
-                name="newPassword"
-                hidden={this.state.current !== 2}
-                rules={[
-                  {
-                    required: true,
-                    message: i18next.t(
-                        "forget:Please input your password!"
-                    ),
-                  },
-                ]}
-                hasFeedback
-            >
-              <Input.Password
-                  disabled={this.state.userId === ""}
-                  prefix={<LockOutlined />}
-                  placeholder={i18next.t("forget:Password")}
-              />
-            </Form.Item>
-            <Form.Item
-                name="confirm"
-                dependencies={["newPassword"]}
-                hasFeedback
-                rules={[
-                  {
-                    required: true,
-                    message: i18next.t(
-                        "forget:Please confirm your password!"
-                    ),
-                  },
-                  ({ getFieldValue }) => ({
-                    validator(rule, value) {
-                      if (!value || getFieldValue("newPassword") === value) {
-                        return Promise.resolve();
-                      }
-                      return Promise.reject(
-                          i18next.t(
-                              "forget:Your confirmed password is inconsistent with the password!"
-                          )
-                      );
-                    },
-                  }),
-                ]}
-            >
-              <Input.Password
-                  disabled={this.state.userId === ""}
-                  prefix={<CheckCircleOutlined />}
-                  placeholder={i18next.t("forget:Confirm")}
-              />
-            </Form.Item>
-            <br />
-            <Form.Item hidden={this.state.current !== 2}>
-              <Button block type="primary"  htmlType="submit" disabled={this.state.userId === ""}>
-                {i18next.t("forget:Change Password")}
-              </Button>
-            </Form.Item>
-          </Form>
-        </Form.Provider>
+  renderForm(application) {
+    if (this.state.current === 0) {
+      return this.renderRequestForm();
+    } else if (this.state.current === 1) {
+      return this.renderResetForm();
+    }
+
+    return (
+      <Result
+        status="success"
+        title={i18next.t("forget:Your password has been reset")}
+        subTitle={i18next.t("forget:You have been signed out everywhere, please sign in with the new password.")}
+        extra={[
+          <Button type="primary" key="login" onClick={() => Setting.goToLogin(this, application)}>
+            {i18next.t("login:Sign In")}
+          </Button>,
+        ]}
+      />
     );
-}
+  }
 
   render() {
     const application = this.getApplicationObj();
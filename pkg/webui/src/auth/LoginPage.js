@@ -24,10 +24,12 @@ import {Button, Checkbox, Col, Form, Input, Result, Row, Spin} from "antd";
 import {LockOutlined, UserOutlined} from "@ant-design/icons";
 import * as AuthBackend from "./AuthBackend";
 import * as ApplicationBackend from "../backend/ApplicationBackend";
+import * as UserBackend from "../backend/UserBackend";
 import * as Provider from "./Provider";
 import * as Util from "./Util";
 import * as Setting from "../Setting";
 import SelfLoginButton from "./SelfLoginButton";
+import {HumanCheckWidget, isHumanCheckWidget} from "../common/HumanCheckWidget";
 import {GithubLoginButton, GoogleLoginButton} from "react-social-login-buttons";
 import FacebookLoginButton from "./FacebookLoginButton";
 import QqLoginButton from "./QqLoginButton";
@@ -47,6 +49,10 @@ import AzureADLoginButton from "./AzureADLoginButton";
 import SlackLoginButton from "./SlackLoginButton";
 import CustomGithubCorner from "../CustomGithubCorner";
 import {CountDownInput} from "../common/CountDownInput";
+import MfaVerifyForm from "./MfaVerifyForm";
+import MfaEnrollForm from "./MfaEnrollForm";
+import PasswordChangeForm from "./PasswordChangeForm";
+import * as WebAuthn from "./WebAuthn";
 
 class LoginPage extends React.Component {
   constructor(props) {
@@ -58,10 +64,22 @@ class LoginPage extends React.Component {
       application: null,
       mode: props.mode !== undefined ? props.mode : (props.match === undefined ? null : props.match.params.mode), // "signup" or "signin"
       isCodeSignin: false,
+      isMfaRequired: false,
+      mfaTypes: [],
+      isMfaEnrollRequired: false,
+      isPasswordExpired: false,
+      captchaId: "",
+      captchaImage: "",
+      humanCheckType: "",
+      humanCheckSiteKey: "",
+      humanCheckToken: "",
+      humanCheckKey: 0,
       msg: null,
       username: null,
       validEmailOrPhone: false
     };
+
+    this.form = React.createRef();
   }
 
   UNSAFE_componentWillMount() {
@@ -82,6 +100,8 @@ class LoginPage extends React.Component {
           this.setState({
             application: res.data,
           });
+          this.loginWithMagicLink(res.data);
+          this.loadHumanCheckWidget(res.data);
         } else {
           // Util.showMessage("error", res.msg);
           this.setState({
@@ -102,6 +122,8 @@ class LoginPage extends React.Component {
         this.setState({
           application: application,
         });
+        this.loginWithMagicLink(application);
+        this.loadHumanCheckWidget(application);
       });
   }
 
@@ -117,53 +139,200 @@ class LoginPage extends React.Component {
     this.props.onUpdateAccount(account);
   }
 
+  loadCaptcha() {
+    UserBackend.getHumanCheck().then((res) => {
+      if (res.type === "captcha") {
+        this.setState({
+          captchaId: res.captchaId,
+          captchaImage: res.captchaImage,
+        });
+      } else if (isHumanCheckWidget(res.type)) {
+        this.setHumanCheckWidget(res);
+      }
+    });
+  }
+
+  // the sign-in links are only sent with the token of the human check provider, if there is one
+  loadHumanCheckWidget(application) {
+    if (application === null || application === undefined || !application.enableMagicLink) {
+      return;
+    }
+
+    UserBackend.getHumanCheck().then((res) => {
+      if (isHumanCheckWidget(res.type)) {
+        this.setHumanCheckWidget(res);
+      }
+    });
+  }
+
+  // a token can only be verified once, so the widget is rendered again for a new one
+  setHumanCheckWidget(humanCheck) {
+    this.setState({
+      humanCheckType: humanCheck.type,
+      humanCheckSiteKey: humanCheck.appKey,
+      humanCheckToken: "",
+      humanCheckKey: this.state.humanCheckKey + 1,
+    });
+  }
+
   onFinish(values) {
-    const application = this.getApplicationObj();
-    const ths = this;
     values["type"] = this.state.type;
+    values["captchaId"] = this.state.captchaId;
+    if (this.state.humanCheckType !== "") {
+      values["captchaCode"] = this.state.humanCheckToken;
+    }
     values["phonePrefix"] = this.getApplicationObj()?.organizationObj.phonePrefix;
     const oAuthParams = Util.getOAuthGetParameters();
 
     AuthBackend.login(values, oAuthParams)
       .then((res) => {
-        if (res.status === 'ok') {
-          const responseType = this.state.type;
-          if (responseType === "login") {
-            Util.showMessage("success", `Logged in successfully`);
-            Setting.goToLink("/");
-          } else if (responseType === "code") {
-            const code = res.data;
-
-            if (Setting.hasPromptPage(application)) {
-              AuthBackend.getAccount("")
-                .then((res) => {
-                  let account = null;
-                  if (res.status === "ok") {
-                    account = res.data;
-                    account.organization = res.data2;
-
-                    this.onUpdateAccount(account);
-
-                    if (Setting.isPromptAnswered(account, application)) {
-                      Setting.goToLink(`${oAuthParams.redirectUri}?code=${code}&state=${oAuthParams.state}`);
-                    } else {
-                      Setting.goToLinkSoft(ths, `/prompt/${application.name}?redirectUri=${oAuthParams.redirectUri}&code=${code}&state=${oAuthParams.state}`);
-                    }
-                  } else {
-                    Setting.showMessage("error", `Failed to sign in: ${res.msg}`);
-                  }
-                });
-            } else {
-              Setting.goToLink(`${oAuthParams.redirectUri}?code=${code}&state=${oAuthParams.state}`);
-            }
+        this.handleLoginResponse(res);
+      });
+  };
 
-            // Util.showMessage("success", `Authorization code: ${res.data}`);
-          }
+  sendMagicLink(application) {
+    if (!this.state.username) {
+      Util.showMessage("error", i18next.t("login:Please input your username, Email or phone!"));
+      return;
+    }
+
+    const values = {
+      type: this.state.type,
+      application: application.name,
+      organization: application.organization,
+      username: this.state.username,
+      autoSignin: this.form.current?.getFieldValue("autoSignin") === true,
+      loginUrl: `${window.location.pathname}${window.location.search}`,
+      captchaCode: this.state.humanCheckToken,
+    };
+    AuthBackend.sendMagicLink(values, Util.getOAuthGetParameters())
+      .then((res) => {
+        if (this.state.humanCheckType !== "") {
+          this.loadCaptcha();
+        }
+        if (res.status === "ok") {
+          Util.showMessage("success", res.msg);
         } else {
+          Util.showMessage("error", `Failed to send the sign-in link: ${res.msg}`);
+        }
+      });
+  }
+
+  // finishes the login when the page is opened from the sign-in link sent by email
+  loginWithMagicLink(application) {
+    const token = new URLSearchParams(window.location.search).get("magicLinkToken");
+    if (application === null || application === undefined || token === null) {
+      return;
+    }
+
+    const values = {
+      application: application.name,
+      token: token,
+    };
+    AuthBackend.loginWithMagicLink(values, Util.getOAuthGetParameters())
+      .then((res) => {
+        this.handleLoginResponse(res);
+      });
+  }
+
+  signInWithPasskey(application) {
+    const oAuthParams = Util.getOAuthGetParameters();
+    AuthBackend.beginWebauthnLogin(application.name, "")
+      .then((res) => {
+        if (res.status !== "ok") {
           Util.showMessage("error", `Failed to log in: ${res.msg}`);
+          return;
         }
+
+        WebAuthn.getCredential(res.data)
+          .then((credential) => {
+            const values = {
+              type: this.state.type,
+              application: application.name,
+              organization: application.organization,
+              autoSignin: true,
+              credential: credential,
+            };
+            return AuthBackend.finishWebauthnLogin(values, oAuthParams);
+          })
+          .then((res) => {
+            this.handleLoginResponse(res);
+          })
+          .catch((error) => {
+            Util.showMessage("error", `Failed to log in: ${error.message}`);
+          });
       });
-  };
+  }
+
+  handleLoginResponse(res) {
+    const application = this.getApplicationObj();
+    const ths = this;
+    const oAuthParams = Util.getOAuthGetParameters();
+
+    if (res.status === "mfa" || res.status === "mfaEnroll") {
+      this.setState({
+        isMfaRequired: true,
+        isMfaEnrollRequired: res.status === "mfaEnroll",
+        mfaTypes: res.data,
+      });
+      return;
+    }
+
+    if (res.status === "passwordExpired") {
+      this.setState({
+        isMfaRequired: false,
+        isPasswordExpired: true,
+      });
+      return;
+    }
+
+    // a captcha can only be answered once, so a new one is needed for the next attempt
+    if (res.status === "captcha") {
+      Util.showMessage("error", res.msg);
+      this.loadCaptcha();
+      return;
+    } else if (res.status !== "ok" && (this.state.captchaId !== "" || this.state.humanCheckType !== "")) {
+      this.loadCaptcha();
+    }
+
+    if (res.status === 'ok') {
+      const responseType = this.state.type;
+      if (responseType === "login") {
+        Util.showMessage("success", `Logged in successfully`);
+        const casLoginUrl = Util.getCasLoginUrl();
+        Setting.goToLink(casLoginUrl !== null ? casLoginUrl : "/");
+      } else if (responseType === "code") {
+        const code = res.data;
+
+        if (Setting.hasPromptPage(application)) {
+          AuthBackend.getAccount("")
+            .then((res) => {
+              let account = null;
+              if (res.status === "ok") {
+                account = res.data;
+                account.organization = res.data2;
+
+                this.onUpdateAccount(account);
+
+                if (Setting.isPromptAnswered(account, application)) {
+                  Setting.goToLink(`${oAuthParams.redirectUri}?code=${code}&state=${oAuthParams.state}`);
+                } else {
+                  Setting.goToLinkSoft(ths, `/prompt/${application.name}?redirectUri=${oAuthParams.redirectUri}&code=${code}&state=${oAuthParams.state}`);
+                }
+              } else {
+                Setting.showMessage("error", `Failed to sign in: ${res.msg}`);
+              }
+            });
+        } else {
+          Setting.goToLink(`${oAuthParams.redirectUri}?code=${code}&state=${oAuthParams.state}`);
+        }
+
+        // Util.showMessage("success", `Authorization code: ${res.data}`);
+      }
+    } else {
+      Util.showMessage("error", `Failed to log in: ${res.msg}`);
+    }
+  }
 
   getSigninButton(type) {
     const text = i18next.t("login:Sign in with {type}").replace("{type}", type);
@@ -265,6 +434,22 @@ class LoginPage extends React.Component {
       return Util.renderMessage(this.state.msg)
     }
 
+    if (this.state.isPasswordExpired) {
+      return (
+        <PasswordChangeForm oAuthParams={Util.getOAuthGetParameters()} onResponse={(res) => this.handleLoginResponse(res)} />
+      )
+    }
+
+    if (this.state.isMfaRequired) {
+      return (
+        this.state.isMfaEnrollRequired ? (
+          <MfaEnrollForm mfaTypes={this.state.mfaTypes} oAuthParams={Util.getOAuthGetParameters()} onResponse={(res) => this.handleLoginResponse(res)} />
+        ) : (
+          <MfaVerifyForm mfaTypes={this.state.mfaTypes} oAuthParams={Util.getOAuthGetParameters()} onResponse={(res) => this.handleLoginResponse(res)} />
+        )
+      )
+    }
+
     if (this.state.mode === "signup" && !application.enableSignUp) {
       return (
         <Result
@@ -288,6 +473,7 @@ class LoginPage extends React.Component {
     if (application.enablePassword) {
       return (
         <Form
+          ref={this.form}
           name="normal_login"
           initialValues={{
             organization: application.organization,
@@ -377,6 +563,34 @@ class LoginPage extends React.Component {
               </Form.Item>
             )
           }
+          {
+            (this.state.isCodeSignin || this.state.captchaId === "") ? null : (
+              <Form.Item
+                name="captchaCode"
+                rules={[{ required: true, message: i18next.t("login:Please input the captcha!") }]}
+              >
+                <Input
+                  prefix={
+                    <img src={`data:image/png;base64,${this.state.captchaImage}`} alt="captcha" style={{height: "30px"}} onClick={() => this.loadCaptcha()} />
+                  }
+                  placeholder={i18next.t("login:Captcha")}
+                  autoComplete="off"
+                />
+              </Form.Item>
+            )
+          }
+          {
+            this.state.humanCheckType === "" ? null : (
+              <Form.Item>
+                <HumanCheckWidget
+                  key={this.state.humanCheckKey}
+                  type={this.state.humanCheckType}
+                  siteKey={this.state.humanCheckSiteKey}
+                  onChange={token => this.setState({humanCheckToken: token})}
+                />
+              </Form.Item>
+            )
+          }
           <Form.Item>
             <Form.Item name="autoSignin" valuePropName="checked" noStyle>
               <Checkbox style={{float: "left"}} disabled={!application.enablePassword}>
@@ -398,6 +612,26 @@ class LoginPage extends React.Component {
             >
               {i18next.t("login:Sign In")}
             </Button>
+            {
+              !WebAuthn.isWebAuthnSupported() ? null : (
+                <Button
+                  style={{width: "100%", marginBottom: '5px'}}
+                  onClick={() => this.signInWithPasskey(application)}
+                >
+                  {i18next.t("login:Sign in with a passkey")}
+                </Button>
+              )
+            }
+            {
+              !application.enableMagicLink ? null : (
+                <Button
+                  style={{width: "100%", marginBottom: '5px'}}
+                  onClick={() => this.sendMagicLink(application)}
+                >
+                  {i18next.t("login:Email me a sign-in link")}
+                </Button>
+              )
+            }
             {
               !application.enableSignUp ? null : this.renderFooter(application)
             }
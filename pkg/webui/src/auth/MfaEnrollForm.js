@@ -0,0 +1,170 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import React from "react";
+import {Button, Input, Typography} from "antd";
+import i18next from "i18next";
+import * as AuthBackend from "./AuthBackend";
+import * as UserBackend from "../backend/UserBackend";
+import * as WebAuthn from "./WebAuthn";
+import * as Util from "./Util";
+
+// MfaEnrollForm sets up a second factor after the server has answered a login with the "mfaEnroll" status,
+// the login is completed once the factor has been added
+class MfaEnrollForm extends React.Component {
+  constructor(props) {
+    super(props);
+    this.state = {
+      classes: props,
+      secret: "",
+      uri: "",
+      passcode: "",
+      recoveryCodes: null,
+      loading: false,
+    };
+  }
+
+  completeLogin() {
+    AuthBackend.verifyMfa({}, this.props.oAuthParams)
+      .then((res) => this.props.onResponse(res));
+  }
+
+  initiateTotp() {
+    UserBackend.initiateTotp()
+      .then((res) => {
+        if (res.status === "ok") {
+          this.setState({
+            secret: res.data,
+            uri: res.data2,
+          });
+        } else {
+          this.props.onResponse(res);
+        }
+      });
+  }
+
+  enableTotp() {
+    this.setState({
+      loading: true,
+    });
+
+    UserBackend.enableTotp(this.state.passcode)
+      .then((res) => {
+        this.setState({
+          loading: false,
+        });
+        if (res.status === "ok") {
+          this.setState({
+            recoveryCodes: res.data,
+          });
+        } else {
+          Util.showMessage("error", res.msg);
+        }
+      });
+  }
+
+  addPasskey() {
+    UserBackend.beginWebauthnRegistration()
+      .then((res) => {
+        if (res.status !== "ok") {
+          this.props.onResponse(res);
+          return;
+        }
+
+        WebAuthn.createCredential(res.data)
+          .then((credential) => UserBackend.finishWebauthnRegistration("", credential))
+          .then((res) => {
+            if (res.status === "ok") {
+              this.completeLogin();
+            } else {
+              Util.showMessage("error", res.msg);
+            }
+          })
+          .catch((error) => Util.showMessage("error", error.message));
+      });
+  }
+
+  renderRecoveryCodes() {
+    return (
+      <div>
+        <div style={{marginBottom: "24px"}}>
+          {i18next.t("user:Save these recovery codes in a safe place. Each of them can be used once to sign in when the authenticator app is not available. They will not be shown again.")}
+        </div>
+        <Typography.Paragraph copyable={{text: this.state.recoveryCodes.join("\n")}}>
+          <pre>{this.state.recoveryCodes.join("\n")}</pre>
+        </Typography.Paragraph>
+        <Button type="primary" style={{width: "100%"}} onClick={() => this.completeLogin()}>
+          {i18next.t("login:Continue")}
+        </Button>
+      </div>
+    );
+  }
+
+  renderTotp() {
+    return (
+      <div>
+        <div style={{marginBottom: "24px"}}>
+          {i18next.t("user:Add the account to your authenticator app with the link or the secret below, then enter the passcode shown by the app.")}
+        </div>
+        <div style={{marginBottom: "24px", wordBreak: "break-all"}}>
+          <a href={this.state.uri}>{this.state.uri}</a>
+        </div>
+        <Input style={{marginBottom: "24px"}} addonBefore={i18next.t("user:Secret")} value={this.state.secret} readOnly />
+        <Input style={{marginBottom: "24px"}} addonBefore={i18next.t("user:Passcode")} value={this.state.passcode} maxLength={6} autoComplete="one-time-code" onChange={(e) => this.setState({passcode: e.target.value})} />
+        <Button type="primary" style={{width: "100%"}} loading={this.state.loading} onClick={() => this.enableTotp()}>
+          {i18next.t("user:Enable")}
+        </Button>
+      </div>
+    );
+  }
+
+  render() {
+    const mfaTypes = this.props.mfaTypes || [];
+
+    return (
+      <div style={{width: "300px"}}>
+        {
+          this.state.recoveryCodes !== null ? this.renderRecoveryCodes() : this.state.secret !== "" ? this.renderTotp() : (
+            <div>
+              <div style={{marginBottom: "24px"}}>
+                {i18next.t("login:Your organization requires a second factor to sign in, please set one up")}
+              </div>
+              {
+                mfaTypes.includes("totp") ? (
+                  <Button type="primary" style={{width: "100%", marginBottom: "5px"}} onClick={() => this.initiateTotp()}>
+                    {i18next.t("login:Use an authenticator app")}
+                  </Button>
+                ) : null
+              }
+              {
+                (mfaTypes.includes("webauthn") && WebAuthn.isWebAuthnSupported()) ? (
+                  <Button style={{width: "100%", marginBottom: "5px"}} onClick={() => this.addPasskey()}>
+                    {i18next.t("login:Use a passkey")}
+                  </Button>
+                ) : null
+              }
+            </div>
+          )
+        }
+      </div>
+    );
+  }
+}
+
+export default MfaEnrollForm;
@@ -0,0 +1,132 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import React from "react";
+import {Button, Form, Input} from "antd";
+import {LockOutlined} from "@ant-design/icons";
+import i18next from "i18next";
+import * as AuthBackend from "./AuthBackend";
+import * as WebAuthn from "./WebAuthn";
+
+// MfaVerifyForm asks for the second factor after the server has answered a login with the "mfa" status
+class MfaVerifyForm extends React.Component {
+  constructor(props) {
+    super(props);
+    this.state = {
+      classes: props,
+      isRecoveryCode: false,
+      loading: false,
+    };
+  }
+
+  onFinish(values) {
+    this.setState({
+      loading: true,
+    });
+
+    const body = this.state.isRecoveryCode ? {recoveryCode: values.code} : {passcode: values.code};
+    AuthBackend.verifyMfa(body, this.props.oAuthParams)
+      .then((res) => {
+        this.setState({
+          loading: false,
+        });
+        this.props.onResponse(res);
+      });
+  }
+
+  verifyWithPasskey() {
+    AuthBackend.beginWebauthnLogin("", "")
+      .then((res) => {
+        if (res.status !== "ok") {
+          this.props.onResponse(res);
+          return;
+        }
+
+        WebAuthn.getCredential(res.data)
+          .then((credential) => AuthBackend.finishWebauthnLogin({credential: credential}, this.props.oAuthParams))
+          .then((res) => this.props.onResponse(res))
+          .catch((error) => this.props.onResponse({status: "error", msg: error.message}));
+      });
+  }
+
+  renderPasskeyButton() {
+    const mfaTypes = this.props.mfaTypes || [];
+    if (!mfaTypes.includes("webauthn") || !WebAuthn.isWebAuthnSupported()) {
+      return null;
+    }
+
+    return (
+      <Button style={{width: "100%", marginBottom: "5px"}} onClick={() => this.verifyWithPasskey()}>
+        {i18next.t("login:Use a passkey")}
+      </Button>
+    );
+  }
+
+  render() {
+    const mfaTypes = this.props.mfaTypes || [];
+    if (!mfaTypes.includes("totp")) {
+      return (
+        <div style={{width: "300px"}}>
+          <div style={{marginBottom: "24px"}}>
+            {i18next.t("login:Verify with one of your passkeys")}
+          </div>
+          {this.renderPasskeyButton()}
+        </div>
+      );
+    }
+
+    return (
+      <Form
+        name="mfa"
+        onFinish={(values) => {this.onFinish(values)}}
+        style={{width: "300px"}}
+        size="large"
+      >
+        <Form.Item>
+          {
+            this.state.isRecoveryCode ? i18next.t("login:Enter one of your recovery codes") : i18next.t("login:Enter the passcode of your authenticator app")
+          }
+        </Form.Item>
+        <Form.Item
+          name="code"
+          rules={[{required: true, message: i18next.t("login:Please input your code!")}]}
+        >
+          <Input
+            prefix={<LockOutlined className="site-form-item-icon" />}
+            placeholder={this.state.isRecoveryCode ? "xxxxx-xxxxx" : "123456"}
+            autoComplete="one-time-code"
+            autoFocus
+          />
+        </Form.Item>
+        <Form.Item>
+          <Button type="primary" htmlType="submit" loading={this.state.loading} style={{width: "100%", marginBottom: "5px"}}>
+            {i18next.t("login:Verify")}
+          </Button>
+          {this.renderPasskeyButton()}
+          <a onClick={() => this.setState({isRecoveryCode: !this.state.isRecoveryCode})}>
+            {this.state.isRecoveryCode ? i18next.t("login:Use the authenticator app") : i18next.t("login:Use a recovery code")}
+          </a>
+        </Form.Item>
+      </Form>
+    );
+  }
+}
+
+export default MfaVerifyForm;
@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import React from "react";
+import {Button, Form, Input} from "antd";
+import {LockOutlined} from "@ant-design/icons";
+import i18next from "i18next";
+import * as AuthBackend from "./AuthBackend";
+import * as Util from "./Util";
+
+// PasswordChangeForm asks for a new password after the server has answered a login with the "passwordExpired" status
+class PasswordChangeForm extends React.Component {
+  constructor(props) {
+    super(props);
+    this.state = {
+      classes: props,
+      loading: false,
+    };
+  }
+
+  onFinish(values) {
+    this.setState({
+      loading: true,
+    });
+
+    AuthBackend.changeExpiredPassword(values.newPassword, this.props.oAuthParams)
+      .then((res) => {
+        this.setState({
+          loading: false,
+        });
+        // the violations of the password policy can be fixed without signing in again
+        if (res.status === "error" && Array.isArray(res.data)) {
+          Util.showMessage("error", res.msg);
+          return;
+        }
+        if (res.status === "ok" && res.msg) {
+          Util.showMessage("warning", res.msg);
+        }
+        this.props.onResponse(res);
+      });
+  }
+
+  render() {
+    return (
+      <Form
+        name="passwordChange"
+        onFinish={(values) => {this.onFinish(values)}}
+        style={{width: "300px"}}
+        size="large"
+      >
+        <Form.Item>
+          {i18next.t("login:Your password has expired, please set a new one")}
+        </Form.Item>
+        <Form.Item
+          name="newPassword"
+          rules={[{required: true, message: i18next.t("login:Please input your password!")}]}
+        >
+          <Input.Password
+            prefix={<LockOutlined className="site-form-item-icon" />}
+            placeholder={i18next.t("user:New Password")}
+            autoComplete="new-password"
+            autoFocus
+          />
+        </Form.Item>
+        <Form.Item
+          name="confirm"
+          dependencies={["newPassword"]}
+          rules={[
+            {required: true, message: i18next.t("login:Please input your password!")},
+            ({getFieldValue}) => ({
+              validator(_, value) {
+                if (!value || getFieldValue("newPassword") === value) {
+                  return Promise.resolve();
+                }
+                return Promise.reject(i18next.t("signup:Your confirmed password is inconsistent with the password!"));
+              },
+            }),
+          ]}
+        >
+          <Input.Password
+            prefix={<LockOutlined className="site-form-item-icon" />}
+            placeholder={i18next.t("user:Re-enter New")}
+            autoComplete="new-password"
+          />
+        </Form.Item>
+        <Form.Item>
+          <Button type="primary" htmlType="submit" loading={this.state.loading} style={{width: "100%"}}>
+            {i18next.t("login:Continue")}
+          </Button>
+        </Form.Item>
+      </Form>
+    );
+  }
+}
+
+export default PasswordChangeForm;
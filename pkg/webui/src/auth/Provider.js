@@ -22,6 +22,7 @@ import React from "react";
 import {Tooltip} from "antd";
 import * as Util from "./Util";
 import {StaticBaseUrl} from "../Setting";
+import {authConfig} from "./Auth";
 
 const authInfo = {
   Google: {
@@ -216,6 +217,17 @@ export function getAuthUrl(application, provider, method) {
     return "";
   }
 
+  if (provider.type === "OpenID" || (provider.type === "Custom" && provider.enablePkce)) {
+    // the authorization URL is built by the backend, which keeps the nonce and PKCE verifier in the session
+    const state = Util.getQueryParamsToState(application.name, provider.name, method);
+    return `${authConfig.serverUrl}/api/get-oidc-login?id=admin/${provider.name}&state=${encodeURIComponent(state)}&redirectUri=${encodeURIComponent(`${window.location.origin}/callback`)}`;
+  }
+
+  if (provider.type === "Custom") {
+    const state = Util.getQueryParamsToState(application.name, provider.name, method);
+    return `${provider.customAuthUrl}?client_id=${provider.clientId}&redirect_uri=${encodeURIComponent(`${window.location.origin}/callback`)}&scope=${encodeURIComponent(provider.scopes)}&response_type=code&state=${encodeURIComponent(state)}`;
+  }
+
   let endpoint = authInfo[provider.type].endpoint;
   const redirectUri = `${window.location.origin}/callback`;
   const scope = authInfo[provider.type].scope;
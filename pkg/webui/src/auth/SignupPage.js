@@ -27,7 +27,9 @@ import i18next from "i18next";
 import * as Util from "./Util";
 import {authConfig} from "./Auth";
 import * as ApplicationBackend from "../backend/ApplicationBackend";
+import * as UserBackend from "../backend/UserBackend";
 import {CountDownInput} from "../common/CountDownInput";
+import {HumanCheckWidget, isHumanCheckWidget} from "../common/HumanCheckWidget";
 import SelectRegionBox from "../SelectRegionBox";
 import CustomGithubCorner from "../CustomGithubCorner";
 
@@ -79,6 +81,10 @@ class SignupPage extends React.Component {
       region: "",
       isTermsOfUseVisible: false,
       termsOfUseContent: "",
+      humanCheckType: "",
+      humanCheckSiteKey: "",
+      humanCheckToken: "",
+      humanCheckKey: 0,
     };
 
     this.form = React.createRef();
@@ -87,6 +93,7 @@ class SignupPage extends React.Component {
   UNSAFE_componentWillMount() {
     if (this.state.applicationName !== undefined) {
       this.getApplication();
+      this.loadHumanCheckWidget();
     } else {
       Util.showMessage("error", `Unknown application name: ${this.state.applicationName}`);
     }
@@ -140,12 +147,31 @@ class SignupPage extends React.Component {
     this.props.onUpdateAccount(account);
   }
 
+  // the sign-up is only accepted with the token of the human check provider, if there is one
+  loadHumanCheckWidget() {
+    UserBackend.getHumanCheck().then((res) => {
+      if (isHumanCheckWidget(res.type)) {
+        this.setState({
+          humanCheckType: res.type,
+          humanCheckSiteKey: res.appKey,
+          humanCheckToken: "",
+          humanCheckKey: this.state.humanCheckKey + 1,
+        });
+      }
+    });
+  }
+
   onFinish(values) {
     const application = this.getApplicationObj();
     values.phonePrefix = application.organizationObj.phonePrefix;
+    values.captchaCode = this.state.humanCheckToken;
     AuthBackend.signup(values)
       .then((res) => {
         if (res.status === 'ok') {
+          // the password is accepted but the organization warns that it is breached
+          if (res.msg) {
+            Setting.showMessage("warning", res.msg);
+          }
           if (Setting.hasPromptPage(application)) {
             AuthBackend.getAccount("")
               .then((res) => {
@@ -165,6 +191,9 @@ class SignupPage extends React.Component {
           }
         } else {
           Setting.showMessage("error", i18next.t(`signup:${res.msg}`));
+          if (this.state.humanCheckType !== "") {
+            this.loadHumanCheckWidget();
+          }
         }
       });
   }
@@ -522,6 +551,18 @@ class SignupPage extends React.Component {
         {
           application.signupItems?.map(signupItem => this.renderFormItem(application, signupItem))
         }
+        {
+          this.state.humanCheckType === "" ? null : (
+            <Form.Item {...tailFormItemLayout}>
+              <HumanCheckWidget
+                key={this.state.humanCheckKey}
+                type={this.state.humanCheckType}
+                siteKey={this.state.humanCheckSiteKey}
+                onChange={token => this.setState({humanCheckToken: token})}
+              />
+            </Form.Item>
+          )
+        }
         <Form.Item {...tailFormItemLayout}>
           <Button type="primary" htmlType="submit">
             {i18next.t("account:Sign Up")}
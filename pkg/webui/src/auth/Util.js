@@ -26,6 +26,8 @@ export function showMessage(type, text) {
     message.success(text);
   } else if (type === "error") {
     message.error(text);
+  } else if (type === "warning") {
+    message.warning(text);
   }
 }
 
@@ -114,6 +116,21 @@ export function getOAuthGetParameters(params) {
   }
 }
 
+// returns the URL to go back to after signing in for a CAS service, or null for other logins
+export function getCasLoginUrl(params) {
+  const queries = (params !== undefined) ? params : new URLSearchParams(window.location.search);
+  const service = queries.get("service");
+  if (service === null || service === "") {
+    return null;
+  }
+
+  let url = `/cas/login?service=${encodeURIComponent(service)}`;
+  if (queries.get("renew") === "true") {
+    url += "&renew=true";
+  }
+  return url;
+}
+
 export function getQueryParamsToState(applicationName, providerName, method) {
   let query = window.location.search;
   query = `${query}&application=${applicationName}&provider=${providerName}&method=${method}`;
@@ -0,0 +1,83 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// The options and the credentials of WebAuthn contain ArrayBuffers, they are exchanged with the
+// server as base64url strings
+
+function base64UrlToBuffer(s) {
+  const base64 = s.replace(/-/g, "+").replace(/_/g, "/");
+  const binary = atob(base64 + "===".slice((base64.length + 3) % 4));
+  return Uint8Array.from(binary, c => c.charCodeAt(0)).buffer;
+}
+
+function bufferToBase64Url(buffer) {
+  const binary = String.fromCharCode(...new Uint8Array(buffer));
+  return btoa(binary).replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+}
+
+function decodeDescriptors(descriptors) {
+  return (descriptors || []).map(descriptor => ({...descriptor, id: base64UrlToBuffer(descriptor.id)}));
+}
+
+export function isWebAuthnSupported() {
+  return window.PublicKeyCredential !== undefined && navigator.credentials !== undefined;
+}
+
+export function createCredential(options) {
+  const publicKey = {
+    ...options,
+    challenge: base64UrlToBuffer(options.challenge),
+    user: {...options.user, id: base64UrlToBuffer(options.user.id)},
+    excludeCredentials: decodeDescriptors(options.excludeCredentials),
+  };
+
+  return navigator.credentials.create({publicKey: publicKey})
+    .then((credential) => ({
+      id: credential.id,
+      rawId: bufferToBase64Url(credential.rawId),
+      type: credential.type,
+      response: {
+        clientDataJSON: bufferToBase64Url(credential.response.clientDataJSON),
+        attestationObject: bufferToBase64Url(credential.response.attestationObject),
+        transports: credential.response.getTransports !== undefined ? credential.response.getTransports() : [],
+      },
+    }));
+}
+
+export function getCredential(options) {
+  const publicKey = {
+    ...options,
+    challenge: base64UrlToBuffer(options.challenge),
+    allowCredentials: decodeDescriptors(options.allowCredentials),
+  };
+
+  return navigator.credentials.get({publicKey: publicKey})
+    .then((credential) => ({
+      id: credential.id,
+      rawId: bufferToBase64Url(credential.rawId),
+      type: credential.type,
+      response: {
+        clientDataJSON: bufferToBase64Url(credential.response.clientDataJSON),
+        authenticatorData: bufferToBase64Url(credential.response.authenticatorData),
+        signature: bufferToBase64Url(credential.response.signature),
+        userHandle: credential.response.userHandle !== null ? bufferToBase64Url(credential.response.userHandle) : "",
+      },
+    }));
+}
@@ -74,4 +74,18 @@ export function deleteApplication(application) {
     credentials: 'include',
     body: JSON.stringify(newApplication),
   }).then(res => res.json());
-}
\ No newline at end of file
+}
+
+export function syncProvisioning(owner, name) {
+  return fetch(`${Setting.ServerUrl}/api/sync-provisioning?id=${owner}/${encodeURIComponent(name)}`, {
+    method: 'POST',
+    credentials: 'include',
+  }).then(res => res.json());
+}
+
+export function getProvisioningStatus(owner, name) {
+  return fetch(`${Setting.ServerUrl}/api/get-provisioning-status?id=${owner}/${encodeURIComponent(name)}`, {
+    method: "GET",
+    credentials: "include"
+  }).then(res => res.json());
+}
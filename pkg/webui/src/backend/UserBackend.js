@@ -134,4 +134,196 @@ export function getHumanCheck() {
   return fetch(`${Setting.ServerUrl}/api/get-human-check`, {
     method: "GET"
   }).then(res => res.json());
-}
\ No newline at end of file
+}
+export function initiateTotp() {
+  return fetch(`${Setting.ServerUrl}/api/initiate-totp`, {
+    method: "POST",
+    credentials: "include",
+  }).then(res => res.json());
+}
+
+export function enableTotp(passcode) {
+  let formData = new FormData();
+  formData.append("passcode", passcode);
+  return fetch(`${Setting.ServerUrl}/api/enable-totp`, {
+    method: "POST",
+    credentials: "include",
+    body: formData
+  }).then(res => res.json());
+}
+
+export function disableTotp(passcode, recoveryCode) {
+  let formData = new FormData();
+  formData.append("passcode", passcode);
+  formData.append("recoveryCode", recoveryCode);
+  return fetch(`${Setting.ServerUrl}/api/disable-totp`, {
+    method: "POST",
+    credentials: "include",
+    body: formData
+  }).then(res => res.json());
+}
+
+export function regenerateRecoveryCodes(passcode, recoveryCode) {
+  let formData = new FormData();
+  formData.append("passcode", passcode);
+  formData.append("recoveryCode", recoveryCode);
+  return fetch(`${Setting.ServerUrl}/api/regenerate-recovery-codes`, {
+    method: "POST",
+    credentials: "include",
+    body: formData
+  }).then(res => res.json());
+}
+
+export function resetMfa(owner, name) {
+  let formData = new FormData();
+  formData.append("id", `${owner}/${name}`);
+  return fetch(`${Setting.ServerUrl}/api/reset-mfa`, {
+    method: "POST",
+    credentials: "include",
+    body: formData
+  }).then(res => res.json());
+}
+
+export function unlockUser(owner, name) {
+  let formData = new FormData();
+  formData.append("id", `${owner}/${name}`);
+  return fetch(`${Setting.ServerUrl}/api/unlock-user`, {
+    method: "POST",
+    credentials: "include",
+    body: formData
+  }).then(res => res.json());
+}
+
+export function beginWebauthnRegistration() {
+  return fetch(`${Setting.ServerUrl}/api/begin-webauthn-registration`, {
+    method: "POST",
+    credentials: "include",
+  }).then(res => res.json());
+}
+
+export function finishWebauthnRegistration(name, credential) {
+  return fetch(`${Setting.ServerUrl}/api/finish-webauthn-registration`, {
+    method: "POST",
+    credentials: "include",
+    body: JSON.stringify({name: name, credential: credential}),
+  }).then(res => res.json());
+}
+
+export function getWebauthnCredentials() {
+  return fetch(`${Setting.ServerUrl}/api/get-webauthn-credentials`, {
+    method: "GET",
+    credentials: "include",
+  }).then(res => res.json());
+}
+
+export function renameWebauthnCredential(id, name) {
+  let formData = new FormData();
+  formData.append("id", id);
+  formData.append("name", name);
+  return fetch(`${Setting.ServerUrl}/api/rename-webauthn-credential`, {
+    method: "POST",
+    credentials: "include",
+    body: formData
+  }).then(res => res.json());
+}
+
+export function deleteWebauthnCredential(id) {
+  let formData = new FormData();
+  formData.append("id", id);
+  return fetch(`${Setting.ServerUrl}/api/delete-webauthn-credential`, {
+    method: "POST",
+    credentials: "include",
+    body: formData
+  }).then(res => res.json());
+}
+
+export function getUserSessions(owner, name) {
+  return fetch(`${Setting.ServerUrl}/api/get-user-sessions?id=${owner}/${encodeURIComponent(name)}`, {
+    method: "GET",
+    credentials: "include"
+  }).then(res => res.json());
+}
+
+export function deleteUserSession(id) {
+  let formData = new FormData();
+  formData.append("id", id);
+  return fetch(`${Setting.ServerUrl}/api/delete-user-session`, {
+    method: "POST",
+    credentials: "include",
+    body: formData
+  }).then(res => res.json());
+}
+
+export function deleteUserSessions(owner, name) {
+  let formData = new FormData();
+  formData.append("id", `${owner}/${name}`);
+  return fetch(`${Setting.ServerUrl}/api/delete-user-sessions`, {
+    method: "POST",
+    credentials: "include",
+    body: formData
+  }).then(res => res.json());
+}
+
+export function impersonateUser(owner, name) {
+  let formData = new FormData();
+  formData.append("id", `${owner}/${name}`);
+  return fetch(`${Setting.ServerUrl}/api/impersonate-user`, {
+    method: "POST",
+    credentials: "include",
+    body: formData
+  }).then(res => res.json());
+}
+
+export function getPersonalAccessTokens(owner, name) {
+  return fetch(`${Setting.ServerUrl}/api/get-personal-access-tokens?id=${owner}/${encodeURIComponent(name)}`, {
+    method: "GET",
+    credentials: "include"
+  }).then(res => res.json());
+}
+
+export function addPersonalAccessToken(token) {
+  return fetch(`${Setting.ServerUrl}/api/add-personal-access-token`, {
+    method: "POST",
+    credentials: "include",
+    body: JSON.stringify(token),
+  }).then(res => res.json());
+}
+
+export function deletePersonalAccessToken(id) {
+  let formData = new FormData();
+  formData.append("id", id);
+  return fetch(`${Setting.ServerUrl}/api/delete-personal-access-token`, {
+    method: "POST",
+    credentials: "include",
+    body: formData
+  }).then(res => res.json());
+}
+
+export function getServiceAccountCredentials(owner, name) {
+  return fetch(`${Setting.ServerUrl}/api/get-service-account-credentials?id=${owner}/${encodeURIComponent(name)}`, {
+    method: "GET",
+    credentials: "include"
+  }).then(res => res.json());
+}
+
+export function addServiceAccountCredential(owner, name, type, publicKey) {
+  let formData = new FormData();
+  formData.append("id", `${owner}/${name}`);
+  formData.append("type", type);
+  formData.append("publicKey", publicKey);
+  return fetch(`${Setting.ServerUrl}/api/add-service-account-credential`, {
+    method: "POST",
+    credentials: "include",
+    body: formData
+  }).then(res => res.json());
+}
+
+export function deleteServiceAccountCredential(id) {
+  let formData = new FormData();
+  formData.append("id", id);
+  return fetch(`${Setting.ServerUrl}/api/delete-service-account-credential`, {
+    method: "POST",
+    credentials: "include",
+    body: formData
+  }).then(res => res.json());
+}
@@ -26,6 +26,7 @@ import * as UserBackend from "../backend/UserBackend";
 import {SafetyOutlined} from "@ant-design/icons";
 import * as Util from "../auth/Util";
 import {isValidEmail, isValidPhone} from "../Setting";
+import {HumanCheckWidget, isHumanCheckWidget} from "./HumanCheckWidget";
 
 const { Search } = Input;
 
@@ -36,6 +37,7 @@ export const CountDownInput = (props) => {
   const [captchaImg, setCaptchaImg] = React.useState("");
   const [checkType, setCheckType] = React.useState("");
   const [checkId, setCheckId] = React.useState("");
+  const [siteKey, setSiteKey] = React.useState("");
   const [buttonLeftTime, setButtonLeftTime] = React.useState(0);
   const [buttonLoading, setButtonLoading] = React.useState(false);
 
@@ -80,13 +82,16 @@ export const CountDownInput = (props) => {
 
   const loadHumanCheck = () => {
     UserBackend.getHumanCheck().then(res => {
-      if (res.type === "none") {
-        UserBackend.sendCode("none", "", "", ...onButtonClickArgs);
-      } else if (res.type === "captcha") {
+      if (res.type === "captcha") {
         setCheckId(res.captchaId);
         setCaptchaImg(res.captchaImage);
         setCheckType("captcha");
         setVisible(true);
+      } else if (isHumanCheckWidget(res.type)) {
+        setCheckId("");
+        setSiteKey(res.appKey);
+        setCheckType(res.type);
+        setVisible(true);
       } else {
         Setting.showMessage("error", i18next.t("signup:Unknown Check Type"));
       }
@@ -116,6 +121,7 @@ export const CountDownInput = (props) => {
 
   const renderCheck = () => {
     if (checkType === "captcha") return renderCaptcha();
+    if (isHumanCheckWidget(checkType)) return <HumanCheckWidget type={checkType} siteKey={siteKey} onChange={setKey} />;
     return null;
   }
 
@@ -144,8 +150,8 @@ export const CountDownInput = (props) => {
         cancelText={i18next.t("user:Cancel")}
         onOk={handleOk}
         onCancel={handleCancel}
-        okButtonProps={{disabled: key.length !== 5}}
-        width={248}
+        okButtonProps={{disabled: checkType === "captcha" ? key.length !== 5 : key === ""}}
+        width={checkType === "captcha" ? 248 : 352}
       >
         {
           renderCheck()
@@ -0,0 +1,85 @@
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import React from "react";
+
+const scriptPromises = {};
+
+function loadScript(src) {
+  if (scriptPromises[src] === undefined) {
+    scriptPromises[src] = new Promise((resolve, reject) => {
+      const script = document.createElement("script");
+      script.src = src;
+      script.async = true;
+      script.defer = true;
+      script.onload = resolve;
+      script.onerror = reject;
+      document.head.appendChild(script);
+    });
+  }
+  return scriptPromises[src];
+}
+
+export function isHumanCheckWidget(type) {
+  return ["reCAPTCHA v2", "reCAPTCHA v3", "hCaptcha", "Turnstile"].includes(type);
+}
+
+// HumanCheckWidget renders the widget of the human check provider, its token is verified by the
+// server with the secret key of the provider. A token can only be verified once, so the widget
+// is to be rendered again with a new key after each attempt.
+export const HumanCheckWidget = (props) => {
+  const {type, siteKey, onChange} = props;
+  const divRef = React.useRef(null);
+
+  React.useEffect(() => {
+    const options = {
+      sitekey: siteKey,
+      callback: token => onChange(token),
+      "expired-callback": () => onChange(""),
+      "error-callback": () => onChange(""),
+    };
+
+    if (type === "reCAPTCHA v2") {
+      loadScript("https://www.google.com/recaptcha/api.js?render=explicit").then(() => {
+        window.grecaptcha.ready(() => window.grecaptcha.render(divRef.current, options));
+      });
+    } else if (type === "reCAPTCHA v3") {
+      // reCAPTCHA v3 has no challenge, the token is scored by the server, which checks the action too
+      loadScript(`https://www.google.com/recaptcha/api.js?render=${siteKey}`).then(() => {
+        window.grecaptcha.ready(() => {
+          window.grecaptcha.execute(siteKey, {action: "submit"}).then(token => onChange(token));
+        });
+      });
+    } else if (type === "hCaptcha") {
+      loadScript("https://js.hcaptcha.com/1/api.js?render=explicit").then(() => {
+        window.hcaptcha.render(divRef.current, options);
+      });
+    } else if (type === "Turnstile") {
+      loadScript("https://challenges.cloudflare.com/turnstile/v0/api.js?render=explicit").then(() => {
+        window.turnstile.render(divRef.current, options);
+      });
+    }
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [type, siteKey]);
+
+  return (
+    <div ref={divRef} />
+  );
+}
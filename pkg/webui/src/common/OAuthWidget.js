@@ -78,9 +78,9 @@ class OAuthWidget extends React.Component {
     this.props.onUnlinked();
   }
 
-  getProviderLink(user, provider) {
+  getProviderLink(identity, provider) {
     if (provider.type === "GitHub") {
-      return `https://github.com/${this.getUserProperty(user, provider.type, "username")}`;
+      return `https://github.com/${identity.username}`;
     } else if (provider.type === "Google") {
       return "https://mail.google.com";
     } else {
@@ -88,15 +88,10 @@ class OAuthWidget extends React.Component {
     }
   }
 
-  getUserProperty(user, providerType, propertyName) {
-    const key = `oauth_${providerType}_${propertyName}`;
-    if (user.properties === null) return "";
-    return user.properties[key];
-  }
-
-  unlinkUser(providerType) {
+  unlinkUser(provider, subject) {
     const body = {
-      providerType: providerType,
+      provider: provider.name,
+      subject: subject,
     };
     AuthBackend.unlink(body)
       .then((res) => {
@@ -110,31 +105,44 @@ class OAuthWidget extends React.Component {
       });
   }
 
-  renderIdp(user, application, providerItem) {
-    const provider = providerItem.provider;
-    const linkedValue = user[provider.type.toLowerCase()];
-    const profileUrl = this.getProviderLink(user, provider);
-    const id = this.getUserProperty(user, provider.type, "id");
-    const username = this.getUserProperty(user, provider.type, "username");
-    const displayName = this.getUserProperty(user, provider.type, "displayName");
-    const email = this.getUserProperty(user, provider.type, "email");
-    let avatarUrl = this.getUserProperty(user, provider.type, "avatarUrl");
+  renderIdentity(provider, providerItem, identity) {
+    const profileUrl = this.getProviderLink(identity, provider);
 
+    let avatarUrl = identity.avatarUrl;
     if (avatarUrl === "" || avatarUrl === undefined) {
       avatarUrl = "data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAAAB4AAAAeCAQAAACROWYpAAAAHElEQVR42mNkoAAwjmoe1TyqeVTzqOZRzcNZMwB18wAfEFQkPQAAAABJRU5ErkJggg==";
     }
 
-    let name = (username === undefined) ? displayName : `${displayName} (${username})`;
-    if (name === undefined) {
-      if (id !== undefined) {
-        name = id;
-      } else if (email !== undefined) {
-        name = email;
-      } else {
-        name = linkedValue;
-      }
+    let name = identity.displayName;
+    if (name === "") {
+      name = identity.email !== "" ? identity.email : identity.subject;
+    } else if (identity.username !== "" && identity.username !== name) {
+      name = `${name} (${identity.username})`;
     }
 
+    return (
+      <div key={identity.name} style={{marginBottom: '10px'}}>
+        <img style={{marginRight: '10px'}} width={30} height={30} src={avatarUrl} alt={name} />
+        <span style={{width: this.props.labelSpan === 3 ? '300px' : '130px', display: (Setting.isMobile()) ? 'inline' : "inline-block"}}>
+          {
+            profileUrl === "" ? name : (
+              <a target="_blank" rel="noreferrer" href={profileUrl}>
+                {
+                  name
+                }
+              </a>
+            )
+          }
+        </span>
+        <Button disabled={!providerItem.canUnlink} style={{marginLeft: '20px', width: '80px'}} onClick={() => this.unlinkUser(provider, identity.subject)}>{i18next.t("user:Unlink")}</Button>
+      </div>
+    )
+  }
+
+  renderIdp(user, application, providerItem) {
+    const provider = providerItem.provider;
+    const identities = Setting.getFederatedIdentities(user, provider);
+
     return (
       <Row key={provider.name} style={{marginTop: '20px'}} >
         <Col style={{marginTop: '5px'}} span={this.props.labelSpan}>
@@ -148,31 +156,19 @@ class OAuthWidget extends React.Component {
           </span>
         </Col>
         <Col span={24 - this.props.labelSpan} >
-          <img style={{marginRight: '10px'}} width={30} height={30} src={avatarUrl} alt={name} />
-          <span style={{width: this.props.labelSpan === 3 ? '300px' : '130px', display: (Setting.isMobile()) ? 'inline' : "inline-block"}}>
-            {
-              linkedValue === "" ? (
-                "(empty)"
-              ) : (
-                profileUrl === "" ? name : (
-                  <a target="_blank" rel="noreferrer" href={profileUrl}>
-                    {
-                      name
-                    }
-                  </a>
-                )
-              )
-            }
-          </span>
           {
-            linkedValue === "" ? (
-              <a key={provider.displayName} href={Provider.getAuthUrl(application, provider, "link")}>
-                <Button style={{marginLeft: '20px', width: '80px'}} type="primary">{i18next.t("user:Link")}</Button>
-              </a>
-            ) : (
-              <Button disabled={!providerItem.canUnlink} style={{marginLeft: '20px', width: '80px'}} onClick={() => this.unlinkUser(provider.type)}>{i18next.t("user:Unlink")}</Button>
-            )
+            identities.map(identity => this.renderIdentity(provider, providerItem, identity))
+          }
+          {
+            identities.length === 0 ? (
+              <span style={{width: this.props.labelSpan === 3 ? '340px' : '170px', display: (Setting.isMobile()) ? 'inline' : "inline-block"}}>
+                (empty)
+              </span>
+            ) : null
           }
+          <a key={provider.displayName} href={Provider.getAuthUrl(application, provider, "link")}>
+            <Button style={identities.length === 0 ? {marginLeft: '20px', width: '80px'} : {width: '80px'}} type="primary">{i18next.t("user:Link")}</Button>
+          </a>
         </Col>
       </Row>
     )